@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// envAgentlessEnabled is the environment variable sending the test spans directly to the
+	// CI Visibility intake, without agent.
+	envAgentlessEnabled = "DD_CIVISIBILITY_AGENTLESS_ENABLED"
+
+	// envAgentlessURL is the environment variable overriding the URL of the intake, like the
+	// one of a proxy.
+	envAgentlessURL = "DD_CIVISIBILITY_AGENTLESS_URL"
+
+	// defaultSite is the Datadog site of the intake when DD_SITE isn't set.
+	defaultSite = "datadoghq.com"
+)
+
+// agentlessByEnv returns whether the DD_CIVISIBILITY_AGENTLESS_ENABLED environment variable
+// enables the agentless mode.
+func agentlessByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envAgentlessEnabled))
+	return v
+}
+
+// agentlessIntake returns the URL of the intake and the API key of the agentless mode. It
+// returns an error when the mode can't be used, like when DD_API_KEY isn't set.
+func agentlessIntake(cfg *runConfig) (string, string, error) {
+	apiKey := os.Getenv("DD_API_KEY")
+	if apiKey == "" {
+		return "", "", fmt.Errorf("DD_API_KEY is not set")
+	}
+	intake := cfg.agentlessURL
+	if intake == "" {
+		site := os.Getenv("DD_SITE")
+		if site == "" {
+			site = defaultSite
+		}
+		intake = "https://citestcycle-intake." + site
+	}
+	u, err := url.Parse(intake)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid intake URL: %v", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid intake URL %q, expected an http or https URL", intake)
+	}
+	return strings.TrimSuffix(intake, "/"), apiKey, nil
+}
+
+// checkAgentless disables the agentless mode of the configuration when it can't be used, so
+// the test spans are sent to the agent instead.
+func checkAgentless(cfg *runConfig) {
+	if !cfg.agentless {
+		return
+	}
+	if _, _, err := agentlessIntake(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: the agentless mode is disabled, the test spans are sent to the agent: %v\n", err)
+		cfg.agentless = false
+	}
+}
+
+// intakeClient returns the HTTP client sending the requests to the intake, with the TLS
+// configuration given with WithTLSConfig and the proxy of the HTTPS_PROXY environment variable.
+func intakeClient(cfg *runConfig, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	var tlsConfig *tls.Config
+	if cfg.tlsConfig != nil {
+		tlsConfig = cfg.tlsConfig.Clone()
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         dialer.DialContext,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+			IdleConnTimeout:     90 * time.Second,
+			MaxIdleConns:        10,
+		},
+	}
+}
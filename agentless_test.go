@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestAgentlessIntake(t *testing.T) {
+	defer os.Setenv("DD_API_KEY", os.Getenv("DD_API_KEY"))
+	defer os.Setenv("DD_SITE", os.Getenv("DD_SITE"))
+
+	os.Unsetenv("DD_API_KEY")
+	cfg := newRunConfig(WithAgentless())
+	if _, _, err := agentlessIntake(cfg); err == nil {
+		t.Error("expected an error without API key")
+	}
+	checkAgentless(cfg)
+	if cfg.agentless {
+		t.Error("expected the agentless mode to be disabled without API key")
+	}
+
+	os.Setenv("DD_API_KEY", "key")
+	os.Unsetenv("DD_SITE")
+	for _, tc := range []struct {
+		site, url, want string
+	}{
+		{want: "https://citestcycle-intake.datadoghq.com"},
+		{site: "datadoghq.eu", want: "https://citestcycle-intake.datadoghq.eu"},
+		{site: "datadoghq.eu", url: "https://proxy.example.com:8443/", want: "https://proxy.example.com:8443"},
+	} {
+		os.Setenv("DD_SITE", tc.site)
+		intake, apiKey, err := agentlessIntake(newRunConfig(WithAgentless(), WithAgentlessURL(tc.url)))
+		if err != nil || intake != tc.want || apiKey != "key" {
+			t.Errorf("%s %s: unexpected intake %s %s %v", tc.site, tc.url, intake, apiKey, err)
+		}
+	}
+	if _, _, err := agentlessIntake(newRunConfig(WithAgentlessURL("proxy:8443"))); err == nil {
+		t.Error("expected an error with an invalid intake URL")
+	}
+}
+
+func TestAgentlessTracer(t *testing.T) {
+	defer os.Setenv("DD_API_KEY", os.Getenv("DD_API_KEY"))
+	os.Setenv("DD_API_KEY", "key")
+
+	var path, apiKey string
+	var body []byte
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, apiKey = r.URL.Path, r.Header.Get("DD-API-KEY")
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	// The certificate of the test server is trusted with the TLS configuration.
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	cfg := newRunConfig(WithAgentless(), WithAgentlessURL(srv.URL), WithTLSConfig(&tls.Config{RootCAs: roots}))
+	setIsolatedTracer(newIsolatedTracer(cfg, "svc", "ci"))
+	defer setIsolatedTracer(nil)
+
+	FinishStep(StartStep(context.Background(), "step"), nil)
+	flushTracer()
+
+	if path != "/api/v2/citestcycle" || apiKey != "key" || len(body) == 0 {
+		t.Errorf("unexpected request %s %s %x", path, apiKey, body)
+	}
+	if got := intakeDescription(cfg); got != "agentless at "+srv.URL {
+		t.Errorf("unexpected intake description: %s", got)
+	}
+}
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+type allureResult struct {
+	UUID          string             `json:"uuid"`
+	HistoryID     string             `json:"historyId"`
+	TestCaseID    string             `json:"testCaseId"`
+	Name          string             `json:"name"`
+	FullName      string             `json:"fullName"`
+	Status        string             `json:"status"`
+	StatusDetails *allureDetails     `json:"statusDetails,omitempty"`
+	Stage         string             `json:"stage"`
+	Start         int64              `json:"start"`
+	Stop          int64              `json:"stop"`
+	Labels        []allureLabel      `json:"labels"`
+	Steps         []allureStep       `json:"steps"`
+	Attachments   []allureAttachment `json:"attachments"`
+}
+
+type allureDetails struct {
+	Message string `json:"message,omitempty"`
+	Trace   string `json:"trace,omitempty"`
+}
+
+type allureLabel struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type allureStep struct {
+	Name          string             `json:"name"`
+	Status        string             `json:"status"`
+	StatusDetails *allureDetails     `json:"statusDetails,omitempty"`
+	Stage         string             `json:"stage"`
+	Start         int64              `json:"start"`
+	Stop          int64              `json:"stop"`
+	Steps         []allureStep       `json:"steps"`
+	Attachments   []allureAttachment `json:"attachments"`
+}
+
+type allureAttachment struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Type   string `json:"type"`
+}
+
+// newAllureWriter returns a result writer that writes the Allure result files of the tests
+// in the given directory.
+func newAllureWriter(dir string) func(*testResult) {
+	return func(r *testResult) {
+		if err := writeAllureResult(dir, r); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: writing the Allure result of %s: %v\n", r.name, err)
+		}
+	}
+}
+
+func writeAllureResult(dir string, r *testResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	fullName := fmt.Sprintf("%s.%s", r.suite, r.name)
+	historyID := md5.Sum([]byte(fullName))
+	result := allureResult{
+		UUID:       newUUID(),
+		HistoryID:  hex.EncodeToString(historyID[:]),
+		TestCaseID: hex.EncodeToString(historyID[:]),
+		Name:       r.name,
+		FullName:   fullName,
+		Status:     allureStatus(r),
+		Stage:      "finished",
+		Start:      r.start.UnixNano() / 1e6,
+		Stop:       r.finish.UnixNano() / 1e6,
+		Labels: []allureLabel{
+			{Name: "suite", Value: r.suite},
+			{Name: "package", Value: r.suite},
+			{Name: "framework", Value: r.framework},
+			{Name: "language", Value: "go"},
+		},
+		Steps:       []allureStep{},
+		Attachments: []allureAttachment{},
+	}
+	if r.errorMsg != "" || r.errorStack != "" {
+		result.StatusDetails = &allureDetails{Message: r.errorMsg, Trace: r.errorStack}
+	}
+
+	r.mu.Lock()
+	attachments := r.attachments
+	r.mu.Unlock()
+	for _, attachment := range attachments {
+		source := newUUID() + "-attachment" + extensionByType(attachment.contentType)
+		if err := ioutil.WriteFile(filepath.Join(dir, source), attachment.data, 0644); err != nil {
+			return err
+		}
+		result.Attachments = append(result.Attachments, allureAttachment{
+			Name:   attachment.name,
+			Source: source,
+			Type:   attachment.contentType,
+		})
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, result.UUID+"-result.json"), data, 0644)
+}
+
+// allureStatus maps the status of a test to an Allure status, panics are broken tests.
+func allureStatus(r *testResult) string {
+	switch r.status {
+	case constants.TestStatusPass:
+		return "passed"
+	case constants.TestStatusSkip:
+		return "skipped"
+	}
+	if r.errorType == "panic" {
+		return "broken"
+	}
+	return "failed"
+}
+
+func extensionByType(contentType string) string {
+	switch contentType {
+	case "text/plain":
+		return ".txt"
+	case "text/x-diff":
+		return ".diff"
+	}
+	if extensions, err := mime.ExtensionsByType(contentType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ""
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
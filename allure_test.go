@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+func TestWriteAllureResult(t *testing.T) {
+	dir, err := ioutil.TempDir("", "allure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &testResult{
+		name:      "TestSample",
+		suite:     "github.com/DataDog/sample",
+		framework: testFramework,
+		start:     time.Now(),
+		finish:    time.Now(),
+		status:    constants.TestStatusFail,
+		errorMsg:  "boom",
+		errorType: "panic",
+	}
+	r.attach("output", "text/plain", []byte("output"))
+	if err := writeAllureResult(dir, r); err != nil {
+		t.Fatal(err)
+	}
+
+	results, _ := filepath.Glob(filepath.Join(dir, "*-result.json"))
+	if len(results) != 1 {
+		t.Fatalf("unexpected result files: %v", results)
+	}
+	data, _ := ioutil.ReadFile(results[0])
+	var result allureResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != "broken" || result.FullName != "github.com/DataDog/sample.TestSample" || result.StatusDetails.Message != "boom" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(result.Attachments) != 1 {
+		t.Fatalf("unexpected attachments: %+v", result.Attachments)
+	}
+	if _, err := os.Stat(filepath.Join(dir, result.Attachments[0].Source)); err != nil {
+		t.Errorf("attachment file was not written: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var (
+	// ambientSpans contains the running test spans started with WithAmbientParent,
+	// the last one is the ambient parent.
+	ambientSpans      []ddtrace.Span
+	ambientSpansMutex sync.Mutex
+)
+
+// AmbientContext returns ctx with the ambient test span when ctx doesn't contain a span.
+// The ambient test span is the last running test started with the WithAmbientParent
+// option. Wrapping the contexts given to code instrumented with the dd-trace-go contrib
+// packages (database/sql, net/http, ...) makes their spans children of the test span
+// even when the test context isn't threaded through:
+//
+//	rows, err := db.QueryContext(ddtesting.AmbientContext(ctx), query)
+//
+// When tests run in parallel, the ambient test span is the last one started, so the
+// test context should be used instead.
+func AmbientContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := tracer.SpanFromContext(ctx); ok {
+		return ctx
+	}
+
+	ambientSpansMutex.Lock()
+	defer ambientSpansMutex.Unlock()
+	if len(ambientSpans) == 0 {
+		return ctx
+	}
+	return tracer.ContextWithSpan(ctx, ambientSpans[len(ambientSpans)-1])
+}
+
+// pushAmbientSpan sets the span as the ambient parent.
+func pushAmbientSpan(span ddtrace.Span) {
+	ambientSpansMutex.Lock()
+	defer ambientSpansMutex.Unlock()
+	ambientSpans = append(ambientSpans, span)
+}
+
+// removeAmbientSpan removes the span from the ambient parents, the previous running
+// span becomes the ambient parent.
+func removeAmbientSpan(span ddtrace.Span) {
+	ambientSpansMutex.Lock()
+	defer ambientSpansMutex.Unlock()
+	for i := len(ambientSpans) - 1; i >= 0; i-- {
+		if ambientSpans[i] == span {
+			ambientSpans = append(ambientSpans[:i], ambientSpans[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestAmbientContext(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	if _, ok := tracer.SpanFromContext(AmbientContext(context.Background())); ok {
+		t.Fatal("unexpected ambient span")
+	}
+
+	ctx, finish := StartTest(t, WithAmbientParent())
+	testSpan, _ := tracer.SpanFromContext(ctx)
+
+	span, _ := tracer.StartSpanFromContext(AmbientContext(context.Background()), "db.query")
+	span.Finish()
+	finish()
+
+	if _, ok := tracer.SpanFromContext(AmbientContext(context.Background())); ok {
+		t.Error("ambient span was not removed when the test finished")
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 || spans[0].ParentID() != testSpan.Context().SpanID() {
+		t.Error("span is not a child of the ambient test span")
+	}
+}
@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// RecordAssertions adds n to the number of assertions of the test running with ctx, for the
+// assertion libraries counting their own assertions. The number of assertions is set as the
+// test.assertions metric of the span, and the passed tests without assertions are tagged with
+// test.assertions.none, a smell of vacuous tests. It returns false when ctx has no test.
+func RecordAssertions(ctx context.Context, n int) bool {
+	result, ok := testResultFromContext(ctx)
+	if !ok {
+		return false
+	}
+	result.countAssertions(n)
+	return true
+}
+
+// CountingT wraps the testing object of a test to count its assertions, for the assertion
+// libraries like github.com/stretchr/testify that call Helper when an assertion starts:
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//
+//	is := assert.New(ddtesting.CountAssertions(ctx, t))
+//	is.Equal(expected, actual)
+//
+// A Helper call is counted as an assertion when the function calling it is called from another
+// module, so the assertions built on other assertions of the same library, like require.Equal
+// calling assert.Equal, are counted once. The module is approximated by the first three elements
+// of the import path, like github.com/stretchr/testify.
+type CountingT struct {
+	testing.TB
+	result *testResult
+}
+
+// CountAssertions returns the testing object counting the assertions of the test running with
+// ctx. The test is reported without assertion metric when ctx has no test.
+func CountAssertions(ctx context.Context, tb testing.TB) *CountingT {
+	result, _ := testResultFromContext(ctx)
+	if result != nil {
+		result.countAssertions(0)
+	}
+	return &CountingT{TB: tb, result: result}
+}
+
+// Helper counts an assertion when it's called by an assertion library, and marks the calling
+// function as a test helper function.
+func (t *CountingT) Helper() {
+	t.TB.Helper()
+	if t.result == nil {
+		return
+	}
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(2, pcs)
+	if isAssertionCall(runtime.CallersFrames(pcs[:n])) {
+		t.result.countAssertions(1)
+	}
+}
+
+// callerFrames is implemented by runtime.Frames.
+type callerFrames interface {
+	Next() (runtime.Frame, bool)
+}
+
+// isAssertionCall returns whether the first frame, the function calling Helper, is called from
+// another module. The wrappers generated by the compiler are ignored.
+func isAssertionCall(frames callerFrames) bool {
+	var modules []string
+	for len(modules) < 2 {
+		frame, more := frames.Next()
+		if frame.Function != "" && frame.File != "<autogenerated>" {
+			modules = append(modules, funcModule(frame.Function))
+		}
+		if !more {
+			break
+		}
+	}
+	return len(modules) == 2 && modules[0] != modules[1]
+}
+
+// funcModule returns the first three elements of the package path of a fully qualified function
+// name, like github.com/stretchr/testify for github.com/stretchr/testify/assert.(*Assertions).Equal.
+func funcModule(function string) string {
+	lastSlash := strings.LastIndexByte(function, '/')
+	if lastSlash < 0 {
+		lastSlash = 0
+	}
+	pkg := function
+	if dot := strings.IndexByte(function[lastSlash:], '.'); dot >= 0 {
+		pkg = function[:lastSlash+dot]
+	}
+	if elems := strings.SplitN(pkg, "/", 4); len(elems) == 4 {
+		return strings.Join(elems[:3], "/")
+	}
+	return pkg
+}
+
+// countAssertions adds n to the number of assertions of the test, and enables the report of the
+// assertions.
+func (r *testResult) countAssertions(n int) {
+	atomic.StoreInt32(&r.assertionsCounted, 1)
+	atomic.AddInt64(&r.assertions, int64(n))
+}
+
+// setAssertionTags sets the number of assertions of the test on its span when they're counted,
+// and tags the passed tests without assertions.
+func (r *testResult) setAssertionTags(span ddtrace.Span) {
+	if atomic.LoadInt32(&r.assertionsCounted) == 0 {
+		return
+	}
+	n := atomic.LoadInt64(&r.assertions)
+	span.SetTag(constants.TestAssertions, n)
+	if n == 0 && r.status == constants.TestStatusPass {
+		span.SetTag(constants.TestNoAssertions, true)
+	}
+}
@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+// fakeFrames returns the frames of the given functions.
+type fakeFrames []runtime.Frame
+
+func (f *fakeFrames) Next() (runtime.Frame, bool) {
+	if len(*f) == 0 {
+		return runtime.Frame{}, false
+	}
+	frame := (*f)[0]
+	*f = (*f)[1:]
+	return frame, len(*f) > 0
+}
+
+func TestIsAssertionCall(t *testing.T) {
+	for _, c := range []struct {
+		functions []string
+		expected  bool
+	}{
+		// assert.Equal called by a test.
+		{[]string{"github.com/stretchr/testify/assert.Equal", "example.com/pkg.TestUpload"}, true},
+		// assert.Equal called by require.Equal.
+		{[]string{"github.com/stretchr/testify/assert.Equal", "github.com/stretchr/testify/require.Equal"}, false},
+		// assert.(*Assertions).Equal called through a wrapper generated by the compiler.
+		{[]string{"github.com/stretchr/testify/assert.(*Assertions).Equal", "autogenerated", "example.com/pkg.TestUpload.func1"}, true},
+		// A helper of the test package.
+		{[]string{"example.com/pkg.checkUpload", "example.com/pkg.TestUpload"}, false},
+		// assert.Equal called by a helper of another package of the repository.
+		{[]string{"github.com/stretchr/testify/assert.Equal", "example.com/org/repo/testutil.CheckUpload"}, true},
+		{[]string{"example.com/pkg.TestUpload"}, false},
+	} {
+		frames := fakeFrames{}
+		for _, fn := range c.functions {
+			if fn == "autogenerated" {
+				frames = append(frames, runtime.Frame{Function: "example.com/pkg.(*T).Helper", File: "<autogenerated>"})
+				continue
+			}
+			frames = append(frames, runtime.Frame{Function: fn, File: "file.go"})
+		}
+		if actual := isAssertionCall(&frames); actual != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.functions, c.expected, actual)
+		}
+	}
+}
+
+func TestAssertionTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	for _, c := range []struct {
+		name       string
+		assertions int
+		counted    bool
+	}{
+		{"TestCounted", 3, true},
+		{"TestVacuous", 0, true},
+		{"TestNotCounted", 0, false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, finish := StartTestWithContext(context.Background(), t)
+			if c.counted {
+				CountAssertions(ctx, t)
+			}
+			for i := 0; i < c.assertions; i++ {
+				RecordAssertions(ctx, 1)
+			}
+			finish()
+		})
+	}
+	if RecordAssertions(context.Background(), 1) {
+		t.Error("unexpected assertion recorded without test")
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 3 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if n := spans[0].Tag(constants.TestAssertions); n != int64(3) || spans[0].Tag(constants.TestNoAssertions) != nil {
+		t.Errorf("unexpected assertions: %v", spans[0].Tags())
+	}
+	if n := spans[1].Tag(constants.TestAssertions); n != int64(0) || spans[1].Tag(constants.TestNoAssertions) != true {
+		t.Errorf("unexpected assertions of a vacuous test: %v", spans[1].Tags())
+	}
+	if n := spans[2].Tag(constants.TestAssertions); n != nil {
+		t.Errorf("unexpected assertions of a test without counting: %v", n)
+	}
+}
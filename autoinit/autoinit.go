@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package autoinit starts the tracer automatically and reports the test spans, for test packages
+// without a TestMain function. The package is imported for its side effects:
+//
+//	import _ "github.com/DataDog/dd-sdk-go-testing/autoinit"
+//
+// The initialization only happens when the DD_CIVISIBILITY_AUTOINIT environment variable is
+// set to true, so the import can be left in place when running the tests locally.
+//
+// Only the test spans are reported. The Go testing package doesn't provide an exit hook, so the
+// tracer is flushed every time a test finishes but never stopped, and the session, module and
+// suite spans, which are finished when the tests exit, are never reported: they need
+// dd_sdk_go_testing.Run called from a TestMain function, which remains the recommended setup.
+package autoinit
+
+import (
+	"os"
+	"strconv"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+)
+
+// envAutoInit is the environment variable enabling the automatic initialization.
+const envAutoInit = "DD_CIVISIBILITY_AUTOINIT"
+
+func init() {
+	if enabled() {
+		ddtesting.Start(ddtesting.WithFlushOnTestFinish())
+	}
+}
+
+// enabled returns whether the automatic initialization has been enabled.
+func enabled() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envAutoInit))
+	return v
+}
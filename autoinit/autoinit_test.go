@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package autoinit
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	defer os.Setenv(envAutoInit, os.Getenv(envAutoInit))
+
+	for value, expected := range map[string]bool{
+		"":      false,
+		"false": false,
+		"no":    false,
+		"1":     true,
+		"true":  true,
+		"TRUE":  true,
+	} {
+		os.Setenv(envAutoInit, value)
+		if actual := enabled(); actual != expected {
+			t.Errorf("%q: expected %v, got %v", value, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"math"
+	"math/bits"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// benchmarkMemStats measures the allocations of a benchmark run.
+type benchmarkMemStats struct {
+	b          *testing.B
+	startAlloc uint64
+	startBytes uint64
+}
+
+// startBenchmarkMemStats starts measuring the allocations of the benchmark run.
+func startBenchmarkMemStats(b *testing.B) *benchmarkMemStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return &benchmarkMemStats{b: b, startAlloc: mem.Mallocs, startBytes: mem.TotalAlloc}
+}
+
+// setTags tags the span with whether the allocations are reported for the benchmark and,
+// when they are, the allocations per operation as reported by the testing package.
+func (s *benchmarkMemStats) setTags(span ddtrace.Span) {
+	enabled := benchmemEnabled(s.b)
+	span.SetTag(constants.TestBenchmarkMem, enabled)
+	if !enabled || s.b.N <= 0 {
+		return
+	}
+	allocs, bytes := s.measure()
+	span.SetTag(constants.TestBenchmarkAllocsPerOp, float64(allocs/uint64(s.b.N)))
+	span.SetTag(constants.TestBenchmarkAllocatedBytesPerOp, float64(bytes/uint64(s.b.N)))
+}
+
+// measure returns the allocations made while the benchmark timer was running. It reads
+// the counters of the testing.B so b.ResetTimer and b.StopTimer are honored, falling back
+// to the allocations since the run started when they are not available.
+func (s *benchmarkMemStats) measure() (allocs, bytes uint64) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	b := reflect.ValueOf(s.b).Elem()
+	timerOn := b.FieldByName("timerOn")
+	startAllocs, startBytes := b.FieldByName("startAllocs"), b.FieldByName("startBytes")
+	netAllocs, netBytes := b.FieldByName("netAllocs"), b.FieldByName("netBytes")
+	for _, f := range []reflect.Value{timerOn, startAllocs, startBytes, netAllocs, netBytes} {
+		if !f.IsValid() {
+			return mem.Mallocs - s.startAlloc, mem.TotalAlloc - s.startBytes
+		}
+	}
+
+	allocs, bytes = netAllocs.Uint(), netBytes.Uint()
+	if timerOn.Bool() {
+		allocs += mem.Mallocs - startAllocs.Uint()
+		bytes += mem.TotalAlloc - startBytes.Uint()
+	}
+	return allocs, bytes
+}
+
+// benchmemEnabled returns whether the allocations of the benchmark are reported, either
+// with the -benchmem flag or by calling b.ReportAllocs.
+func benchmemEnabled(b *testing.B) bool {
+	if benchmemFlag() {
+		return true
+	}
+	show := reflect.ValueOf(b).Elem().FieldByName("showAllocResult")
+	return show.IsValid() && show.Bool()
+}
+
+// benchmemFlag returns whether the -benchmem flag is set.
+func benchmemFlag() bool {
+	f := flag.Lookup("test.benchmem")
+	return f != nil && f.Value.String() == "true"
+}
+
+// iterationSubBuckets is the number of linear sub-buckets of each power of two of the iteration
+// histogram, bounding the error of the percentiles to 1/16 of the duration.
+const iterationSubBuckets = 16
+
+// iterationHistogram records the durations of the iterations of a benchmark in log-linear buckets,
+// so the percentiles are computed in constant memory whatever the number of iterations.
+type iterationHistogram struct {
+	counts [61 * iterationSubBuckets]int64
+	count  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+// iterationBucket returns the bucket of the duration, exact below 32ns.
+func iterationBucket(d int64) int {
+	v := uint64(d)
+	if v < 2*iterationSubBuckets {
+		return int(v)
+	}
+	e := bits.Len64(v)
+	top := v >> uint(e-5)
+	return (e-4)*iterationSubBuckets + int(top-iterationSubBuckets)
+}
+
+// iterationBucketValue returns the middle of the bucket.
+func iterationBucketValue(i int) int64 {
+	if i < 2*iterationSubBuckets {
+		return int64(i)
+	}
+	e := i/iterationSubBuckets + 4
+	top := uint64(iterationSubBuckets + i%iterationSubBuckets)
+	lower := top << uint(e-5)
+	upper := (top+1)<<uint(e-5) - 1
+	return int64(lower + (upper-lower)/2)
+}
+
+// record adds the duration of an iteration.
+func (h *iterationHistogram) record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+	h.counts[iterationBucket(v)]++
+}
+
+// percentile returns the duration in nanoseconds below which the fraction p of the iterations are.
+func (h *iterationHistogram) percentile(p float64) int64 {
+	rank := int64(math.Ceil(p * float64(h.count)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, n := range h.counts {
+		if seen += n; seen >= rank {
+			v := iterationBucketValue(i)
+			if v < h.min {
+				return h.min
+			}
+			if v > h.max {
+				return h.max
+			}
+			return v
+		}
+	}
+	return h.max
+}
+
+// histogram returns the number of iterations by power of two of their duration in nanoseconds,
+// keyed by the upper bound of the range.
+func (h *iterationHistogram) histogram() map[string]int64 {
+	histogram := map[string]int64{}
+	for i, n := range h.counts {
+		if n == 0 {
+			continue
+		}
+		bound := uint64(1)<<uint(bits.Len64(uint64(iterationBucketValue(i)))) - 1
+		histogram[strconv.FormatUint(bound, 10)] += n
+	}
+	return histogram
+}
+
+// setTags tags the span with the statistics of the iterations.
+func (h *iterationHistogram) setTags(span ddtrace.Span) {
+	if h.count == 0 {
+		return
+	}
+	span.SetTag(constants.TestBenchmarkIterations, h.count)
+	span.SetTag(constants.TestBenchmarkIterationMin, float64(h.min))
+	span.SetTag(constants.TestBenchmarkIterationMax, float64(h.max))
+	span.SetTag(constants.TestBenchmarkIterationMean, float64(h.sum)/float64(h.count))
+	span.SetTag(constants.TestBenchmarkIterationP50, float64(h.percentile(0.5)))
+	span.SetTag(constants.TestBenchmarkIterationP90, float64(h.percentile(0.9)))
+	span.SetTag(constants.TestBenchmarkIterationP99, float64(h.percentile(0.99)))
+	if histogram, err := json.Marshal(h.histogram()); err == nil {
+		span.SetTag(constants.TestBenchmarkIterationHistogram, string(histogram))
+	}
+}
+
+// BenchmarkLoop runs the body of the benchmark of ctx b.N times. When the benchmark is started with
+// WithBenchmarkIterationStats, the duration of each iteration is recorded, and the percentiles and
+// the histogram of the durations are tagged in its span, so the tail latency regressions are
+// noticed rather than hidden by the mean. Timing the iterations adds the cost of two clock reads
+// to each of them.
+func BenchmarkLoop(ctx context.Context, b *testing.B, body func()) {
+	var h *iterationHistogram
+	if result, ok := testResultFromContext(ctx); ok {
+		h = result.iterations
+	}
+	if h == nil {
+		for i := 0; i < b.N; i++ {
+			body()
+		}
+		return
+	}
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		body()
+		h.record(time.Since(start))
+	}
+}
@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+var benchSink []byte
+
+func TestBenchmarkMemStats(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		_, finish := StartTest(b)
+		defer finish()
+
+		for i := 0; i < b.N; i++ {
+			benchSink = make([]byte, 4096)
+		}
+	})
+
+	spans := mt.FinishedSpans()
+	if len(spans) == 0 {
+		t.Fatal("no benchmark spans")
+	}
+	s := spans[len(spans)-1]
+	if s.Tag(constants.TestBenchmarkMem) != true {
+		t.Errorf("unexpected benchmem tag: %v", s.Tag(constants.TestBenchmarkMem))
+	}
+	if allocs, _ := s.Tag(constants.TestBenchmarkAllocsPerOp).(float64); allocs < 1 {
+		t.Errorf("unexpected allocs per op: %v", allocs)
+	}
+	if bytes, _ := s.Tag(constants.TestBenchmarkAllocatedBytesPerOp).(float64); bytes < 4096 {
+		t.Errorf("unexpected allocated bytes per op: %v", bytes)
+	}
+}
+
+func TestBenchmarkMemStatsDisabled(t *testing.T) {
+	if benchmemFlag() {
+		t.Skip("-benchmem is set")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	testing.Benchmark(func(b *testing.B) {
+		_, finish := StartTest(b)
+		defer finish()
+	})
+
+	s := mt.FinishedSpans()[0]
+	if s.Tag(constants.TestBenchmarkMem) != false {
+		t.Errorf("unexpected benchmem tag: %v", s.Tag(constants.TestBenchmarkMem))
+	}
+	if allocs := s.Tag(constants.TestBenchmarkAllocsPerOp); allocs != nil {
+		t.Errorf("unexpected allocs per op: %v", allocs)
+	}
+}
+
+func TestIterationHistogram(t *testing.T) {
+	h := new(iterationHistogram)
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	for p, expected := range map[float64]float64{0.5: 500e3, 0.9: 900e3, 0.99: 990e3} {
+		v := float64(h.percentile(p))
+		if math.Abs(v-expected)/expected > 1.0/iterationSubBuckets {
+			t.Errorf("p%v: expected about %v, got %v", p*100, expected, v)
+		}
+	}
+	if h.min != int64(time.Microsecond) || h.max != int64(time.Millisecond) {
+		t.Errorf("unexpected bounds: %d, %d", h.min, h.max)
+	}
+	var total int64
+	for _, n := range h.histogram() {
+		total += n
+	}
+	if total != 1000 {
+		t.Errorf("unexpected number of iterations in the histogram: %d", total)
+	}
+	for d := int64(0); d < 1<<20; d = d*3/2 + 1 {
+		if v := iterationBucketValue(iterationBucket(d)); math.Abs(float64(v-d)) > float64(d)/iterationSubBuckets {
+			t.Errorf("%d: the bucket value %d is too far", d, v)
+		}
+	}
+}
+
+func TestBenchmarkIterationStats(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	testing.Benchmark(func(b *testing.B) {
+		ctx, finish := StartTest(b, WithBenchmarkIterationStats())
+		defer finish()
+
+		BenchmarkLoop(ctx, b, func() {
+			benchSink = make([]byte, 64)
+		})
+	})
+
+	s := mt.FinishedSpans()[len(mt.FinishedSpans())-1]
+	if n, _ := s.Tag(constants.TestBenchmarkIterations).(int64); n < 1 {
+		t.Fatalf("unexpected number of iterations: %v", s.Tag(constants.TestBenchmarkIterations))
+	}
+	p50, _ := s.Tag(constants.TestBenchmarkIterationP50).(float64)
+	p99, _ := s.Tag(constants.TestBenchmarkIterationP99).(float64)
+	max, _ := s.Tag(constants.TestBenchmarkIterationMax).(float64)
+	if p50 > p99 || p99 > max {
+		t.Errorf("unexpected percentiles: p50=%v p99=%v max=%v", p50, p99, max)
+	}
+	if _, ok := s.Tag(constants.TestBenchmarkIterationHistogram).(string); !ok {
+		t.Error("the histogram is missing")
+	}
+}
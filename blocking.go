@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default timeouts of the external operations of the SDK.
+const (
+	defaultGitTimeout      = 10 * time.Second
+	defaultSettingsTimeout = 2 * time.Second
+	defaultUploadTimeout   = 10 * time.Second
+	defaultMaxBlockingTime = time.Minute
+)
+
+// gitTimeout is the timeout of the git commands reading the Git metadata, in nanoseconds.
+var gitTimeout = int64(defaultGitTimeout)
+
+// currentGitTimeout returns the timeout of the git commands.
+func currentGitTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&gitTimeout))
+}
+
+// setGitTimeout sets the timeout of the git commands, restoring the default when it's not
+// positive.
+func setGitTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultGitTimeout
+	}
+	atomic.StoreInt64(&gitTimeout, int64(timeout))
+}
+
+// blockingBudget bounds the total time the SDK blocks the test binary in the external
+// operations done when the session starts and stops, like the git commands, the settings
+// requests and the final flush. A nil budget is unlimited.
+type blockingBudget struct {
+	mu        sync.Mutex
+	remaining time.Duration
+	unlimited bool
+}
+
+// newBlockingBudget returns a budget of max, unlimited when max isn't positive.
+func newBlockingBudget(max time.Duration) *blockingBudget {
+	return &blockingBudget{remaining: max, unlimited: max <= 0}
+}
+
+// timeout returns the timeout of an operation, bounded by the remaining budget. A timeout that
+// isn't positive means no timeout, so the remaining budget is returned. When the budget is
+// exhausted, the returned timeout is a nanosecond so the operation is abandoned right away.
+func (b *blockingBudget) timeout(timeout time.Duration) time.Duration {
+	if b == nil {
+		return timeout
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.unlimited:
+		return timeout
+	case b.remaining <= 0:
+		return time.Nanosecond
+	case timeout <= 0 || timeout > b.remaining:
+		return b.remaining
+	}
+	return timeout
+}
+
+// spend removes the time elapsed since start from the budget.
+func (b *blockingBudget) spend(start time.Time) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remaining -= time.Since(start)
+}
+
+// run runs fn with the timeout bounded by the budget, and returns false if it timed out.
+func (b *blockingBudget) run(fn func(), timeout time.Duration) bool {
+	defer b.spend(time.Now())
+	return runWithTimeout(fn, b.timeout(timeout))
+}
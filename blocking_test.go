@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlockingBudgetTimeout(t *testing.T) {
+	b := newBlockingBudget(time.Second)
+	for _, tc := range []struct {
+		timeout  time.Duration
+		expected time.Duration
+	}{
+		{100 * time.Millisecond, 100 * time.Millisecond},
+		{2 * time.Second, time.Second},
+		{0, time.Second},
+	} {
+		if actual := b.timeout(tc.timeout); actual != tc.expected {
+			t.Errorf("timeout(%v): expected %v, got %v", tc.timeout, tc.expected, actual)
+		}
+	}
+
+	b.spend(time.Now().Add(-2 * time.Second))
+	if actual := b.timeout(time.Second); actual != time.Nanosecond {
+		t.Errorf("expected an exhausted budget, got %v", actual)
+	}
+
+	unlimited := newBlockingBudget(0)
+	unlimited.spend(time.Now().Add(-time.Hour))
+	if actual := unlimited.timeout(5 * time.Second); actual != 5*time.Second {
+		t.Errorf("expected no limit, got %v", actual)
+	}
+}
+
+func TestBlockingBudgetRun(t *testing.T) {
+	b := newBlockingBudget(50 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+
+	if b.run(func() { <-release }, time.Minute) {
+		t.Error("expected the operation to exceed the budget")
+	}
+	if b.run(func() {}, time.Minute) {
+		t.Error("expected the operation to be abandoned once the budget is spent")
+	}
+}
+
+func TestSetGitTimeout(t *testing.T) {
+	defer setGitTimeout(defaultGitTimeout)
+
+	setGitTimeout(time.Second)
+	if actual := currentGitTimeout(); actual != time.Second {
+		t.Errorf("expected 1s, got %v", actual)
+	}
+	setGitTimeout(0)
+	if actual := currentGitTimeout(); actual != defaultGitTimeout {
+		t.Errorf("expected the default timeout, got %v", actual)
+	}
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+)
+
+// envCIEnvFile is the environment variable with the path of an environment file with the
+// variables of the CI provider, for the tests running in a container that doesn't inherit them,
+// like the nested containers of Docker-in-Docker steps.
+const envCIEnvFile = "DD_CI_ENV_FILE"
+
+var ciEnvFileOnce sync.Once
+
+// loadCIEnvFile sets the variables of the DD_CI_ENV_FILE file in the environment of the process,
+// once, before the configuration and the CI provider are read from it. The variables already
+// set take precedence over the file.
+func loadCIEnvFile() {
+	ciEnvFileOnce.Do(func() {
+		path := os.Getenv(envCIEnvFile)
+		if path == "" {
+			return
+		}
+		if err := applyEnvFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: reading the CI environment file: %v\n", err)
+		}
+	})
+}
+
+// applyEnvFile sets the variables of the environment file that aren't set yet.
+func applyEnvFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	values, err := utils.ParseEnvFile(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
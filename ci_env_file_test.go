@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyEnvFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ci-env-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ci.env")
+	data := "DD_TEST_ENV_FILE_PIPELINE=1234\nDD_TEST_ENV_FILE_SET=from-file\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("DD_TEST_ENV_FILE_SET", "from-env")
+	defer os.Unsetenv("DD_TEST_ENV_FILE_SET")
+	defer os.Unsetenv("DD_TEST_ENV_FILE_PIPELINE")
+
+	if err := applyEnvFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if v := os.Getenv("DD_TEST_ENV_FILE_PIPELINE"); v != "1234" {
+		t.Errorf("unexpected value of the variable of the file: %q", v)
+	}
+	if v := os.Getenv("DD_TEST_ENV_FILE_SET"); v != "from-env" {
+		t.Errorf("the variable of the environment was overridden: %q", v)
+	}
+	if err := applyEnvFile(filepath.Join(dir, "missing.env")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package cisim fabricates CI environments in-process and returns the CI and Git tags the SDK
+// reports in them, so platform teams can validate their pipeline templates before rolling them
+// out:
+//
+//	func TestPipelineTemplate(t *testing.T) {
+//		env := cisim.Environment{
+//			Env: map[string]string{
+//				"GITLAB_CI":          "true",
+//				"CI_REPOSITORY_URL":  "https://gitlab.com/my-org/my-repo.git",
+//				"CI_COMMIT_SHA":      "0123456789abcdef",
+//				"CI_COMMIT_REF_NAME": "main",
+//			},
+//		}
+//		env.AssertTags(t, map[string]string{
+//			ci.CIProviderName: "gitlab",
+//			ci.GitBranch:      "main",
+//		})
+//	}
+//
+// The tags are computed from the fabricated environment variables only, without touching the
+// environment of the process, so the simulations can run in parallel. The local Git repository
+// isn't read: the Git metadata only comes from the fabricated environment.
+package cisim
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+)
+
+// Environment is a fabricated CI environment.
+type Environment struct {
+	// Env contains the environment variables of the CI provider, like GITHUB_SHA and GITHUB_REF.
+	Env map[string]string
+
+	// Git is the Git metadata of the repository. Its non-empty fields take precedence over the
+	// metadata of the CI provider, like the DD_GIT_* environment variables.
+	Git Git
+}
+
+// Git is the Git metadata of a fabricated CI environment.
+type Git struct {
+	RepositoryURL  string
+	Branch         string
+	Tag            string
+	CommitSHA      string
+	CommitMessage  string
+	AuthorName     string
+	AuthorEmail    string
+	AuthorDate     string
+	CommitterName  string
+	CommitterEmail string
+	CommitterDate  string
+}
+
+// env returns the DD_GIT_* environment variables of the Git metadata.
+func (g Git) env() map[string]string {
+	return map[string]string{
+		"DD_GIT_REPOSITORY_URL":         g.RepositoryURL,
+		"DD_GIT_BRANCH":                 g.Branch,
+		"DD_GIT_TAG":                    g.Tag,
+		"DD_GIT_COMMIT_SHA":             g.CommitSHA,
+		"DD_GIT_COMMIT_MESSAGE":         g.CommitMessage,
+		"DD_GIT_COMMIT_AUTHOR_NAME":     g.AuthorName,
+		"DD_GIT_COMMIT_AUTHOR_EMAIL":    g.AuthorEmail,
+		"DD_GIT_COMMIT_AUTHOR_DATE":     g.AuthorDate,
+		"DD_GIT_COMMIT_COMMITTER_NAME":  g.CommitterName,
+		"DD_GIT_COMMIT_COMMITTER_EMAIL": g.CommitterEmail,
+		"DD_GIT_COMMIT_COMMITTER_DATE":  g.CommitterDate,
+	}
+}
+
+// Tags returns the CI and Git tags the SDK reports in the environment. The tag names are the
+// constants of the ext/ci package. The tags of the OS and of the Go runtime aren't included, and
+// the workspace paths relative to the home directory are expanded with the one of the process.
+func (e Environment) Tags() map[string]string {
+	env := e.env()
+	return utils.GetEnvironmentTagsFrom(func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+}
+
+// AssertTags checks the environment produces the expected tags, an empty value meaning the tag
+// is missing. The other tags are ignored. It reports the differences with tb.Errorf and returns
+// whether the tags match.
+func (e Environment) AssertTags(tb testing.TB, expected map[string]string) bool {
+	tb.Helper()
+	tags := e.Tags()
+	keys := make([]string, 0, len(expected))
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ok := true
+	for _, k := range keys {
+		if actual := tags[k]; actual != expected[k] {
+			tb.Errorf("cisim: tag %s: expected %q, got %q", k, expected[k], actual)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// env returns the environment variables of the simulated environment.
+func (e Environment) env() map[string]string {
+	env := map[string]string{}
+	for k, v := range e.Env {
+		env[k] = v
+	}
+	for k, v := range e.Git.env() {
+		if v != "" {
+			env[k] = v
+		}
+	}
+	return env
+}
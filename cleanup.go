@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// cleaner is implemented by testing.TB since Go 1.14.
+type cleaner interface {
+	Cleanup(func())
+}
+
+// Cleanup registers a cleanup function with tb.Cleanup, recording its run as a child span of
+// the test span of ctx with its duration and panic, if any:
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//
+//	db := openDatabase(t)
+//	ddtesting.Cleanup(ctx, t, "close database", func() { db.Close() })
+//
+// The cleanup functions run after the test span finishes, so their duration isn't added to the
+// duration of the test. It requires Go 1.14, the test fails with older versions.
+func Cleanup(ctx context.Context, tb testing.TB, name string, fn func()) {
+	c, ok := tb.(cleaner)
+	if !ok {
+		tb.Fatal("ddtesting.Cleanup requires Go 1.14 or later")
+		return
+	}
+	c.Cleanup(func() {
+		span, _ := startScrubbedSpan(ctx, constants.SpanTypeTestCleanup,
+			tracer.ResourceName(name),
+			tracer.Tag(constants.Origin, constants.CIAppTestOrigin))
+		defer func() {
+			if r := recover(); r != nil {
+				span.SetTag(ext.Error, true)
+				span.SetTag(ext.ErrorMsg, fmt.Sprint(r))
+				span.SetTag(ext.ErrorStack, getStacktrace(2))
+				span.SetTag(ext.ErrorType, "panic")
+				span.Finish()
+				panic(r)
+			}
+			span.Finish()
+			flushIncremental()
+		}()
+		fn()
+	})
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestCleanup(t *testing.T) {
+	if _, ok := interface{}(t).(cleaner); !ok {
+		t.Skip("t.Cleanup requires Go 1.14")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var parent uint64
+	t.Run("subtest", func(t *testing.T) {
+		span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+		parent = span.Context().SpanID()
+		Cleanup(ctx, t, "slow cleanup", func() { time.Sleep(10 * time.Millisecond) })
+		span.Finish()
+	})
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	slow := spans[1]
+	if slow.OperationName() != constants.SpanTypeTestCleanup || slow.Tag(ext.ResourceName) != "slow cleanup" ||
+		slow.ParentID() != parent {
+		t.Errorf("unexpected cleanup span: %v", slow)
+	}
+	if d := slow.FinishTime().Sub(slow.StartTime()); d < 10*time.Millisecond {
+		t.Errorf("unexpected cleanup duration: %v", d)
+	}
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock provides the timestamps of the session and test spans, and the durations of the tests.
+// It can be replaced with WithClock, like in deterministic tests of the instrumentation or in
+// tools replaying recorded runs. The overhead of the SDK and its timeouts use the system clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock reading the time of the system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// clockHolder wraps the clock, since atomic.Value requires a consistent concrete type.
+type clockHolder struct {
+	clock Clock
+}
+
+// currentClock is the clock of the running session.
+var currentClock atomic.Value
+
+func init() {
+	currentClock.Store(clockHolder{systemClock{}})
+}
+
+// setClock sets the clock of the session, or the system clock when it's nil.
+func setClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+	currentClock.Store(clockHolder{c})
+}
+
+// now returns the current time of the clock of the session.
+func now() time.Time {
+	return currentClock.Load().(clockHolder).clock.Now()
+}
+
+// since returns the time elapsed since t according to the clock of the session.
+func since(t time.Time) time.Duration {
+	return now().Sub(t)
+}
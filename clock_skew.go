@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// envClockSkewThreshold is the environment variable enabling the clock skew detection with the
+// maximum skew of the clock against the agent, like 5s.
+const envClockSkewThreshold = "DD_CIVISIBILITY_CLOCK_SKEW_THRESHOLD"
+
+// clockSkewThresholdByEnv returns the threshold of DD_CIVISIBILITY_CLOCK_SKEW_THRESHOLD, or 0 when
+// the detection isn't enabled.
+func clockSkewThresholdByEnv() time.Duration {
+	v := os.Getenv(envClockSkewThreshold)
+	if v == "" {
+		return 0
+	}
+	threshold, err := time.ParseDuration(v)
+	if err != nil || threshold <= 0 {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: invalid %s %q, the clock skew isn't checked\n", envClockSkewThreshold, v)
+		return 0
+	}
+	return threshold
+}
+
+// measureClockSkew returns the difference between the clock of the session and the clock of the
+// server, read in the Date header of a request to the URL. The Date header has a resolution of one
+// second, so the server time is taken in the middle of its second and compared with the middle of
+// the request, leaving half a second of error.
+func measureClockSkew(url string, client *http.Client) (time.Duration, error) {
+	before := now()
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	after := now()
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return 0, errors.New("no Date header in the response")
+	}
+	server, err := http.ParseTime(date)
+	if err != nil {
+		return 0, err
+	}
+	local := before.Add(after.Sub(before) / 2)
+	return local.Sub(server.Add(500 * time.Millisecond)), nil
+}
+
+// checkClockSkew measures the skew of the clock against the agent within the timeout and tags
+// the session span with it. When it's larger than the threshold, the session is tagged as having
+// unreliable timestamps and a warning is printed, since the spans of a skewed runner sort
+// incorrectly.
+func checkClockSkew(span ddtrace.Span, cfg *runConfig, timeout time.Duration) {
+	url, client := agentURL(cfg, timeout)
+	skew, err := measureClockSkew(url+"/info", client)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: measuring the clock skew: %v\n", err)
+		return
+	}
+	setClockSkewTags(span, skew, cfg.clockSkewThreshold)
+}
+
+// setClockSkewTags tags the session span with the skew of the clock, positive when the clock is
+// ahead of the agent, and whether it's larger than the threshold.
+func setClockSkewTags(span ddtrace.Span, skew, threshold time.Duration) {
+	span.SetTag(constants.TestSessionClockSkew, skew.Seconds())
+	skewed := skew > threshold || skew < -threshold
+	span.SetTag(constants.TestSessionClockSkewed, skewed)
+	if skewed {
+		direction := "ahead of"
+		if skew < 0 {
+			direction, skew = "behind", -skew
+		}
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: the clock is %v %s the agent, the timestamps of the tests may be unreliable\n",
+			skew.Round(time.Millisecond), direction)
+	}
+}
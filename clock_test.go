@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// fakeClock is a Clock returning a time advanced manually.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func TestClock(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	clock := &fakeClock{t: time.Date(2021, 10, 1, 10, 0, 0, 0, time.UTC)}
+	setClock(clock)
+	defer setClock(nil)
+
+	_, finish := StartTest(t)
+	clock.t = clock.t.Add(3 * time.Second)
+	finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if start := spans[0].StartTime(); !start.Equal(time.Date(2021, 10, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start time: %v", start)
+	}
+	if d := spans[0].FinishTime().Sub(spans[0].StartTime()); d != 3*time.Second {
+		t.Errorf("unexpected duration: %v", d)
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "Fri, 01 Oct 2021 10:00:00 GMT")
+	}))
+	defer server.Close()
+
+	setClock(&fakeClock{t: time.Date(2021, 10, 1, 10, 0, 10, 0, time.UTC)})
+	defer setClock(nil)
+	skew, err := measureClockSkew(server.URL, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skew != 9500*time.Millisecond {
+		t.Errorf("unexpected skew: %v", skew)
+	}
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	for _, c := range []struct {
+		skew     time.Duration
+		expected bool
+	}{
+		{skew, true},
+		{-time.Second, false},
+		{-10 * time.Second, true},
+	} {
+		span := tracer.StartSpan("session")
+		setClockSkewTags(span, c.skew, 5*time.Second)
+		if span := span.(mocktracer.Span); span.Tag(constants.TestSessionClockSkew) != c.skew.Seconds() || span.Tag(constants.TestSessionClockSkewed) != c.expected {
+			t.Errorf("%v: unexpected tags: %v", c.skew, span.Tags())
+		}
+	}
+}
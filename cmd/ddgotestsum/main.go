@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Command ddgotestsum reports the tests of a `go test -json` output file to Datadog.
+//
+// The file is the first argument, or the GOTESTSUM_JSONFILE environment variable set by
+// gotestsum when the command is used as its post-run command:
+//
+//	gotestsum --jsonfile test-output.json --post-run-command ddgotestsum
+package main
+
+import (
+	"fmt"
+	"os"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/contrib/ddgotestsum"
+)
+
+func main() {
+	path := os.Getenv("GOTESTSUM_JSONFILE")
+	if len(os.Args) > 1 {
+		path = os.Args[1]
+	}
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "usage: ddgotestsum <go test -json output file>")
+		os.Exit(2)
+	}
+
+	if err := report(path); err != nil {
+		fmt.Fprintf(os.Stderr, "ddgotestsum: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// report reports the tests of the output file in a new test session.
+func report(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ddtesting.Start()
+	defer ddtesting.Stop()
+	return ddgotestsum.Report(file)
+}
@@ -0,0 +1,349 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/contrib/ddgotestsum"
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// droppedSuffix is added to the files of the drop directory once they're reported.
+	droppedSuffix = ".done"
+
+	// envAggregatorToken is the environment variable with the token authorizing the requests
+	// finishing the session of the aggregation server.
+	envAggregatorToken = "DD_CIVISIBILITY_AGGREGATOR_TOKEN"
+)
+
+// aggregator merges the `go test -json` outputs of many workers into the session of the process,
+// reporting every package as one module of the session whatever the number of workers testing it.
+type aggregator struct {
+	mu      sync.Mutex
+	modules map[string]*aggregatedModule
+}
+
+// aggregatedModule is the module of a package tested by the workers, with the suite of its tests.
+type aggregatedModule struct {
+	module  *ddtesting.TestModule
+	suite   *ddtesting.TestSuite
+	end     time.Time
+	failed  bool
+	workers map[string]bool
+}
+
+// newAggregator returns an aggregator reporting the modules in the running session.
+func newAggregator() *aggregator {
+	return &aggregator{modules: map[string]*aggregatedModule{}}
+}
+
+// report reads the `go test -json` output of a worker and reports its tests in the modules of
+// their packages. The lines that aren't test events, like build errors, are ignored.
+func (a *aggregator) report(r io.Reader, worker string) error {
+	outputs := map[string]*bytes.Buffer{}
+	var packages []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e struct {
+			Time    time.Time
+			Action  string
+			Package string
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Package == "" {
+			continue
+		}
+		a.record(e.Package, worker, e.Time, e.Action == "fail")
+		output, ok := outputs[e.Package]
+		if !ok {
+			output = new(bytes.Buffer)
+			outputs[e.Package] = output
+			packages = append(packages, e.Package)
+		}
+		output.Write(scanner.Bytes())
+		output.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, pkg := range packages {
+		m := a.module(pkg)
+		err := ddgotestsum.Report(outputs[pkg],
+			ddtesting.WithSuite(m.suite),
+			ddtesting.WithSpanOptions(tracer.Tag(constants.TestWorker, worker)),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record records an event of the package, starting its module span with the first one.
+func (a *aggregator) record(pkg, worker string, t time.Time, failed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m, ok := a.modules[pkg]
+	if !ok {
+		module := ddtesting.StartTestModule(pkg, tracer.StartTime(t))
+		m = &aggregatedModule{
+			module:  module,
+			suite:   module.StartTestSuite(pkg, tracer.StartTime(t)),
+			workers: map[string]bool{},
+		}
+		a.modules[pkg] = m
+	}
+	if t.After(m.end) {
+		m.end = t
+	}
+	m.failed = m.failed || failed
+	m.workers[worker] = true
+}
+
+// module returns the module of the package.
+func (a *aggregator) module(pkg string) *aggregatedModule {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.modules[pkg]
+}
+
+// finish finishes the modules, and returns whether a test failed.
+func (a *aggregator) finish() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	failed := false
+	for _, m := range a.modules {
+		if m.failed {
+			m.module.Fail()
+			failed = true
+		}
+		workers := make([]string, 0, len(m.workers))
+		for w := range m.workers {
+			workers = append(workers, w)
+		}
+		sort.Strings(workers)
+		m.module.SetTag(constants.TestWorker, strings.Join(workers, ","))
+		m.module.Finish(tracer.FinishTime(m.end))
+	}
+	a.modules = map[string]*aggregatedModule{}
+	return failed
+}
+
+// collect reports the `go test -json` outputs dropped in the directory, renaming them with the
+// droppedSuffix once reported. The files modified during the last interval may still be written,
+// they're reported by a later collection unless all is set.
+func (a *aggregator) collect(dir string, interval time.Duration, all bool) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !all && time.Since(info.ModTime()) < interval {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = a.report(f, strings.TrimSuffix(filepath.Base(path), ".json"))
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if err := os.Rename(path, path+droppedSuffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handler returns the HTTP handler receiving the outputs of the workers on /v1/events, with the
+// name of the worker in the worker query parameter, and finishing the session on /v1/finish. The
+// session is only finished by the local requests authorized with the token, so a worker can't end
+// it before the others are done.
+func (a *aggregator) handler(finish func(), token string, stderr io.Writer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		worker := r.URL.Query().Get("worker")
+		if worker == "" {
+			worker = r.RemoteAddr
+		}
+		if err := a.report(r.Body, worker); err != nil {
+			fmt.Fprintf(stderr, "ddtest: reading the events of %s: %v\n", worker, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/v1/finish", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !isLoopback(r.RemoteAddr) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		auth := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if subtle.ConstantTimeCompare(auth, []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		finish()
+	})
+	return mux
+}
+
+// isLoopback returns whether the remote address of a request is a loopback address.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newToken returns a random token finishing the session of the aggregation server.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// aggregate runs the aggregation server, reporting the `go test -json` outputs of the workers in
+// a single session until it's finished with /v1/finish, SIGINT or SIGTERM. It exits with exitGated
+// when a test failed, and exitFailure when the server can't run.
+func aggregate(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ddtest aggregate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", "", "address of the HTTP endpoint receiving the outputs of the workers, like :8128")
+	dir := fs.String("dir", "", "directory where the workers drop their outputs as .json files")
+	interval := fs.Duration("interval", time.Second, "interval between two collections of the dropped files")
+	token := fs.String("token", os.Getenv(envAggregatorToken), "token authorizing the local requests to /v1/finish, random by default")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: ddtest aggregate [-addr address] [-dir directory]")
+		fmt.Fprintln(stderr, "\nReports the `go test -json` outputs of many workers in a single session.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	if *addr == "" && *dir == "" {
+		fs.Usage()
+		return exitFailure
+	}
+	if *addr != "" && *token == "" {
+		t, err := newToken()
+		if err != nil {
+			fmt.Fprintf(stderr, "ddtest: %v\n", err)
+			return exitFailure
+		}
+		*token = t
+		fmt.Fprintf(stderr, "ddtest: token of /v1/finish: %s\n", t)
+	}
+
+	ddtesting.Start(ddtesting.WithSessionRoot(), ddtesting.WithSignalHandlerDisabled())
+	a := newAggregator()
+	done := make(chan struct{})
+	var doneOnce sync.Once
+	finish := func() { doneOnce.Do(func() { close(done) }) }
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			finish()
+		case <-done:
+		}
+	}()
+
+	var server *http.Server
+	if *addr != "" {
+		server = &http.Server{Addr: *addr, Handler: a.handler(finish, *token, stderr)}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Fprintf(stderr, "ddtest: %v\n", err)
+				finish()
+			}
+		}()
+	}
+	var collector sync.WaitGroup
+	if *dir != "" {
+		collector.Add(1)
+		go func() {
+			defer collector.Done()
+			ticker := time.NewTicker(*interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if err := a.collect(*dir, *interval, false); err != nil {
+						fmt.Fprintf(stderr, "ddtest: %v\n", err)
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	<-done
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		server.Shutdown(ctx)
+		cancel()
+	}
+	// The last collection waits for a running one, so a file isn't reported twice.
+	collector.Wait()
+	if *dir != "" {
+		if err := a.collect(*dir, *interval, true); err != nil {
+			fmt.Fprintf(stderr, "ddtest: %v\n", err)
+		}
+	}
+	failed := a.finish()
+	ddtesting.Stop()
+	if failed {
+		return exitGated
+	}
+	return exitOK
+}
@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+const (
+	workerA = `{"Time":"2021-10-01T10:00:00Z","Action":"run","Package":"example.com/pkg","Test":"TestA"}
+{"Time":"2021-10-01T10:00:01Z","Action":"pass","Package":"example.com/pkg","Test":"TestA","Elapsed":1}
+{"Time":"2021-10-01T10:00:01Z","Action":"pass","Package":"example.com/pkg","Elapsed":1}
+`
+	workerB = `{"Time":"2021-10-01T10:00:00Z","Action":"run","Package":"example.com/pkg","Test":"TestB"}
+{"Time":"2021-10-01T10:00:03Z","Action":"fail","Package":"example.com/pkg","Test":"TestB","Elapsed":3}
+{"Time":"2021-10-01T10:00:03Z","Action":"fail","Package":"example.com/pkg","Elapsed":3}
+`
+)
+
+func TestAggregate(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	dir, err := ioutil.TempDir("", "ddtest-aggregate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "worker-b.json"), []byte(workerB), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := newAggregator()
+	finished := make(chan struct{})
+	server := httptest.NewServer(a.handler(func() { close(finished) }, "secret", ioutil.Discard))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/v1/events?worker=worker-a", "application/json", strings.NewReader(workerA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+	if err := a.collect(dir, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "worker-b.json"+droppedSuffix)); err != nil {
+		t.Errorf("the dropped file wasn't renamed: %v", err)
+	}
+	resp, err = http.Post(server.URL+"/v1/finish", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unexpected status code without the token: %d", resp.StatusCode)
+	}
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/finish", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	<-finished
+	if !a.finish() {
+		t.Error("expected a failed test")
+	}
+
+	var modules, suites, tests []mocktracer.Span
+	for _, span := range mt.FinishedSpans() {
+		switch {
+		case span.Tag(constants.TestModuleID) == nil:
+		case span.OperationName() == constants.SpanTypeTestModule:
+			modules = append(modules, span)
+		case span.OperationName() == constants.SpanTypeTestSuite:
+			suites = append(suites, span)
+		default:
+			tests = append(tests, span)
+		}
+	}
+	if len(modules) != 1 || len(suites) != 1 || len(tests) != 2 {
+		t.Fatalf("unexpected spans: %d modules, %d suites, %d tests", len(modules), len(suites), len(tests))
+	}
+	module := modules[0]
+	if module.Tag(constants.TestModule) != "example.com/pkg" || module.Tag(constants.TestStatus) != constants.TestStatusFail ||
+		module.Tag(constants.TestWorker) != "worker-a,worker-b" {
+		t.Errorf("unexpected module span: %v", module.Tags())
+	}
+	if d := module.FinishTime().Sub(module.StartTime()).Seconds(); d != 3 {
+		t.Errorf("unexpected module duration: %vs", d)
+	}
+	for _, test := range tests {
+		if test.Tag(constants.TestModuleID) != module.Tag(constants.TestModuleID) {
+			t.Errorf("unexpected module of %v: %v", test.Tag(constants.TestName), test.Tag(constants.TestModuleID))
+		}
+		if test.Tag(constants.TestSuiteID) != suites[0].Tag(constants.TestSuiteID) {
+			t.Errorf("unexpected suite of %v: %v", test.Tag(constants.TestName), test.Tag(constants.TestSuiteID))
+		}
+	}
+	if tests[0].Tag(constants.TestWorker) != "worker-a" || tests[1].Tag(constants.TestWorker) != "worker-b" {
+		t.Errorf("unexpected workers: %v, %v", tests[0].Tag(constants.TestWorker), tests[1].Tag(constants.TestWorker))
+	}
+}
+
+func TestAggregateFinishRemote(t *testing.T) {
+	a := newAggregator()
+	h := a.handler(func() { t.Error("the session was finished by a remote request") }, "secret", ioutil.Discard)
+	req := httptest.NewRequest(http.MethodPost, "/v1/finish", nil)
+	req.RemoteAddr = "192.0.2.1:41000"
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code: %d", w.Code)
+	}
+}
@@ -0,0 +1,285 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Exit codes of the flaky subcommand.
+const (
+	exitOK      = 0
+	exitGated   = 1
+	exitFailure = 2
+)
+
+// testRecord is a recorded execution of a test.
+type testRecord struct {
+	name     string
+	status   string
+	start    int64
+	duration time.Duration
+}
+
+// testStats summarizes the recorded executions of a test.
+type testStats struct {
+	name   string
+	runs   int
+	passed int
+	failed int
+	flips  int
+	total  time.Duration
+	max    time.Duration
+	last   string
+}
+
+// flaky reads the results recorded locally and prints the flaky and the slowest tests. It exits
+// with exitGated when a gating condition is met, and exitFailure when the results can't be read.
+func flaky(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ddtest flaky", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	top := fs.Int("top", 10, "number of slowest tests to print")
+	minRuns := fs.Int("min-runs", 2, "minimum number of executions of a test to report it as flaky")
+	failOnFlaky := fs.Bool("fail-on-flaky", false, "exit with code 1 when flaky tests are found")
+	slowerThan := fs.Duration("slower-than", 0, "exit with code 1 when the slowest execution of a test is longer")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: ddtest flaky [flags] <results>...")
+		fmt.Fprintln(stderr, "\nReads the Allure result files written with WithAllureResults, and the test events written with")
+		fmt.Fprintln(stderr, "DD_CIVISIBILITY_OUTPUT=file:<path> or WithOutputFile in .ndjson files, from directories or files.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitFailure
+	}
+
+	var records []testRecord
+	for _, path := range fs.Args() {
+		r, err := readRecords(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "ddtest: %v\n", err)
+			return exitFailure
+		}
+		records = append(records, r...)
+	}
+	// The result files have random names, order the executions by their start.
+	sort.SliceStable(records, func(i, j int) bool { return records[i].start < records[j].start })
+	stats := summarize(records)
+
+	flakyTests := flakyStats(stats, *minRuns)
+	fmt.Fprintf(stdout, "Flaky tests (%d of %d tests, %d executions):\n", len(flakyTests), len(stats), len(records))
+	for _, s := range flakyTests {
+		fmt.Fprintf(stdout, "  %s: %d failed of %d runs, %d status changes\n", s.name, s.failed, s.runs, s.flips)
+	}
+
+	slowest := slowestStats(stats, *top)
+	fmt.Fprintf(stdout, "\nSlowest tests:\n")
+	for _, s := range slowest {
+		fmt.Fprintf(stdout, "  %s: max %v, mean %v over %d runs\n", s.name, s.max, s.total/time.Duration(s.runs), s.runs)
+	}
+
+	code := exitOK
+	if *failOnFlaky && len(flakyTests) > 0 {
+		fmt.Fprintf(stderr, "ddtest: found %d flaky tests\n", len(flakyTests))
+		code = exitGated
+	}
+	if *slowerThan > 0 && len(slowest) > 0 && slowest[0].max > *slowerThan {
+		fmt.Fprintf(stderr, "ddtest: %s took %v, longer than %v\n", slowest[0].name, slowest[0].max, *slowerThan)
+		code = exitGated
+	}
+	return code
+}
+
+// readRecords reads the Allure result files and the test event files of a directory, or a single
+// file of either kind. The test event files are recognized by their .ndjson extension.
+func readRecords(path string) ([]testRecord, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if strings.HasSuffix(path, ".ndjson") {
+			return readTestCycleRecords(path)
+		}
+		return readAllureRecords([]string{path})
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*-result.json"))
+	if err != nil {
+		return nil, err
+	}
+	records, err := readAllureRecords(files)
+	if err != nil {
+		return nil, err
+	}
+	if files, err = filepath.Glob(filepath.Join(path, "*.ndjson")); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		r, err := readTestCycleRecords(file)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r...)
+	}
+	return records, nil
+}
+
+// readAllureRecords reads Allure result files.
+func readAllureRecords(files []string) ([]testRecord, error) {
+	records := make([]testRecord, 0, len(files))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			FullName string `json:"fullName"`
+			Status   string `json:"status"`
+			Start    int64  `json:"start"`
+			Stop     int64  `json:"stop"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("reading %s: %v", file, err)
+		}
+		records = append(records, testRecord{
+			name:     result.FullName,
+			status:   result.Status,
+			start:    result.Start,
+			duration: time.Duration(result.Stop-result.Start) * time.Millisecond,
+		})
+	}
+	return records, nil
+}
+
+// testCycleStatuses are the Allure statuses of the statuses of the test events.
+var testCycleStatuses = map[string]string{
+	"pass": "passed",
+	"fail": "failed",
+	"skip": "skipped",
+}
+
+// readTestCycleRecords reads the test events of a file written in offline export mode, a test
+// cycle payload per line. The other events, like the ones of the suites, are ignored.
+func readTestCycleRecords(file string) ([]testRecord, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []testRecord
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for n := 1; ; n++ {
+		var payload struct {
+			Events []struct {
+				Type    string `json:"type"`
+				Content struct {
+					Start    int64             `json:"start"`
+					Duration int64             `json:"duration"`
+					Meta     map[string]string `json:"meta"`
+				} `json:"content"`
+			} `json:"events"`
+		}
+		if err := dec.Decode(&payload); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("reading %s, payload %d: %v", file, n, err)
+		}
+		for _, e := range payload.Events {
+			if e.Type != "test" {
+				continue
+			}
+			meta := e.Content.Meta
+			records = append(records, testRecord{
+				name:     meta["test.suite"] + "." + meta["test.name"],
+				status:   testCycleStatuses[meta["test.status"]],
+				start:    e.Content.Start / int64(time.Millisecond),
+				duration: time.Duration(e.Content.Duration),
+			})
+		}
+	}
+}
+
+// summarize returns the statistics of the tests by name, ignoring the skipped executions.
+func summarize(records []testRecord) map[string]*testStats {
+	stats := map[string]*testStats{}
+	for _, r := range records {
+		if r.status == "skipped" {
+			continue
+		}
+		s, ok := stats[r.name]
+		if !ok {
+			s = &testStats{name: r.name}
+			stats[r.name] = s
+		}
+		passed := r.status == "passed"
+		if s.runs > 0 && passed != (s.last == "passed") {
+			s.flips++
+		}
+		s.runs++
+		if passed {
+			s.passed++
+		} else {
+			s.failed++
+		}
+		s.total += r.duration
+		if r.duration > s.max {
+			s.max = r.duration
+		}
+		s.last = r.status
+	}
+	return stats
+}
+
+// flakyStats returns the tests that both passed and failed in at least minRuns executions, the
+// most unstable first.
+func flakyStats(stats map[string]*testStats, minRuns int) []*testStats {
+	var flaky []*testStats
+	for _, s := range stats {
+		if s.runs >= minRuns && s.passed > 0 && s.failed > 0 {
+			flaky = append(flaky, s)
+		}
+	}
+	sort.Slice(flaky, func(i, j int) bool {
+		if flaky[i].flips != flaky[j].flips {
+			return flaky[i].flips > flaky[j].flips
+		}
+		return flaky[i].name < flaky[j].name
+	})
+	return flaky
+}
+
+// slowestStats returns the top tests with the slowest executions.
+func slowestStats(stats map[string]*testStats, top int) []*testStats {
+	slowest := make([]*testStats, 0, len(stats))
+	for _, s := range stats {
+		slowest = append(slowest, s)
+	}
+	sort.Slice(slowest, func(i, j int) bool {
+		if slowest[i].max != slowest[j].max {
+			return slowest[i].max > slowest[j].max
+		}
+		return slowest[i].name < slowest[j].name
+	})
+	if top >= 0 && len(slowest) > top {
+		slowest = slowest[:top]
+	}
+	return slowest
+}
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFlaky(t *testing.T) {
+	dir, err := ioutil.TempDir("", "allure-results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, r := range []struct {
+		name   string
+		status string
+		start  int64
+		stop   int64
+	}{
+		{"pkg.TestStable", "passed", 1000, 1100},
+		{"pkg.TestFlaky", "failed", 1000, 1200},
+		{"pkg.TestSlow", "passed", 1000, 6000},
+		{"pkg.TestStable", "passed", 2000, 2100},
+		{"pkg.TestFlaky", "passed", 2000, 2200},
+		{"pkg.TestSlow", "passed", 2000, 4000},
+		{"pkg.TestFlaky", "failed", 3000, 3200},
+		{"pkg.TestSkipped", "skipped", 3000, 3000},
+	} {
+		data := fmt.Sprintf(`{"fullName":%q,"status":%q,"start":%d,"stop":%d}`, r.name, r.status, r.start, r.stop)
+		if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%d-result.json", i)), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := flaky([]string{"-top", "2", dir}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, expected := range []string{
+		"Flaky tests (1 of 3 tests, 8 executions):",
+		"pkg.TestFlaky: 2 failed of 3 runs, 2 status changes",
+		"pkg.TestSlow: max 5s, mean 3.5s over 2 runs",
+		"pkg.TestFlaky: max 200ms",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in the output:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "TestStable: max") {
+		t.Errorf("unexpected test beyond the top:\n%s", out)
+	}
+
+	if code := flaky([]string{"-fail-on-flaky", dir}, ioutil.Discard, ioutil.Discard); code != exitGated {
+		t.Errorf("expected the flaky tests to gate, got %d", code)
+	}
+	if code := flaky([]string{"-slower-than", "4s", dir}, ioutil.Discard, ioutil.Discard); code != exitGated {
+		t.Errorf("expected the slow tests to gate, got %d", code)
+	}
+	if code := flaky([]string{"-slower-than", "10s", dir}, ioutil.Discard, ioutil.Discard); code != exitOK {
+		t.Errorf("unexpected gating, got %d", code)
+	}
+	if code := flaky([]string{filepath.Join(dir, "missing")}, ioutil.Discard, ioutil.Discard); code != exitFailure {
+		t.Errorf("expected a failure for missing results, got %d", code)
+	}
+}
+
+func TestFlakyOutputFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dd-output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A test cycle payload per flush, with the events of the tests and of their suite.
+	event := `{"type":"test","version":2,"content":{"start":%d,"duration":%d,"meta":{"test.suite":"pkg","test.name":"TestFlaky","test.status":%q}}}`
+	var lines []string
+	for i, status := range []string{"fail", "pass", "fail"} {
+		test := fmt.Sprintf(event, (i+1)*int(time.Second), 200*int(time.Millisecond), status)
+		lines = append(lines, `{"version":1,"metadata":{"*":{"language":"go"}},"events":[`+test+`,{"type":"test_suite_end","version":1,"content":{}}]}`)
+	}
+	path := filepath.Join(dir, "dd-results.ndjson")
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	for _, results := range []string{path, dir} {
+		stdout.Reset()
+		if code := flaky([]string{results}, &stdout, &stderr); code != exitOK {
+			t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+		}
+		if out := stdout.String(); !strings.Contains(out, "pkg.TestFlaky: 2 failed of 3 runs, 2 status changes") ||
+			!strings.Contains(out, "pkg.TestFlaky: max 200ms") {
+			t.Errorf("unexpected output for %s:\n%s", results, out)
+		}
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := flaky([]string{path}, ioutil.Discard, ioutil.Discard); code != exitFailure {
+		t.Errorf("expected a failure for a truncated file, got %d", code)
+	}
+}
@@ -0,0 +1,190 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Command ddtest runs `go test` in a single test session, so the packages tested by the test
+// binaries are reported as the modules of one session instead of one session per package:
+//
+//	ddtest ./... -race
+//
+// The arguments are given to `go test`, and ddtest exits with its exit code. With the -monitor
+// flag, given before the arguments of `go test`, ddtest reads the output of the test binaries
+// and reports the tests interrupted by a crash or by the code under test calling os.Exit, whose
+// spans were never flushed by the test binary:
+//
+//	ddtest -monitor ./...
+//
+// The flaky subcommand reads the results recorded locally across many runs, the Allure result
+// files written with WithAllureResults or the test events written in offline export mode, and
+// prints the flaky and the slowest tests. Its exit
+// code can gate a pipeline:
+//
+//	ddtest flaky -fail-on-flaky -slower-than 30s build/allure-results
+//
+// The aggregate subcommand runs a server receiving the `go test -json` outputs of distributed
+// workers, posted to its HTTP endpoint or dropped in a directory, and reports them in a single
+// session with one module per package until it's finished with POST /v1/finish or SIGTERM:
+//
+//	ddtest aggregate -addr :8128 -dir /shared/test-results
+//
+// The upload subcommand sends the test events written to files in offline export mode, with
+// DD_CIVISIBILITY_OUTPUT=file:<path>, from a machine with network access:
+//
+//	ddtest upload -agentless dd-results.ndjson
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/contrib/ddgotestsum"
+)
+
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "flaky" {
+		os.Exit(flaky(args[1:], os.Stdout, os.Stderr))
+	}
+	if len(args) > 0 && args[0] == "aggregate" {
+		os.Exit(aggregate(args[1:], os.Stderr))
+	}
+	if len(args) > 0 && args[0] == "upload" {
+		os.Exit(upload(args[1:], os.Stdout, os.Stderr))
+	}
+	monitor := false
+	for len(args) > 0 && (args[0] == "-monitor" || args[0] == "--monitor") {
+		monitor = true
+		args = args[1:]
+	}
+
+	// The signals are forwarded to `go test` by run, and the session is finished once it exits.
+	ddtesting.Start(ddtesting.WithSessionRoot(), ddtesting.WithSignalHandlerDisabled())
+	var code int
+	if monitor {
+		code = goTestMonitored(args)
+	} else {
+		code = goTest(args)
+	}
+	ddtesting.StopWithExitCode(code)
+	os.Exit(code)
+}
+
+// goTest runs `go test` with the given arguments and returns its exit code. The test binaries
+// inherit the session from the environment variables set by ddtesting.Start.
+func goTest(args []string) int {
+	return run(newGoTest(args))
+}
+
+// goTestMonitored runs `go test -json` with the given arguments, printing the output of the
+// tests like `go test` would, or the JSON events when -json is one of the arguments, and reports
+// the tests interrupted by a crash or an exit.
+func goTestMonitored(args []string) int {
+	raw := false
+	for _, arg := range args {
+		if arg == "-json" || arg == "--json" {
+			raw = true
+		}
+	}
+	cmd := newGoTest(append([]string{"-json"}, args...))
+
+	printR, printW := io.Pipe()
+	reportR, reportW := io.Pipe()
+	cmd.Stdout = io.MultiWriter(printW, reportW)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		printOutput(printR, raw)
+		done <- struct{}{}
+	}()
+	go func() {
+		if err := ddgotestsum.ReportInterrupted(reportR); err != nil {
+			fmt.Fprintf(os.Stderr, "ddtest: %v\n", err)
+		}
+		// Drain the output so the printer isn't blocked when the report fails.
+		io.Copy(ioutil.Discard, reportR)
+		done <- struct{}{}
+	}()
+
+	code := run(cmd)
+	printW.Close()
+	reportW.Close()
+	<-done
+	<-done
+	return code
+}
+
+// newGoTest returns the `go test` command with the given arguments.
+func newGoTest(args []string) *exec.Cmd {
+	cmd := exec.Command("go", append([]string{"test"}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// run runs the command and returns its exit code. The interrupt and termination signals are
+// forwarded to the process group of the command, which is waited for, so the test binaries flush
+// their data and the session is finished with the exit code of the command.
+func run(cmd *exec.Cmd) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "ddtest: %v\n", err)
+		return 1
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				// The error is ignored, the command already exited.
+				signalProcessGroup(cmd, sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "ddtest: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printOutput prints the output of the `go test -json` events, or the events themselves when
+// raw is set. The lines that aren't events, like build errors, are printed as is.
+func printOutput(r io.Reader, raw bool) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var e struct {
+			Action string
+			Output string
+		}
+		if raw || json.Unmarshal(line, &e) != nil {
+			os.Stdout.Write(append(line, '\n'))
+			continue
+		}
+		if e.Action == "output" {
+			os.Stdout.WriteString(e.Output)
+		}
+	}
+	io.Copy(ioutil.Discard, r)
+}
@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing, the command stays in the process group of ddtest.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup sends the signal to the command. The interrupts of the console are already
+// received by all its processes on Windows, where they can't be sent.
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
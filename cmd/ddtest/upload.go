@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+)
+
+// upload sends the test events written to files in offline export mode. It exits with
+// exitFailure when a file can't be read or sent, after trying the other ones.
+func upload(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ddtest upload", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	agentless := fs.Bool("agentless", false, "send the events to the intake with DD_API_KEY instead of the agent")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: ddtest upload [-agentless] <files>...")
+		fmt.Fprintln(stderr, "\nSends the test events written with DD_CIVISIBILITY_OUTPUT=file:<path> or WithOutputFile.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitFailure
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitFailure
+	}
+
+	var opts []ddtesting.RunOption
+	if *agentless {
+		opts = append(opts, ddtesting.WithAgentless())
+	}
+	code := exitOK
+	for _, path := range fs.Args() {
+		n, err := ddtesting.UploadOutputFile(path, opts...)
+		if err != nil {
+			fmt.Fprintf(stderr, "ddtest: uploading %s, %d events sent: %v\n", path, n, err)
+			code = exitFailure
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: %d events sent\n", path, n)
+	}
+	return code
+}
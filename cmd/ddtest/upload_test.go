@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUpload(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := upload(nil, &stdout, &stderr); code != exitFailure || !strings.Contains(stderr.String(), "usage: ddtest upload") {
+		t.Errorf("unexpected exit code %d without files: %s", code, stderr.String())
+	}
+
+	// The agentless mode needs an API key.
+	stderr.Reset()
+	if code := upload([]string{"-agentless", "missing.ndjson"}, &stdout, &stderr); code != exitFailure ||
+		!strings.Contains(stderr.String(), "ddtest: uploading missing.ndjson") {
+		t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+	}
+}
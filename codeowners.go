@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// envCodeownersTeams is the environment variable with the path of the file mapping the code
+// owners to Datadog teams.
+const envCodeownersTeams = "DD_CIVISIBILITY_CODEOWNERS_TEAMS"
+
+// codeownersResolver resolves the owners of the test files from the CODEOWNERS file of the
+// repository, and their Datadog teams.
+type codeownersResolver struct {
+	teams map[string]string
+
+	once       sync.Once
+	codeowners *utils.Codeowners
+	root       string
+
+	mu    sync.Mutex
+	cache map[string][]string
+}
+
+// newCodeownersResolver returns the resolver of the code owners of the configuration. The teams
+// of the mapping file override the ones of the configuration file.
+func newCodeownersResolver(cfg *runConfig) (*codeownersResolver, error) {
+	r := &codeownersResolver{teams: map[string]string{}, cache: map[string][]string{}}
+	for owner, team := range cfg.codeownersTeams {
+		r.teams[strings.ToLower(owner)] = team
+	}
+	if cfg.codeownersTeamsFile == "" {
+		return r, nil
+	}
+	teams, err := readCodeownersTeams(cfg.codeownersTeamsFile)
+	for owner, team := range teams {
+		r.teams[strings.ToLower(owner)] = team
+	}
+	return r, err
+}
+
+// readCodeownersTeams reads a mapping file: a JSON object with the Datadog teams of the GitHub
+// teams and users, like {"@org/payments-backend": "payments", "@alice": "payments"}.
+func readCodeownersTeams(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var teams map[string]string
+	if err := json.Unmarshal(data, &teams); err != nil {
+		return nil, fmt.Errorf("parsing the code owners mapping %s: %v", path, err)
+	}
+	return teams, nil
+}
+
+// owners returns the code owners of a file. The CODEOWNERS file is read from the workspace of
+// the CI tags the first time.
+func (r *codeownersResolver) owners(file string) []string {
+	r.once.Do(func() {
+		ensureCITags()
+		r.root, _ = getFromCITags(constants.CIWorkspacePath)
+		if r.root == "" {
+			return
+		}
+		c, err := utils.FindCodeowners(r.root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: reading the CODEOWNERS file: %v\n", err)
+		}
+		r.codeowners = c
+	})
+	if r.codeowners == nil || file == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if owners, ok := r.cache[file]; ok {
+		return owners
+	}
+	var owners []string
+	if rel, err := filepath.Rel(r.root, file); err == nil && !strings.HasPrefix(rel, "..") {
+		owners = r.codeowners.Owners(filepath.ToSlash(rel))
+	}
+	r.cache[file] = owners
+	return owners
+}
+
+// setTags tags the span of a test with the code owners of its file, and with the Datadog team
+// of the first owner mapped to one.
+func (r *codeownersResolver) setTags(span ddtrace.Span, file string) {
+	if r == nil {
+		return
+	}
+	owners := r.owners(file)
+	if len(owners) == 0 {
+		return
+	}
+	data, _ := json.Marshal(owners)
+	span.SetTag(constants.TestCodeowners, string(data))
+	for _, owner := range owners {
+		if team, ok := r.teams[strings.ToLower(owner)]; ok {
+			span.SetTag(constants.Team, team)
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestCodeownersTags(t *testing.T) {
+	mapping, err := ioutil.TempFile("", "teams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(mapping.Name())
+	mapping.WriteString(`{"@Org/Payments-Backend": "payments"}`)
+	mapping.Close()
+
+	cfg := newRunConfig(WithCodeownersTeams(mapping.Name()))
+	cfg.codeownersTeams = map[string]string{"@org/payments-backend": "checkout", "@org/platform": "platform"}
+	r, err := newCodeownersResolver(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Use a CODEOWNERS file of a fake workspace instead of the one of the CI tags.
+	r.once.Do(func() {})
+	r.root = filepath.FromSlash("/src/repo")
+	r.codeowners, _ = utils.ParseCodeowners(strings.NewReader("* @org/platform\n/payments/ @alice @org/payments-backend\n"))
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("test")
+	r.setTags(span, filepath.FromSlash("/src/repo/payments/card_test.go"))
+	tags := span.(mocktracer.Span).Tags()
+	if tags[constants.TestCodeowners] != `["@alice","@org/payments-backend"]` {
+		t.Errorf("unexpected code owners: %v", tags[constants.TestCodeowners])
+	}
+	// The mapping file overrides the configuration file, and the handles are case-insensitive.
+	if tags[constants.Team] != "payments" {
+		t.Errorf("unexpected team: %v", tags[constants.Team])
+	}
+
+	span = tracer.StartSpan("test")
+	r.setTags(span, filepath.FromSlash("/elsewhere/main_test.go"))
+	if tag := span.(mocktracer.Span).Tag(constants.TestCodeowners); tag != nil {
+		t.Errorf("unexpected code owners outside of the workspace: %v", tag)
+	}
+
+	var none *codeownersResolver
+	none.setTags(span, "main_test.go")
+}
+
+func TestSourceLocationTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, finish := StartTest(t)
+	finish()
+
+	// The source location, resolving the code owners, is the one of the function calling StartTest.
+	s := mt.FinishedSpans()[0]
+	if file, _ := s.Tag(constants.TestSourceFile).(string); filepath.Base(file) != "codeowners_test.go" {
+		t.Errorf("unexpected source file: %q", file)
+	}
+	if line, _ := s.Tag(constants.TestSourceStartLine).(int); line <= 0 {
+		t.Errorf("unexpected source start line: %v", s.Tag(constants.TestSourceStartLine))
+	}
+}
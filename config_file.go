@@ -0,0 +1,266 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+)
+
+// envConfigFile is the environment variable with the path of the configuration file.
+const envConfigFile = "DD_CIVISIBILITY_CONFIG_FILE"
+
+// configFileNames are the names of the configuration file, looked up from the working directory
+// up to the root of the repository.
+var configFileNames = []string{"dd-test.yaml", "dd-test.yml", "dd-test.json"}
+
+// configFile is the configuration loaded from the configuration file.
+type configFile struct {
+	path   string
+	values map[string]interface{}
+}
+
+// loadConfigFile applies the configuration file to the run configuration. The environment
+// variables take precedence over the file, and the options over both.
+func loadConfigFile(cfg *runConfig) {
+	path := os.Getenv(envConfigFile)
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return
+		}
+		if path = findConfigFile(wd); path == "" {
+			return
+		}
+	}
+	file, err := readConfigFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: reading the configuration file: %v\n", err)
+		return
+	}
+	file.apply(cfg)
+}
+
+// findConfigFile returns the path of the configuration file of the nearest directory, from dir
+// up to the root of the repository, or an empty string.
+func findConfigFile(dir string) string {
+	for {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readConfigFile reads a YAML or JSON configuration file.
+func readConfigFile(path string) (*configFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &configFile{path: path}
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(data, &file.values)
+	} else {
+		file.values, err = utils.ParseYAML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return file, nil
+}
+
+// apply sets the values of the file that aren't set by environment variables.
+func (f *configFile) apply(cfg *runConfig) {
+	if v, ok := f.bool("enabled"); ok && !isEnvSet(envEnabled) {
+		cfg.enabled = v
+	}
+	if v := f.string("service"); v != "" && !isEnvSet("DD_SERVICE") {
+		cfg.service = v
+	}
+	if v := f.string("env"); v != "" && !isEnvSet("DD_ENV") {
+		cfg.env = v
+	}
+	if v := f.string("agent_addr"); v != "" && !isEnvSet("DD_AGENT_HOST") && !isEnvSet("DD_TRACE_AGENT_PORT") {
+		cfg.agentAddr = v
+	}
+	if v := f.string("suite_trim_prefix"); v != "" {
+		cfg.suiteTrimPrefix = v
+	}
+
+	envTags := parseDDTags(os.Getenv("DD_TAGS"))
+	tags := f.stringMap("tags")
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		if _, ok := envTags[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cfg.globalTags = append(cfg.globalTags, [2]string{k, tags[k]})
+	}
+
+	services := f.stringMap("services")
+	patterns := make([]string, 0, len(services))
+	for pattern := range services {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		cfg.serviceMappings = append(cfg.serviceMappings, serviceMapping{pattern: pattern, service: services[pattern]})
+	}
+
+	for owner, team := range f.stringMap("codeowners_teams") {
+		if cfg.codeownersTeams == nil {
+			cfg.codeownersTeams = map[string]string{}
+		}
+		cfg.codeownersTeams[owner] = team
+	}
+
+	budgets := f.stringMap("duration_budgets")
+	patterns = patterns[:0]
+	for pattern := range budgets {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		max, err := time.ParseDuration(budgets[pattern])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: %s: invalid duration budget of %s: %v\n", f.path, pattern, err)
+			continue
+		}
+		cfg.durationBudgets = append(cfg.durationBudgets, durationBudget{pattern: pattern, max: max})
+	}
+	if v, ok := f.bool("duration_budgets_enforced"); ok {
+		cfg.durationBudgetsEnforced = v
+	}
+
+	for _, expr := range f.stringList("scrub") {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: %s: invalid scrubbing rule: %v\n", f.path, err)
+			continue
+		}
+		cfg.scrubRules = append(cfg.scrubRules, utils.ScrubRule{Pattern: pattern, Replacement: utils.ScrubbedValue})
+	}
+
+	for _, feature := range f.stringList("features") {
+		if featureSetLocally(cfg, feature) {
+			continue
+		}
+		if !enableFeature(cfg, feature) {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: %s: unknown feature %q\n", f.path, feature)
+		}
+	}
+}
+
+// enableFeature enables the feature with the given name, and returns false if it's unknown.
+func enableFeature(cfg *runConfig, feature string) bool {
+	switch feature {
+	case "runtime_metrics":
+		cfg.runtimeMetrics = true
+	case "file_leak_check":
+		cfg.fileLeakCheck = true
+	case "goroutine_leak_check":
+		cfg.leakCheck = true
+	case "logs_forwarding":
+		cfg.logsForwarding = true
+	case "flush_on_test_finish":
+		cfg.flushOnTestFinish = true
+	case "session_level_ci_tags":
+		cfg.ciTagsLevel = ciTagsLevelSession
+	default:
+		return false
+	}
+	return true
+}
+
+// string returns the value of a key as a string.
+func (f *configFile) string(key string) string {
+	switch v := f.values[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// bool returns the value of a boolean key, and whether it's set.
+func (f *configFile) bool(key string) (bool, bool) {
+	if v, ok := f.values[key].(bool); ok {
+		return v, true
+	}
+	v, err := strconv.ParseBool(f.string(key))
+	return v, err == nil
+}
+
+// stringMap returns the value of a mapping key, with the values as strings.
+func (f *configFile) stringMap(key string) map[string]string {
+	values, _ := f.values[key].(map[string]interface{})
+	m := make(map[string]string, len(values))
+	for k, v := range values {
+		m[k] = fmt.Sprint(v)
+	}
+	return m
+}
+
+// stringList returns the value of a sequence key, with the items as strings.
+func (f *configFile) stringList(key string) []string {
+	switch v := f.values[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return items
+	}
+	return nil
+}
+
+// isEnvSet returns whether the environment variable is set to a non-empty value.
+func isEnvSet(name string) bool {
+	return os.Getenv(name) != ""
+}
+
+// parseDDTags parses the `key:value` pairs of the DD_TAGS environment variable, separated
+// by commas or spaces.
+func parseDDTags(s string) map[string]string {
+	tags := map[string]string{}
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		kv := strings.SplitN(tag, ":", 2)
+		if len(kv) == 2 {
+			tags[kv[0]] = kv[1]
+		} else {
+			tags[kv[0]] = ""
+		}
+	}
+	return tags
+}
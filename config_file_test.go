@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const configYAML = `service: my-service
+env: staging
+enabled: true
+agent_addr: agent:8126
+suite_trim_prefix: github.com/DataDog/
+tags:
+  team: platform
+  owner: go
+services:
+  example.com/repo/payments/...: payments
+scrub:
+  - internal-[a-z]+
+codeowners_teams:
+  "@org/payments-backend": payments
+features:
+  - runtime_metrics
+  - file_leak_check
+`
+
+func TestConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dd-test-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	nested := filepath.Join(dir, "module", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.Mkdir(filepath.Join(dir, ".git"), 0755)
+	path := filepath.Join(dir, "dd-test.yaml")
+	if err := ioutil.WriteFile(path, []byte(configYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if found := findConfigFile(nested); found != path {
+		t.Fatalf("unexpected configuration file: %q", found)
+	}
+	file, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"DD_SERVICE", "DD_ENV", "DD_AGENT_HOST", "DD_TRACE_AGENT_PORT", "DD_TAGS"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+	os.Setenv("DD_ENV", "ci")
+	os.Setenv("DD_TAGS", "owner:sre")
+
+	cfg := new(runConfig)
+	runDefaults(cfg)
+	file.apply(cfg)
+	if cfg.service != "my-service" || cfg.agentAddr != "agent:8126" || cfg.suiteTrimPrefix != "github.com/DataDog/" {
+		t.Errorf("unexpected configuration: %+v", cfg)
+	}
+	if cfg.env != "" {
+		t.Errorf("DD_ENV doesn't take precedence over the file: %q", cfg.env)
+	}
+	if expected := [][2]string{{"team", "platform"}}; !reflect.DeepEqual(cfg.globalTags, expected) {
+		t.Errorf("unexpected global tags: %v", cfg.globalTags)
+	}
+	if expected := []serviceMapping{{pattern: "example.com/repo/payments/...", service: "payments"}}; !reflect.DeepEqual(cfg.serviceMappings, expected) {
+		t.Errorf("unexpected service mappings: %v", cfg.serviceMappings)
+	}
+	if expected := map[string]string{"@org/payments-backend": "payments"}; !reflect.DeepEqual(cfg.codeownersTeams, expected) {
+		t.Errorf("unexpected code owners teams: %v", cfg.codeownersTeams)
+	}
+	if len(cfg.scrubRules) != 1 || cfg.scrubRules[0].Pattern.String() != "internal-[a-z]+" {
+		t.Errorf("unexpected scrubbing rules: %v", cfg.scrubRules)
+	}
+	if !cfg.runtimeMetrics || !cfg.fileLeakCheck || cfg.leakCheck {
+		t.Errorf("unexpected features: %+v", cfg)
+	}
+}
+
+func TestConfigFileJSON(t *testing.T) {
+	file, err := ioutil.TempFile("", "dd-test-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"enabled": false, "features": ["goroutine_leak_check"], "tags": {"tier": 1}}`)
+	file.Close()
+
+	defer os.Setenv(envEnabled, os.Getenv(envEnabled))
+	defer os.Setenv(envConfigFile, os.Getenv(envConfigFile))
+	os.Unsetenv(envEnabled)
+	os.Setenv(envConfigFile, file.Name())
+
+	cfg := newRunConfig(WithEnv("test"))
+	if cfg.enabled || !cfg.leakCheck || cfg.env != "test" {
+		t.Errorf("unexpected configuration: %+v", cfg)
+	}
+	if expected := [][2]string{{"tier", "1"}}; !reflect.DeepEqual(cfg.globalTags, expected) {
+		t.Errorf("unexpected global tags: %v", cfg.globalTags)
+	}
+}
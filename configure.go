@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+var (
+	// configuredOpts are the options stored by Configure, applied before the options given
+	// to Run, RunWithOptions or Start.
+	configuredOpts      []RunOption
+	configuredOptsMutex sync.Mutex
+)
+
+// Config is the configuration of the SDK given to Configure. The empty fields keep their
+// default value.
+type Config struct {
+	// Service is the name of the service or library under test.
+	Service string
+
+	// Env is the environment of the tests.
+	Env string
+
+	// AgentURL is the URL of the Datadog Agent: http://host:port, or unix:///path/to/socket
+	// for a Unix domain socket. The agent is reached in plain HTTP, https isn't supported.
+	AgentURL string
+
+	// Options are additional options.
+	Options []RunOption
+}
+
+// Configure validates and stores the configuration of the SDK, used by the session started
+// afterwards by Run, RunWithOptions or Start. The options given to them take precedence.
+// Invalid or conflicting settings are returned as an error, so wrapper libraries can fail
+// fast and test their configuration. It must be called before the session starts.
+func Configure(cfg Config) error {
+	opts, err := cfg.options()
+	if err != nil {
+		return err
+	}
+	runCfg := new(runConfig)
+	runDefaults(runCfg)
+	for _, fn := range opts {
+		fn(runCfg)
+	}
+	if errs := runCfg.validate(); len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+		return fmt.Errorf("invalid configuration: %s", strings.Join(msgs, "; "))
+	}
+	if currentSession() != nil {
+		return errors.New("the test session is already started")
+	}
+
+	configuredOptsMutex.Lock()
+	defer configuredOptsMutex.Unlock()
+	configuredOpts = opts
+	return nil
+}
+
+// options returns the options of the configuration.
+func (cfg Config) options() ([]RunOption, error) {
+	var opts []RunOption
+	if cfg.Service != "" {
+		opts = append(opts, func(c *runConfig) { c.service = cfg.Service })
+	}
+	if cfg.Env != "" {
+		opts = append(opts, WithEnv(cfg.Env))
+	}
+	if cfg.AgentURL != "" {
+		u, err := url.Parse(cfg.AgentURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration: the agent URL is invalid: %v", err)
+		}
+		switch u.Scheme {
+		case "https":
+			// The tracer sends the payloads to the agent address in plain HTTP.
+			return nil, fmt.Errorf("invalid configuration: the agent URL %s uses https, the agent only accepts http", cfg.AgentURL)
+		case "http":
+			if u.Port() == "" {
+				return nil, fmt.Errorf("invalid configuration: the agent URL %s has no port", cfg.AgentURL)
+			}
+			opts = append(opts, WithAgentAddr(u.Host))
+		case "unix":
+			if u.Path == "" {
+				return nil, fmt.Errorf("invalid configuration: the agent URL %s has no socket path", cfg.AgentURL)
+			}
+			opts = append(opts, WithUDS(u.Path))
+		default:
+			return nil, fmt.Errorf("invalid configuration: the agent URL %s must use the http or unix scheme", cfg.AgentURL)
+		}
+	}
+	return append(opts, cfg.Options...), nil
+}
+
+// configuredOptions returns the options stored by Configure.
+func configuredOptions() []RunOption {
+	configuredOptsMutex.Lock()
+	defer configuredOptsMutex.Unlock()
+	return configuredOpts
+}
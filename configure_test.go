@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigAgentURL(t *testing.T) {
+	for agentURL, expected := range map[string][2]string{
+		"http://agent:8126":                  {"agent:8126", ""},
+		"unix:///var/run/datadog/apm.socket": {"", "/var/run/datadog/apm.socket"},
+	} {
+		opts, err := Config{AgentURL: agentURL}.options()
+		if err != nil {
+			t.Errorf("%s: %v", agentURL, err)
+			continue
+		}
+		cfg := newRunConfig(opts...)
+		if cfg.agentAddr != expected[0] || cfg.udsPath != expected[1] {
+			t.Errorf("%s: unexpected agent %q and socket %q", agentURL, cfg.agentAddr, cfg.udsPath)
+		}
+	}
+}
+
+func TestConfigureErrors(t *testing.T) {
+	for _, test := range []struct {
+		cfg Config
+		err string
+	}{
+		{cfg: Config{AgentURL: "http://agent"}, err: "has no port"},
+		{cfg: Config{AgentURL: "ftp://agent:21"}, err: "must use the http or unix scheme"},
+		{cfg: Config{AgentURL: "https://agent:443"}, err: "uses https"},
+		{cfg: Config{AgentURL: "unix://"}, err: "has no socket path"},
+		{cfg: Config{AgentURL: "http://agent:8126", Options: []RunOption{WithUDS("/tmp/apm.socket")}}, err: "is ignored"},
+		{cfg: Config{Options: []RunOption{WithSampleRate(-0.5)}}, err: "sample rate"},
+		// The tests of the package run in the session started by TestMain.
+		{cfg: Config{Service: "my-service"}, err: "already started"},
+	} {
+		if err := Configure(test.cfg); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%+v: expected error %q, got %v", test.cfg, test.err, err)
+		}
+	}
+}
+
+func TestConfiguredOptions(t *testing.T) {
+	defer func(opts []RunOption) { configuredOpts = opts }(configuredOptions())
+	opts, err := Config{Service: "my-service", Env: "test"}.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configuredOpts = opts
+
+	cfg := newRunConfig(WithEnv("override"))
+	if cfg.service != "my-service" || cfg.env != "override" {
+		t.Errorf("unexpected configuration: %+v", cfg)
+	}
+}
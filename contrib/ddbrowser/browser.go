@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddbrowser correlates the browser tests driven by chromedp, Selenium or any other
+// driver with the Datadog RUM sessions of the application under test.
+//
+// The RUM SDK links its session to the test when the page has the test execution cookie.
+// With chromedp:
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//	ddbrowser.SetBrowser(ctx, "chrome", version, "chromedp", "")
+//
+//	c := ddbrowser.Cookie(ctx)
+//	err := chromedp.Run(browserCtx,
+//		network.SetCookie(c.Name, c.Value).WithURL(appURL),
+//		network.SetExtraHTTPHeaders(network.Headers(ddbrowser.HeadersMap(ctx))),
+//		chromedp.Navigate(appURL),
+//		// ...
+//		chromedp.Evaluate(ddbrowser.StopRUMSessionScript, nil),
+//	)
+//
+// The headers propagate the trace context of the test to the backend of the application, so
+// its spans appear under the test span.
+package ddbrowser
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/isolated"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// CookieName is the name of the cookie read by the RUM SDK to link its session to a test.
+const CookieName = "datadog-ci-visibility-test-execution-id"
+
+// StopRUMSessionScript is the script stopping the RUM session at the end of a test, so the
+// RUM data is sent before the browser closes.
+const StopRUMSessionScript = `if (window.DD_RUM && typeof window.DD_RUM.stopSession === "function") { window.DD_RUM.stopSession(); }`
+
+// TestExecutionID returns the ID of the test execution of the span of ctx, its trace ID.
+// It returns an empty string when ctx has no span.
+func TestExecutionID(ctx context.Context) string {
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return strconv.FormatUint(span.Context().TraceID(), 10)
+}
+
+// Cookie returns the test execution cookie to set in the browser before loading the
+// application. It returns nil when ctx has no span.
+func Cookie(ctx context.Context) *http.Cookie {
+	id := TestExecutionID(ctx)
+	if id == "" {
+		return nil
+	}
+	return &http.Cookie{Name: CookieName, Value: id, Path: "/"}
+}
+
+// Headers returns the HTTP headers propagating the trace context of the span of ctx, to be
+// added to the requests made by the browser.
+func Headers(ctx context.Context) http.Header {
+	h := http.Header{}
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		isolated.Inject(span.Context(), tracer.HTTPHeadersCarrier(h))
+	}
+	return h
+}
+
+// HeadersMap returns the headers of Headers as a map, the format expected by the drivers.
+func HeadersMap(ctx context.Context) map[string]interface{} {
+	m := map[string]interface{}{}
+	for k, vs := range Headers(ctx) {
+		if len(vs) > 0 {
+			m[k] = vs[0]
+		}
+	}
+	return m
+}
+
+// URL returns the URL with the test execution ID added as a query parameter, for the
+// applications reading it from the URL when cookies can't be set before the first page load.
+func URL(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if id := TestExecutionID(ctx); id != "" {
+		q := u.Query()
+		q.Set(CookieName, id)
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+// SetBrowser tags the test span of ctx as a browser test with the browser and the driver
+// used. Empty values are omitted.
+func SetBrowser(ctx context.Context, name, version, driver, driverVersion string) {
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	span.SetTag(constants.TestIsBrowser, "true")
+	for tag, value := range map[string]string{
+		constants.TestBrowserName:          name,
+		constants.TestBrowserVersion:       version,
+		constants.TestBrowserDriver:        driver,
+		constants.TestBrowserDriverVersion: driverVersion,
+	} {
+		if value != "" {
+			span.SetTag(tag, value)
+		}
+	}
+}
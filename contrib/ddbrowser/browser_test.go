@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddbrowser
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestBrowser(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	if c := Cookie(context.Background()); c != nil {
+		t.Errorf("unexpected cookie without span: %v", c)
+	}
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	id := strconv.FormatUint(span.Context().TraceID(), 10)
+
+	if c := Cookie(ctx); c == nil || c.Name != CookieName || c.Value != id {
+		t.Errorf("unexpected cookie: %v", c)
+	}
+	if h := Headers(ctx); h.Get("x-datadog-trace-id") != id {
+		t.Errorf("unexpected headers: %v", h)
+	}
+	if u, err := URL(ctx, "http://localhost:8080/login?next=home"); err != nil ||
+		u != "http://localhost:8080/login?"+CookieName+"="+id+"&next=home" {
+		t.Errorf("unexpected URL: %s (%v)", u, err)
+	}
+
+	SetBrowser(ctx, "chrome", "94.0", "chromedp", "")
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	if s.Tag(constants.TestIsBrowser) != "true" || s.Tag(constants.TestBrowserName) != "chrome" ||
+		s.Tag(constants.TestBrowserVersion) != "94.0" || s.Tag(constants.TestBrowserDriver) != "chromedp" {
+		t.Errorf("unexpected browser tags: %v", s.Tags())
+	}
+	if _, ok := s.Tags()[constants.TestBrowserDriverVersion]; ok {
+		t.Error("unexpected empty driver version tag")
+	}
+}
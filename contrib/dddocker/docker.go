@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package dddocker records the Docker containers used by a test as child spans of the
+// test span. It doesn't depend on any Docker client, so it can be used with
+// github.com/ory/dockertest, the Docker CLI or any other tool:
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//
+//	c := dddocker.Record(ctx, "postgres:13")
+//	resource, err := pool.Run("postgres", "13", nil)
+//	if err != nil {
+//		c.Finish(-1, err)
+//		t.Fatal(err)
+//	}
+//	c.SetContainerID(resource.Container.ID)
+//	defer func() { c.Finish(0, pool.Purge(resource)) }()
+//
+// Command runs the Docker CLI recording the command as a child span:
+//
+//	out, err := dddocker.Command(ctx, "run", "--rm", "alpine", "true")
+package dddocker
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/isolated"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	containerOperation = "docker.container"
+	commandOperation   = "docker.command"
+)
+
+// Container is the span of a container used by a test.
+type Container struct {
+	span ddtrace.Span
+}
+
+// Record starts the span of a container of the given image as a child of the test span of ctx.
+// The span lasts until Finish is called.
+func Record(ctx context.Context, image string) *Container {
+	span, _ := isolated.StartSpanFromContext(ctx, containerOperation,
+		tracer.ResourceName(image),
+		tracer.Tag(constants.DockerImage, image))
+	return &Container{span: span}
+}
+
+// SetContainerID sets the ID of the container.
+func (c *Container) SetContainerID(id string) {
+	c.span.SetTag(constants.DockerContainerID, id)
+}
+
+// Finish finishes the span of the container with its exit code and error, if any.
+func (c *Container) Finish(exitCode int, err error) {
+	c.span.SetTag(constants.DockerExitCode, exitCode)
+	c.span.Finish(tracer.WithError(err))
+}
+
+// Command runs the docker CLI with the given arguments, recording the command as a child
+// span of the test span of ctx. It returns the combined output of the command.
+func Command(ctx context.Context, args ...string) ([]byte, error) {
+	span, _ := isolated.StartSpanFromContext(ctx, commandOperation,
+		tracer.ResourceName(commandResource(args)),
+		tracer.Tag(constants.DockerCommand, strings.Join(args, " ")))
+	if image := runImage(args); image != "" {
+		span.SetTag(constants.DockerImage, image)
+	}
+
+	cmd := exec.Command("docker", args...)
+	out, err := cmd.CombinedOutput()
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		exitCode = -1
+	}
+	if image := runImage(args); image != "" && err == nil {
+		// docker run -d prints the ID of the started container.
+		if id := strings.TrimSpace(string(out)); len(id) == 64 && !strings.ContainsAny(id, " \n") {
+			span.SetTag(constants.DockerContainerID, id)
+		}
+	}
+	span.SetTag(constants.DockerExitCode, exitCode)
+	span.Finish(tracer.WithError(err))
+	return out, err
+}
+
+// commandResource returns the docker subcommand used as resource name.
+func commandResource(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return "docker " + arg
+		}
+	}
+	return "docker"
+}
+
+// runImage returns the image of a `docker run` command: the first argument after the
+// run subcommand that isn't a flag or a flag value.
+func runImage(args []string) string {
+	i := 0
+	for ; i < len(args) && args[i] != "run"; i++ {
+	}
+	for i++; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+		// Flags with a separate value, e.g. `-e KEY=VALUE`.
+		if !strings.Contains(arg, "=") && flagWithValue(arg) {
+			i++
+		}
+	}
+	return ""
+}
+
+// flagWithValue returns whether a `docker run` flag expects a value.
+func flagWithValue(flag string) bool {
+	switch flag {
+	case "-d", "--detach", "--rm", "-i", "--interactive", "-t", "--tty", "--privileged", "--init", "-P", "--publish-all", "--read-only":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dddocker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestRunImage(t *testing.T) {
+	tests := []struct {
+		args  []string
+		image string
+	}{
+		{[]string{"run", "alpine"}, "alpine"},
+		{[]string{"run", "--rm", "-d", "-e", "A=B", "-p", "5432:5432", "postgres:13", "postgres"}, "postgres:13"},
+		{[]string{"--debug", "run", "--name=db", "redis"}, "redis"},
+		{[]string{"ps", "-a"}, ""},
+	}
+	for _, test := range tests {
+		if image := runImage(test.args); image != test.image {
+			t.Errorf("unexpected image for %v: %s", test.args, image)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	c := Record(ctx, "postgres:13")
+	c.SetContainerID("abc")
+	c.Finish(1, errors.New("exited"))
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	if s.ParentID() != span.Context().SpanID() {
+		t.Error("container span is not a child of the test span")
+	}
+	if s.Tag(constants.DockerImage) != "postgres:13" || s.Tag(constants.DockerContainerID) != "abc" || s.Tag(constants.DockerExitCode) != 1 {
+		t.Errorf("unexpected tags: %v", s.Tags())
+	}
+}
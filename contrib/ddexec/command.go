@@ -0,0 +1,189 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddexec
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/isolated"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	commandOperation = "exec.command"
+
+	// maxStderrSize is the maximum size of the end of the standard error kept in the span.
+	maxStderrSize = 4 * 1024
+)
+
+// Cmd is an exec.Cmd recorded as a child span of the test span when it runs. The environment
+// of the command contains the trace context of its span, see Env.
+type Cmd struct {
+	*exec.Cmd
+
+	ctx    context.Context
+	span   ddtrace.Span
+	stderr tailBuffer
+}
+
+// CommandContext returns a Cmd to run the named program with the given arguments, like
+// exec.CommandContext. The command is recorded as a child span of the test span of ctx:
+//
+//	out, err := ddexec.CommandContext(ctx, "./bin/cli", "migrate", "--dry-run").Output()
+func CommandContext(ctx context.Context, name string, args ...string) *Cmd {
+	return &Cmd{Cmd: exec.CommandContext(ctx, name, args...), ctx: ctx}
+}
+
+// Start starts the command and its span.
+func (c *Cmd) Start() error {
+	var ctx context.Context
+	c.span, ctx = isolated.StartSpanFromContext(c.ctx, commandOperation,
+		tracer.ResourceName(filepath.Base(c.Path)),
+		tracer.Tag(constants.ExecCommand, strings.Join(ScrubArgs(c.Args), " ")))
+
+	if c.Env == nil {
+		c.Env = os.Environ()
+	}
+	c.Env = append(c.Env, Env(ctx)...)
+	if c.Stderr == nil {
+		c.Stderr = &c.stderr
+	} else {
+		c.Stderr = io.MultiWriter(c.Stderr, &c.stderr)
+	}
+
+	if err := c.Cmd.Start(); err != nil {
+		c.finish(err)
+		return err
+	}
+	return nil
+}
+
+// Wait waits for the command to exit and finishes its span.
+func (c *Cmd) Wait() error {
+	err := c.Cmd.Wait()
+	c.finish(err)
+	return err
+}
+
+// Run starts the command and waits for it to exit.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output, like exec.Cmd.Output.
+func (c *Cmd) Output() ([]byte, error) {
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	err := c.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && c.Stderr == &c.stderr {
+		exitErr.Stderr = c.stderr.Bytes()
+	}
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its combined standard output and standard
+// error, like exec.Cmd.CombinedOutput.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	var out syncBuffer
+	c.Stdout = &out
+	c.Stderr = &out
+	err := c.Run()
+	return out.Bytes(), err
+}
+
+// finish finishes the span of the command with its exit code and standard error.
+func (c *Cmd) finish(err error) {
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		exitCode = -1
+	}
+	c.span.SetTag(constants.ExecExitCode, exitCode)
+	if stderr := c.stderr.String(); stderr != "" {
+		c.span.SetTag(constants.ExecStderr, stderr)
+	}
+	c.span.Finish(tracer.WithError(err))
+}
+
+// ScrubArgs returns a copy of the arguments of a command with the values of sensitive flags,
+// like --password=value or --token value, and the credentials of URLs replaced.
+func ScrubArgs(args []string) []string {
+	scrubbed := make([]string, len(args))
+	scrubNext := false
+	for i, arg := range args {
+		switch {
+		case scrubNext:
+			arg = utils.ScrubbedValue
+			scrubNext = false
+		case strings.Contains(arg, "="):
+			idx := strings.IndexByte(arg, '=')
+			arg = arg[:idx+1] + utils.ScrubValue(arg[:idx], arg[idx+1:])
+		case strings.HasPrefix(arg, "-") && utils.IsSensitiveName(arg):
+			scrubNext = true
+		}
+		scrubbed[i] = utils.ScrubURLCredentials(arg)
+	}
+	return scrubbed
+}
+
+// tailBuffer keeps the last bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > maxStderrSize {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-maxStderrSize:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf...)
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.Bytes())
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of the standard output
+// and error of a command.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
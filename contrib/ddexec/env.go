@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddexec provides helpers to trace the processes started by the tests.
+//
+// CommandContext records the external commands run by a test as child spans of the test span,
+// with their scrubbed arguments, exit code and the end of their standard error:
+//
+//	out, err := ddexec.CommandContext(ctx, "git", "clone", repoURL).CombinedOutput()
+//
+// Env returns the environment variables propagating the trace context of the test span to a
+// child process, like the compiled binary of the service under test:
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//
+//	cmd := exec.Command("./bin/server")
+//	cmd.Env = append(os.Environ(), ddexec.Env(ctx)...)
+//
+// The child process continues the trace with ExtractEnv:
+//
+//	opts := []ddtrace.StartSpanOption{}
+//	if sctx, err := ddexec.ExtractEnv(); err == nil {
+//		opts = append(opts, tracer.ChildOf(sctx))
+//	}
+//	span := tracer.StartSpan("server.start", opts...)
+package ddexec
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/isolated"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// envPrefix is the prefix of the environment variables propagating the trace context. The
+// variables are the propagation headers in uppercase, e.g. X_DATADOG_TRACE_ID.
+const envPrefix = "X_DATADOG_"
+
+// envCarrier adapts a list of environment variables to the tracer propagation carriers.
+type envCarrier struct {
+	env []string
+}
+
+func (c *envCarrier) Set(key, val string) {
+	name := strings.ToUpper(strings.Replace(key, "-", "_", -1))
+	c.env = append(c.env, name+"="+val)
+}
+
+func (c *envCarrier) ForeachKey(handler func(key, val string) error) error {
+	for _, kv := range c.env {
+		if !strings.HasPrefix(kv, envPrefix) {
+			continue
+		}
+		idx := strings.IndexByte(kv, '=')
+		if idx < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.Replace(kv[:idx], "_", "-", -1))
+		if err := handler(key, kv[idx+1:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Env returns the environment variables containing the trace context of the span of ctx, in
+// the KEY=value format of exec.Cmd.Env. They contain the trace ID, the parent span ID and the
+// origin and sampling priority of the trace. It returns nil when ctx has no span.
+func Env(ctx context.Context) []string {
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	c := &envCarrier{}
+	isolated.Inject(span.Context(), c)
+	return c.env
+}
+
+// ExtractEnv returns the trace context propagated in the environment of the current process.
+func ExtractEnv() (ddtrace.SpanContext, error) {
+	return isolated.Extract(&envCarrier{env: os.Environ()})
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddexec
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestEnv(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	if env := Env(context.Background()); env != nil {
+		t.Errorf("unexpected environment without span: %v", env)
+	}
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	defer span.Finish()
+
+	env := Env(ctx)
+	expected := "X_DATADOG_TRACE_ID=" + strconv.FormatUint(span.Context().TraceID(), 10)
+	found := false
+	for _, kv := range env {
+		if kv == expected {
+			found = true
+		}
+		if !strings.HasPrefix(kv, envPrefix) {
+			t.Errorf("unexpected variable: %s", kv)
+		}
+	}
+	if !found {
+		t.Errorf("trace ID not found in %v", env)
+	}
+
+	for _, kv := range env {
+		idx := strings.IndexByte(kv, '=')
+		defer os.Unsetenv(kv[:idx])
+		os.Setenv(kv[:idx], kv[idx+1:])
+	}
+	sctx, err := ExtractEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sctx.TraceID() != span.Context().TraceID() || sctx.SpanID() != span.Context().SpanID() {
+		t.Errorf("unexpected extracted context: %d/%d", sctx.TraceID(), sctx.SpanID())
+	}
+}
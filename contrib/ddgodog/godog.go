@@ -0,0 +1,176 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddgodog reports github.com/cucumber/godog features and scenarios.
+//
+// Every feature is reported as a suite, and every scenario as a test of its feature. The features
+// join the module of the running test session, or a godog module without session.
+// The functions of this package must be called from the godog hooks:
+//
+//	func InitializeTestSuite(sc *godog.TestSuiteContext) {
+//		sc.AfterSuite(ddgodog.FinishFeatures)
+//	}
+//
+//	func InitializeScenario(sc *godog.ScenarioContext) {
+//		sc.Before(func(ctx context.Context, s *godog.Scenario) (context.Context, error) {
+//			var tags []string
+//			for _, tag := range s.Tags {
+//				tags = append(tags, tag.Name)
+//			}
+//			return ddgodog.StartScenario(ctx, s.Uri, s.Name, tags, len(s.Steps)), nil
+//		})
+//		sc.StepContext().After(func(ctx context.Context, st *godog.Step, status godog.StepResultStatus, err error) (context.Context, error) {
+//			if err != nil {
+//				ddgodog.StepFailed(ctx, st.Text, err)
+//			}
+//			return ctx, nil
+//		})
+//		sc.After(func(ctx context.Context, s *godog.Scenario, err error) (context.Context, error) {
+//			ddgodog.FinishScenario(ctx, err)
+//			return ctx, nil
+//		})
+//	}
+package ddgodog
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	testFramework = "github.com/cucumber/godog"
+
+	// moduleName is the name of the module of the features run without test session.
+	moduleName = "godog"
+)
+
+type contextKey struct{}
+
+var (
+	// features contains the suite of each feature by its URI.
+	features = map[string]*ddtesting.TestSuite{}
+	// module is the module of the features when no test session is running.
+	module        *ddtesting.TestModule
+	featuresMutex sync.Mutex
+)
+
+// scenario contains the state of a running scenario.
+type scenario struct {
+	tb         *ddtesting.TBAdapter
+	finish     ddtesting.FinishFunc
+	mu         sync.Mutex
+	failedStep string
+	err        error
+}
+
+// StartScenario starts the test span of a scenario, starting the suite of its feature if needed.
+// The returned context must be used by the following hooks of the scenario.
+func StartScenario(ctx context.Context, featureURI, name string, tags []string, steps int,
+	opts ...ddtesting.Option) context.Context {
+	f := startFeature(featureURI)
+
+	s := &scenario{tb: &ddtesting.TBAdapter{TestName: name}}
+	scenarioOpts := []ddtesting.Option{
+		ddtesting.WithTestFramework(testFramework),
+		ddtesting.WithTestSuite(featureURI),
+		ddtesting.WithSuite(f),
+		ddtesting.WithSourceLocation(featureURI, 0),
+		ddtesting.WithSpanOptions(
+			tracer.Tag(constants.TestType, constants.TestTypeTest),
+			tracer.Tag(constants.BDDScenarioTags, strings.Join(tags, ",")),
+			tracer.Tag(constants.BDDStepsCount, steps),
+		),
+	}
+	opts = append(scenarioOpts, opts...)
+
+	testCtx, finish := ddtesting.StartTestWithContext(context.Background(), s.tb, opts...)
+	s.finish = finish
+	if span, ok := tracer.SpanFromContext(testCtx); ok {
+		ctx = tracer.ContextWithSpan(ctx, span)
+	}
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// StepFailed records the failed step in the running scenario.
+func StepFailed(ctx context.Context, step string, err error) {
+	s, ok := ctx.Value(contextKey{}).(*scenario)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failedStep == "" {
+		s.failedStep = step
+		s.err = err
+	}
+}
+
+// FinishScenario finishes the test span of the scenario, it fails when err is not nil.
+func FinishScenario(ctx context.Context, err error) {
+	s, ok := ctx.Value(contextKey{}).(*scenario)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	if err == nil {
+		err = s.err
+	}
+	failedStep := s.failedStep
+	s.mu.Unlock()
+
+	if err != nil {
+		s.tb.Fail()
+		if span, ok := tracer.SpanFromContext(ctx); ok {
+			span.SetTag(ext.ErrorMsg, err.Error())
+			if failedStep != "" {
+				span.SetTag(constants.BDDFailedStep, failedStep)
+			}
+		}
+	}
+	s.finish()
+}
+
+// FinishFeatures finishes the suites of all the features, tagged with the status rolled up
+// from their scenarios.
+func FinishFeatures() {
+	featuresMutex.Lock()
+	defer featuresMutex.Unlock()
+
+	for uri, f := range features {
+		f.Finish()
+		delete(features, uri)
+	}
+	module.Finish()
+	module = nil
+}
+
+// startFeature returns the suite of a feature, starting it on the first call.
+func startFeature(uri string) *ddtesting.TestSuite {
+	featuresMutex.Lock()
+	defer featuresMutex.Unlock()
+
+	if f, ok := features[uri]; ok {
+		return f
+	}
+
+	framework := tracer.Tag(constants.TestFramework, testFramework)
+	f := ddtesting.StartTestSuite(uri, framework)
+	if f == nil {
+		if module == nil {
+			module = ddtesting.StartTestModule(moduleName, framework)
+		}
+		f = module.StartTestSuite(uri, framework)
+	}
+	features[uri] = f
+	return f
+}
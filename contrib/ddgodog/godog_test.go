@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddgodog
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestScenarios(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx := StartScenario(context.Background(), "features/login.feature", "successful login", []string{"@smoke"}, 3)
+	FinishScenario(ctx, nil)
+
+	ctx = StartScenario(context.Background(), "features/login.feature", "wrong password", nil, 2)
+	StepFailed(ctx, "the user is logged in", errors.New("not logged in"))
+	FinishScenario(ctx, nil)
+
+	FinishFeatures()
+
+	// Without test session, the feature is a suite of the godog module.
+	spans := mt.FinishedSpans()
+	if len(spans) != 4 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+
+	suite := spans[2]
+	if suite.OperationName() != constants.SpanTypeTestSuite || suite.Tag(constants.TestStatus) != constants.TestStatusFail ||
+		suite.Tag(constants.TestSuite) != "features/login.feature" || suite.Tag(constants.TestFramework) != testFramework {
+		t.Errorf("unexpected suite span: %v", suite)
+	}
+	module := spans[3]
+	if module.OperationName() != constants.SpanTypeTestModule || module.Tag(constants.TestModule) != moduleName ||
+		suite.ParentID() != module.SpanID() {
+		t.Errorf("unexpected module span: %v", module)
+	}
+
+	pass := spans[0]
+	if pass.Tag(constants.TestName) != "successful login" || pass.Tag(constants.TestStatus) != constants.TestStatusPass {
+		t.Errorf("unexpected passed scenario span: %v", pass)
+	}
+	if pass.Tag(constants.BDDScenarioTags) != "@smoke" || pass.Tag(constants.BDDStepsCount) != 3 {
+		t.Errorf("unexpected scenario tags: %v", pass.Tags())
+	}
+	if pass.ParentID() != suite.SpanID() || pass.Tag(constants.TestSuiteID) != suite.Tag(constants.TestSuiteID) {
+		t.Error("scenario span is not a child of the feature span")
+	}
+
+	fail := spans[1]
+	if fail.Tag(constants.TestStatus) != constants.TestStatusFail || fail.Tag(ext.ErrorMsg) != "not logged in" {
+		t.Errorf("unexpected failed scenario span: %v", fail.Tags())
+	}
+	if fail.Tag(constants.BDDFailedStep) != "the user is logged in" {
+		t.Errorf("unexpected failed step: %v", fail.Tag(constants.BDDFailedStep))
+	}
+}
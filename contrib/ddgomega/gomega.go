@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddgomega records the failed github.com/onsi/gomega expectations in the test span
+// when gomega is used outside Ginkgo.
+//
+// Use T to create the gomega instance of a test:
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//
+//	g := gomega.NewWithT(ddgomega.T(ctx, t))
+//	g.Expect(actual).To(gomega.Equal(expected))
+//
+// Or register FailHandler as the global gomega fail handler:
+//
+//	gomega.RegisterFailHandler(ddgomega.FailHandler(ctx, t))
+package ddgomega
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// maxValueSize is the maximum size of the actual and expected values stored in the span.
+const maxValueSize = 1024
+
+// TestingT is a gomega testing object that records the failures in the test span.
+type TestingT struct {
+	ctx context.Context
+	tb  testing.TB
+}
+
+// T returns a gomega testing object that records the failures in the test span of ctx
+// before failing the test.
+func T(ctx context.Context, tb testing.TB) *TestingT {
+	return &TestingT{ctx: ctx, tb: tb}
+}
+
+// Helper marks the calling function as a test helper function.
+func (t *TestingT) Helper() {
+	t.tb.Helper()
+}
+
+// Fatalf records the failure in the test span and fails the test.
+func (t *TestingT) Fatalf(format string, args ...interface{}) {
+	t.tb.Helper()
+	recordFailure(t.ctx, fmt.Sprintf(format, args...), callerLocation(-1))
+	t.tb.Fatalf(format, args...)
+}
+
+// FailHandler returns a gomega fail handler that records the failure in the test span
+// of ctx before failing the test.
+func FailHandler(ctx context.Context, tb testing.TB) func(message string, callerSkip ...int) {
+	return func(message string, callerSkip ...int) {
+		tb.Helper()
+		skip := -1
+		if len(callerSkip) > 0 {
+			// Skip this function and the frames requested by gomega.
+			skip = callerSkip[0] + 2
+		}
+		recordFailure(ctx, message, callerLocation(skip))
+		tb.Fatal(message)
+	}
+}
+
+// recordFailure sets the failure message, its parts and location in the span of ctx.
+func recordFailure(ctx context.Context, message, location string) {
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	span.SetTag(ext.ErrorMsg, message)
+	span.SetTag(ext.ErrorType, "gomega")
+	if location != "" {
+		span.SetTag(constants.TestFailureLocation, location)
+	}
+
+	actual, matcher, expected := parseMessage(message)
+	if matcher != "" {
+		span.SetTag(constants.TestFailureMatcher, matcher)
+	}
+	if actual != "" {
+		span.SetTag(constants.TestFailureActual, truncate(actual))
+	}
+	if expected != "" {
+		span.SetTag(constants.TestFailureExpected, truncate(expected))
+	}
+}
+
+// parseMessage splits a gomega failure message in the actual value, the matcher description
+// and the expected value. Gomega messages have the following format:
+//
+//	Expected
+//	    <int>: 1
+//	to equal
+//	    <int>: 2
+func parseMessage(message string) (actual, matcher, expected string) {
+	lines := strings.Split(message, "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[0]) != "Expected" {
+		return "", "", ""
+	}
+
+	var actualLines, expectedLines []string
+	i := 1
+	for ; i < len(lines) && isIndented(lines[i]); i++ {
+		actualLines = append(actualLines, strings.TrimSpace(lines[i]))
+	}
+	if i < len(lines) {
+		matcher = strings.TrimSpace(lines[i])
+		i++
+	}
+	for ; i < len(lines) && isIndented(lines[i]); i++ {
+		expectedLines = append(expectedLines, strings.TrimSpace(lines[i]))
+	}
+	return strings.Join(actualLines, "\n"), matcher, strings.Join(expectedLines, "\n")
+}
+
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
+
+func truncate(value string) string {
+	value, _ = utils.Truncate(value, maxValueSize)
+	return value
+}
+
+// callerLocation returns the file and line of the caller. When skip is negative, it
+// returns the first frame outside gomega and this package.
+func callerLocation(skip int) string {
+	if skip >= 0 {
+		if _, file, line, ok := runtime.Caller(skip); ok {
+			return fmt.Sprintf("%s:%d", file, line)
+		}
+		return ""
+	}
+
+	pcs := make([]uintptr, 64)
+	total := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:total])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/onsi/gomega") &&
+			!strings.HasPrefix(frame.Function, "github.com/DataDog/dd-sdk-go-testing/contrib/ddgomega.") &&
+			!strings.HasPrefix(frame.Function, "testing.") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
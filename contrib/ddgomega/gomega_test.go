@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddgomega
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseMessage(t *testing.T) {
+	actual, matcher, expected := parseMessage("Expected\n    <int>: 1\nto equal\n    <int>: 2")
+	if actual != "<int>: 1" {
+		t.Errorf("unexpected actual value: %s", actual)
+	}
+	if matcher != "to equal" {
+		t.Errorf("unexpected matcher: %s", matcher)
+	}
+	if expected != "<int>: 2" {
+		t.Errorf("unexpected expected value: %s", expected)
+	}
+
+	actual, matcher, expected = parseMessage("unexpected error")
+	if actual != "" || matcher != "" || expected != "" {
+		t.Error("message without the gomega format was parsed")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	value := truncate(strings.Repeat("é", maxValueSize))
+	if !strings.HasSuffix(value, "...(truncated)") || len(value) > maxValueSize || !utf8.ValidString(value) {
+		t.Errorf("unexpected truncated value: %s", value)
+	}
+}
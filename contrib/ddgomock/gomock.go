@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddgomock records the unmet expectations of github.com/golang/mock mocks as
+// structured tags of the test span:
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//
+//	ctrl := gomock.NewController(ddgomock.Reporter(ctx, t))
+//	defer ctrl.Finish()
+//
+// The reporter also implements the TestingT interface of github.com/stretchr/testify/mock,
+// used by the mocks generated by mockery, recording their failure messages.
+package ddgomock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var (
+	missingCallRegex    = regexp.MustCompile(`(?s)^missing call\(s\) to (\S+)\.(\w+)\((.*)\) (\S+:\d+)\s*$`)
+	unexpectedCallRegex = regexp.MustCompile(`(?s)^Unexpected call to (\S+)\.(\w+)\((.*?)\) at (\S+:\d+) because: (.*)$`)
+	gotWantRegex        = regexp.MustCompile(`(?s)Got: (.*?)\n\s*Want: (.*?)\s*$`)
+)
+
+// Failure is a mock failure reported by gomock.
+type Failure struct {
+	Kind     string `json:"kind"`
+	Mock     string `json:"mock,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+// TestReporter is a gomock.TestReporter recording the mock failures in the test span.
+type TestReporter struct {
+	ctx context.Context
+	tb  testing.TB
+
+	mu       sync.Mutex
+	failures []Failure
+}
+
+// Reporter returns a gomock.TestReporter that records the mock failures in the test span
+// of ctx before reporting them to tb.
+func Reporter(ctx context.Context, tb testing.TB) *TestReporter {
+	return &TestReporter{ctx: ctx, tb: tb}
+}
+
+// Errorf records the failure and reports it to the testing object.
+func (r *TestReporter) Errorf(format string, args ...interface{}) {
+	r.tb.Helper()
+	r.record(fmt.Sprintf(format, args...))
+	r.tb.Errorf(format, args...)
+}
+
+// Fatalf records the failure and reports it to the testing object.
+func (r *TestReporter) Fatalf(format string, args ...interface{}) {
+	r.tb.Helper()
+	r.record(fmt.Sprintf(format, args...))
+	r.tb.Fatalf(format, args...)
+}
+
+// Logf forwards the message to the testing object.
+func (r *TestReporter) Logf(format string, args ...interface{}) {
+	r.tb.Helper()
+	r.tb.Logf(format, args...)
+}
+
+// FailNow stops the execution of the test.
+func (r *TestReporter) FailNow() {
+	r.tb.Helper()
+	r.tb.FailNow()
+}
+
+// Helper marks the calling function as a test helper function.
+func (r *TestReporter) Helper() {
+	r.tb.Helper()
+}
+
+// Failures returns the mock failures recorded so far.
+func (r *TestReporter) Failures() []Failure {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Failure(nil), r.failures...)
+}
+
+func (r *TestReporter) record(message string) {
+	// gomock aborts the test after reporting the missing calls.
+	if strings.HasPrefix(message, "aborting test due to missing call(s)") {
+		return
+	}
+
+	failure := parseFailure(message)
+	r.mu.Lock()
+	r.failures = append(r.failures, failure)
+	failures := append([]Failure(nil), r.failures...)
+	r.mu.Unlock()
+
+	span, ok := tracer.SpanFromContext(r.ctx)
+	if !ok {
+		return
+	}
+	if data, err := json.Marshal(failures); err == nil {
+		span.SetTag(constants.MockFailures, string(data))
+	}
+	span.SetTag(constants.MockFailuresCount, len(failures))
+	if len(failures) == 1 {
+		span.SetTag(ext.ErrorMsg, failure.Message)
+		span.SetTag(ext.ErrorType, "mock."+failure.Kind)
+		span.SetTag(constants.MockType, failure.Mock)
+		span.SetTag(constants.MockMethod, failure.Method)
+		if failure.Expected != "" {
+			span.SetTag(constants.MockExpected, failure.Expected)
+		}
+		if failure.Actual != "" {
+			span.SetTag(constants.MockActual, failure.Actual)
+		}
+	}
+}
+
+// parseFailure extracts the details of a gomock failure message.
+func parseFailure(message string) Failure {
+	if matches := missingCallRegex.FindStringSubmatch(message); matches != nil {
+		return Failure{
+			Kind:     "missing_call",
+			Mock:     matches[1],
+			Method:   matches[2],
+			Expected: matches[3],
+			Location: matches[4],
+			Message:  message,
+		}
+	}
+	if matches := unexpectedCallRegex.FindStringSubmatch(message); matches != nil {
+		failure := Failure{
+			Kind:     "unexpected_call",
+			Mock:     matches[1],
+			Method:   matches[2],
+			Actual:   matches[3],
+			Location: matches[4],
+			Message:  message,
+		}
+		if gotWant := gotWantRegex.FindStringSubmatch(matches[5]); gotWant != nil {
+			failure.Actual = strings.TrimSpace(gotWant[1])
+			failure.Expected = strings.TrimSpace(gotWant[2])
+		}
+		return failure
+	}
+	return Failure{Kind: "failure", Message: message}
+}
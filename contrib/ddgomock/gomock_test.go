@@ -0,0 +1,28 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddgomock
+
+import "testing"
+
+func TestParseFailure(t *testing.T) {
+	f := parseFailure("missing call(s) to *mocks.MockStore.Get(is equal to 1 (int)) /src/store_test.go:21")
+	if f.Kind != "missing_call" || f.Mock != "*mocks.MockStore" || f.Method != "Get" ||
+		f.Expected != "is equal to 1 (int)" || f.Location != "/src/store_test.go:21" {
+		t.Errorf("unexpected missing call failure: %+v", f)
+	}
+
+	f = parseFailure("Unexpected call to *mocks.MockStore.Get([2]) at /src/store.go:10 because: \n" +
+		"expected call at /src/store_test.go:21 doesn't match the argument at index 0.\nGot: 2 (int)\nWant: is equal to 1 (int)")
+	if f.Kind != "unexpected_call" || f.Mock != "*mocks.MockStore" || f.Method != "Get" ||
+		f.Actual != "2 (int)" || f.Expected != "is equal to 1 (int)" || f.Location != "/src/store.go:10" {
+		t.Errorf("unexpected unexpected call failure: %+v", f)
+	}
+
+	f = parseFailure("something else")
+	if f.Kind != "failure" || f.Message != "something else" {
+		t.Errorf("unexpected generic failure: %+v", f)
+	}
+}
@@ -0,0 +1,256 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddgotestsum reports the tests of a `go test -json` output, like the file written by
+// gotestsum with the --jsonfile flag, without changing how the tests are invoked.
+//
+// Every package is reported as a suite, and every test with the start and finish times of its
+// events. The output of the failed tests is attached to their spans, and the tests running when
+// the test binary crashed or timed out are reported as failed with the crash signal or the
+// goroutine dump. The cmd/ddgotestsum command wraps this package so it can be used as the
+// post-run command of gotestsum:
+//
+//	gotestsum --jsonfile test-output.json --post-run-command ddgotestsum
+//
+// To report the output programmatically, start the tracer and call Report:
+//
+//	ddtesting.Start()
+//	defer ddtesting.Stop()
+//	err := ddgotestsum.Report(file)
+package ddgotestsum
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// maxOutputSize is the maximum size of the output attached to a failed test.
+const maxOutputSize = 16 * 1024
+
+var (
+	// crashMessageRegex matches the first fatal error or panic message of a crash output.
+	crashMessageRegex = regexp.MustCompile(`(?m)^((?:fatal error|panic): .*)$`)
+
+	// crashSignalRegex matches the signal of a crash output, like `[signal SIGSEGV: ...]`
+	// or `SIGABRT: abort`.
+	crashSignalRegex = regexp.MustCompile(`(?m)^\[?(?:signal )?(SIG[A-Z]+)`)
+
+	// exitStatusRegex matches the exit status printed by go test when a test binary exited
+	// without a crash, like when the code under test called os.Exit.
+	exitStatusRegex = regexp.MustCompile(`(?m)^exit status (\d+)$`)
+)
+
+// event is a test event of the `go test -json` output, see `go doc test2json`.
+type event struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// test contains the state of a test being read from the output.
+type test struct {
+	pkg    string
+	name   string
+	start  time.Time
+	output strings.Builder
+}
+
+// Report reads a `go test -json` output and reports its tests. The given options are added
+// to every test. Lines that aren't test events, like build errors, are ignored.
+//
+// The tests still running when their package fails, or when the output ends, were running
+// when the test binary crashed, for example with a SIGSEGV in cgo code. They are reported as
+// failed with the crash output and signal, so the crash doesn't yield an empty session.
+func Report(r io.Reader, opts ...ddtesting.Option) error {
+	return read(r, true, opts)
+}
+
+// ReportInterrupted reads a `go test -json` output and only reports the tests running when their
+// test binary crashed or exited, like Report, for the test binaries instrumented with the SDK that
+// report their finished tests themselves. The tests reported by ddtesting.Exit aren't reported again.
+func ReportInterrupted(r io.Reader, opts ...ddtesting.Option) error {
+	return read(r, false, opts)
+}
+
+// read reads a `go test -json` output, reporting the finished tests when finished is set, and the
+// tests interrupted by a crash.
+func read(r io.Reader, finished bool, opts []ddtesting.Option) error {
+	tests := map[string]*test{}
+	packages := map[string]*strings.Builder{}
+	var last time.Time
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Package == "" {
+			continue
+		}
+		last = e.Time
+
+		if e.Test == "" {
+			switch e.Action {
+			case "output":
+				pkg, ok := packages[e.Package]
+				if !ok {
+					pkg = &strings.Builder{}
+					packages[e.Package] = pkg
+				}
+				if pkg.Len() < maxOutputSize {
+					pkg.WriteString(e.Output)
+				}
+			case "fail":
+				var output string
+				if pkg, ok := packages[e.Package]; ok {
+					output = pkg.String()
+				}
+				reportCrashed(tests, e.Package, output, e.Time, opts)
+				delete(packages, e.Package)
+			case "pass", "skip":
+				delete(packages, e.Package)
+			}
+			continue
+		}
+
+		key := e.Package + "." + e.Test
+		t, ok := tests[key]
+		if !ok {
+			t = &test{pkg: e.Package, name: e.Test, start: e.Time}
+			tests[key] = t
+		}
+		switch e.Action {
+		case "output":
+			if t.output.Len() < maxOutputSize {
+				t.output.WriteString(e.Output)
+			}
+		case "pass", "fail", "skip":
+			if !ok && e.Elapsed > 0 {
+				t.start = e.Time.Add(-time.Duration(e.Elapsed * float64(time.Second)))
+			}
+			if finished {
+				report(e, t, "", opts)
+			}
+			delete(tests, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// The output ended while tests were running, the test process was killed.
+	for pkg, output := range packages {
+		reportCrashed(tests, pkg, output.String(), last, opts)
+	}
+	reportCrashed(tests, "", "", last, opts)
+	return nil
+}
+
+// reportCrashed reports the running tests of the package as crashed, or the running tests
+// of every package when pkg is empty. The output of the package is added to their output.
+func reportCrashed(tests map[string]*test, pkg, output string, end time.Time, opts []ddtesting.Option) {
+	keys := make([]string, 0, len(tests))
+	for key, t := range tests {
+		if pkg == "" || t.pkg == pkg {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		t := tests[key]
+		delete(tests, key)
+		if strings.Contains(output, ddtesting.ExitReportedMarker+t.name+"\n") ||
+			strings.Contains(t.output.String(), ddtesting.ExitReportedMarker+t.name+"\n") {
+			// The test was reported by ddtesting.Exit before the test binary exited.
+			continue
+		}
+		e := event{Time: end, Action: "fail", Package: t.pkg, Test: t.name}
+		report(e, t, t.output.String()+output, opts)
+	}
+}
+
+// report reports a finished test. The crash output is set when the test process crashed
+// while the test was running.
+func report(e event, t *test, crash string, opts []ddtesting.Option) {
+	tb := &ddtesting.TBAdapter{TestName: e.Test}
+	switch e.Action {
+	case "fail":
+		tb.Fail()
+	case "skip":
+		tb.SkipNow()
+	}
+	testOpts := []ddtesting.Option{
+		ddtesting.WithTestSuite(e.Package),
+		ddtesting.WithSourceLocation(e.Package, 0),
+		ddtesting.WithSpanOptions(
+			tracer.StartTime(t.start),
+			tracer.Tag(constants.TestType, constants.TestTypeTest),
+		),
+		ddtesting.WithFinishOptions(tracer.FinishTime(e.Time)),
+	}
+	if strings.HasPrefix(e.Test, "Benchmark") {
+		testOpts = append(testOpts, ddtesting.WithSpanOptions(tracer.Tag(constants.TestType, constants.TestTypeBenchmark)))
+	}
+	testOpts = append(testOpts, opts...)
+
+	ctx, finish := ddtesting.StartTestWithContext(context.Background(), tb, testOpts...)
+	if tb.Failed() {
+		if span, ok := tracer.SpanFromContext(ctx); ok {
+			output := t.output.String()
+			if crash != "" {
+				output = crash
+			}
+			if len(output) > maxOutputSize {
+				output = output[:maxOutputSize] + "\n...(truncated)"
+			}
+			span.SetTag(constants.TestOutput, output)
+			if crash != "" {
+				setCrashTags(span, crash)
+			}
+		}
+	}
+	finish()
+}
+
+// setCrashTags sets the error of a test that was running when the test process crashed,
+// with the fatal error or panic message and the signal found in the crash output.
+func setCrashTags(span ddtrace.Span, output string) {
+	msg := "test process crashed"
+	if m := crashMessageRegex.FindStringSubmatch(output); m != nil {
+		msg = m[1]
+	}
+	if strings.HasPrefix(msg, "panic: test timed out after") {
+		// The testing package panics with a goroutine dump when the -timeout deadline expires.
+		span.SetTag(ext.ErrorType, "timeout")
+		span.SetTag(ext.ErrorMsg, strings.TrimPrefix(msg, "panic: "))
+		span.SetTag(ext.ErrorStack, output)
+		return
+	}
+	if m := exitStatusRegex.FindStringSubmatch(output); m != nil && !crashMessageRegex.MatchString(output) &&
+		!crashSignalRegex.MatchString(output) {
+		span.SetTag(ext.ErrorType, "exit")
+		span.SetTag(ext.ErrorMsg, "the test binary exited with code "+m[1])
+		return
+	}
+	span.SetTag(ext.ErrorType, "crash")
+	span.SetTag(ext.ErrorMsg, msg)
+	if m := crashSignalRegex.FindStringSubmatch(output); m != nil {
+		span.SetTag(constants.TestCrashSignal, m[1])
+	}
+}
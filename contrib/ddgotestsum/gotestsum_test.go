@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddgotestsum
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+const output = `{"Time":"2021-10-01T10:00:00Z","Action":"run","Package":"example.com/pkg","Test":"TestPass"}
+{"Time":"2021-10-01T10:00:01Z","Action":"pass","Package":"example.com/pkg","Test":"TestPass","Elapsed":1}
+{"Time":"2021-10-01T10:00:01Z","Action":"run","Package":"example.com/pkg","Test":"TestFail"}
+{"Time":"2021-10-01T10:00:01Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"    pkg_test.go:12: unexpected value\n"}
+{"Time":"2021-10-01T10:00:02Z","Action":"fail","Package":"example.com/pkg","Test":"TestFail","Elapsed":1}
+# example.com/broken
+{"Time":"2021-10-01T10:00:03Z","Action":"skip","Package":"example.com/pkg","Test":"TestSkip","Elapsed":0.5}
+{"Time":"2021-10-01T10:00:03Z","Action":"fail","Package":"example.com/pkg","Elapsed":3}
+`
+
+func TestReport(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	if err := Report(strings.NewReader(output)); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 3 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+
+	pass := spans[0]
+	if pass.Tag(constants.TestName) != "TestPass" || pass.Tag(constants.TestSuite) != "example.com/pkg" ||
+		pass.Tag(constants.TestStatus) != constants.TestStatusPass {
+		t.Errorf("unexpected passed test span: %v", pass.Tags())
+	}
+	if d := pass.FinishTime().Sub(pass.StartTime()); d != time.Second {
+		t.Errorf("unexpected duration: %v", d)
+	}
+
+	fail := spans[1]
+	if fail.Tag(constants.TestStatus) != constants.TestStatusFail ||
+		fail.Tag(constants.TestOutput) != "    pkg_test.go:12: unexpected value\n" {
+		t.Errorf("unexpected failed test span: %v", fail.Tags())
+	}
+
+	skip := spans[2]
+	if skip.Tag(constants.TestStatus) != constants.TestStatusSkip {
+		t.Errorf("unexpected skipped test span: %v", skip.Tags())
+	}
+	if d := skip.FinishTime().Sub(skip.StartTime()); d != 500*time.Millisecond {
+		t.Errorf("unexpected duration: %v", d)
+	}
+}
+
+const crashOutput = `{"Time":"2021-10-01T10:00:00Z","Action":"run","Package":"example.com/cgo","Test":"TestPass"}
+{"Time":"2021-10-01T10:00:01Z","Action":"pass","Package":"example.com/cgo","Test":"TestPass","Elapsed":1}
+{"Time":"2021-10-01T10:00:01Z","Action":"run","Package":"example.com/cgo","Test":"TestCrash"}
+{"Time":"2021-10-01T10:00:01Z","Action":"output","Package":"example.com/cgo","Test":"TestCrash","Output":"fatal error: unexpected signal during runtime execution\n"}
+{"Time":"2021-10-01T10:00:01Z","Action":"output","Package":"example.com/cgo","Test":"TestCrash","Output":"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a2f3c]\n"}
+{"Time":"2021-10-01T10:00:02Z","Action":"output","Package":"example.com/cgo","Output":"FAIL\texample.com/cgo\t2.000s\n"}
+{"Time":"2021-10-01T10:00:02Z","Action":"fail","Package":"example.com/cgo","Elapsed":2}
+{"Time":"2021-10-01T10:00:02Z","Action":"run","Package":"example.com/killed","Test":"TestKilled"}
+{"Time":"2021-10-01T10:00:03Z","Action":"output","Package":"example.com/killed","Test":"TestKilled","Output":"SIGABRT: abort\n"}
+`
+
+func TestReportCrash(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	if err := Report(strings.NewReader(crashOutput)); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 3 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+
+	crash := spans[1]
+	if crash.Tag(constants.TestName) != "TestCrash" || crash.Tag(constants.TestStatus) != constants.TestStatusFail ||
+		crash.Tag(ext.ErrorType) != "crash" || crash.Tag(constants.TestCrashSignal) != "SIGSEGV" ||
+		crash.Tag(ext.ErrorMsg) != "fatal error: unexpected signal during runtime execution" {
+		t.Errorf("unexpected crashed test span: %v", crash.Tags())
+	}
+	if output, _ := crash.Tag(constants.TestOutput).(string); !strings.HasSuffix(output, "FAIL\texample.com/cgo\t2.000s\n") {
+		t.Errorf("unexpected output: %q", output)
+	}
+	if d := crash.FinishTime().Sub(crash.StartTime()); d != time.Second {
+		t.Errorf("unexpected duration: %v", d)
+	}
+
+	killed := spans[2]
+	if killed.Tag(constants.TestName) != "TestKilled" || killed.Tag(constants.TestCrashSignal) != "SIGABRT" ||
+		killed.Tag(ext.ErrorMsg) != "test process crashed" {
+		t.Errorf("unexpected killed test span: %v", killed.Tags())
+	}
+}
+
+const timeoutOutput = `{"Time":"2021-10-01T10:00:00Z","Action":"run","Package":"example.com/slow","Test":"TestSlow"}
+{"Time":"2021-10-01T10:10:00Z","Action":"output","Package":"example.com/slow","Test":"TestSlow","Output":"panic: test timed out after 10m0s\n"}
+{"Time":"2021-10-01T10:10:00Z","Action":"output","Package":"example.com/slow","Test":"TestSlow","Output":"\nrunning tests:\n\tTestSlow (10m0s)\n\ngoroutine 7 [running]:\n"}
+{"Time":"2021-10-01T10:10:00Z","Action":"fail","Package":"example.com/slow","Elapsed":600}
+`
+
+func TestReportTimeout(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	if err := Report(strings.NewReader(timeoutOutput)); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	s := spans[0]
+	if s.Tag(ext.ErrorType) != "timeout" || s.Tag(ext.ErrorMsg) != "test timed out after 10m0s" ||
+		!strings.Contains(s.Tag(ext.ErrorStack).(string), "goroutine 7 [running]") {
+		t.Errorf("unexpected timed out test span: %v", s.Tags())
+	}
+}
+
+const exitOutput = `{"Time":"2021-10-01T10:00:00Z","Action":"run","Package":"example.com/cli","Test":"TestPass"}
+{"Time":"2021-10-01T10:00:01Z","Action":"pass","Package":"example.com/cli","Test":"TestPass","Elapsed":1}
+{"Time":"2021-10-01T10:00:01Z","Action":"run","Package":"example.com/cli","Test":"TestExit"}
+{"Time":"2021-10-01T10:00:02Z","Action":"output","Package":"example.com/cli","Test":"TestExit","Output":"dd-sdk-go-testing: reported the test interrupted by the exit: TestExit\n"}
+{"Time":"2021-10-01T10:00:02Z","Action":"fail","Package":"example.com/cli","Elapsed":2}
+{"Time":"2021-10-01T10:00:00Z","Action":"run","Package":"example.com/app","Test":"TestOSExit"}
+{"Time":"2021-10-01T10:00:01Z","Action":"output","Package":"example.com/app","Output":"exit status 2\n"}
+{"Time":"2021-10-01T10:00:01Z","Action":"fail","Package":"example.com/app","Elapsed":1}
+`
+
+func TestReportInterrupted(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	if err := ReportInterrupted(strings.NewReader(exitOutput)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The finished tests and the ones reported by ddtesting.Exit are skipped.
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if spans[0].Tag(constants.TestName) != "TestOSExit" || spans[0].Tag(ext.ErrorType) != "exit" ||
+		spans[0].Tag(ext.ErrorMsg) != "the test binary exited with code 2" {
+		t.Errorf("unexpected interrupted test span: %v", spans[0].Tags())
+	}
+}
@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddgrpc provides gRPC interceptors propagating the trace context of the test span
+// through the gRPC metadata, so the RPCs made by the test appear under the test span.
+//
+// The client interceptors inject the trace context of the span of the context of the call:
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//
+//	conn, err := grpc.Dial(addr, grpc.WithInsecure(),
+//		grpc.WithUnaryInterceptor(ddgrpc.UnaryClientInterceptor()),
+//		grpc.WithStreamInterceptor(ddgrpc.StreamClientInterceptor()))
+//	// ...
+//	resp, err := client.Method(ctx, req)
+//
+// And the server interceptors of the in-process servers, like the ones listening on a bufconn
+// listener, continue the trace with a span of the method:
+//
+//	server := grpc.NewServer(
+//		grpc.UnaryInterceptor(ddgrpc.UnaryServerInterceptor()),
+//		grpc.StreamInterceptor(ddgrpc.StreamServerInterceptor()))
+package ddgrpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/isolated"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// metadataCarrier adapts the gRPC metadata to the tracer propagation carriers.
+// gRPC metadata keys are lowercase.
+type metadataCarrier map[string][]string
+
+func (c metadataCarrier) Set(key, val string) {
+	c[strings.ToLower(key)] = []string{val}
+}
+
+func (c metadataCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, vs := range c {
+		for _, v := range vs {
+			if err := handler(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Metadata returns the gRPC metadata containing the trace context of the span of ctx.
+// It returns an empty metadata when ctx has no span.
+func Metadata(ctx context.Context) map[string][]string {
+	md := metadataCarrier{}
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		isolated.Inject(span.Context(), md)
+	}
+	return md
+}
+
+// Extract returns the trace context propagated in the gRPC metadata.
+func Extract(md map[string][]string) (ddtrace.SpanContext, error) {
+	return isolated.Extract(metadataCarrier(md))
+}
+
+// StartServerSpan starts the span of a gRPC server method, as a child of the trace
+// context propagated in the metadata.
+func StartServerSpan(ctx context.Context, md map[string][]string, method string) (ddtrace.Span, context.Context) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.ResourceName(method),
+		tracer.Tag(constants.SpanKind, constants.SpanKindServer),
+		tracer.Tag("grpc.method.name", method),
+	}
+	if sctx, err := Extract(md); err == nil {
+		opts = append(opts, tracer.ChildOf(sctx))
+	}
+	return isolated.StartSpanFromContext(ctx, "grpc.server", opts...)
+}
+
+// outgoingContext returns the context of a call with the trace context of its span added to
+// the outgoing metadata.
+func outgoingContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return metadata.NewOutgoingContext(ctx, metadata.Join(md, Metadata(ctx)))
+}
+
+// UnaryClientInterceptor returns a client interceptor injecting the trace context of the span
+// of the context of the unary calls into their metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns a client interceptor injecting the trace context of the span
+// of the context of the streams into their metadata.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+	}
+}
+
+// UnaryServerInterceptor returns a server interceptor starting the span of the unary methods
+// as a child of the trace context propagated in their metadata.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		span, ctx := StartServerSpan(ctx, md, info.FullMethod)
+		resp, err := handler(ctx, req)
+		span.Finish(tracer.WithError(err))
+		return resp, err
+	}
+}
+
+// serverStream is a server stream whose context has the span of the method.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamServerInterceptor returns a server interceptor starting the span of the streaming
+// methods as a child of the trace context propagated in their metadata.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		span, ctx := StartServerSpan(ss.Context(), md, info.FullMethod)
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		span.Finish(tracer.WithError(err))
+		return err
+	}
+}
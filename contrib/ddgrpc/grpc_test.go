@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddgrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestPropagation(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	md := Metadata(ctx)
+	for k := range md {
+		if k != "x-datadog-trace-id" && k != "x-datadog-parent-id" && k != "x-datadog-sampling-priority" && k != "x-datadog-origin" {
+			t.Errorf("unexpected metadata key: %s", k)
+		}
+	}
+
+	server, _ := StartServerSpan(context.Background(), md, "/pkg.Service/Method")
+	server.Finish()
+	span.Finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if spans[0].ParentID() != spans[1].SpanID() || spans[0].TraceID() != spans[1].TraceID() {
+		t.Error("server span is not a child of the test span")
+	}
+}
+
+// fakeServerStream is a server stream with the incoming metadata of a call.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptors(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	test, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	// The metadata sent by the client interceptors is received by the server interceptors.
+	var sent metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		sent, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	ctx = metadata.NewOutgoingContext(ctx, metadata.MD{"user": []string{"value"}})
+	if err := UnaryClientInterceptor()(ctx, "/pkg.Service/Method", nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent["user"]) != 1 || len(sent["x-datadog-trace-id"]) != 1 {
+		t.Fatalf("unexpected metadata %v", sent)
+	}
+	incoming := metadata.NewIncomingContext(context.Background(), sent)
+	handlerErr := errors.New("failed")
+	_, err := UnaryServerInterceptor()(incoming, nil, &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Method"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			if _, ok := tracer.SpanFromContext(ctx); !ok {
+				t.Error("expected the span of the method in the context of the handler")
+			}
+			return nil, handlerErr
+		})
+	if err != handlerErr {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		sent, _ = metadata.FromOutgoingContext(ctx)
+		return nil, nil
+	}
+	if _, err := StreamClientInterceptor()(ctx, &grpc.StreamDesc{}, nil, "/pkg.Service/Stream", streamer); err != nil {
+		t.Fatal(err)
+	}
+	stream := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), sent)}
+	err = StreamServerInterceptor()(nil, stream, &grpc.StreamServerInfo{FullMethod: "/pkg.Service/Stream"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			if _, ok := tracer.SpanFromContext(ss.Context()); !ok {
+				t.Error("expected the span of the method in the context of the stream")
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 3 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	for _, s := range spans[:2] {
+		if s.ParentID() != test.Context().SpanID() || s.Tag(constants.SpanKind) != constants.SpanKindServer {
+			t.Errorf("unexpected server span %v", s)
+		}
+	}
+	if spans[0].Tag(ext.Error) == nil || spans[1].Tag(ext.Error) != nil {
+		t.Error("unexpected error of the server spans")
+	}
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddhttp provides helpers to propagate the trace context of the test span to
+// the HTTP services called by the test, so the spans of the service under test appear
+// under the test span.
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//
+//	s := ddhttp.NewServer(ctx, handler)
+//	defer s.Close()
+//
+//	resp, err := s.Client().Get(s.URL)
+//
+// TracingClient and TracingRoundTripper also record every outbound request as a child
+// span of the test span, with its status code and timings.
+package ddhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/isolated"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// roundTripper injects the trace context in the headers of the requests.
+type roundTripper struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// RoundTripper returns a http.RoundTripper that injects the trace context in the headers
+// of the requests. The span of the request context is used when there is one, otherwise
+// the span of ctx is used. When base is nil, http.DefaultTransport is used.
+func RoundTripper(ctx context.Context, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &roundTripper{ctx: ctx, base: base}
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	span, ok := tracer.SpanFromContext(req.Context())
+	if !ok {
+		span, ok = tracer.SpanFromContext(rt.ctx)
+	}
+	if !ok {
+		return rt.base.RoundTrip(req)
+	}
+
+	// The request must not be modified by a RoundTripper, so the headers are copied.
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	if err := isolated.Inject(span.Context(), tracer.HTTPHeadersCarrier(r.Header)); err != nil {
+		return rt.base.RoundTrip(req)
+	}
+	return rt.base.RoundTrip(r)
+}
+
+// Client returns a http.Client that propagates the trace context of ctx.
+func Client(ctx context.Context) *http.Client {
+	return &http.Client{Transport: RoundTripper(ctx, nil)}
+}
+
+// WrapClient sets a transport that propagates the trace context of ctx in the client.
+// The client is modified and returned, so shared clients like http.DefaultClient must be
+// copied first. A nil client returns Client(ctx).
+func WrapClient(ctx context.Context, client *http.Client) *http.Client {
+	if client == nil {
+		return Client(ctx)
+	}
+	client.Transport = RoundTripper(ctx, client.Transport)
+	return client
+}
+
+// NewServer starts a httptest.Server whose Client propagates the trace context of ctx.
+func NewServer(ctx context.Context, handler http.Handler) *httptest.Server {
+	s := httptest.NewServer(handler)
+	WrapClient(ctx, s.Client())
+	return s
+}
+
+// NewTLSServer starts a TLS httptest.Server whose Client propagates the trace context of ctx.
+func NewTLSServer(ctx context.Context, handler http.Handler) *httptest.Server {
+	s := httptest.NewTLSServer(handler)
+	WrapClient(ctx, s.Client())
+	return s
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/isolated"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestNewServer(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	defer span.Finish()
+
+	var traceID uint64
+	s := NewServer(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sctx, err := tracer.Extract(tracer.HTTPHeadersCarrier(r.Header)); err == nil {
+			traceID = sctx.TraceID()
+		}
+	}))
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if traceID != span.Context().TraceID() {
+		t.Errorf("trace context was not propagated: %d", traceID)
+	}
+}
+
+func TestWrapClient(t *testing.T) {
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+	}))
+	defer srv.Close()
+
+	// The headers are injected with the isolated tracer, the global tracer isn't started.
+	tr := isolated.New(isolated.Config{URL: srv.URL})
+	isolated.SetCurrent(tr)
+	defer isolated.SetCurrent(nil)
+	span := tr.StartSpan("test")
+	defer span.Finish()
+	ctx := tracer.ContextWithSpan(context.Background(), span)
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("X-Custom", "value")
+	resp, err := WrapClient(ctx, nil).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if headers.Get(tracer.DefaultTraceIDHeader) != strconv.FormatUint(span.Context().TraceID(), 10) ||
+		headers.Get(tracer.DefaultParentIDHeader) != strconv.FormatUint(span.Context().SpanID(), 10) {
+		t.Errorf("the trace context wasn't injected: %v", headers)
+	}
+	if headers.Get("X-Custom") != "value" {
+		t.Errorf("the headers of the request weren't kept: %v", headers)
+	}
+	if req.Header.Get(tracer.DefaultTraceIDHeader) != "" {
+		t.Error("the request was modified")
+	}
+}
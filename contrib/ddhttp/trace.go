@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/isolated"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const requestOperation = "http.request"
+
+// tracingRoundTripper records the requests as child spans of the test span.
+type tracingRoundTripper struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// TracingRoundTripper returns a http.RoundTripper that records each request as a child span
+// of the span of the request context, or of the span of ctx when the request has none. The
+// span contains the method, host, status code and the timings of the request, and its trace
+// context is propagated in the request headers. When base is nil, http.DefaultTransport is used.
+func TracingRoundTripper(ctx context.Context, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingRoundTripper{ctx: ctx, base: base}
+}
+
+// TracingClient returns a http.Client that records the requests as child spans of the span of ctx.
+func TracingClient(ctx context.Context) *http.Client {
+	return &http.Client{Transport: TracingRoundTripper(ctx, nil)}
+}
+
+func (rt *tracingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	parent := req.Context()
+	if _, ok := tracer.SpanFromContext(parent); !ok {
+		if span, ok := tracer.SpanFromContext(rt.ctx); ok {
+			parent = tracer.ContextWithSpan(parent, span)
+		}
+	}
+
+	span, ctx := isolated.StartSpanFromContext(parent, requestOperation,
+		tracer.SpanType(ext.SpanTypeHTTP),
+		tracer.ResourceName(req.Method+" "+req.URL.Host),
+		tracer.Tag(ext.HTTPMethod, req.Method),
+		tracer.Tag(ext.HTTPURL, req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+		tracer.Tag(ext.TargetHost, req.URL.Hostname()),
+		tracer.Tag(constants.SpanKind, constants.SpanKindClient))
+	timings := newRequestTimings(span)
+	ctx = httptrace.WithClientTrace(ctx, timings.clientTrace())
+
+	// The request must not be modified by a RoundTripper, so a copy is used.
+	r := req.WithContext(ctx)
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = append([]string(nil), v...)
+	}
+	isolated.Inject(span.Context(), tracer.HTTPHeadersCarrier(r.Header))
+
+	resp, err := rt.base.RoundTrip(r)
+	if resp != nil {
+		span.SetTag(ext.HTTPCode, strconv.Itoa(resp.StatusCode))
+		if resp.StatusCode >= 500 {
+			span.SetTag(ext.Error, true)
+		}
+	}
+	timings.setTags()
+	span.Finish(tracer.WithError(err))
+	return resp, err
+}
+
+// requestTimings measures the phases of a request with httptrace.
+type requestTimings struct {
+	span  ddtrace.Span
+	start time.Time
+
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dns          time.Duration
+	connectStart time.Time
+	connect      time.Duration
+	tlsStart     time.Time
+	tls          time.Duration
+	firstByte    time.Duration
+	reused       bool
+}
+
+func newRequestTimings(span ddtrace.Span) *requestTimings {
+	return &requestTimings{span: span, start: time.Now()}
+}
+
+func (rt *requestTimings) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.dns = time.Since(rt.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.connectStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.connect = time.Since(rt.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.tls = time.Since(rt.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.reused = info.Reused
+		},
+		GotFirstResponseByte: func() {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.firstByte = time.Since(rt.start)
+		},
+	}
+}
+
+// setTags sets the measured timings in the span.
+func (rt *requestTimings) setTags() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	metrics := []struct {
+		name  string
+		value time.Duration
+	}{
+		{constants.HTTPDNSTime, rt.dns},
+		{constants.HTTPConnectTime, rt.connect},
+		{constants.HTTPTLSTime, rt.tls},
+		{constants.HTTPFirstByteTime, rt.firstByte},
+	}
+	for _, metric := range metrics {
+		if metric.value > 0 {
+			rt.span.SetTag(metric.name, float64(metric.value)/float64(time.Millisecond))
+		}
+	}
+	rt.span.SetTag(constants.HTTPConnectionReused, rt.reused)
+}
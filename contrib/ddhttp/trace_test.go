@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestTracingClient(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer s.Close()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	resp, err := TracingClient(ctx).Get(s.URL + "/path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	span.Finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+
+	req := spans[0]
+	if req.ParentID() != span.Context().SpanID() {
+		t.Error("request span is not a child of the test span")
+	}
+	if req.Tag(ext.HTTPMethod) != "GET" || req.Tag(ext.HTTPCode) != "418" || req.Tag(ext.HTTPURL) != s.URL+"/path" {
+		t.Errorf("unexpected tags: %v", req.Tags())
+	}
+	if _, ok := req.Tag(constants.HTTPFirstByteTime).(float64); !ok {
+		t.Error("first byte timing was not recorded")
+	}
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddlogrus correlates the github.com/sirupsen/logrus entries emitted during the tests
+// with the test spans, and forwards them to Datadog when the logs forwarding is enabled.
+//
+// Hook is added to the loggers used in the tests:
+//
+//	logger.AddHook(ddlogrus.NewHook())
+//
+// The entries are correlated with the span of their context, or with the test given in their
+// fields:
+//
+//	logger.WithContext(ctx).Info("cart created")
+//	logger.WithFields(ddlogrus.TB(t)).Info("cart created")
+//
+// The entries without context nor test are written unchanged, since the running test can't
+// be known when tests run in parallel.
+package ddlogrus
+
+import (
+	"testing"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/logs"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// tbKey is the key of the field carrying the test of the entry, removed by the hook.
+	tbKey = "dd.test"
+
+	traceIDKey = "dd.trace_id"
+	spanIDKey  = "dd.span_id"
+)
+
+// TB returns the fields correlating the entry with the test of tb started with
+// ddtesting.StartTest, to be given to WithFields.
+func TB(tb testing.TB) logrus.Fields {
+	return logrus.Fields{tbKey: tb}
+}
+
+// Hook is a logrus hook adding the correlation fields to the entries emitted during the tests,
+// and forwarding them to Datadog.
+type Hook struct{}
+
+var _ logrus.Hook = (*Hook)(nil)
+
+// NewHook returns a hook correlating the entries with the test spans.
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+// Levels returns all the levels, the entries of every level are correlated.
+func (h *Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire adds the correlation fields to the data of the entry, unless they are already set, and
+// forwards the entry. It never fails.
+func (h *Hook) Fire(e *logrus.Entry) error {
+	span, ok := spanOf(e)
+	if !ok {
+		return nil
+	}
+	traceID, spanID := span.Context().TraceID(), span.Context().SpanID()
+	if _, set := e.Data[traceIDKey]; !set && e.Data != nil {
+		e.Data[traceIDKey] = traceID
+		e.Data[spanIDKey] = spanID
+	}
+	logs.Send(logs.Entry{
+		Time:    e.Time,
+		Status:  logs.Status(e.Level.String()),
+		Message: e.Message,
+		TraceID: traceID,
+		SpanID:  spanID,
+		Logger:  "logrus",
+	})
+	return nil
+}
+
+// spanOf returns the span of the context of the entry, or of the test of its fields. The test
+// field is removed, so it isn't formatted.
+func spanOf(e *logrus.Entry) (ddtrace.Span, bool) {
+	if tb, ok := e.Data[tbKey].(testing.TB); ok {
+		delete(e.Data, tbKey)
+		if span, ok := ddtesting.TestSpan(tb); ok {
+			return span, true
+		}
+	}
+	if e.Context != nil {
+		return tracer.SpanFromContext(e.Context)
+	}
+	return nil, false
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddlogrus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/logs"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestHook(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var received []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer srv.Close()
+	logs.SetForwarder(logs.NewForwarder(logs.Config{URL: srv.URL}))
+	defer logs.SetForwarder(nil)
+
+	// The entries without context nor test aren't correlated, even during a test.
+	hook := NewHook()
+	ctx, finish := ddtesting.StartTest(t)
+	defer finish()
+	unknown := &logrus.Entry{Data: logrus.Fields{}, Level: logrus.InfoLevel, Message: "unknown"}
+	hook.Fire(unknown)
+	if len(unknown.Data) != 0 {
+		t.Errorf("unexpected correlation of an entry without test: %v", unknown.Data)
+	}
+
+	testSpan, _ := tracer.SpanFromContext(ctx)
+	test := &logrus.Entry{Data: TB(t), Level: logrus.WarnLevel, Message: "test"}
+	hook.Fire(test)
+	if len(test.Data) != 2 || test.Data[traceIDKey] != testSpan.Context().TraceID() || test.Data[spanIDKey] != testSpan.Context().SpanID() {
+		t.Errorf("unexpected correlation with the test: %v", test.Data)
+	}
+
+	span, spanCtx := tracer.StartSpanFromContext(context.Background(), "http.request")
+	defer span.Finish()
+	request := &logrus.Entry{Data: logrus.Fields{}, Level: logrus.InfoLevel, Message: "request", Context: spanCtx}
+	hook.Fire(request)
+	if request.Data[spanIDKey] != span.Context().SpanID() {
+		t.Errorf("unexpected correlation with the span: %v", request.Data)
+	}
+
+	logs.Flush()
+	if len(received) != 2 || received[0]["message"] != "test" || received[0]["status"] != "warn" || received[1]["message"] != "request" {
+		t.Errorf("unexpected forwarded entries: %v", received)
+	}
+}
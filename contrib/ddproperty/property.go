@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddproperty records the seed and the counterexample of property-based tests
+// written with pgregory.net/rapid or github.com/leanovate/gopter in the test span.
+//
+// With rapid, wrap the testing object passed to rapid.Check:
+//
+//	ctx, finish := ddtesting.StartTest(t)
+//	defer finish()
+//
+//	rapid.Check(ddproperty.RapidT(ctx, t), func(t *rapid.T) { ... })
+//
+// With gopter, record the seed of the parameters and wrap the output of the reporter:
+//
+//	parameters := gopter.DefaultTestParameters()
+//	ddproperty.RecordSeed(ctx, parameters.Seed())
+//	properties := gopter.NewProperties(parameters)
+//	...
+//	properties.TestingRun(t, gopter.NewFormatedReporter(true, 160, ddproperty.GopterOutput(ctx, os.Stdout)))
+package ddproperty
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// maxCounterexampleSize is the maximum size of the counterexample stored in the span.
+const maxCounterexampleSize = 4096
+
+var rapidSeedRegex = regexp.MustCompile(`-rapid\.seed=(\d+)`)
+
+// RecordSeed sets the seed of the generator in the test span of ctx.
+func RecordSeed(ctx context.Context, seed int64) {
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		span.SetTag(constants.TestPropertySeed, strconv.FormatInt(seed, 10))
+	}
+}
+
+// recordCounterexample sets the counterexample in the test span of ctx.
+func recordCounterexample(ctx context.Context, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	counterexample := strings.Join(lines, "\n")
+	if len(counterexample) > maxCounterexampleSize {
+		counterexample = counterexample[:maxCounterexampleSize] + "...(truncated)"
+	}
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		span.SetTag(constants.TestPropertyCounterexample, counterexample)
+	}
+}
+
+// RapidTB is a testing object for rapid.Check that records the seed and the
+// counterexample of the failed checks in the test span.
+type RapidTB struct {
+	testing.TB
+	ctx context.Context
+
+	mu    sync.Mutex
+	draws []string
+}
+
+// RapidT returns a testing object for rapid.Check that records the seed and the
+// counterexample of the failed checks in the test span of ctx.
+func RapidT(ctx context.Context, tb testing.TB) *RapidTB {
+	// The seed is only known upfront when it's set by the -rapid.seed flag.
+	if f := flag.Lookup("rapid.seed"); f != nil {
+		if seed, err := strconv.ParseInt(f.Value.String(), 10, 64); err == nil && seed != 0 {
+			RecordSeed(ctx, seed)
+		}
+	}
+	return &RapidTB{TB: tb, ctx: ctx}
+}
+
+// Log records the draws of the counterexample and forwards the arguments to the testing object.
+func (t *RapidTB) Log(args ...interface{}) {
+	t.TB.Helper()
+	t.recordDraw(fmt.Sprint(args...))
+	t.TB.Log(args...)
+}
+
+// Logf records the draws of the counterexample and forwards the arguments to the testing object.
+func (t *RapidTB) Logf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.recordDraw(fmt.Sprintf(format, args...))
+	t.TB.Logf(format, args...)
+}
+
+// Error records the failure and forwards the arguments to the testing object.
+func (t *RapidTB) Error(args ...interface{}) {
+	t.TB.Helper()
+	t.recordFailure(fmt.Sprint(args...))
+	t.TB.Error(args...)
+}
+
+// Errorf records the failure and forwards the arguments to the testing object.
+func (t *RapidTB) Errorf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.recordFailure(fmt.Sprintf(format, args...))
+	t.TB.Errorf(format, args...)
+}
+
+// Fatal records the failure and forwards the arguments to the testing object.
+func (t *RapidTB) Fatal(args ...interface{}) {
+	t.TB.Helper()
+	t.recordFailure(fmt.Sprint(args...))
+	t.TB.Fatal(args...)
+}
+
+// Fatalf records the failure and forwards the arguments to the testing object.
+func (t *RapidTB) Fatalf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.recordFailure(fmt.Sprintf(format, args...))
+	t.TB.Fatalf(format, args...)
+}
+
+func (t *RapidTB) recordDraw(message string) {
+	if !strings.Contains(message, "[rapid] draw") {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.draws = append(t.draws, strings.TrimSpace(message))
+}
+
+func (t *RapidTB) recordFailure(message string) {
+	if matches := rapidSeedRegex.FindStringSubmatch(message); len(matches) > 1 {
+		if seed, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
+			RecordSeed(t.ctx, seed)
+		}
+	}
+
+	t.mu.Lock()
+	draws := t.draws
+	t.mu.Unlock()
+	recordCounterexample(t.ctx, draws)
+}
+
+// gopterOutput captures the report of gopter while writing it to the underlying writer.
+type gopterOutput struct {
+	ctx context.Context
+	w   io.Writer
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// GopterOutput returns a writer for the gopter reporter that records the arguments of
+// the falsified properties in the test span of ctx, writing the report to w.
+func GopterOutput(ctx context.Context, w io.Writer) io.Writer {
+	return &gopterOutput{ctx: ctx, w: w}
+}
+
+func (o *gopterOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	o.buf.Write(p)
+	report := o.buf.String()
+	o.mu.Unlock()
+
+	if strings.Contains(report, "Falsified") {
+		var args []string
+		for _, line := range strings.Split(report, "\n") {
+			if strings.HasPrefix(line, "ARG_") {
+				args = append(args, strings.TrimSpace(line))
+			}
+		}
+		recordCounterexample(o.ctx, args)
+	}
+	return o.w.Write(p)
+}
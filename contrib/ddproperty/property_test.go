@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddproperty
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// fakeTB records the failures instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	failures []string
+}
+
+func (tb *fakeTB) Helper()                              {}
+func (tb *fakeTB) Logf(format string, a ...interface{}) {}
+func (tb *fakeTB) Errorf(format string, a ...interface{}) {
+	tb.failures = append(tb.failures, fmt.Sprintf(format, a...))
+}
+
+func TestRapidT(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	tb := &fakeTB{}
+	rt := RapidT(ctx, tb)
+	rt.Logf("[rapid] draw %v: %#v", "n", 42)
+	rt.Logf("unrelated log")
+	rt.Errorf("[rapid] failed after 3 tests: boom\nTo reproduce, specify -run=%q -rapid.seed=%d", "TestX", 1234)
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	if v := s.Tag(constants.TestPropertySeed); v != "1234" {
+		t.Errorf("unexpected seed: %v", v)
+	}
+	if v := s.Tag(constants.TestPropertyCounterexample); v != "[rapid] draw n: 42" {
+		t.Errorf("unexpected counterexample: %v", v)
+	}
+	if len(tb.failures) != 1 {
+		t.Error("failure was not forwarded")
+	}
+}
+
+func TestGopterOutput(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	var buf bytes.Buffer
+	w := GopterOutput(ctx, &buf)
+	fmt.Fprint(w, "! sum: Falsified after 5 passed tests.\n")
+	fmt.Fprint(w, "ARG_0: -1\nARG_0_ORIGINAL (3 shrinks): -37\n")
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	if v := s.Tag(constants.TestPropertyCounterexample); v != "ARG_0: -1\nARG_0_ORIGINAL (3 shrinks): -37" {
+		t.Errorf("unexpected counterexample: %v", v)
+	}
+	if buf.Len() == 0 {
+		t.Error("report was not forwarded")
+	}
+}
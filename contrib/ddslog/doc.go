@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddslog correlates the log/slog records emitted during the tests with the test
+// spans, and forwards them to Datadog when the logs forwarding is enabled. It requires Go 1.21.
+//
+// NewHandler wraps the handler of the logger used in the tests:
+//
+//	logger := slog.New(ddslog.NewHandler(slog.NewJSONHandler(os.Stderr, nil)))
+//	logger.InfoContext(ctx, "user created", "id", id)
+//
+// The records are correlated with the span of their context, or with the last started test
+// when they are logged without a context.
+package ddslog
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+//go:build go1.21
+// +build go1.21
+
+package ddslog
+
+import (
+	"context"
+	"log/slog"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/logs"
+)
+
+// Handler adds the dd.trace_id and dd.span_id attributes to the records emitted during a
+// test before passing them to the wrapped handler.
+type Handler struct {
+	next slog.Handler
+}
+
+// NewHandler returns a handler correlating the records with the tests, wrapping next.
+func NewHandler(next slog.Handler) *Handler {
+	return &Handler{next: next}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle adds the correlation attributes to the record, forwards it to Datadog when the logs
+// forwarding is enabled and passes it to the wrapped handler.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if traceID, spanID, ok := ddtesting.CorrelationIDs(ctx); ok {
+		r = r.Clone()
+		r.AddAttrs(slog.Uint64("dd.trace_id", traceID), slog.Uint64("dd.span_id", spanID))
+		logs.Send(logs.Entry{
+			Time:    r.Time,
+			Status:  logs.Status(r.Level.String()),
+			Message: r.Message,
+			TraceID: traceID,
+			SpanID:  spanID,
+			Logger:  "slog",
+		})
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler wrapping the wrapped handler with the given attributes.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler wrapping the wrapped handler with the given group. The
+// correlation attributes are added in the group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name)}
+}
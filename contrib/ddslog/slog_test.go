@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+//go:build go1.21
+// +build go1.21
+
+package ddslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestHandler(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	buf := new(bytes.Buffer)
+	logger := slog.New(NewHandler(slog.NewJSONHandler(buf, nil))).With("component", "test")
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	logger.InfoContext(ctx, "inside")
+	span.Finish()
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["msg"] != "inside" || record["component"] != "test" ||
+		record["dd.trace_id"] != float64(span.Context().TraceID()) || record["dd.span_id"] != float64(span.Context().SpanID()) {
+		t.Errorf("unexpected record: %v", record)
+	}
+
+	buf.Reset()
+	logger.Info("outside")
+	if bytes.Contains(buf.Bytes(), []byte("dd.trace_id")) {
+		t.Errorf("unexpected correlation outside of a test: %s", buf.String())
+	}
+}
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddtestify provides an integration for test suites written with
+// github.com/stretchr/testify/suite.
+//
+// The suite lifecycle hooks must call BeforeTest and AfterTest, every suite method
+// is then reported as a test:
+//
+//	func (s *MySuite) BeforeTest(suiteName, testName string) {
+//		ddtestify.BeforeTest(s, suiteName, testName)
+//	}
+//
+//	func (s *MySuite) AfterTest(suiteName, testName string) {
+//		ddtestify.AfterTest(s, suiteName, testName)
+//	}
+//
+// To report the assert and require failure messages, and the number of assertions of the
+// tests, build the assertions with Recorder:
+//
+//	assert.New(ddtestify.Recorder(s)).Equal(expected, actual)
+package ddtestify
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const testFramework = "github.com/stretchr/testify/suite"
+
+// TestingSuite is the part of the testify suite interface used by the integration.
+type TestingSuite interface {
+	T() *testing.T
+}
+
+// testState contains the state of a running suite method.
+type testState struct {
+	ctx    context.Context
+	finish ddtesting.FinishFunc
+
+	mu       sync.Mutex
+	failures []string
+}
+
+var (
+	// tests contains the running suite methods by their testing.T.
+	tests      = map[*testing.T]*testState{}
+	testsMutex sync.Mutex
+)
+
+// BeforeTest starts the test span of a suite method. It must be called from the
+// BeforeTest method of the suite.
+func BeforeTest(s TestingSuite, suiteName, testName string, opts ...ddtesting.Option) {
+	t := s.T()
+	suiteOpts := []ddtesting.Option{
+		ddtesting.WithTestFramework(testFramework),
+		ddtesting.WithTestSuite(suitePackage(s, suiteName)),
+	}
+	if method, ok := reflect.TypeOf(s).MethodByName(testName); ok {
+		suiteOpts = append(suiteOpts, ddtesting.WithSourceLocation(utils.GetSourceLocation(method.Func.Pointer())))
+	}
+
+	opts = append(suiteOpts, opts...)
+	ctx, finish := ddtesting.StartTestWithContext(context.Background(), t, opts...)
+
+	testsMutex.Lock()
+	defer testsMutex.Unlock()
+	tests[t] = &testState{ctx: ctx, finish: finish}
+}
+
+// AfterTest finishes the test span of a suite method. It must be called from the
+// AfterTest method of the suite.
+func AfterTest(s TestingSuite, suiteName, testName string) {
+	t := s.T()
+	testsMutex.Lock()
+	state, ok := tests[t]
+	delete(tests, t)
+	testsMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	state.mu.Lock()
+	failures := state.failures
+	state.mu.Unlock()
+	if len(failures) > 0 {
+		if span, ok := tracer.SpanFromContext(state.ctx); ok {
+			span.SetTag(ext.ErrorMsg, strings.Join(failures, "\n"))
+			span.SetTag(ext.ErrorType, "assertion")
+		}
+	}
+	state.finish()
+}
+
+// Context returns the context of the running suite method, it contains the test span.
+func Context(s TestingSuite) context.Context {
+	testsMutex.Lock()
+	defer testsMutex.Unlock()
+	if state, ok := tests[s.T()]; ok {
+		return state.ctx
+	}
+	return context.Background()
+}
+
+// Recorder returns a testing object compatible with the assert and require packages
+// that records the failure messages and counts the assertions in the test span of the
+// running suite method.
+func Recorder(s TestingSuite) *FailureRecorder {
+	t := s.T()
+	return &FailureRecorder{CountingT: ddtesting.CountAssertions(Context(s), t), t: t}
+}
+
+// FailureRecorder records the failure messages of the assertions and forwards them to testing.T.
+// The assertions are counted by the Helper method of the embedded CountingT.
+type FailureRecorder struct {
+	*ddtesting.CountingT
+	t *testing.T
+}
+
+// Errorf records the failure message and reports it to testing.T.
+func (r *FailureRecorder) Errorf(format string, args ...interface{}) {
+	r.t.Helper()
+	testsMutex.Lock()
+	state, ok := tests[r.t]
+	testsMutex.Unlock()
+	if ok {
+		state.mu.Lock()
+		state.failures = append(state.failures, strings.TrimSpace(fmt.Sprintf(format, args...)))
+		state.mu.Unlock()
+	}
+	r.t.Errorf(format, args...)
+}
+
+// FailNow stops the execution of the test.
+func (r *FailureRecorder) FailNow() {
+	r.t.Helper()
+	r.t.FailNow()
+}
+
+// suitePackage returns the package where the suite type is declared.
+func suitePackage(s TestingSuite, suiteName string) string {
+	typ := reflect.TypeOf(s)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if pkg := typ.PkgPath(); pkg != "" {
+		return pkg
+	}
+	return suiteName
+}
@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddtestify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+type sampleSuite struct {
+	t *testing.T
+}
+
+func (s *sampleSuite) T() *testing.T { return s.t }
+
+func (s *sampleSuite) TestMethod() {}
+
+func TestBeforeAfterTest(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	s := &sampleSuite{}
+	t.Run("TestMethod", func(t *testing.T) {
+		s.t = t
+		BeforeTest(s, "sampleSuite", "TestMethod")
+		AfterTest(s, "sampleSuite", "TestMethod")
+	})
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+
+	span := spans[0]
+	const pkg = "github.com/DataDog/dd-sdk-go-testing/contrib/ddtestify"
+	if v := span.Tag(constants.TestSuite); v != pkg {
+		t.Errorf("unexpected suite: %v", v)
+	}
+	if v := span.Tag(constants.TestName); v != "TestBeforeAfterTest/TestMethod" {
+		t.Errorf("unexpected name: %v", v)
+	}
+	if v := span.Tag(ext.ResourceName); v != pkg+".TestBeforeAfterTest/TestMethod" {
+		t.Errorf("unexpected resource: %v", v)
+	}
+	if v := span.Tag(constants.TestFramework); v != testFramework {
+		t.Errorf("unexpected framework: %v", v)
+	}
+	if v, _ := span.Tag(constants.TestSourceFile).(string); !strings.HasSuffix(v, "suite_test.go") {
+		t.Errorf("unexpected source file: %v", v)
+	}
+	if v := span.Tag(constants.TestStatus); v != constants.TestStatusPass {
+		t.Errorf("unexpected status: %v", v)
+	}
+}
+
+func TestRecorderAssertions(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	s := &sampleSuite{}
+	t.Run("TestMethod", func(t *testing.T) {
+		s.t = t
+		BeforeTest(s, "sampleSuite", "TestMethod")
+		Recorder(s)
+		AfterTest(s, "sampleSuite", "TestMethod")
+	})
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if v := spans[0].Tag(constants.TestAssertions); v != int64(0) {
+		t.Errorf("unexpected number of assertions: %v", v)
+	}
+	if v := spans[0].Tag(constants.TestNoAssertions); v != true {
+		t.Errorf("the test without assertions isn't tagged: %v", v)
+	}
+}
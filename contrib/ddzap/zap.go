@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ddzap correlates the go.uber.org/zap entries emitted during the tests with the test
+// spans, and forwards them to Datadog when the logs forwarding is enabled.
+//
+// WrapCore wraps the core of the loggers used in the tests:
+//
+//	logger = logger.WithOptions(zap.WrapCore(ddzap.WrapCore))
+//
+// The entries are correlated with the span of the context or with the test given in their
+// fields, or in the fields of the logger:
+//
+//	logger.Info("cart created", ddzap.TB(t))
+//	logger.With(ddzap.Context(ctx)).Info("cart created")
+//
+// The entries without context nor test are written unchanged, since the running test can't
+// be known when tests run in parallel.
+package ddzap
+
+import (
+	"context"
+	"testing"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/logs"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// contextKey and tbKey are the keys of the fields carrying the context and the test of the
+	// entries. They are skipped by the encoders.
+	contextKey = "dd.context"
+	tbKey      = "dd.test"
+
+	traceIDKey = "dd.trace_id"
+	spanIDKey  = "dd.span_id"
+)
+
+// Context returns a field correlating the entry, or the entries of the logger, with the span
+// of ctx.
+func Context(ctx context.Context) zap.Field {
+	return zap.Field{Key: contextKey, Type: zapcore.SkipType, Interface: ctx}
+}
+
+// TB returns a field correlating the entry, or the entries of the logger, with the test of tb
+// started with ddtesting.StartTest.
+func TB(tb testing.TB) zap.Field {
+	return zap.Field{Key: tbKey, Type: zapcore.SkipType, Interface: tb}
+}
+
+// core adds the correlation fields to the entries written to the wrapped core, and forwards
+// them to Datadog.
+type core struct {
+	zapcore.Core
+
+	// span is the span given in the fields of the logger.
+	span ddtrace.Span
+}
+
+// WrapCore returns a core correlating the entries written to c with the test spans, to be given
+// to zap.WrapCore.
+func WrapCore(c zapcore.Core) zapcore.Core {
+	return &core{Core: c}
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	span := c.span
+	if s, ok := spanOf(fields); ok {
+		span = s
+	}
+	return &core{Core: c.Core.With(fields), span: span}
+}
+
+func (c *core) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+func (c *core) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	span := c.span
+	if s, ok := spanOf(fields); ok {
+		span = s
+	}
+	if span == nil {
+		return c.Core.Write(e, fields)
+	}
+
+	traceID, spanID := span.Context().TraceID(), span.Context().SpanID()
+	logger := e.LoggerName
+	if logger == "" {
+		logger = "zap"
+	}
+	logs.Send(logs.Entry{
+		Time:    e.Time,
+		Status:  logs.Status(e.Level.String()),
+		Message: e.Message,
+		TraceID: traceID,
+		SpanID:  spanID,
+		Logger:  logger,
+	})
+	// The fields of the caller are copied, so the correlation fields don't modify them.
+	fields = append(fields[:len(fields):len(fields)], zap.Uint64(traceIDKey, traceID), zap.Uint64(spanIDKey, spanID))
+	return c.Core.Write(e, fields)
+}
+
+// spanOf returns the span of the last context or test of the fields.
+func spanOf(fields []zapcore.Field) (ddtrace.Span, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		switch v := fields[i].Interface.(type) {
+		case context.Context:
+			if fields[i].Key == contextKey && v != nil {
+				if span, ok := tracer.SpanFromContext(v); ok {
+					return span, true
+				}
+			}
+		case testing.TB:
+			if fields[i].Key == tbKey {
+				if span, ok := ddtesting.TestSpan(v); ok {
+					return span, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
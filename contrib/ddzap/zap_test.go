@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ddzap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/logs"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestWrapCore(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var received []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer srv.Close()
+	logs.SetForwarder(logs.NewForwarder(logs.Config{URL: srv.URL}))
+	defer logs.SetForwarder(nil)
+
+	observed, entries := observer.New(zapcore.DebugLevel)
+	logger := zap.New(observed).WithOptions(zap.WrapCore(WrapCore))
+
+	// The entries without context nor test aren't correlated, even during a test.
+	ctx, finish := ddtesting.StartTest(t)
+	testSpan, _ := tracer.SpanFromContext(ctx)
+	logger.Info("unknown")
+
+	logger.Warn("test", TB(t))
+	span, spanCtx := tracer.StartSpanFromContext(ctx, "http.request")
+	logger.With(Context(spanCtx)).Named("client").Info("request")
+	span.Finish()
+	finish()
+	logger.Info("finished", TB(t))
+
+	all := entries.All()
+	if len(all) != 4 {
+		t.Fatalf("unexpected entries: %v", all)
+	}
+	if fields := all[0].ContextMap(); fields[traceIDKey] != nil {
+		t.Errorf("unexpected correlation of an entry without test: %v", fields)
+	}
+	if fields := all[1].ContextMap(); fields[traceIDKey] != testSpan.Context().TraceID() || fields[spanIDKey] != testSpan.Context().SpanID() {
+		t.Errorf("unexpected correlation with the test: %v", fields)
+	}
+	if fields := all[2].ContextMap(); fields[spanIDKey] != span.Context().SpanID() {
+		t.Errorf("unexpected correlation with the span: %v", fields)
+	}
+	if fields := all[3].ContextMap(); fields[traceIDKey] != nil {
+		t.Errorf("unexpected correlation with a finished test: %v", fields)
+	}
+
+	logs.Flush()
+	if len(received) != 2 || received[0]["message"] != "test" || received[0]["status"] != "warn" ||
+		received[0]["dd.trace_id"] != strconv.FormatUint(testSpan.Context().TraceID(), 10) ||
+		received[1]["message"] != "request" || received[1]["logger.name"] != "client" {
+		t.Errorf("unexpected forwarded entries: %v", received)
+	}
+}
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// envOutputSpanIDs is the environment variable logging the trace and span IDs of every test.
+const envOutputSpanIDs = "DD_CIVISIBILITY_OUTPUT_SPAN_IDS"
+
+// TestCorrelationID returns the correlation ID of the test running with ctx: a stable 128-bit
+// identifier, in hexadecimal, derived from the repository, the suite and the name of the test.
+// Unlike the trace and span IDs, it's the same for every execution of the test, so external
+// systems like ticketing or flaky test dashboards can join on it across runs. It waits for the
+// Git metadata when it's still being detected.
+func TestCorrelationID(ctx context.Context) (string, bool) {
+	result, ok := testResultFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return correlationID(result.suite, result.name), true
+}
+
+// correlationID returns the correlation ID of the test of the repository of the CI tags.
+func correlationID(suite, name string) string {
+	ensureCITags()
+	repository, _ := getFromCITags(constants.GitRepositoryURL)
+	return testCorrelationID(repository, suite, name)
+}
+
+// testCorrelationID returns the correlation ID of a test. The repository URL is normalized, so
+// the HTTPS and SSH clones of a repository get the same IDs.
+func testCorrelationID(repositoryURL, suite, name string) string {
+	h := sha256.New()
+	for _, s := range []string{normalizeRepositoryURL(repositoryURL), suite, name} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// outputSpanIDsByEnv returns whether the DD_CIVISIBILITY_OUTPUT_SPAN_IDS environment variable is true.
+func outputSpanIDsByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envOutputSpanIDs))
+	return v
+}
+
+// logSpanIDs logs a line with the trace and span IDs of the test, using the keys of the
+// dd-trace-go log correlation, so the tools scraping the go test output and the humans reading
+// the CI logs can jump to the span of the test.
+func logSpanIDs(tb testing.TB, span ddtrace.Span) {
+	tb.Helper()
+	tb.Logf("dd.trace_id=%d dd.span_id=%d", span.Context().TraceID(), span.Context().SpanID())
+}
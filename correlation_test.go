@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestTestCorrelationID(t *testing.T) {
+	id := testCorrelationID("https://github.com/DataDog/dd-sdk-go-testing.git", "pkg", "TestUpload")
+	if len(id) != 32 {
+		t.Fatalf("unexpected correlation ID: %s", id)
+	}
+	if other := testCorrelationID("git@github.com:DataDog/dd-sdk-go-testing", "pkg", "TestUpload"); other != id {
+		t.Errorf("the clones of a repository have different correlation IDs: %s != %s", id, other)
+	}
+	for _, other := range []string{
+		testCorrelationID("https://github.com/DataDog/dd-trace-go.git", "pkg", "TestUpload"),
+		testCorrelationID("https://github.com/DataDog/dd-sdk-go-testing.git", "pkg", "TestDownload"),
+		// The separators keep the fields apart.
+		testCorrelationID("https://github.com/DataDog/dd-sdk-go-testing.git", "pkgT", "estUpload"),
+	} {
+		if other == id {
+			t.Errorf("unexpected equal correlation IDs: %s", id)
+		}
+	}
+}
+
+func TestCorrelationIDTag(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	if _, ok := TestCorrelationID(context.Background()); ok {
+		t.Error("unexpected correlation ID outside of a test")
+	}
+	var ids []string
+	for i := 0; i < 2; i++ {
+		ctx, finish := StartTest(t, WithTestSuite("correlation"))
+		id, ok := TestCorrelationID(ctx)
+		if !ok {
+			t.Fatal("no correlation ID in the test context")
+		}
+		ids = append(ids, id)
+		finish()
+	}
+	spans := mt.FinishedSpans()
+	if ids[0] != ids[1] || spans[0].Tag(constants.TestCorrelationID) != ids[0] || spans[1].Tag(constants.TestCorrelationID) != ids[0] {
+		t.Errorf("the correlation IDs of the executions differ: %v, %v, %v", ids,
+			spans[0].Tag(constants.TestCorrelationID), spans[1].Tag(constants.TestCorrelationID))
+	}
+}
+
+// loggingTB records the lines logged by the test.
+type loggingTB struct {
+	testing.TB
+	lines []string
+}
+
+func (tb *loggingTB) Helper() {}
+
+func (tb *loggingTB) Logf(format string, args ...interface{}) {
+	tb.lines = append(tb.lines, fmt.Sprintf(format, args...))
+}
+
+func TestLogSpanIDs(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	tb := &loggingTB{TB: t}
+	logSpanIDs(tb, span)
+
+	expected := fmt.Sprintf("dd.trace_id=%d dd.span_id=%d", span.Context().TraceID(), span.Context().SpanID())
+	if len(tb.lines) != 1 || tb.lines[0] != expected {
+		t.Errorf("unexpected output: %q", tb.lines)
+	}
+}
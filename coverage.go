@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+const (
+	// envCoverageDir is the environment variable with the directory collecting the coverage
+	// counters of the binaries built with -cover and started by the tests.
+	envCoverageDir = "DD_CIVISIBILITY_COVERAGE_DIR"
+
+	// envGoCoverDir is the environment variable read by the binaries built with -cover, since Go
+	// 1.20, to know where to write their coverage counters when they exit.
+	envGoCoverDir = "GOCOVERDIR"
+
+	// coverageProfileName is the name of the merged coverage profile written to the directory.
+	coverageProfileName = "coverage.out"
+
+	// defaultCoverageTimeout is the timeout of the merge of the coverage counters.
+	defaultCoverageTimeout = 30 * time.Second
+)
+
+// setupIntegrationCoverage creates the coverage directory and sets GOCOVERDIR, so the binaries
+// built with -cover and started by the tests, which inherit the environment, write their counters
+// to it. It returns the absolute path of the directory.
+func setupIntegrationCoverage(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, os.Setenv(envGoCoverDir, dir)
+}
+
+// reportIntegrationCoverage merges the coverage counters of the directory into a coverage profile,
+// written to the directory, and sets the percentage of covered statements on the span. It does
+// nothing when no binary wrote counters.
+func reportIntegrationCoverage(span ddtrace.Span, dir string, timeout time.Duration) error {
+	if meta, _ := filepath.Glob(filepath.Join(dir, "covmeta.*")); len(meta) == 0 {
+		return nil
+	}
+	profile := filepath.Join(dir, coverageProfileName)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "tool", "covdata", "textfmt", "-i="+dir, "-o="+profile)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("merging the coverage counters of %s: %v: %s", dir, err, strings.TrimSpace(stderr.String()))
+	}
+
+	f, err := os.Open(profile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	covered, total, err := parseCoverProfile(f)
+	if err != nil {
+		return fmt.Errorf("reading the coverage profile %s: %v", profile, err)
+	}
+	span.SetTag(constants.CodeCoverageEnabled, true)
+	if total > 0 {
+		span.SetTag(constants.CodeCoverageLinesPct, float64(covered)*100/float64(total))
+	}
+	span.SetTag(constants.CodeCoverageProfile, profile)
+	return nil
+}
+
+// parseCoverProfile returns the number of covered statements and the total number of statements
+// of a coverage profile in the format of `go test -coverprofile`. The blocks listed many times,
+// like by the counters of different processes, are covered when one of them is.
+func parseCoverProfile(r io.Reader) (covered, total int64, err error) {
+	type block struct {
+		statements int64
+		covered    bool
+	}
+	blocks := map[string]*block{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// file.go:12.34,15.2 3 1
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return 0, 0, fmt.Errorf("invalid line %q", line)
+		}
+		statements, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid line %q", line)
+		}
+		count, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid line %q", line)
+		}
+		b, ok := blocks[fields[0]]
+		if !ok {
+			b = &block{statements: statements}
+			blocks[fields[0]] = b
+		}
+		b.covered = b.covered || count > 0
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	for _, b := range blocks {
+		total += b.statements
+		if b.covered {
+			covered += b.statements
+		}
+	}
+	return covered, total, nil
+}
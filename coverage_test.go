@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestParseCoverProfile(t *testing.T) {
+	profile := `mode: set
+example.com/server/main.go:5.13,7.2 2 1
+example.com/server/main.go:9.13,11.2 3 0
+example.com/server/main.go:9.13,11.2 3 1
+example.com/server/main.go:13.13,15.2 5 0
+`
+	covered, total, err := parseCoverProfile(strings.NewReader(profile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if covered != 5 || total != 10 {
+		t.Errorf("expected 5 statements covered out of 10, got %d out of %d", covered, total)
+	}
+
+	if _, _, err := parseCoverProfile(strings.NewReader("example.com/server/main.go:5.13,7.2 two 1\n")); err == nil {
+		t.Error("expected an error for an invalid profile")
+	}
+}
+
+func TestIntegrationCoverage(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "coverage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	defer os.Setenv(envGoCoverDir, os.Getenv(envGoCoverDir))
+
+	dir, err := setupIntegrationCoverage(filepath.Join(tmp, "counters"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv(envGoCoverDir) != dir {
+		t.Fatalf("GOCOVERDIR isn't set to the coverage directory: %s", os.Getenv(envGoCoverDir))
+	}
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// Nothing is reported when no binary wrote counters.
+	span := tracer.StartSpan("session")
+	if err := reportIntegrationCoverage(span, dir, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if tag := span.(mocktracer.Span).Tag(constants.CodeCoverageEnabled); tag != nil {
+		t.Errorf("unexpected coverage without counters: %v", tag)
+	}
+
+	// Build and run a binary with -cover, like a service started by a test.
+	src := filepath.Join(tmp, "main.go")
+	program := "package main\n\nfunc main() {\n\tif len(\"a\") > 1 {\n\t\tprintln(\"never\")\n\t}\n}\n"
+	if err := ioutil.WriteFile(src, []byte(program), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(tmp, "server")
+	build := exec.Command("go", "build", "-cover", "-o", bin, src)
+	build.Env = append(os.Environ(), "GOFLAGS=", "GO111MODULE=off")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Skipf("building with -cover isn't supported: %v: %s", err, out)
+	}
+	if out, err := exec.Command(bin).CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	if err := reportIntegrationCoverage(span, dir, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	tags := span.(mocktracer.Span).Tags()
+	if tags[constants.CodeCoverageEnabled] != true {
+		t.Errorf("the coverage wasn't reported: %v", tags)
+	}
+	if pct, ok := tags[constants.CodeCoverageLinesPct].(float64); !ok || pct <= 0 || pct >= 100 {
+		t.Errorf("unexpected coverage: %v", tags[constants.CodeCoverageLinesPct])
+	}
+	if _, err := os.Stat(filepath.Join(dir, coverageProfileName)); err != nil {
+		t.Errorf("the merged profile wasn't written: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+// deadliner is implemented by *testing.T since Go 1.15.
+type deadliner interface {
+	Deadline() (time.Time, bool)
+}
+
+// testDeadline returns the earliest deadline of the test: the one of its context, or the one
+// of the -timeout flag.
+func testDeadline(ctx context.Context, tb testing.TB) (time.Time, bool) {
+	deadline, ok := ctx.Deadline()
+	if d, ok2 := tb.(deadliner); ok2 {
+		if tbDeadline, ok2 := d.Deadline(); ok2 && (!ok || tbDeadline.Before(deadline)) {
+			deadline, ok = tbDeadline, true
+		}
+	}
+	return deadline, ok
+}
+
+// setDeadlineExceeded classifies the failure of a test as a timeout when its deadline was
+// reached, and sets how long the test ran past it. It returns whether the test timed out.
+func setDeadlineExceeded(span ddtrace.Span, result *testResult, ctx context.Context, tb testing.TB, now time.Time) bool {
+	deadline, ok := testDeadline(ctx, tb)
+	if !ok || (ctx.Err() != context.DeadlineExceeded && now.Before(deadline)) {
+		return false
+	}
+	overrun := now.Sub(deadline)
+	if overrun < 0 {
+		overrun = 0
+	}
+	result.errorType = "timeout"
+	span.SetTag(ext.ErrorType, result.errorType)
+	span.SetTag(constants.TestTimeoutOverrun, overrun.Nanoseconds())
+	return true
+}
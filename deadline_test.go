@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+// failedTB reports the test as failed, with the given deadline.
+type failedTB struct {
+	testing.TB
+	deadline time.Time
+}
+
+func (tb *failedTB) Failed() bool { return true }
+
+func (tb *failedTB) Deadline() (time.Time, bool) { return tb.deadline, !tb.deadline.IsZero() }
+
+func TestWithTimeout(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx, finish := StartTest(&failedTB{TB: t}, WithTimeout(time.Millisecond))
+	<-ctx.Done()
+	time.Sleep(10 * time.Millisecond)
+	finish()
+
+	span := mt.FinishedSpans()[0]
+	if span.Tag(ext.ErrorType) != "timeout" {
+		t.Errorf("expected the timeout error type, got %v", span.Tag(ext.ErrorType))
+	}
+	if overrun, _ := span.Tag(constants.TestTimeoutOverrun).(int64); overrun < int64(5*time.Millisecond) {
+		t.Errorf("unexpected overrun %v", span.Tag(constants.TestTimeoutOverrun))
+	}
+}
+
+func TestSetDeadlineExceeded(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	now := time.Now()
+	for _, tc := range []struct {
+		name     string
+		tb       *failedTB
+		expected bool
+	}{
+		{"no deadline", &failedTB{TB: t}, false},
+		{"before the deadline", &failedTB{TB: t, deadline: now.Add(time.Minute)}, false},
+		{"after the deadline", &failedTB{TB: t, deadline: now.Add(-time.Second)}, true},
+	} {
+		span, _ := startScrubbedSpan(context.Background(), "test")
+		result := &testResult{}
+		if actual := setDeadlineExceeded(span, result, context.Background(), tc.tb, now); actual != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, actual)
+		}
+		span.Finish()
+		if tc.expected && result.errorType != "timeout" {
+			t.Errorf("%s: unexpected error type %q", tc.name, result.errorType)
+		}
+	}
+
+	if overrun := mt.FinishedSpans()[2].Tag(constants.TestTimeoutOverrun); overrun != int64(time.Second) {
+		t.Errorf("unexpected overrun %v", overrun)
+	}
+}
@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+)
+
+// envDebug is the environment variable enabling the startup diagnostics.
+const envDebug = "DD_CIVISIBILITY_DEBUG"
+
+// debugByEnv returns whether the DD_CIVISIBILITY_DEBUG environment variable is true.
+func debugByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envDebug))
+	return v
+}
+
+// writeDiagnostics writes the effective configuration of the session, so misconfigurations
+// are noticed when the tests run. It waits for the CI tags detection.
+func writeDiagnostics(w io.Writer, cfg *runConfig, service, env, sessionID string) {
+	ensureCITags()
+	tag := func(key string) string {
+		if v, ok := getFromCITags(key); ok && v != "" {
+			return utils.ScrubURLCredentials(v)
+		}
+		return "-"
+	}
+	if service == "" {
+		service = "-"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "dd-sdk-go-testing: effective configuration")
+	fmt.Fprintf(tw, "  ci provider:\t%s\n", tag(constants.CIProviderName))
+	fmt.Fprintf(tw, "  service:\t%s\n", service)
+	fmt.Fprintf(tw, "  env:\t%s\n", env)
+	fmt.Fprintf(tw, "  repository:\t%s\n", tag(constants.GitRepositoryURL))
+	fmt.Fprintf(tw, "  commit:\t%s\n", tag(constants.GitCommitSHA))
+	fmt.Fprintf(tw, "  branch:\t%s\n", tag(constants.GitBranch))
+	fmt.Fprintf(tw, "  session:\t%s\n", sessionID)
+	fmt.Fprintf(tw, "  intake:\t%s\n", intakeDescription(cfg))
+	fmt.Fprintf(tw, "  features:\t%s\n", strings.Join(enabledFeatures(cfg), ", "))
+	tw.Flush()
+}
+
+// intakeDescription describes where the data is sent.
+func intakeDescription(cfg *runConfig) string {
+	if cfg.outputFile != nil {
+		return "file at " + cfg.outputFile.path
+	}
+	if intake, _, err := agentlessIntake(cfg); cfg.agentless && err == nil {
+		return "agentless at " + intake
+	}
+	agent := "agent"
+	if cfg.evpProxy {
+		agent = "agent EVP proxy"
+	}
+	if cfg.udsPath != "" {
+		return agent + " at unix://" + cfg.udsPath
+	}
+	return agent + " at " + agentAddr(cfg)
+}
+
+// agentAddr returns the host:port address of the agent, when it's not reached through a Unix
+// socket.
+func agentAddr(cfg *runConfig) string {
+	if cfg.agentAddr != "" {
+		return cfg.agentAddr
+	}
+	host, port := os.Getenv("DD_AGENT_HOST"), os.Getenv("DD_TRACE_AGENT_PORT")
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = "8126"
+	}
+	return host + ":" + port
+}
+
+// enabledFeatures returns the names of the optional features enabled in the configuration.
+func enabledFeatures(cfg *runConfig) []string {
+	features := []string{}
+	for _, f := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"flush_on_test_finish", cfg.flushOnTestFinish},
+		{"goroutine_leak_check", cfg.leakCheck},
+		{"file_leak_check", cfg.fileLeakCheck},
+		{"runtime_metrics", cfg.runtimeMetrics},
+		{"cpu_profile", cfg.cpuProfileThreshold > 0 || len(cfg.cpuProfileTests) > 0},
+		{"heap_profile", cfg.heapProfile},
+		{"execution_trace", len(cfg.executionTraceTests) > 0},
+		{"logs_forwarding", cfg.logsForwarding},
+		{"profiler", cfg.profilerStart != nil},
+		{"allure_results", cfg.allureResultsDir != ""},
+		{"git_collection", !cfg.gitCollectionDisabled && gitCollectionEnabled()},
+		{"remote_config", cfg.remoteConfig},
+		{"isolated_tracer", cfg.isolatedTracer},
+		{"agentless", cfg.agentless},
+		{"evp_proxy", cfg.evpProxy},
+		{"output_file", cfg.outputFile != nil},
+		{"session_link", !cfg.sessionLinkDisabled},
+		{"duration_baseline", cfg.durationBaseline != ""},
+		{"periodic_flush", cfg.flushPeriod > 0},
+		{"integration_coverage", cfg.coverageDir != ""},
+		{"modified_tests", cfg.baseBranch != "" || utils.ProviderBaseBranch() != ""},
+		{"output_span_ids", cfg.outputSpanIDs},
+		{"session_level_ci_tags", cfg.ciTagsLevel == ciTagsLevelSession},
+		{"clock_skew_detection", cfg.clockSkewThreshold > 0},
+		{"ci_env_file", os.Getenv(envCIEnvFile) != ""},
+		{"codeowners_teams", len(cfg.codeownersTeams) > 0 || cfg.codeownersTeamsFile != ""},
+	} {
+		if f.enabled {
+			features = append(features, f.name)
+		}
+	}
+	return features
+}
@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDiagnostics(t *testing.T) {
+	cfg := newRunConfig(WithUDS("/var/run/datadog/apm.socket"), WithTestRuntimeMetrics(), WithFileLeakCheck(0))
+	var buf bytes.Buffer
+	writeDiagnostics(&buf, cfg, "my-service", "ci", "1234")
+
+	output := buf.String()
+	for _, expected := range []string{
+		"service:      my-service\n",
+		"env:          ci\n",
+		"session:      1234\n",
+		"intake:       agent at unix:///var/run/datadog/apm.socket\n",
+		"runtime_metrics",
+		"file_leak_check",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("%q not found in the diagnostics:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+const (
+	// envDurationBaseline is the environment variable with the path of the duration baseline file.
+	envDurationBaseline = "DD_CIVISIBILITY_DURATION_BASELINE"
+
+	// envDurationBaselineUpdate is the environment variable writing the durations of the passed
+	// tests to the baseline file.
+	envDurationBaselineUpdate = "DD_CIVISIBILITY_DURATION_BASELINE_UPDATE"
+
+	// defaultDurationRegressionRatio is how many times slower than its baseline a test must be
+	// to be tagged as a regression.
+	defaultDurationRegressionRatio = 1.5
+
+	// defaultDurationRegressionMinDelta is how much slower than its baseline a test must be to be
+	// tagged as a regression, so the jitter of the fast tests isn't reported.
+	defaultDurationRegressionMinDelta = 100 * time.Millisecond
+)
+
+// durationBaseline contains the previous durations of the tests by fully qualified name.
+type durationBaseline struct {
+	path     string
+	ratio    float64
+	minDelta time.Duration
+	update   bool
+
+	mu        sync.Mutex
+	durations map[string]time.Duration
+	current   map[string]time.Duration
+}
+
+// durationBaselineUpdateByEnv returns whether the DD_CIVISIBILITY_DURATION_BASELINE_UPDATE
+// environment variable enables the update of the baseline file.
+func durationBaselineUpdateByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envDurationBaselineUpdate))
+	return v
+}
+
+// loadDurationBaseline reads the baseline file of the configuration, or returns nil when there's
+// none. A missing file is an empty baseline, so it can be created with the update mode.
+func loadDurationBaseline(cfg *runConfig) (*durationBaseline, error) {
+	if cfg.durationBaseline == "" {
+		return nil, nil
+	}
+	b := &durationBaseline{
+		path:      cfg.durationBaseline,
+		ratio:     cfg.durationRegressionRatio,
+		minDelta:  cfg.durationRegressionMinDelta,
+		update:    cfg.durationBaselineUpdate,
+		durations: map[string]time.Duration{},
+		current:   map[string]time.Duration{},
+	}
+	durations, err := readDurationBaseline(b.path)
+	if err != nil {
+		return b, err
+	}
+	b.durations = durations
+	return b, nil
+}
+
+// readDurationBaseline reads a baseline file: a JSON object with the durations of the tests, like
+// {"github.com/org/repo/pkg.TestUpload": "1.5s"}.
+func readDurationBaseline(path string) (map[string]time.Duration, error) {
+	durations := map[string]time.Duration{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return durations, nil
+	}
+	if err != nil {
+		return durations, err
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return durations, fmt.Errorf("parsing the duration baseline %s: %v", path, err)
+	}
+	for name, value := range raw {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return durations, fmt.Errorf("parsing the duration baseline %s: %s: %v", path, name, err)
+		}
+		durations[name] = d
+	}
+	return durations, nil
+}
+
+// check tags the span of a passed test with its baseline duration, and as a regression when
+// it's slower than the baseline by more than the ratio and the minimum delta.
+func (b *durationBaseline) check(span ddtrace.Span, fqn, status string, duration time.Duration) {
+	if b == nil || status != constants.TestStatusPass {
+		return
+	}
+	b.mu.Lock()
+	baseline, ok := b.durations[fqn]
+	if b.update {
+		b.current[fqn] = duration
+	}
+	b.mu.Unlock()
+	if !ok || baseline <= 0 {
+		return
+	}
+
+	ratio := float64(duration) / float64(baseline)
+	span.SetTag(constants.TestDurationBaseline, int64(baseline))
+	span.SetTag(constants.TestDurationRatio, ratio)
+	span.SetTag(constants.TestDurationRegressed, ratio > b.ratio && duration-baseline > b.minDelta)
+}
+
+// save writes the durations of the passed tests to the baseline file in update mode, keeping the
+// durations of the other tests.
+func (b *durationBaseline) save() error {
+	if b == nil || !b.update {
+		return nil
+	}
+	// Re-read the file, it may have been updated by the test binaries of the other packages.
+	durations, err := readDurationBaseline(b.path)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	for name, d := range b.current {
+		durations[name] = d
+	}
+	b.mu.Unlock()
+
+	raw := make(map[string]string, len(durations))
+	for name, d := range durations {
+		raw[name] = d.String()
+	}
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write a temporary file and rename it, so the other test binaries never read a partial file.
+	tmp, err := ioutil.TempFile(filepath.Dir(b.path), filepath.Base(b.path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestDurationBaseline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "durations.json")
+	err = ioutil.WriteFile(path, []byte(`{"pkg.TestSlow": "1s", "pkg.TestFast": "10ms", "pkg.TestOther": "2s"}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	baseline, err := loadDurationBaseline(newRunConfig(WithDurationBaseline(path), WithDurationBaselineUpdate()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	for _, test := range []struct {
+		fqn      string
+		status   string
+		duration time.Duration
+	}{
+		{"pkg.TestSlow", constants.TestStatusPass, 2 * time.Second},
+		// The fast tests aren't regressions below the minimum delta.
+		{"pkg.TestFast", constants.TestStatusPass, 50 * time.Millisecond},
+		{"pkg.TestNew", constants.TestStatusPass, time.Second},
+		{"pkg.TestOther", constants.TestStatusFail, time.Minute},
+	} {
+		span := tracer.StartSpan("test")
+		baseline.check(span, test.fqn, test.status, test.duration)
+		span.Finish()
+	}
+
+	spans := mt.FinishedSpans()
+	if spans[0].Tag(constants.TestDurationRegressed) != true || spans[0].Tag(constants.TestDurationRatio) != 2.0 {
+		t.Errorf("unexpected tags of the regressed test: %v", spans[0].Tags())
+	}
+	if spans[0].Tag(constants.TestDurationBaseline) != int64(time.Second) {
+		t.Errorf("unexpected baseline: %v", spans[0].Tag(constants.TestDurationBaseline))
+	}
+	if spans[1].Tag(constants.TestDurationRegressed) != false {
+		t.Errorf("unexpected tags of the fast test: %v", spans[1].Tags())
+	}
+	for _, span := range spans[2:] {
+		if span.Tag(constants.TestDurationBaseline) != nil {
+			t.Errorf("unexpected baseline of a test without baseline or failed: %v", span.Tags())
+		}
+	}
+
+	// The durations of the passed tests are updated, the other ones are kept.
+	if err := baseline.save(); err != nil {
+		t.Fatal(err)
+	}
+	durations, err := readDurationBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]time.Duration{
+		"pkg.TestSlow":  2 * time.Second,
+		"pkg.TestFast":  50 * time.Millisecond,
+		"pkg.TestNew":   time.Second,
+		"pkg.TestOther": 2 * time.Second,
+	}
+	for name, d := range expected {
+		if durations[name] != d {
+			t.Errorf("unexpected duration of %s: %v", name, durations[name])
+		}
+	}
+}
+
+func TestDurationBaselineInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "durations.json")
+	if err := ioutil.WriteFile(path, []byte(`{"pkg.TestSlow": "fast"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadDurationBaseline(newRunConfig(WithDurationBaseline(path))); err == nil {
+		t.Error("expected an error with an invalid duration")
+	}
+
+	// A missing file is an empty baseline.
+	baseline, err := loadDurationBaseline(newRunConfig(WithDurationBaseline(filepath.Join(dir, "missing.json"))))
+	if err != nil || baseline == nil || len(baseline.durations) != 0 {
+		t.Errorf("unexpected baseline of a missing file: %v, %v", baseline, err)
+	}
+}
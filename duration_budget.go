@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// durationBudget is the maximum duration of the tests matching a pattern.
+type durationBudget struct {
+	pattern string
+	max     time.Duration
+}
+
+// budgetForTest returns the duration budget of the test with the fully qualified name, using the
+// most specific pattern, or zero when no pattern matches. Patterns are matched with path.Match,
+// so `*` doesn't match the `/` of the package paths and the subtests.
+func budgetForTest(budgets []durationBudget, fqn string) time.Duration {
+	var max time.Duration
+	var pattern string
+	for _, b := range budgets {
+		if len(b.pattern) <= len(pattern) {
+			continue
+		}
+		if matched, _ := path.Match(b.pattern, fqn); matched || b.pattern == fqn {
+			max, pattern = b.max, b.pattern
+		}
+	}
+	return max
+}
+
+// checkDurationBudget tags the span with the duration budget of the test and, when the test
+// exceeded it, by how long. The test fails when the budget is enforced.
+func checkDurationBudget(tb testing.TB, span ddtrace.Span, budget time.Duration, enforced bool, duration time.Duration) {
+	if budget <= 0 {
+		return
+	}
+	span.SetTag(constants.TestDurationBudget, int64(budget))
+	exceeded := duration > budget
+	span.SetTag(constants.TestDurationOverBudget, exceeded)
+	if !exceeded {
+		return
+	}
+	span.SetTag(constants.TestDurationOverage, int64(duration-budget))
+	if enforced {
+		tb.Errorf("the test took %v, exceeding its duration budget of %v by %v", duration, budget, duration-budget)
+	}
+}
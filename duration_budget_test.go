@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestCheckDurationBudget(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	tb := &failureRecorderTB{TB: t}
+	span := tracer.StartSpan("test")
+	checkDurationBudget(tb, span, time.Second, false, 3*time.Second)
+	span.Finish()
+	if tb.failed {
+		t.Error("the test failed without an enforced budget")
+	}
+
+	span = tracer.StartSpan("test")
+	checkDurationBudget(tb, span, time.Second, true, 3*time.Second)
+	span.Finish()
+	if !tb.failed {
+		t.Error("the test didn't fail with an enforced budget")
+	}
+
+	span = tracer.StartSpan("test")
+	checkDurationBudget(tb, span, time.Second, true, time.Millisecond)
+	span.Finish()
+
+	spans := mt.FinishedSpans()
+	for _, span := range spans[:2] {
+		if span.Tag(constants.TestDurationBudget) != int64(time.Second) || span.Tag(constants.TestDurationOverBudget) != true ||
+			span.Tag(constants.TestDurationOverage) != int64(2*time.Second) {
+			t.Errorf("unexpected tags of the test over budget: %v", span.Tags())
+		}
+	}
+	if spans[2].Tag(constants.TestDurationOverBudget) != false || spans[2].Tag(constants.TestDurationOverage) != nil {
+		t.Errorf("unexpected tags of the test within budget: %v", spans[2].Tags())
+	}
+}
+
+func TestWithMaxDuration(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, finish := StartTest(t, WithDurationBudget(time.Hour))
+	finish()
+	span := mt.FinishedSpans()[0]
+	if span.Tag(constants.TestDurationBudget) != int64(time.Hour) || span.Tag(constants.TestDurationOverBudget) != false {
+		t.Errorf("unexpected tags: %v", span.Tags())
+	}
+}
+
+func TestBudgetForTest(t *testing.T) {
+	budgets := []durationBudget{
+		{pattern: "example.com/repo/api.*", max: time.Second},
+		{pattern: "example.com/repo/api.TestCheckout", max: 5 * time.Second},
+	}
+	for fqn, expected := range map[string]time.Duration{
+		"example.com/repo/api.TestList":     time.Second,
+		"example.com/repo/api.TestCheckout": 5 * time.Second,
+		"example.com/repo/db.TestQuery":     0,
+	} {
+		if max := budgetForTest(budgets, fqn); max != expected {
+			t.Errorf("unexpected budget of %s: %v", fqn, max)
+		}
+	}
+}
+
+func TestDurationBudgetsConfigFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "dd-test-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"duration_budgets": {"example.com/repo/api.*": "1s", "invalid": "fast"}, "duration_budgets_enforced": true}`)
+	file.Close()
+
+	config, err := readConfigFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := new(runConfig)
+	runDefaults(cfg)
+	config.apply(cfg)
+	if len(cfg.durationBudgets) != 1 || cfg.durationBudgets[0] != (durationBudget{pattern: "example.com/repo/api.*", max: time.Second}) {
+		t.Errorf("unexpected duration budgets: %v", cfg.durationBudgets)
+	}
+	if !cfg.durationBudgetsEnforced {
+		t.Error("the duration budgets aren't enforced")
+	}
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+// envEnabled is the environment variable enabling or disabling the SDK.
+const envEnabled = "DD_CIVISIBILITY_ENABLED"
+
+// Values of enabledState.
+const (
+	enabledUnset int32 = iota
+	enabledTrue
+	enabledFalse
+)
+
+// enabledState records whether the SDK is enabled. It's set when the session starts, from the
+// options and the DD_CIVISIBILITY_ENABLED environment variable, or from the environment variable
+// by the first test started without a session, so the tests don't read the environment.
+var enabledState int32
+
+// isEnabled returns whether the SDK is enabled. When it's disabled, Run only runs the tests and
+// the tests started with StartTest aren't reported.
+func isEnabled() bool {
+	state := atomic.LoadInt32(&enabledState)
+	if state == enabledUnset {
+		setEnabled(enabledByEnv())
+		state = atomic.LoadInt32(&enabledState)
+	}
+	return state == enabledTrue
+}
+
+// setEnabled records whether the SDK is enabled.
+func setEnabled(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&enabledState, enabledTrue)
+	} else {
+		atomic.StoreInt32(&enabledState, enabledFalse)
+	}
+}
+
+// enabledByEnv returns false when the DD_CIVISIBILITY_ENABLED environment variable is false.
+func enabledByEnv() bool {
+	v, err := strconv.ParseBool(os.Getenv(envEnabled))
+	return err != nil || v
+}
@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestEnabledByEnv(t *testing.T) {
+	defer os.Setenv(envEnabled, os.Getenv(envEnabled))
+
+	for value, expected := range map[string]bool{
+		"":      true,
+		"true":  true,
+		"1":     true,
+		"false": false,
+		"0":     false,
+		"maybe": true,
+	} {
+		os.Setenv(envEnabled, value)
+		if actual := enabledByEnv(); actual != expected {
+			t.Errorf("%q: expected %v, got %v", value, expected, actual)
+		}
+		if actual := newRunConfig().enabled; actual != expected {
+			t.Errorf("%q: expected run config enabled %v, got %v", value, expected, actual)
+		}
+	}
+}
+
+func TestEnabledReadOnce(t *testing.T) {
+	defer atomic.StoreInt32(&enabledState, atomic.LoadInt32(&enabledState))
+	defer os.Setenv(envEnabled, os.Getenv(envEnabled))
+
+	atomic.StoreInt32(&enabledState, enabledUnset)
+	os.Setenv(envEnabled, "false")
+	if isEnabled() {
+		t.Error("the SDK isn't disabled by the environment variable")
+	}
+	os.Setenv(envEnabled, "true")
+	if isEnabled() {
+		t.Error("the environment variable is read again")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	defer atomic.StoreInt32(&enabledState, atomic.LoadInt32(&enabledState))
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	setEnabled(newRunConfig(WithEnabled(false)).enabled)
+	ctx, finish := StartTest(t)
+	if _, ok := testResultFromContext(ctx); ok {
+		t.Error("a disabled test has a result")
+	}
+	finish()
+	if spans := mt.FinishedSpans(); len(spans) != 0 {
+		t.Errorf("unexpected spans: %d", len(spans))
+	}
+
+	setEnabled(true)
+	_, finish = StartTest(t)
+	finish()
+	if spans := mt.FinishedSpans(); len(spans) != 1 {
+		t.Errorf("unexpected spans: %d", len(spans))
+	}
+}
+
+func TestDisabledByEnv(t *testing.T) {
+	defer atomic.StoreInt32(&enabledState, atomic.LoadInt32(&enabledState))
+	defer os.Setenv(envEnabled, os.Getenv(envEnabled))
+	defer os.Setenv(envConfigFile, os.Getenv(envConfigFile))
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	dir, err := ioutil.TempDir("", "dd-test-disabled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dd-test.yaml")
+	if err := ioutil.WriteFile(path, []byte("service: my-service\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(envConfigFile, path)
+	os.Setenv(envEnabled, "false")
+
+	// The configuration file isn't read by the disabled SDK, unless an option enables it.
+	if cfg := newRunConfig(); cfg.enabled || cfg.service != "" {
+		t.Errorf("unexpected configuration of the disabled SDK: enabled %v, service %q", cfg.enabled, cfg.service)
+	}
+	if cfg := newRunConfig(WithEnabled(true)); !cfg.enabled || cfg.service != "my-service" {
+		t.Errorf("unexpected configuration of the enabled SDK: enabled %v, service %q", cfg.enabled, cfg.service)
+	}
+	applied := 0
+	if cfg := newRunConfig(func(*runConfig) { applied++ }); cfg.enabled || applied != 1 {
+		t.Errorf("the options of the disabled SDK are applied %d times", applied)
+	}
+
+	atomic.StoreInt32(&enabledState, enabledUnset)
+	ctx := context.Background()
+	if StartStep(ctx, "step") != ctx {
+		t.Error("a disabled step changed the context")
+	}
+	module := StartTestModule("module")
+	if module != nil {
+		t.Fatal("a disabled module isn't nil")
+	}
+	suite := module.StartTestSuite("suite")
+	suite.Fail()
+	suite.Finish()
+	module.Finish()
+	if spans := mt.FinishedSpans(); len(spans) != 0 {
+		t.Errorf("unexpected spans: %d", len(spans))
+	}
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// defaultEnvAllowlist contains the environment variables always captured in the snapshot.
+var defaultEnvAllowlist = []string{"GOFLAGS", "GODEBUG", "GOARCH", "GOOS", "CGO_ENABLED", "GORACE", "GOTRACEBACK", "LANG"}
+
+// setEnvironmentTags sets a snapshot of the settings of the test binary as tags of the session
+// span: the runtime settings, the limits, the time zone and the allowlisted environment
+// variables. The values of the sensitive variables are scrubbed.
+func setEnvironmentTags(span ddtrace.Span, allowlist []string) {
+	span.SetTag(constants.EnvGOMAXPROCS, float64(runtime.GOMAXPROCS(0)))
+	span.SetTag(constants.EnvNumCPU, float64(runtime.NumCPU()))
+	if v := os.Getenv("GOGC"); v != "" {
+		span.SetTag(constants.EnvGOGC, v)
+	}
+	if v := os.Getenv("GOMEMLIMIT"); v != "" {
+		span.SetTag(constants.EnvGOMEMLIMIT, v)
+	}
+	tz := os.Getenv("TZ")
+	if tz == "" {
+		tz = time.Local.String()
+	}
+	span.SetTag(constants.EnvTimezone, tz)
+	if limit, ok := utils.FileDescriptorLimit(); ok {
+		span.SetTag(constants.EnvFileDescriptorLimit, float64(limit))
+	}
+
+	for _, lists := range [][]string{defaultEnvAllowlist, allowlist} {
+		for _, name := range lists {
+			if v, ok := os.LookupEnv(name); ok {
+				span.SetTag(constants.EnvVariablePrefix+name, utils.ScrubValue(name, v))
+			}
+		}
+	}
+}
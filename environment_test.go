@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestSetEnvironmentTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	os.Setenv("DD_TEST_REGION", "eu")
+	os.Setenv("DD_TEST_TOKEN", "secret")
+	defer os.Unsetenv("DD_TEST_REGION")
+	defer os.Unsetenv("DD_TEST_TOKEN")
+
+	span := tracer.StartSpan("session")
+	setEnvironmentTags(span, []string{"DD_TEST_REGION", "DD_TEST_TOKEN", "DD_TEST_MISSING"})
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	if s.Tag(constants.EnvGOMAXPROCS) != float64(runtime.GOMAXPROCS(0)) || s.Tag(constants.EnvTimezone) == nil {
+		t.Errorf("unexpected runtime tags: %v", s.Tags())
+	}
+	if s.Tag(constants.EnvVariablePrefix+"DD_TEST_REGION") != "eu" || s.Tag(constants.EnvVariablePrefix+"DD_TEST_TOKEN") != "?" {
+		t.Errorf("unexpected variable tags: %v", s.Tags())
+	}
+	if _, ok := s.Tags()[constants.EnvVariablePrefix+"DD_TEST_MISSING"]; ok {
+		t.Error("unexpected tag of a missing variable")
+	}
+}
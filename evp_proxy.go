@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// envEVPProxyDisabled is the environment variable disabling the EVP proxy of the agent.
+	envEVPProxyDisabled = "DD_CIVISIBILITY_EVP_PROXY_DISABLED"
+
+	// evpProxyEndpoint is the endpoint listed by the agents with an EVP proxy supporting the
+	// CI Visibility test cycle events.
+	evpProxyEndpoint = "/evp_proxy/v2"
+)
+
+// evpProxyDisabledByEnv returns whether the DD_CIVISIBILITY_EVP_PROXY_DISABLED environment
+// variable disables the EVP proxy.
+func evpProxyDisabledByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envEVPProxyDisabled))
+	return v
+}
+
+// agentEndpoints returns the endpoints listed by the /info endpoint of the agent.
+func agentEndpoints(url string, client *http.Client) ([]string, error) {
+	resp, err := client.Get(url + "/info")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var info struct {
+		Endpoints []string `json:"endpoints"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	return info.Endpoints, nil
+}
+
+// detectEVPProxy sets whether the test spans are sent through the EVP proxy of the agent, when
+// it's listed by the agent within the timeout. The agents without EVP proxy, like the older
+// ones, receive the test spans as APM spans.
+func detectEVPProxy(cfg *runConfig, timeout time.Duration) {
+	cfg.evpProxy = false
+	if cfg.evpProxyDisabled || cfg.agentless {
+		return
+	}
+	supported, err := agentEVPProxy(cfg, timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: detecting the EVP proxy of the agent, the test spans are sent as APM spans: %v\n", err)
+		return
+	}
+	cfg.evpProxy = supported
+}
+
+// agentEVPProxy returns whether the agent lists the EVP proxy within the timeout.
+func agentEVPProxy(cfg *runConfig, timeout time.Duration) (bool, error) {
+	url, client := agentURL(cfg, timeout)
+	endpoints, err := agentEndpoints(url, client)
+	if err != nil {
+		return false, err
+	}
+	for _, endpoint := range endpoints {
+		if strings.TrimSuffix(endpoint, "/") == evpProxyEndpoint {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEVPProxy(t *testing.T) {
+	endpoints := `["/v0.4/traces", "/evp_proxy/v2/", "/info"]`
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" {
+			fmt.Fprintf(w, `{"version": "7.40.0", "endpoints": %s}`, endpoints)
+			return
+		}
+		paths = append(paths, r.URL.Path)
+	}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	// The agent supports the EVP proxy, the test spans are sent through it.
+	cfg := newRunConfig(WithAgentAddr(addr), WithIsolatedTracer())
+	detectEVPProxy(cfg, time.Second)
+	if !cfg.evpProxy {
+		t.Fatal("expected the EVP proxy to be detected")
+	}
+	setIsolatedTracer(newIsolatedTracer(cfg, "svc", "ci"))
+	FinishStep(StartStep(context.Background(), "step"), nil)
+	flushTracer()
+	setIsolatedTracer(nil)
+	if len(paths) != 1 || paths[0] != "/evp_proxy/v2/api/v2/citestcycle" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+	if got := intakeDescription(cfg); got != "agent EVP proxy at "+addr {
+		t.Errorf("unexpected intake description: %s", got)
+	}
+
+	// The EVP proxy is disabled.
+	cfg = newRunConfig(WithAgentAddr(addr), WithIsolatedTracer(), WithEVPProxyDisabled())
+	detectEVPProxy(cfg, time.Second)
+	if cfg.evpProxy {
+		t.Error("unexpected EVP proxy when it's disabled")
+	}
+
+	// Older agents receive APM spans.
+	endpoints = `["/v0.4/traces", "/info"]`
+	paths = nil
+	cfg = newRunConfig(WithAgentAddr(addr), WithIsolatedTracer())
+	detectEVPProxy(cfg, time.Second)
+	if cfg.evpProxy {
+		t.Fatal("unexpected EVP proxy")
+	}
+	setIsolatedTracer(newIsolatedTracer(cfg, "svc", "ci"))
+	FinishStep(StartStep(context.Background(), "step"), nil)
+	flushTracer()
+	setIsolatedTracer(nil)
+	if len(paths) != 1 || paths[0] != "/v0.4/traces" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+}
+
+func TestTestCycleClient(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	defer srv.Close()
+
+	// The traces of the global tracer are converted into test cycle events.
+	cfg := newRunConfig(WithAgentAddr(strings.TrimPrefix(srv.URL, "http://")))
+	cfg.evpProxy = true
+	client := testCycleClient(cfg, "svc", "ci")
+	// A trace with a single span in msgpack.
+	resp, err := client.Post(srv.URL+"/v0.4/traces", "application/msgpack", strings.NewReader("\x91\x91\x81\xa4name\xa4test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || path != "/evp_proxy/v2/api/v2/citestcycle" {
+		t.Errorf("unexpected response %d, request %s", resp.StatusCode, path)
+	}
+}
@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExitReportedMarker prefixes the line written to stderr by Exit for every running test it
+// reported, so the tools reading the output of the test binary, like ddtest -monitor, don't
+// report the test again. The name of the test follows the marker.
+const ExitReportedMarker = "dd-sdk-go-testing: reported the test interrupted by the exit: "
+
+// osExit is os.Exit, replaced by the tests.
+var osExit = os.Exit
+
+// Exit finishes the running tests as failed, flushes the session and exits with the given code.
+// os.Exit doesn't run the deferred functions, so the code under test calling it drops the
+// buffered spans; calling Exit instead, for example by replacing an exit function variable of
+// the code under test in TestMain, reports the tests interrupted by the exit:
+//
+//	func TestMain(m *testing.M) {
+//		app.Exit = ddtesting.Exit
+//		os.Exit(ddtesting.Run(m))
+//	}
+func Exit(code int) {
+	msg := fmt.Sprintf("the test binary exited with code %d", code)
+	for _, t := range finishActiveTests(msg, "exit", getStacktrace(2)) {
+		fmt.Fprintf(os.Stderr, "%s%s\n", ExitReportedMarker, t.result.name)
+	}
+	Stop()
+	osExit(code)
+}
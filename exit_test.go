@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestExit(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// The session of the test binary isn't stopped.
+	sessionMutex.Lock()
+	s := session
+	session = nil
+	sessionMutex.Unlock()
+	defer func() {
+		sessionMutex.Lock()
+		session = s
+		sessionMutex.Unlock()
+	}()
+
+	var code int
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	osExit = func(c int) { code = c }
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(stderr *os.File) { os.Stderr = stderr }(os.Stderr)
+	os.Stderr = w
+
+	_, finish := StartTest(t)
+	Exit(3)
+	finish()
+	w.Close()
+	output, _ := ioutil.ReadAll(r)
+
+	if code != 3 {
+		t.Errorf("unexpected exit code: %d", code)
+	}
+	if !strings.Contains(string(output), ExitReportedMarker+t.Name()+"\n") {
+		t.Errorf("unexpected output: %q", output)
+	}
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if spans[0].Tag(constants.TestStatus) != constants.TestStatusFail || spans[0].Tag(ext.ErrorType) != "exit" ||
+		spans[0].Tag(ext.ErrorMsg) != "the test binary exited with code 3" {
+		t.Errorf("unexpected tags: %v", spans[0].Tags())
+	}
+}
@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package ci contains the names of the CI and Git tags reported on the spans of the tests, so
+// wrapper libraries can read or override them without hardcoding their names:
+//
+//	sha, _ := ci.GetCITag(ci.GitCommitSHA)
+//
+//	ctx, finish := ddtesting.StartTest(t, ddtesting.WithSpanOptions(tracer.Tag(ci.GitBranch, branch)))
+//
+// The tags set with the span options of a test take precedence over the detected ones. The names
+// are stable: they're part of the public API of the SDK.
+package ci
+
+import (
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+// CI tags.
+const (
+	// CIJobName indicates job name.
+	CIJobName = constants.CIJobName
+
+	// CIJobURL indicates job URL.
+	CIJobURL = constants.CIJobURL
+
+	// CIMatrix indicates the variant of the matrix job, as a JSON object like {"go":"1.22","os":"windows"}.
+	CIMatrix = constants.CIMatrix
+
+	// CIPipelineID indicates pipeline ID.
+	CIPipelineID = constants.CIPipelineID
+
+	// CIPipelineName indicates pipeline name.
+	CIPipelineName = constants.CIPipelineName
+
+	// CIPipelineNumber indicates pipeline number.
+	CIPipelineNumber = constants.CIPipelineNumber
+
+	// CIPipelineURL indicates pipeline URL.
+	CIPipelineURL = constants.CIPipelineURL
+
+	// CIProviderName indicates provider name.
+	CIProviderName = constants.CIProviderName
+
+	// CIStageName indicates stage name.
+	CIStageName = constants.CIStageName
+
+	// CIWorkspacePath records an absolute path to the directory where the project has been checked out.
+	CIWorkspacePath = constants.CIWorkspacePath
+)
+
+// Git tags.
+const (
+	// GitBranch indicates the current git branch.
+	GitBranch = constants.GitBranch
+
+	// GitCommitAuthorDate indicates git commit author date related to the build.
+	GitCommitAuthorDate = constants.GitCommitAuthorDate
+
+	// GitCommitAuthorEmail indicates git commit author email related to the build.
+	GitCommitAuthorEmail = constants.GitCommitAuthorEmail
+
+	// GitCommitAuthorName indicates git commit author name related to the build.
+	GitCommitAuthorName = constants.GitCommitAuthorName
+
+	// GitCommitCommitterDate indicates git commit committer date related to the build.
+	GitCommitCommitterDate = constants.GitCommitCommitterDate
+
+	// GitCommitCommitterEmail indicates git commit committer email related to the build.
+	GitCommitCommitterEmail = constants.GitCommitCommitterEmail
+
+	// GitCommitCommitterName indicates git commit committer name related to the build.
+	GitCommitCommitterName = constants.GitCommitCommitterName
+
+	// GitCommitMessage indicates git commit message related to the build.
+	GitCommitMessage = constants.GitCommitMessage
+
+	// GitCommitSHA indicates git commit SHA1 hash related to the build.
+	GitCommitSHA = constants.GitCommitSHA
+
+	// GitRepositoryURL indicates git repository URL related to the build.
+	GitRepositoryURL = constants.GitRepositoryURL
+
+	// GitTag indicates the current git tag.
+	GitTag = constants.GitTag
+)
+
+// GetCITag returns the value of a CI or Git tag of the test session, and whether it's set. It
+// waits for the detection of the tags when it's still running.
+func GetCITag(key string) (string, bool) {
+	value, ok := ddtesting.CITags()[key]
+	return value, ok
+}
+
+// Tags returns a copy of the CI and Git tags of the test session, by tag name.
+func Tags() map[string]string {
+	return ddtesting.CITags()
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package ci
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/cisim"
+)
+
+// pipeline is the fabricated CI environment the tags of the session are detected in, so the
+// tests don't depend on the Git repository nor on the CI provider running them.
+var pipeline = cisim.Environment{
+	Env: map[string]string{
+		"GITLAB_CI":          "true",
+		"CI_PIPELINE_ID":     "42",
+		"CI_REPOSITORY_URL":  "https://gitlab.com/my-org/my-repo.git",
+		"CI_COMMIT_SHA":      "0123456789abcdef0123456789abcdef01234567",
+		"CI_COMMIT_REF_NAME": "main",
+		"CI_PROJECT_DIR":     "/builds/my-org/my-repo",
+	},
+}
+
+func TestMain(m *testing.M) {
+	// The tags are detected once, in the fabricated environment, without the git commands.
+	kept := map[string]string{}
+	for _, k := range []string{"HOME", "USERPROFILE", "PATH", "TMPDIR"} {
+		kept[k] = os.Getenv(k)
+	}
+	os.Clearenv()
+	for k, v := range kept {
+		os.Setenv(k, v)
+	}
+	for k, v := range pipeline.Env {
+		os.Setenv(k, v)
+	}
+	os.Setenv("DD_CIVISIBILITY_GIT_COLLECTION_DISABLED", "true")
+	os.Exit(m.Run())
+}
+
+func TestTagNames(t *testing.T) {
+	// The names are part of the public API, they must not change.
+	for name, expected := range map[string]string{
+		CIPipelineID:     "ci.pipeline.id",
+		CIProviderName:   "ci.provider.name",
+		CIWorkspacePath:  "ci.workspace_path",
+		GitBranch:        "git.branch",
+		GitCommitSHA:     "git.commit.sha",
+		GitRepositoryURL: "git.repository_url",
+	} {
+		if name != expected {
+			t.Errorf("expected %q, got %q", expected, name)
+		}
+	}
+}
+
+func TestGetCITag(t *testing.T) {
+	sha, ok := GetCITag(GitCommitSHA)
+	if !ok || sha != pipeline.Env["CI_COMMIT_SHA"] {
+		t.Errorf("unexpected commit SHA: %q, %v", sha, ok)
+	}
+	if _, ok := GetCITag("ci.unknown"); ok {
+		t.Error("unexpected unknown tag")
+	}
+
+	// The session reports the tags of the environment, along with the ones of the OS and runtime.
+	tags := Tags()
+	for k, v := range pipeline.Tags() {
+		if tags[k] != v {
+			t.Errorf("tag %s: expected %q, got %q", k, v, tags[k])
+		}
+	}
+	tags[GitCommitSHA] = "modified"
+	if value, _ := GetCITag(GitCommitSHA); value != sha {
+		t.Error("the tags of the session were modified through the copy")
+	}
+}
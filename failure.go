@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// maxFailureValueSize is the maximum size of the expected and actual values stored in the span.
+	maxFailureValueSize = 1024
+
+	// maxFailureDiffSize is the maximum size of the comparison diff stored in the span.
+	maxFailureDiffSize = 16 * 1024
+)
+
+// RecordComparisonFailure attaches the expected and actual values of a failed comparison to
+// the test span of ctx, so failures can be grouped by their values instead of their messages.
+// The values are formatted with %#v. The diff is optional, it's also attached to the test
+// result. Assertion libraries and test helpers call it before failing the test:
+//
+//	if got != want {
+//		ddtesting.RecordComparisonFailure(ctx, want, got, "")
+//		t.Errorf("got %v, want %v", got, want)
+//	}
+func RecordComparisonFailure(ctx context.Context, expected, actual interface{}, diff string) {
+	if ctx == nil {
+		return
+	}
+	if diff != "" {
+		if result, ok := testResultFromContext(ctx); ok {
+			result.attach("comparison.diff", "text/x-diff", []byte(diff))
+		}
+	}
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	span.SetTag(ext.ErrorType, "comparison")
+	span.SetTag(constants.TestFailureExpected, truncateValue(fmt.Sprintf("%#v", expected), maxFailureValueSize))
+	span.SetTag(constants.TestFailureActual, truncateValue(fmt.Sprintf("%#v", actual), maxFailureValueSize))
+	if diff != "" {
+		span.SetTag(constants.TestFailureDiff, truncateValue(diff, maxFailureDiffSize))
+	}
+}
+
+// truncateValue truncates the value to the maximum size.
+func truncateValue(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max] + "...(truncated)"
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestRecordComparisonFailure(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx, finish := StartTest(t)
+	RecordComparisonFailure(ctx, 42, "42", "-42\n+\"42\"\n")
+	RecordComparisonFailure(nil, 1, 2, "")
+	result, _ := testResultFromContext(ctx)
+	finish()
+
+	s := mt.FinishedSpans()[0]
+	if s.Tag(ext.ErrorType) != "comparison" || s.Tag(constants.TestFailureExpected) != "42" ||
+		s.Tag(constants.TestFailureActual) != `"42"` || s.Tag(constants.TestFailureDiff) != "-42\n+\"42\"\n" {
+		t.Errorf("unexpected comparison failure tags: %v", s.Tags())
+	}
+	if len(result.attachments) != 1 || result.attachments[0].name != "comparison.diff" {
+		t.Errorf("unexpected attachments: %v", result.attachments)
+	}
+}
+
+func TestRecordComparisonFailureTruncated(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx, finish := StartTest(t)
+	RecordComparisonFailure(ctx, strings.Repeat("a", 2*maxFailureValueSize), "", "")
+	finish()
+
+	expected, _ := mt.FinishedSpans()[0].Tag(constants.TestFailureExpected).(string)
+	if len(expected) != maxFailureValueSize+len("...(truncated)") {
+		t.Errorf("unexpected expected value size: %d", len(expected))
+	}
+}
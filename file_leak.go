@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// filesSnapshot contains the open file descriptors and the temp directory entries when a
+// test starts.
+type filesSnapshot struct {
+	fds   int
+	fdsOK bool
+	temp  map[string]struct{}
+}
+
+// takeFilesSnapshot returns the current files snapshot.
+func takeFilesSnapshot() *filesSnapshot {
+	fds, ok := utils.OpenFileDescriptors()
+	return &filesSnapshot{fds: fds, fdsOK: ok, temp: utils.DirEntries(os.TempDir())}
+}
+
+// checkFileLeaks tags the span with the file descriptors opened since the snapshot and the
+// new temp entries of at least minTempSize bytes.
+func checkFileLeaks(span ddtrace.Span, start *filesSnapshot, testName string, minTempSize int64) {
+	if start.fdsOK {
+		if fds, ok := utils.OpenFileDescriptors(); ok && fds > start.fds {
+			span.SetTag(constants.TestLeakedFileDescriptors, float64(fds-start.fds))
+		}
+	}
+
+	tempDir := os.TempDir()
+	prefixes := tempDirPrefixes(testName)
+	var leaked []string
+	var size int64
+	for name := range utils.DirEntries(tempDir) {
+		if _, ok := start.temp[name]; ok || hasAnyPrefix(name, prefixes) {
+			continue
+		}
+		leaked = append(leaked, name)
+		size += utils.PathSize(filepath.Join(tempDir, name))
+	}
+	if len(leaked) > 0 && size >= minTempSize {
+		sort.Strings(leaked)
+		span.SetTag(constants.TestLeakedTempBytes, float64(size))
+		span.SetTag(constants.TestLeakedTempFiles, strings.Join(leaked, ","))
+	}
+}
+
+// tempDirPrefixes returns the prefixes of the directories created by t.TempDir, which are
+// removed after the test span finishes. The name is mapped like in the testing package.
+func tempDirPrefixes(testName string) []string {
+	mapped := strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf {
+			const allowed = "!#$%&()+,-.=@^_{}~ "
+			if '0' <= r && r <= '9' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || strings.ContainsRune(allowed, r) {
+				return r
+			}
+		} else if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return r
+		}
+		return -1
+	}, testName)
+	// Go 1.15 and 1.16 replace the slashes of the subtests names.
+	return []string{mapped, strings.Replace(testName, "/", "_", -1)}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
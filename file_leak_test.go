@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestCheckFileLeaks(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	start := takeFilesSnapshot()
+	f, err := os.Open(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	leaked, err := ioutil.TempDir("", "leaked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(leaked)
+	ioutil.WriteFile(filepath.Join(leaked, "data"), make([]byte, 2048), 0644)
+	ignored, err := ioutil.TempDir("", "TestCheckFileLeaks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(ignored)
+
+	span := tracer.StartSpan("test")
+	checkFileLeaks(span, start, "TestCheckFileLeaks", 1024)
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	if start.fdsOK {
+		if fds, _ := s.Tag(constants.TestLeakedFileDescriptors).(float64); fds < 1 {
+			t.Errorf("unexpected leaked file descriptors: %v", fds)
+		}
+	}
+	if s.Tag(constants.TestLeakedTempFiles) != filepath.Base(leaked) || s.Tag(constants.TestLeakedTempBytes) != float64(2048) {
+		t.Errorf("unexpected leaked temp files: %v", s.Tags())
+	}
+}
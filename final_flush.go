@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultFinalFlushTimeout is the default timeout of the final flush of the session.
+const defaultFinalFlushTimeout = 10 * time.Second
+
+// FlushStatus is the status of the final flush of the session.
+type FlushStatus string
+
+const (
+	// FlushStatusNotRun is the status when the session hasn't been stopped.
+	FlushStatusNotRun FlushStatus = ""
+
+	// FlushStatusFlushed is the status when all the data has been flushed.
+	FlushStatusFlushed FlushStatus = "flushed"
+
+	// FlushStatusTimedOut is the status when the final flush timed out, so data may have
+	// been dropped.
+	FlushStatusTimedOut FlushStatus = "timed_out"
+)
+
+var (
+	// finalFlushStatus is the status of the final flush of the last stopped session.
+	finalFlushStatus      FlushStatus
+	finalFlushStatusMutex sync.Mutex
+)
+
+// FinalFlushStatus returns the status of the final flush of the session, once Run returns or
+// Stop is called.
+func FinalFlushStatus() FlushStatus {
+	finalFlushStatusMutex.Lock()
+	defer finalFlushStatusMutex.Unlock()
+	return finalFlushStatus
+}
+
+// setFinalFlushStatus records the status of the final flush.
+func setFinalFlushStatus(status FlushStatus) {
+	finalFlushStatusMutex.Lock()
+	defer finalFlushStatusMutex.Unlock()
+	finalFlushStatus = status
+}
+
+// runWithTimeout runs fn and returns whether it returned before the timeout. The function
+// keeps running in background after the timeout. There's no timeout when it's not positive.
+func runWithTimeout(fn func(), timeout time.Duration) bool {
+	if timeout <= 0 {
+		fn()
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
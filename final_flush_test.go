@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunWithTimeout(t *testing.T) {
+	if !runWithTimeout(func() {}, time.Second) {
+		t.Error("a fast function timed out")
+	}
+	if !runWithTimeout(func() { time.Sleep(10 * time.Millisecond) }, 0) {
+		t.Error("a function without timeout timed out")
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	if runWithTimeout(func() { <-release }, 10*time.Millisecond) {
+		t.Error("a blocked function didn't time out")
+	}
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// FixtureFunc is a setup or teardown function of a test binary.
+type FixtureFunc func(ctx context.Context) error
+
+// RunWithSetup runs a `testing.M` object like RunWithOptions, recording the setup function run
+// before the tests and the teardown function run after them as fixture spans of the session:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(ddtesting.RunWithSetup(m, func(ctx context.Context) error {
+//			return db.Migrate(ctx)
+//		}, func(ctx context.Context) error {
+//			return pool.Purge(ctx)
+//		}))
+//	}
+//
+// When the setup fails, the tests aren't run and the exit code is 1. The teardown always runs,
+// and its failure sets the exit code to 1. Either function can be nil.
+func RunWithSetup(m *testing.M, setup, teardown FixtureFunc, runOpts ...RunOption) int {
+	pc, _, _, _ := runtime.Caller(1)
+	return run(m, pc, setup, teardown, runOpts...)
+}
+
+// RecordFixture runs fn as a named fixture of the test session, recorded as a child span of the
+// session span with its status and duration, for the work done in TestMain outside of the tests
+// when the session is started with Start. The fixture fails when fn returns an error or panics.
+// The context given to fn contains the fixture span. Without a running session, fn is only run.
+func RecordFixture(name string, fn FixtureFunc) (err error) {
+	s := currentSession()
+	if s == nil || !isEnabled() {
+		return fn(context.Background())
+	}
+	span, ctx := startScrubbedSpan(context.Background(), constants.SpanTypeTestFixture,
+		tracer.ChildOf(s.span.Context()),
+		tracer.SpanType(constants.SpanTypeTestFixture),
+		tracer.ResourceName(name),
+		tracer.Tag(constants.TestFixtureName, name),
+		tracer.Tag(constants.TestSessionID, s.id),
+		tracer.Tag(constants.Origin, constants.CIAppTestOrigin))
+	defer func() {
+		if r := recover(); r != nil {
+			span.SetTag(constants.TestFixtureStatus, constants.TestStatusFail)
+			span.SetTag(ext.Error, true)
+			span.SetTag(ext.ErrorMsg, fmt.Sprint(r))
+			span.SetTag(ext.ErrorStack, getStacktrace(2))
+			span.SetTag(ext.ErrorType, "panic")
+			span.Finish()
+			panic(r)
+		}
+		if err != nil {
+			span.SetTag(constants.TestFixtureStatus, constants.TestStatusFail)
+			span.SetTag(ext.Error, true)
+			span.SetTag(ext.ErrorMsg, err.Error())
+		} else {
+			span.SetTag(constants.TestFixtureStatus, constants.TestStatusPass)
+		}
+		span.Finish()
+		flushIncremental()
+	}()
+	return fn(ctx)
+}
+
+// runWithFixtures runs the setup, the tests and the teardown, and returns the exit code.
+func runWithFixtures(m *testing.M, setup, teardown FixtureFunc) int {
+	code := 0
+	if setup != nil {
+		if err := RecordFixture("setup", setup); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: the setup failed, the tests aren't run: %v\n", err)
+			code = 1
+		}
+	}
+	if code == 0 {
+		code = m.Run()
+	}
+	if teardown != nil {
+		if err := RecordFixture("teardown", teardown); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: the teardown failed: %v\n", err)
+			code = 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestRecordFixture(t *testing.T) {
+	if currentSession() == nil {
+		t.Skip("the session isn't running")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// The session span is started with the mock tracer, so the fixtures are its children.
+	s := &testSession{id: "1", span: tracer.StartSpan(constants.SpanTypeTestSession)}
+	sessionMutex.Lock()
+	running := session
+	session = s
+	sessionMutex.Unlock()
+	defer func() {
+		sessionMutex.Lock()
+		session = running
+		sessionMutex.Unlock()
+	}()
+
+	err := RecordFixture("migrations", func(ctx context.Context) error {
+		span, _ := tracer.StartSpanFromContext(ctx, "migrate")
+		span.Finish()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = RecordFixture("container pool", func(context.Context) error {
+		return errors.New("docker is unavailable")
+	})
+	if err == nil || err.Error() != "docker is unavailable" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 3 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	migrate, migrations, pool := spans[0], spans[1], spans[2]
+	if migrate.ParentID() != migrations.SpanID() || migrations.ParentID() != s.span.Context().SpanID() {
+		t.Error("unexpected fixture hierarchy")
+	}
+	if migrations.Tag(ext.SpanType) != constants.SpanTypeTestFixture ||
+		migrations.Tag(constants.TestFixtureName) != "migrations" ||
+		migrations.Tag(constants.TestFixtureStatus) != constants.TestStatusPass ||
+		migrations.Tag(constants.TestSessionID) != s.id {
+		t.Errorf("unexpected passed fixture span: %v", migrations.Tags())
+	}
+	if pool.Tag(constants.TestFixtureStatus) != constants.TestStatusFail ||
+		pool.Tag(ext.ErrorMsg) != "docker is unavailable" {
+		t.Errorf("unexpected failed fixture span: %v", pool.Tags())
+	}
+}
+
+func TestRunWithFixtures(t *testing.T) {
+	var calls []string
+	setup := func(context.Context) error {
+		calls = append(calls, "setup")
+		return errors.New("migration failed")
+	}
+	teardown := func(context.Context) error {
+		calls = append(calls, "teardown")
+		return nil
+	}
+
+	// The tests aren't run when the setup fails, so m isn't used.
+	if code := runWithFixtures(nil, setup, teardown); code != 1 {
+		t.Errorf("expected the exit code 1, got %d", code)
+	}
+	if len(calls) != 2 || calls[0] != "setup" || calls[1] != "teardown" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// envFlushPeriod is the environment variable with the period of the background flushes.
+	envFlushPeriod = "DD_CIVISIBILITY_FLUSH_PERIOD"
+
+	// defaultFlushPeriod is the default period of the background flushes.
+	defaultFlushPeriod = time.Minute
+)
+
+var (
+	// flusher paces the flushes done by the test binary.
+	flusher      = newFlushController(new(runConfig))
+	flusherMutex sync.Mutex
+)
+
+// flushController coordinates the flushes of the tracer, limiting how many can be
+// done concurrently and how often, with a random jitter to spread the load on the agent.
+type flushController struct {
+	sem      chan struct{}
+	interval time.Duration
+	jitter   time.Duration
+	sync     bool
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	// pending counts the tests finished since the last flush.
+	pending int64
+
+	// flushFunc flushes the tracer, it can be replaced in tests.
+	flushFunc func()
+}
+
+func newFlushController(cfg *runConfig) *flushController {
+	maxConcurrent := cfg.maxConcurrentFlushes
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &flushController{
+		sem:       make(chan struct{}, maxConcurrent),
+		interval:  cfg.flushInterval,
+		jitter:    cfg.flushJitter,
+		sync:      cfg.flushOnTestFinish,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		flushFunc: flushTracer,
+	}
+}
+
+// setFlusher replaces the global flush controller.
+func setFlusher(f *flushController) {
+	flusherMutex.Lock()
+	defer flusherMutex.Unlock()
+	flusher = f
+}
+
+// flush flushes the tracer using the global flush controller.
+func flush(force bool) {
+	flusherMutex.Lock()
+	f := flusher
+	flusherMutex.Unlock()
+	f.flush(force)
+}
+
+// flushIncremental records a finished test, flushing the tracer synchronously when configured
+// to flush on every test. Otherwise, the test is flushed in background by the goroutine of
+// startPeriodicFlush.
+func flushIncremental() {
+	flusherMutex.Lock()
+	f := flusher
+	flusherMutex.Unlock()
+	atomic.AddInt64(&f.pending, 1)
+	if f.sync {
+		f.flush(true)
+	}
+}
+
+// flush flushes the tracer. A forced flush waits for a free flush slot, while a background flush
+// is skipped when all the slots are in use and is delayed by the random jitter.
+func (f *flushController) flush(force bool) bool {
+	if force {
+		f.sem <- struct{}{}
+	} else {
+		select {
+		case f.sem <- struct{}{}:
+		default:
+			// Another flush is in progress, it will send the same data.
+			return false
+		}
+	}
+	defer func() { <-f.sem }()
+
+	if !force && f.jitter > 0 {
+		f.mu.Lock()
+		delay := time.Duration(f.rand.Int63n(int64(f.jitter)))
+		f.mu.Unlock()
+		time.Sleep(delay)
+	}
+	atomic.StoreInt64(&f.pending, 0)
+	f.flushFunc()
+	return true
+}
+
+// flushPeriodByEnv returns the period of the background flushes set by the
+// DD_CIVISIBILITY_FLUSH_PERIOD environment variable, or the default one.
+func flushPeriodByEnv() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(envFlushPeriod)); err == nil {
+		return d
+	}
+	return defaultFlushPeriod
+}
+
+// startPeriodicFlush flushes the tracer in background when tests finished since the last flush,
+// every flush interval when it's set, or every period otherwise, so the results of long sessions
+// are reported while they run and aren't all lost on a crash. The returned function stops the
+// flushes. They're disabled when neither the interval nor the period is positive.
+func (f *flushController) startPeriodicFlush(period time.Duration) func() {
+	if f.interval > 0 && (period <= 0 || f.interval < period) {
+		period = f.interval
+	}
+	if period <= 0 {
+		return func() {}
+	}
+	ticker := time.NewTicker(period)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if atomic.LoadInt64(&f.pending) > 0 {
+					f.flush(false)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
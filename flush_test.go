@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlushController(t *testing.T) {
+	t.Run("interval", func(t *testing.T) {
+		var count int32
+		f := newFlushController(&runConfig{flushInterval: 10 * time.Millisecond, maxConcurrentFlushes: 1})
+		f.flushFunc = func() { atomic.AddInt32(&count, 1) }
+
+		// The interval replaces the period of the background flushes.
+		stop := f.startPeriodicFlush(time.Hour)
+		defer stop()
+		atomic.AddInt64(&f.pending, 1)
+		deadline := time.Now().Add(5 * time.Second)
+		for atomic.LoadInt32(&count) == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if n := atomic.LoadInt32(&count); n != 1 {
+			t.Errorf("expected a background flush of the finished test, got %d", n)
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		f := newFlushController(&runConfig{flushJitter: time.Hour, maxConcurrentFlushes: 1})
+		f.flushFunc = func() {}
+
+		done := make(chan struct{})
+		go func() {
+			f.flush(true)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("the forced flush is delayed by the jitter")
+		}
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		var current, max int32
+		f := newFlushController(&runConfig{maxConcurrentFlushes: 2})
+		f.flushFunc = func() {
+			value := atomic.AddInt32(&current, 1)
+			for {
+				old := atomic.LoadInt32(&max)
+				if value <= old || atomic.CompareAndSwapInt32(&max, old, value) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&current, -1)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f.flush(true)
+			}()
+		}
+		wg.Wait()
+
+		if max > 2 {
+			t.Errorf("too many concurrent flushes: %d", max)
+		}
+	})
+}
+
+func TestPeriodicFlush(t *testing.T) {
+	var count int32
+	f := newFlushController(&runConfig{maxConcurrentFlushes: 1})
+	f.flushFunc = func() { atomic.AddInt32(&count, 1) }
+
+	stop := f.startPeriodicFlush(10 * time.Millisecond)
+	defer stop()
+
+	// Nothing is flushed until a test finishes.
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&count); n != 0 {
+		t.Fatalf("unexpected flushes without finished tests: %d", n)
+	}
+
+	atomic.AddInt64(&f.pending, 1)
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&count) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Fatalf("expected a flush of the finished test, got %d", n)
+	}
+
+	stop()
+	stop()
+	atomic.AddInt64(&f.pending, 1)
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&count); n != 1 {
+		t.Errorf("unexpected flushes after stop: %d", n)
+	}
+
+	if stop := f.startPeriodicFlush(0); stop == nil {
+		t.Error("expected a stop function when the flushes are disabled")
+	}
+}
@@ -7,7 +7,11 @@ require (
 	github.com/google/uuid v1.2.0 // indirect
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/philhofer/fwd v1.1.1 // indirect
+	github.com/sirupsen/logrus v1.8.1
+	github.com/tinylib/msgp v1.1.2
+	go.uber.org/zap v1.16.0
 	golang.org/x/sys v0.0.0-20210124154548-22da62e12c0c
 	golang.org/x/time v0.0.0-20210611083556-38a9dc6acbc6 // indirect
+	google.golang.org/grpc v1.27.0
 	gopkg.in/DataDog/dd-trace-go.v1 v1.31.1
 )
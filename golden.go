@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// maxGoldenDiffSize is the maximum size of the golden diff stored in the span.
+	maxGoldenDiffSize = 16 * 1024
+
+	// goldenDiffContext is the number of context lines of the golden diff.
+	goldenDiffContext = 3
+)
+
+// AssertGolden compares the output of a test with the golden file testdata/<name>.golden.
+// On mismatch, the unified diff is attached to the test span of ctx and the test fails.
+// When the DD_UPDATE_GOLDEN_FILES environment variable is true, the golden file is
+// written with the output instead.
+func AssertGolden(ctx context.Context, tb testing.TB, name string, actual []byte) bool {
+	tb.Helper()
+	path := filepath.Join("testdata", name+".golden")
+
+	if update, _ := strconv.ParseBool(os.Getenv("DD_UPDATE_GOLDEN_FILES")); update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatalf("creating the golden file directory: %v", err)
+		}
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			tb.Fatalf("writing the golden file: %v", err)
+		}
+		return true
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Errorf("reading the golden file: %v", err)
+		return false
+	}
+
+	diff := utils.UnifiedDiff(path, "actual", string(expected), string(actual), goldenDiffContext)
+	if diff == "" {
+		return true
+	}
+
+	if result, ok := testResultFromContext(ctx); ok {
+		result.attach(path+".diff", "text/x-diff", []byte(diff))
+	}
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		span.SetTag(constants.TestGoldenFile, path)
+		span.SetTag(ext.ErrorType, "golden")
+		if len(diff) > maxGoldenDiffSize {
+			span.SetTag(constants.TestGoldenDiff, diff[:maxGoldenDiffSize]+"\n...(truncated)")
+		} else {
+			span.SetTag(constants.TestGoldenDiff, diff)
+		}
+	}
+	tb.Errorf("output doesn't match the golden file %s:\n%s", path, diff)
+	return false
+}
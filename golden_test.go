@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// failureRecorderTB records the failures instead of failing the test.
+type failureRecorderTB struct {
+	testing.TB
+	failed bool
+}
+
+func (tb *failureRecorderTB) Helper()                                {}
+func (tb *failureRecorderTB) Errorf(format string, a ...interface{}) { tb.failed = true }
+
+func TestAssertGolden(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	if !AssertGolden(ctx, t, "sample", []byte("line 1\nline 2\n")) {
+		t.Error("matching output failed")
+	}
+
+	tb := &failureRecorderTB{}
+	if AssertGolden(ctx, tb, "sample", []byte("line 1\nline two\n")) || !tb.failed {
+		t.Error("mismatching output didn't fail")
+	}
+	span.Finish()
+
+	diff, _ := mt.FinishedSpans()[0].Tag(constants.TestGoldenDiff).(string)
+	if !strings.Contains(diff, "-line 2\n+line two\n") {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+}
@@ -0,0 +1,365 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// statusRollup aggregates the statuses of the tests of a session, module or suite: it fails when
+// a test failed, passes when a test passed, and is skipped when all the tests were skipped.
+type statusRollup struct {
+	mu      sync.Mutex
+	counts  map[string]int
+	failed  bool
+	parents []*statusRollup
+}
+
+// record records the status of a test, in the rollup and its parents.
+func (r *statusRollup) record(status string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.counts == nil {
+		r.counts = map[string]int{}
+	}
+	r.counts[status]++
+	parents := r.parents
+	r.mu.Unlock()
+	for _, p := range parents {
+		p.record(status)
+	}
+}
+
+// fail marks the rollup and its parents as failed whatever the statuses of the tests.
+func (r *statusRollup) fail() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.failed = true
+	parents := r.parents
+	r.mu.Unlock()
+	for _, p := range parents {
+		p.fail()
+	}
+}
+
+// status returns the aggregated status, or an empty string when no test was recorded.
+func (r *statusRollup) status() string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case r.failed || r.counts[constants.TestStatusFail] > 0:
+		return constants.TestStatusFail
+	case r.counts[constants.TestStatusPass] > 0:
+		return constants.TestStatusPass
+	case r.counts[constants.TestStatusSkip] > 0:
+		return constants.TestStatusSkip
+	}
+	return ""
+}
+
+// TestModule is a module of the test session, like a Go module or a test binary, whose status
+// is rolled up from its suites. It's started with StartTestModule.
+type TestModule struct {
+	name    string
+	id      string
+	span    ddtrace.Span
+	session *testSession
+	rollup  *statusRollup
+
+	// owned is unset when the module is the session span of a test binary reporting a module
+	// of a parent session, which is finished with the session.
+	owned bool
+
+	mu       sync.Mutex
+	suites   map[string]*TestSuite
+	finished bool
+}
+
+// TestSuite is a suite of a test module, like the tests of a package, whose status is rolled up
+// from its tests. It's started with StartTestSuite or TestModule.StartTestSuite, and the tests
+// join it with the WithSuite option.
+type TestSuite struct {
+	name   string
+	id     string
+	span   ddtrace.Span
+	module *TestModule
+	rollup *statusRollup
+
+	once sync.Once
+}
+
+// StartTestModule starts a module of the running test session, finished with its Finish method.
+// The options can set the start time or tags of the module span. The tests of the session join
+// the module through its suites. It returns nil when the SDK is disabled, the methods of a nil
+// module and of its nil suites do nothing.
+func StartTestModule(name string, opts ...ddtrace.StartSpanOption) *TestModule {
+	if !isEnabled() {
+		return nil
+	}
+	s := currentSession()
+	m := startTestModule(s, name, opts...)
+	if s != nil {
+		s.addModule(m)
+	}
+	return m
+}
+
+// startTestModule starts a module of the session, which may be nil.
+func startTestModule(s *testSession, name string, opts ...ddtrace.StartSpanOption) *TestModule {
+	startOpts := []ddtrace.StartSpanOption{
+		tracer.SpanType(constants.SpanTypeTestModule),
+		tracer.ResourceName(name),
+		tracer.Tag(constants.TestModule, name),
+		tracer.Tag(constants.TestFramework, testFramework),
+		tracer.Tag(constants.Origin, constants.CIAppTestOrigin),
+		tracer.Tag(ext.ManualKeep, true),
+	}
+	m := &TestModule{name: name, session: s, rollup: new(statusRollup), owned: true, suites: map[string]*TestSuite{}}
+	if s != nil {
+		startOpts = append(startOpts, tracer.ChildOf(s.span.Context()), tracer.Tag(constants.TestSessionID, s.id))
+		m.rollup.parents = []*statusRollup{s.rollup}
+	}
+	m.span, _ = startScrubbedSpan(context.Background(), constants.SpanTypeTestModule, append(startOpts, opts...)...)
+	m.id = strconv.FormatUint(m.span.Context().SpanID(), 10)
+	m.span.SetTag(constants.TestModuleID, m.id)
+	return m
+}
+
+// Name returns the name of the module.
+func (m *TestModule) Name() string {
+	if m == nil {
+		return ""
+	}
+	return m.name
+}
+
+// ID returns the ID of the module, reported in the test_module_id tag of its suites and tests.
+func (m *TestModule) ID() string {
+	if m == nil {
+		return ""
+	}
+	return m.id
+}
+
+// SetTag sets a tag of the module span.
+func (m *TestModule) SetTag(key string, value interface{}) {
+	if m == nil {
+		return
+	}
+	m.span.SetTag(key, value)
+}
+
+// Fail marks the module and its session as failed whatever the statuses of the tests, like when
+// the setup of the module failed.
+func (m *TestModule) Fail() {
+	if m == nil {
+		return
+	}
+	m.rollup.fail()
+}
+
+// StartTestSuite starts a suite of the module, or returns the running suite with the same name.
+// The suites still running when the module finishes are finished with it.
+func (m *TestModule) StartTestSuite(name string, opts ...ddtrace.StartSpanOption) *TestSuite {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if suite, ok := m.suites[name]; ok {
+		return suite
+	}
+
+	startOpts := []ddtrace.StartSpanOption{
+		tracer.ChildOf(m.span.Context()),
+		tracer.SpanType(constants.SpanTypeTestSuite),
+		tracer.ResourceName(name),
+		tracer.Tag(constants.TestSuite, name),
+		tracer.Tag(constants.TestModule, m.name),
+		tracer.Tag(constants.TestModuleID, m.id),
+		tracer.Tag(constants.TestFramework, testFramework),
+		tracer.Tag(constants.Origin, constants.CIAppTestOrigin),
+		tracer.Tag(ext.ManualKeep, true),
+	}
+	if m.session != nil {
+		startOpts = append(startOpts, tracer.Tag(constants.TestSessionID, m.session.id))
+	}
+	suite := &TestSuite{name: name, module: m, rollup: &statusRollup{parents: []*statusRollup{m.rollup}}}
+	suite.span, _ = startScrubbedSpan(context.Background(), constants.SpanTypeTestSuite, append(startOpts, opts...)...)
+	suite.id = strconv.FormatUint(suite.span.Context().SpanID(), 10)
+	suite.span.SetTag(constants.TestSuiteID, suite.id)
+	m.suites[name] = suite
+	return suite
+}
+
+// Finish finishes the running suites of the module and the module span, tagged with the status
+// rolled up from the suites. The options can set the finish time of the module span.
+func (m *TestModule) Finish(opts ...ddtrace.FinishOption) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	if m.finished {
+		m.mu.Unlock()
+		return
+	}
+	m.finished = true
+	suites := make([]*TestSuite, 0, len(m.suites))
+	for _, suite := range m.suites {
+		suites = append(suites, suite)
+	}
+	m.mu.Unlock()
+
+	for _, suite := range suites {
+		suite.Finish(opts...)
+	}
+	if status := m.rollup.status(); status != "" {
+		m.span.SetTag(constants.TestStatus, status)
+	}
+	if m.owned {
+		m.span.Finish(opts...)
+	}
+}
+
+// StartTestSuite starts a suite in the module of the running test session, or returns the running
+// suite with the same name. The tests started with the WithSuite option join it, and the tests
+// started without join the suite of their package automatically. It returns nil without session.
+func StartTestSuite(name string, opts ...ddtrace.StartSpanOption) *TestSuite {
+	s := currentSession()
+	if s == nil {
+		return nil
+	}
+	return s.defaultModule().StartTestSuite(name, opts...)
+}
+
+// Name returns the name of the suite.
+func (s *TestSuite) Name() string {
+	if s == nil {
+		return ""
+	}
+	return s.name
+}
+
+// ID returns the ID of the suite, reported in the test_suite_id tag of its tests.
+func (s *TestSuite) ID() string {
+	if s == nil {
+		return ""
+	}
+	return s.id
+}
+
+// Module returns the module of the suite.
+func (s *TestSuite) Module() *TestModule {
+	if s == nil {
+		return nil
+	}
+	return s.module
+}
+
+// SetTag sets a tag of the suite span.
+func (s *TestSuite) SetTag(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.span.SetTag(key, value)
+}
+
+// Fail marks the suite, its module and its session as failed whatever the statuses of the tests,
+// like when the setup of the suite failed.
+func (s *TestSuite) Fail() {
+	if s == nil {
+		return
+	}
+	s.rollup.fail()
+}
+
+// Finish finishes the suite span, tagged with the status rolled up from its tests. It runs once.
+// The options can set the finish time of the suite span.
+func (s *TestSuite) Finish(opts ...ddtrace.FinishOption) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		if status := s.rollup.status(); status != "" {
+			s.span.SetTag(constants.TestStatus, status)
+		}
+		s.span.Finish(opts...)
+	})
+}
+
+// testHierarchyTags returns the span options joining a test to its suite, module and session.
+func testHierarchyTags(suite *TestSuite) []ddtrace.StartSpanOption {
+	return []ddtrace.StartSpanOption{
+		tracer.Tag(constants.TestSuiteID, suite.id),
+		tracer.Tag(constants.TestModuleID, suite.module.id),
+		tracer.Tag(constants.TestModule, suite.module.name),
+	}
+}
+
+// childOfSuite returns the span option making a test a child of the suite span. The context of
+// the suite is propagated rather than shared, so the tracer flushes the test span when the test
+// finishes instead of keeping it until the suite finishes.
+func childOfSuite(suite *TestSuite) ddtrace.StartSpanOption {
+	parent, err := extractSpanContext(tracer.TextMapCarrier{
+		tracer.DefaultTraceIDHeader:  strconv.FormatUint(suite.span.Context().TraceID(), 10),
+		tracer.DefaultParentIDHeader: suite.id,
+	})
+	if err != nil {
+		return tracer.ChildOf(suite.span.Context())
+	}
+	return tracer.ChildOf(parent)
+}
+
+// defaultModule returns the module of the tests of the session: its span when the test binary
+// reports a module of a parent session, or a module started on the first call otherwise.
+func (s *testSession) defaultModule() *TestModule {
+	s.modulesMutex.Lock()
+	defer s.modulesMutex.Unlock()
+	if s.module == nil {
+		s.module = startTestModule(s, moduleName(s.cfg))
+		s.modules = append(s.modules, s.module)
+	}
+	return s.module
+}
+
+// addModule records a module started in the session, finished with the session.
+func (s *testSession) addModule(m *TestModule) {
+	s.modulesMutex.Lock()
+	defer s.modulesMutex.Unlock()
+	s.modules = append(s.modules, m)
+}
+
+// finishModules finishes the modules still running and tags the session span with the status
+// rolled up from the tests.
+func (s *testSession) finishModules() {
+	s.modulesMutex.Lock()
+	modules := append([]*TestModule(nil), s.modules...)
+	if s.module != nil && !s.module.owned {
+		modules = append(modules, s.module)
+	}
+	s.modulesMutex.Unlock()
+
+	for _, m := range modules {
+		m.Finish()
+	}
+	if status := s.rollup.status(); status != "" {
+		s.span.SetTag(constants.TestStatus, status)
+	}
+}
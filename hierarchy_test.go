@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestStatusRollup(t *testing.T) {
+	for _, tc := range []struct {
+		statuses []string
+		failed   bool
+		want     string
+	}{
+		{want: ""},
+		{statuses: []string{constants.TestStatusSkip}, want: constants.TestStatusSkip},
+		{statuses: []string{constants.TestStatusSkip, constants.TestStatusPass}, want: constants.TestStatusPass},
+		{statuses: []string{constants.TestStatusPass, constants.TestStatusFail}, want: constants.TestStatusFail},
+		{failed: true, want: constants.TestStatusFail},
+	} {
+		parent := new(statusRollup)
+		r := &statusRollup{parents: []*statusRollup{parent}}
+		for _, status := range tc.statuses {
+			r.record(status)
+		}
+		if tc.failed {
+			r.fail()
+		}
+		if got := r.status(); got != tc.want {
+			t.Errorf("%v: expected status %q, got %q", tc.statuses, tc.want, got)
+		}
+		if got := parent.status(); got != tc.want {
+			t.Errorf("%v: expected parent status %q, got %q", tc.statuses, tc.want, got)
+		}
+	}
+
+	// The roll-up of the sessions built without one is ignored.
+	var r *statusRollup
+	r.record(constants.TestStatusFail)
+	r.fail()
+	if r.status() != "" {
+		t.Errorf("unexpected status of a nil roll-up: %s", r.status())
+	}
+}
+
+func TestHierarchy(t *testing.T) {
+	s := currentSession()
+	if s == nil {
+		t.Skip("the session isn't running")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	m := StartTestModule("github.com/DataDog/example")
+	passing := m.StartTestSuite("passing")
+	skipped := m.StartTestSuite("skipped")
+	if m.StartTestSuite("passing") != passing {
+		t.Error("expected the running suite with the same name")
+	}
+	t.Run("pass", func(t *testing.T) {
+		_, finish := StartTestWithContext(context.Background(), t, WithSuite(passing))
+		finish()
+	})
+	t.Run("skip", func(t *testing.T) {
+		_, finish := StartTestWithContext(context.Background(), t, WithSuite(skipped))
+		defer finish()
+		t.Skip("skipped")
+	})
+	m.Finish()
+
+	spans := map[string]mocktracer.Span{}
+	for _, span := range mt.FinishedSpans() {
+		if name, ok := span.Tag(constants.TestName).(string); ok {
+			spans[name] = span
+		} else if span.Tag(ext.ResourceName) != nil {
+			spans[span.OperationName()+" "+span.Tag(ext.ResourceName).(string)] = span
+		}
+	}
+	module := spans[constants.SpanTypeTestModule+" github.com/DataDog/example"]
+	if module == nil {
+		t.Fatalf("module span not finished: %v", spans)
+	}
+	if module.Tag(constants.TestSessionID) != s.id || module.Tag(constants.TestStatus) != constants.TestStatusPass {
+		t.Errorf("unexpected module span: %v", module.Tags())
+	}
+	for name, suite := range map[string]*TestSuite{"pass": passing, "skip": skipped} {
+		suiteSpan := spans[constants.SpanTypeTestSuite+" "+suite.Name()]
+		if suiteSpan == nil {
+			t.Fatalf("suite %s not finished", suite.Name())
+		}
+		if suiteSpan.ParentID() != module.SpanID() || suiteSpan.Tag(constants.TestModuleID) != m.ID() ||
+			suiteSpan.Tag(constants.TestStatus) != name {
+			t.Errorf("unexpected span of the suite %s: %v", suite.Name(), suiteSpan.Tags())
+		}
+		test := spans[t.Name()+"/"+name]
+		if test == nil {
+			t.Fatalf("test %s not finished", name)
+		}
+		// The tests are children of their suite.
+		if test.ParentID() != suiteSpan.SpanID() || test.TraceID() != suiteSpan.TraceID() ||
+			test.Tag(constants.TestSuiteID) != suite.ID() || test.Tag(constants.TestModuleID) != m.ID() ||
+			test.Tag(constants.TestModule) != m.Name() {
+			t.Errorf("unexpected span of the test %s: %v", name, test.Tags())
+		}
+	}
+}
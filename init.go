@@ -9,13 +9,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"os"
-	"os/signal"
 	"regexp"
 	"runtime"
 	"strings"
-	"syscall"
 	"testing"
+	"time"
 
 	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
 	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
@@ -35,39 +33,44 @@ type FinishFunc func()
 
 // Run is a helper function to run a `testing.M` object and gracefully stopping the tracer afterwards
 func Run(m *testing.M, opts ...tracer.StartOption) int {
-	// Preload all CI and Git tags.
-	ensureCITags()
-
-	// Check if DD_SERVICE has been set; otherwise we default to repo name.
-	if v := os.Getenv("DD_SERVICE"); v == "" {
-		if repoUrl, ok := getFromCITags(constants.GitRepositoryURL); ok {
-			matches := repoRegex.FindStringSubmatch(repoUrl)
-			if len(matches) > 1 {
-				repoUrl = strings.TrimSuffix(matches[1], ".git")
-			}
-			opts = append(opts, tracer.WithService(repoUrl))
-		}
-	}
+	pc, _, _, _ := runtime.Caller(1)
+	return run(m, pc, nil, nil, WithTracerOptions(opts...))
+}
 
-	// Initialize tracer
-	tracer.Start(opts...)
-	exitFunc := func() {
-		tracer.Flush()
-		tracer.Stop()
+// RunWithOptions runs a `testing.M` object like Run, using the given options to configure the SDK.
+func RunWithOptions(m *testing.M, runOpts ...RunOption) int {
+	pc, _, _, _ := runtime.Caller(1)
+	return run(m, pc, nil, nil, runOpts...)
+}
+
+// run runs the tests between the setup and the teardown, pc is the program counter of the
+// TestMain function.
+func run(m *testing.M, pc uintptr, setup, teardown FixtureFunc, runOpts ...RunOption) int {
+	cfg := newRunConfig(runOpts...)
+	setEnabled(cfg.enabled)
+	if !cfg.enabled {
+		return runWithFixtures(m, setup, teardown)
 	}
-	defer exitFunc()
+	suite, _ := utils.GetPackageAndName(pc)
+	cfg.module = suite
 
-	// Handle SIGINT and SIGTERM
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-signals
-		exitFunc()
-		os.Exit(1)
-	}()
+	s := startSession(cfg)
+	defer s.stop()
+	fixShuffleSeed()
+	if seed, ok := shuffleSeed(); ok {
+		s.span.SetTag(constants.TestShuffleSeed, seed)
+	}
 
 	// Execute test suite
-	return m.Run()
+	code := runWithFixtures(m, setup, teardown)
+
+	if code == 0 && cfg.leakCheck {
+		if err := findLeakedGoroutines(cfg); err != nil {
+			reportLeakedGoroutines(s, suite, err)
+			code = 1
+		}
+	}
+	return code
 }
 
 // StartTest returns a new span with the given testing.TB interface and options. It uses
@@ -80,65 +83,249 @@ func StartTest(tb testing.TB, opts ...Option) (context.Context, FinishFunc) {
 // StartTestWithContext returns a new span with the given testing.TB interface and options. It uses
 // tracer.StartSpanFromContext function to start the span with automatically detected information.
 func StartTestWithContext(ctx context.Context, tb testing.TB, opts ...Option) (context.Context, FinishFunc) {
-	cfg := new(config)
-	defaults(cfg)
+	if !isEnabled() {
+		return ctx, func() {}
+	}
+	defer addOverhead(&overhead.startTest, time.Now())
+	s := currentSession()
+	cfg := acquireConfig()
+	if s != nil {
+		for _, fn := range s.cfg.testOpts {
+			fn(cfg)
+		}
+	}
 	for _, fn := range opts {
 		fn(cfg)
 	}
 
 	pc, _, _, _ := runtime.Caller(cfg.skip)
 	suite, _ := utils.GetPackageAndName(pc)
+	if s != nil && s.cfg.suiteTrimPrefix != "" {
+		suite = strings.TrimPrefix(suite, s.cfg.suiteTrimPrefix)
+	}
+	if cfg.suite != "" {
+		suite = cfg.suite
+	}
+	file, line := utils.GetSourceLocation(pc)
+	if cfg.sourceFile != "" {
+		file, line = cfg.sourceFile, cfg.sourceLine
+	}
 	name := tb.Name()
 	fqn := fmt.Sprintf("%s.%s", suite, name)
 
-	testOpts := []tracer.StartSpanOption{
+	// The start time of the options, like the ones of the reported results, overrides the clock.
+	testOpts := append(cfg.startOpts[:0],
+		tracer.StartTime(now()),
 		tracer.ResourceName(fqn),
 		tracer.Tag(constants.TestName, name),
 		tracer.Tag(constants.TestSuite, suite),
-		tracer.Tag(constants.TestFramework, testFramework),
+		tracer.Tag(constants.TestFramework, cfg.framework),
+		tracer.Tag(constants.TestSourceFile, file),
 		tracer.Tag(constants.Origin, constants.CIAppTestOrigin),
+	)
+	if line > 0 {
+		testOpts = append(testOpts, tracer.Tag(constants.TestSourceStartLine, line))
+	}
+	if cfg.frameworkVersion != "" {
+		testOpts = append(testOpts, tracer.Tag(constants.TestFrameworkVersion, cfg.frameworkVersion))
+	}
+	testSuite := cfg.testSuite
+	if testSuite == nil && s != nil {
+		testSuite = s.defaultModule().StartTestSuite(suite)
+	}
+	if testSuite != nil {
+		testOpts = append(testOpts, testHierarchyTags(testSuite)...)
+		if _, ok := tracer.SpanFromContext(ctx); !ok {
+			// The tests without parent span are children of their suite, the subtests of their test.
+			testOpts = append(testOpts, childOfSuite(testSuite))
+		}
+	}
+	if s != nil {
+		testOpts = append(testOpts, tracer.Tag(constants.TestSessionID, s.id))
+		if service := serviceForSuite(s.cfg.serviceMappings, suite); service != "" {
+			testOpts = append(testOpts, tracer.ServiceName(service))
+		}
+		if isQuarantined(s.cfg, suite, name) {
+			testOpts = append(testOpts, tracer.Tag(constants.TestQuarantined, true))
+		}
+		s.startWatchdog(tb)
 	}
 
-	switch tb.(type) {
+	budget, budgetEnforced := cfg.maxDuration, cfg.maxDurationEnforced
+	if budget == 0 && s != nil {
+		budget, budgetEnforced = budgetForTest(s.cfg.durationBudgets, fqn), s.cfg.durationBudgetsEnforced
+	}
+
+	var benchMem *benchmarkMemStats
+	switch b := tb.(type) {
 	case *testing.T:
 		testOpts = append(testOpts, tracer.Tag(constants.TestType, constants.TestTypeTest))
 	case *testing.B:
 		testOpts = append(testOpts, tracer.Tag(constants.TestType, constants.TestTypeBenchmark))
+		benchMem = startBenchmarkMemStats(b)
 	}
 
-	cfg.spanOpts = append(testOpts, cfg.spanOpts...)
-	span, ctx := tracer.StartSpanFromContext(ctx, constants.SpanTypeTest, cfg.spanOpts...)
+	cfg.startOpts = append(testOpts, cfg.spanOpts...)
+	optTags := spanOptionTags(cfg.startOpts)
+	span, ctx := startScrubbedSpanWithTags(ctx, constants.SpanTypeTest, optTags, cfg.startOpts...)
+	if cfg.ambient {
+		pushAmbientSpan(span)
+	}
+	if benchMem == nil {
+		// The rounds of a benchmark run the same function, they aren't retries.
+		attempt, firstSpanID := linkTestAttempt(span, fqn)
+		setRepeatTags(span, attempt, firstSpanID)
+	}
+	setShuffleTags(span)
+	if s != nil && s.cfg.outputSpanIDs {
+		logSpanIDs(tb, span)
+	}
+	var stats *runtimeStats
+	var profile *cpuProfile
+	var execTrace *executionTrace
+	var files *filesSnapshot
+	var restoreLabels func()
+	if s != nil {
+		if s.cfg.runtimeMetrics {
+			stats = readRuntimeStats()
+		}
+		if s.cfg.fileLeakCheck {
+			files = takeFilesSnapshot()
+		}
+		if s.cfg.profilerStart != nil {
+			restoreLabels = setCodeHotspotsLabels(ctx, span, fqn)
+		}
+		profile = startCPUProfile(s.cfg, suite, name)
+		execTrace = startExecutionTrace(s.cfg, suite, name, cfg.flaky)
+	}
+	result := &testResult{
+		name:      name,
+		suite:     suite,
+		framework: cfg.framework,
+		file:      file,
+		line:      line,
+		start:     now(),
+		testSuite: testSuite,
+	}
+	if benchMem != nil && cfg.iterations {
+		result.iterations = new(iterationHistogram)
+	}
+	cancelTimeout := func() {}
+	if cfg.timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, cfg.timeout)
+	}
+	ctx = context.WithValue(ctx, testResultContextKey{}, result)
+	// teardown stops what was started with the test, by its finish function or by the watchdog
+	// finishing it when it times out.
+	teardown := func() {
+		if cfg.ambient {
+			removeAmbientSpan(span)
+		}
+		if profile != nil {
+			profile.stop(span)
+		}
+		if execTrace != nil {
+			execTrace.stop(span)
+		}
+		cancelTimeout()
+	}
+	pushActiveTest(span, tb, result, teardown, optTags)
 
 	return ctx, func() {
+		// The profiler labels are the ones of the goroutine of the test, they're restored here
+		// even when the watchdog finished the test.
+		if restoreLabels != nil {
+			restoreLabels()
+		}
+		if !result.claim() {
+			// The test was already finished by the timeout watchdog.
+			if r := recover(); r != nil {
+				panic(r)
+			}
+			return
+		}
+		finishStart := time.Now()
 		var r interface{} = nil
 
 		if r = recover(); r != nil {
 			// Panic handling
-			span.SetTag(constants.TestStatus, constants.TestStatusFail)
+			result.status = constants.TestStatusFail
+			result.errorMsg = fmt.Sprint(r)
+			result.errorStack = getStacktrace(2)
+			result.errorType = "panic"
+			span.SetTag(constants.TestStatus, result.status)
 			span.SetTag(ext.Error, true)
-			span.SetTag(ext.ErrorMsg, fmt.Sprint(r))
-			span.SetTag(ext.ErrorStack, getStacktrace(2))
-			span.SetTag(ext.ErrorType, "panic")
+			span.SetTag(ext.ErrorMsg, result.errorMsg)
+			span.SetTag(ext.ErrorStack, result.errorStack)
+			span.SetTag(ext.ErrorType, result.errorType)
 		} else {
 			// Normal finalization
+			checkDurationBudget(tb, span, budget, budgetEnforced, since(result.start))
 			span.SetTag(ext.Error, tb.Failed())
 
 			if tb.Failed() {
-				span.SetTag(constants.TestStatus, constants.TestStatusFail)
+				result.status = constants.TestStatusFail
+				if fm, ok := tb.(failureMessager); ok {
+					if msg := fm.FailureMessage(); msg != "" {
+						result.errorMsg = msg
+						span.SetTag(ext.ErrorMsg, msg)
+					}
+				}
+				setDeadlineExceeded(span, result, ctx, tb, now())
 			} else if tb.Skipped() {
-				span.SetTag(constants.TestStatus, constants.TestStatusSkip)
+				result.status = constants.TestStatusSkip
+				if sr, ok := tb.(skipReasoner); ok {
+					if reason := sr.SkipReason(); reason != "" {
+						span.SetTag(constants.TestSkipReason, reason)
+					}
+				}
 			} else {
-				span.SetTag(constants.TestStatus, constants.TestStatusPass)
+				result.status = constants.TestStatusPass
 			}
+			span.SetTag(constants.TestStatus, result.status)
 		}
 
+		teardown()
+		removeActiveTest(span)
+		setNetworkMetrics(span, result)
+		result.setAssertionTags(span)
+		if benchMem != nil {
+			benchMem.setTags(span)
+			if result.iterations != nil {
+				result.iterations.setTags(span)
+			}
+		}
+		if stats != nil {
+			setRuntimeMetrics(span, stats, readRuntimeStats())
+		}
+		if files != nil {
+			checkFileLeaks(span, files, name, s.cfg.minLeakedTemp)
+		}
+		if s != nil {
+			captureHeapProfile(s.cfg, span, fqn, result.status)
+			s.baseline.check(span, fqn, result.status, since(result.start))
+			s.owners.setTags(span, file)
+			s.changes.setTags(span, file, line)
+		}
+		setTestCITags(span, optTags)
+		releaseSpanOptionTags(optTags)
+		span.SetTag(constants.TestCorrelationID, correlationID(suite, name))
 		span.Finish(cfg.finishOpts...)
+		releaseConfig(cfg)
+		result.finish = now()
+		writeTestResult(result)
+		addOverhead(&overhead.finishTest, finishStart)
+		if adapter, ok := tb.(*TBAdapter); ok {
+			// The harness of the adapter doesn't run the cleanup functions of its tests.
+			adapter.runCleanups()
+		}
 
 		if r != nil {
-			tracer.Flush()
-			tracer.Stop()
+			flush(true)
+			stopTracer()
 			panic(r)
 		}
+		flushIncremental()
 	}
 }
 
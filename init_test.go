@@ -134,3 +134,37 @@ func assertNotEmpty(actual string) {
 		panic("Value is empty")
 	}
 }
+
+func TestSessionTestOptions(t *testing.T) {
+	s := currentSession()
+	if s == nil {
+		t.Skip("no running session")
+	}
+	defer func(opts []Option, prefix string) {
+		s.cfg.testOpts, s.cfg.suiteTrimPrefix = opts, prefix
+	}(s.cfg.testOpts, s.cfg.suiteTrimPrefix)
+	s.cfg.testOpts = []Option{
+		WithTestFramework("example.com/framework"),
+		WithTestFrameworkVersion("1.4.0"),
+		WithSpanOptions(tracer.Tag("team", "sdk")),
+	}
+	s.cfg.suiteTrimPrefix = "github.com/DataDog/"
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, finish := StartTest(t, WithSpanOptions(tracer.Tag("team", "override")))
+	finish()
+
+	span := mt.FinishedSpans()[0]
+	for key, expected := range map[string]string{
+		constants.TestFramework:        "example.com/framework",
+		constants.TestFrameworkVersion: "1.4.0",
+		constants.TestSuite:            "dd-sdk-go-testing",
+		"team":                         "override",
+	} {
+		if actual := span.Tag(key); actual != expected {
+			t.Errorf("%s: expected %q, got %v", key, expected, actual)
+		}
+	}
+}
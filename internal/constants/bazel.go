@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// BazelTarget indicates the label of the Bazel test target.
+	BazelTarget = "bazel.target"
+
+	// BazelShardIndex indicates the index of the Bazel test shard.
+	BazelShardIndex = "bazel.shard.index"
+
+	// BazelShardTotal indicates the total number of Bazel test shards.
+	BazelShardTotal = "bazel.shard.total"
+)
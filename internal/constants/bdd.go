@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// BDDScenarioTags indicates the tags of a BDD scenario.
+	BDDScenarioTags = "test.bdd.scenario.tags"
+
+	// BDDStepsCount indicates the number of steps of a BDD scenario.
+	BDDStepsCount = "test.bdd.steps.count"
+
+	// BDDFailedStep indicates the text of the failed step of a BDD scenario.
+	BDDFailedStep = "test.bdd.failed_step"
+)
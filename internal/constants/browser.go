@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// TestIsBrowser indicates the test drives a browser.
+	TestIsBrowser = "test.is_browser"
+
+	// TestBrowserName indicates the name of the browser driven by the test.
+	TestBrowserName = "test.browser.name"
+
+	// TestBrowserVersion indicates the version of the browser driven by the test.
+	TestBrowserVersion = "test.browser.version"
+
+	// TestBrowserDriver indicates the driver used to control the browser, e.g. chromedp or selenium.
+	TestBrowserDriver = "test.browser.driver"
+
+	// TestBrowserDriverVersion indicates the version of the driver used to control the browser.
+	TestBrowserDriverVersion = "test.browser.driver_version"
+)
@@ -12,6 +12,9 @@ const (
 	// CIJobURL indicates job URL.
 	CIJobURL = "ci.job.url"
 
+	// CIMatrix indicates the variant of the matrix job, as a JSON object like {"go":"1.22","os":"windows"}.
+	CIMatrix = "ci.matrix"
+
 	// CIPipelineID indicates pipeline ID.
 	CIPipelineID = "ci.pipeline.id"
 
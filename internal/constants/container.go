@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// ContainerID indicates the ID of the container running the tests.
+	ContainerID = "container.id"
+
+	// ContainerImage indicates the image of the container running the tests.
+	ContainerImage = "container.image"
+
+	// KubernetesPodName indicates the name of the Kubernetes pod running the tests.
+	KubernetesPodName = "kubernetes.pod.name"
+
+	// KubernetesNamespace indicates the namespace of the Kubernetes pod running the tests.
+	KubernetesNamespace = "kubernetes.namespace"
+
+	// KubernetesNodeName indicates the name of the Kubernetes node running the tests.
+	KubernetesNodeName = "kubernetes.node.name"
+)
@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// DockerImage indicates the image of a container used by a test.
+	DockerImage = "docker.image"
+
+	// DockerContainerID indicates the ID of a container used by a test.
+	DockerContainerID = "docker.container.id"
+
+	// DockerExitCode indicates the exit code of a container or docker command used by a test.
+	DockerExitCode = "docker.exit_code"
+
+	// DockerCommand indicates the docker command executed by a test.
+	DockerCommand = "docker.command"
+)
@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// EnvGOMAXPROCS indicates the GOMAXPROCS of the test binary.
+	EnvGOMAXPROCS = "env.gomaxprocs"
+
+	// EnvNumCPU indicates the number of logical CPUs usable by the test binary.
+	EnvNumCPU = "env.num_cpu"
+
+	// EnvGOGC indicates the GOGC environment variable.
+	EnvGOGC = "env.gogc"
+
+	// EnvGOMEMLIMIT indicates the GOMEMLIMIT environment variable.
+	EnvGOMEMLIMIT = "env.gomemlimit"
+
+	// EnvTimezone indicates the local time zone of the test binary.
+	EnvTimezone = "env.tz"
+
+	// EnvFileDescriptorLimit indicates the soft limit of open file descriptors.
+	EnvFileDescriptorLimit = "env.ulimit.nofile"
+
+	// EnvVariablePrefix prefixes the tags of the allowlisted environment variables.
+	EnvVariablePrefix = "env.var."
+)
@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// ExecCommand indicates the command line of an external command run by a test, with the
+	// sensitive arguments scrubbed.
+	ExecCommand = "exec.command"
+
+	// ExecExitCode indicates the exit code of an external command run by a test.
+	ExecExitCode = "exec.exit_code"
+
+	// ExecStderr indicates the end of the standard error of an external command run by a test.
+	ExecStderr = "exec.stderr"
+)
@@ -0,0 +1,27 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// HostCPUModel indicates the model of the CPU of the host running the tests.
+	HostCPUModel = "host.cpu.model"
+
+	// HostCPUCores indicates the number of physical CPU cores of the host.
+	HostCPUCores = "host.cpu.cores"
+
+	// HostCPULogicalCores indicates the number of logical CPUs of the host, which may be more than
+	// the CPUs usable by the test binary reported in env.num_cpu.
+	HostCPULogicalCores = "host.cpu.logical_cores"
+
+	// HostMemoryTotal indicates the total memory of the host, in bytes.
+	HostMemoryTotal = "host.memory.total"
+
+	// HostVirtualized indicates whether the host is a virtual machine.
+	HostVirtualized = "host.virtualized"
+
+	// HostHypervisor indicates the hypervisor of a virtual host, when known.
+	HostHypervisor = "host.hypervisor"
+)
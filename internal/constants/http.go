@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// HTTPDNSTime indicates the time in milliseconds spent resolving the host of a request.
+	HTTPDNSTime = "http.timing.dns_ms"
+
+	// HTTPConnectTime indicates the time in milliseconds spent connecting to the host of a request.
+	HTTPConnectTime = "http.timing.connect_ms"
+
+	// HTTPTLSTime indicates the time in milliseconds spent in the TLS handshake of a request.
+	HTTPTLSTime = "http.timing.tls_ms"
+
+	// HTTPFirstByteTime indicates the time in milliseconds until the first byte of the response.
+	HTTPFirstByteTime = "http.timing.first_byte_ms"
+
+	// HTTPConnectionReused indicates whether the request reused an existing connection.
+	HTTPConnectionReused = "http.connection.reused"
+)
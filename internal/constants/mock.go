@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// MockFailures indicates the list of mock failures of a test, in JSON.
+	MockFailures = "test.mock.failures"
+
+	// MockFailuresCount indicates the number of mock failures of a test.
+	MockFailuresCount = "test.mock.failures_count"
+
+	// MockType indicates the type of the mock of the first mock failure.
+	MockType = "test.mock.type"
+
+	// MockMethod indicates the method of the first mock failure.
+	MockMethod = "test.mock.method"
+
+	// MockExpected indicates the expected arguments of the first mock failure.
+	MockExpected = "test.mock.expected"
+
+	// MockActual indicates the actual arguments of the first mock failure.
+	MockActual = "test.mock.actual"
+)
@@ -16,4 +16,16 @@ const (
 
 	// OSArchitecture indicates git repository URL related to the build.
 	OSArchitecture = "os.architecture"
+
+	// OSDistributionName indicates the Linux distribution, or the macOS or Windows product.
+	OSDistributionName = "os.distribution.name"
+
+	// OSDistributionVersion indicates the version of the distribution or product.
+	OSDistributionVersion = "os.distribution.version"
+
+	// OSKernelVersion indicates the version of the kernel of the operating system.
+	OSKernelVersion = "os.kernel.version"
+
+	// OSBuild indicates the build number of the operating system, on macOS and Windows.
+	OSBuild = "os.build"
 )
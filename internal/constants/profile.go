@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// TestProfileCPU indicates the path of the CPU profile captured during the test.
+	TestProfileCPU = "test.profile.cpu"
+
+	// TestProfileHeap indicates the path of the heap profile captured when the test finished.
+	TestProfileHeap = "test.profile.heap"
+
+	// TestExecutionTrace indicates the path of the runtime execution trace captured during the test.
+	TestExecutionTrace = "test.execution_trace"
+)
@@ -6,9 +6,54 @@
 package constants
 
 const (
-	// RuntimeName indicates name of the runtime Compiler.
+	// RuntimeName indicates the name of the runtime, "go".
 	RuntimeName = "runtime.name"
 
-	// RuntimeVersion indicates the Go tree's version string
+	// RuntimeVersion indicates the version of Go, without the "go" prefix.
 	RuntimeVersion = "runtime.version"
+
+	// RuntimeCompiler indicates the Go compiler that built the test binary, like "gc" or "gccgo".
+	RuntimeCompiler = "runtime.compiler"
+
+	// TestMemoryAllocatedBytes indicates the bytes allocated in the heap during the test.
+	TestMemoryAllocatedBytes = "test.memory.allocated_bytes"
+
+	// TestMemoryAllocations indicates the heap objects allocated during the test.
+	TestMemoryAllocations = "test.memory.allocations"
+
+	// TestMemoryHeapInUseBytes indicates the bytes in use in the heap when the test finishes.
+	TestMemoryHeapInUseBytes = "test.memory.heap_in_use_bytes"
+
+	// TestGCCount indicates the garbage collections completed during the test.
+	TestGCCount = "test.gc.count"
+
+	// TestGCPauseTotal indicates the time in milliseconds the garbage collector paused the program during the test.
+	TestGCPauseTotal = "test.gc.pause_total_ms"
+
+	// TestGoroutinesDelta indicates the difference between the goroutines running when the test finishes and starts.
+	TestGoroutinesDelta = "test.goroutines.delta"
+
+	// TestNetworkConnections indicates the network connections opened during the test.
+	TestNetworkConnections = "test.network.connections"
+
+	// TestNetworkBytesSent indicates the bytes sent through the connections opened during the test.
+	TestNetworkBytesSent = "test.network.bytes_sent"
+
+	// TestNetworkBytesReceived indicates the bytes received through the connections opened during the test.
+	TestNetworkBytesReceived = "test.network.bytes_received"
+
+	// TestNetworkHosts indicates the number of distinct hosts contacted during the test.
+	TestNetworkHosts = "test.network.hosts"
+
+	// TestNetworkHostList indicates the distinct hosts contacted during the test.
+	TestNetworkHostList = "test.network.host_list"
+
+	// TestLeakedFileDescriptors indicates the file descriptors opened during the test and still open when it finishes.
+	TestLeakedFileDescriptors = "test.leaks.file_descriptors"
+
+	// TestLeakedTempBytes indicates the size of the files left in the temp directory by the test.
+	TestLeakedTempBytes = "test.leaks.temp_bytes"
+
+	// TestLeakedTempFiles indicates the files and directories left in the temp directory by the test.
+	TestLeakedTempFiles = "test.leaks.temp_files"
 )
@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// SDKOverhead indicates the total time in milliseconds spent by the SDK.
+	SDKOverhead = "dd.sdk.overhead_ms"
+
+	// SDKOverheadStartTest indicates the time in milliseconds spent starting tests.
+	SDKOverheadStartTest = "dd.sdk.overhead.start_test_ms"
+
+	// SDKOverheadFinishTest indicates the time in milliseconds spent finishing tests.
+	SDKOverheadFinishTest = "dd.sdk.overhead.finish_test_ms"
+
+	// SDKOverheadGit indicates the time in milliseconds spent collecting the git metadata.
+	SDKOverheadGit = "dd.sdk.overhead.git_ms"
+
+	// SDKOverheadFlush indicates the time in milliseconds spent flushing the tracer.
+	SDKOverheadFlush = "dd.sdk.overhead.flush_ms"
+)
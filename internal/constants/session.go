@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package constants
+
+const (
+	// TestSessionPassed indicates the number of passed tests of the session.
+	TestSessionPassed = "test_session.tests.pass"
+
+	// TestSessionFailed indicates the number of failed tests of the session.
+	TestSessionFailed = "test_session.tests.fail"
+
+	// TestSessionSkipped indicates the number of skipped tests of the session.
+	TestSessionSkipped = "test_session.tests.skip"
+
+	// TestSessionSlowestTests indicates the slowest tests of the session with their durations.
+	TestSessionSlowestTests = "test_session.slowest_tests"
+
+	// TestSessionSetupTime indicates the time in milliseconds between the start of the session and the first test.
+	TestSessionSetupTime = "test_session.setup_ms"
+
+	// TestSessionTimeToFirstFailure indicates the time in milliseconds between the start of the session and the first failure.
+	TestSessionTimeToFirstFailure = "test_session.time_to_first_failure_ms"
+
+	// TestSessionFlushTimedOut indicates whether the flush of the test spans timed out when the session finished,
+	// so some of them may have been dropped.
+	TestSessionFlushTimedOut = "test_session.flush.timed_out"
+
+	// TestSessionDiffBase indicates the base branch the changes of the session are compared with.
+	TestSessionDiffBase = "test_session.diff.base"
+
+	// TestSessionDiffFilesChanged indicates the number of files changed since the base branch.
+	TestSessionDiffFilesChanged = "test_session.diff.files_changed"
+
+	// TestSessionDiffInsertions indicates the number of lines inserted since the base branch.
+	TestSessionDiffInsertions = "test_session.diff.insertions"
+
+	// TestSessionDiffDeletions indicates the number of lines deleted since the base branch.
+	TestSessionDiffDeletions = "test_session.diff.deletions"
+
+	// TestSessionDiffDirectories indicates the top-level directories of the files changed since the base
+	// branch, as a JSON array.
+	TestSessionDiffDirectories = "test_session.diff.directories"
+
+	// TestSessionRepeatCount indicates the number of executions of each test requested with the -count flag.
+	TestSessionRepeatCount = "test_session.repeat.count"
+
+	// TestSessionRepeatStability indicates the passed executions and the executions of the tests of each
+	// suite run with the -count flag, as a JSON object like {"pkg":{"passes":9,"runs":10}}.
+	TestSessionRepeatStability = "test_session.repeat.stability"
+
+	// TestSessionUnstableTests indicates the number of tests run with the -count flag that both passed and failed.
+	TestSessionUnstableTests = "test_session.repeat.unstable_tests"
+
+	// TestSessionTruncatedTags indicates the number of tag values truncated because they were
+	// larger than their limit. The truncated tags are marked with a tag suffixed by "_truncated".
+	TestSessionTruncatedTags = "test_session.truncated_tags"
+
+	// TestSessionClockSkew indicates the skew of the clock of the runner against the agent, in
+	// seconds, positive when the clock is ahead.
+	TestSessionClockSkew = "test_session.clock_skew"
+
+	// TestSessionClockSkewed indicates whether the clock skew is larger than the threshold, so the
+	// timestamps of the session may be unreliable.
+	TestSessionClockSkewed = "test_session.clock_skewed"
+
+	// CodeCoverageEnabled indicates whether the coverage of the binaries started by the tests was collected.
+	CodeCoverageEnabled = "test.code_coverage.enabled"
+
+	// CodeCoverageLinesPct indicates the percentage of statements covered by the binaries started by the tests.
+	CodeCoverageLinesPct = "test.code_coverage.lines_pct"
+
+	// CodeCoverageProfile indicates the path of the merged coverage profile of the binaries started by the tests.
+	CodeCoverageProfile = "test.code_coverage.profile"
+)
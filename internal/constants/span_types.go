@@ -8,4 +8,22 @@ package constants
 const (
 	// SpanTypeTest marks a span as a test execution.
 	SpanTypeTest = "test"
+
+	// SpanTypeTestSession marks a span as a test session.
+	SpanTypeTestSession = "test_session_end"
+
+	// SpanTypeTestModule marks a span as a test module of a session shared by many test binaries.
+	SpanTypeTestModule = "test_module_end"
+
+	// SpanTypeTestSuite marks a span as a test suite.
+	SpanTypeTestSuite = "test_suite_end"
+
+	// SpanTypeTestCleanup marks a span as a cleanup function of a test.
+	SpanTypeTestCleanup = "test.cleanup"
+
+	// SpanTypeTestStep marks a span as a named step of a test.
+	SpanTypeTestStep = "test.step"
+
+	// SpanTypeTestFixture marks a span as a setup or teardown fixture of a test session.
+	SpanTypeTestFixture = "test.fixture"
 )
@@ -9,6 +9,12 @@ const (
 	// SpanKind defines the Span kind.
 	SpanKind = "span.kind"
 
+	// SpanKindClient is the span kind of the outgoing requests.
+	SpanKindClient = "client"
+
+	// SpanKindServer is the span kind of the handled requests.
+	SpanKindServer = "server"
+
 	// Origin tag
 	Origin = "_dd.origin"
 
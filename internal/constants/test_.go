@@ -9,12 +9,40 @@ const (
 	// TestName is a tag with specifies the test name.
 	TestName = "test.name"
 
+	// TestSessionID indicates the ID of the test session shared by all the processes of a run.
+	TestSessionID = "test_session_id"
+
+	// TestModule indicates the module of the test, the package of the test binary.
+	TestModule = "test.module"
+
+	// TestModuleID indicates the ID of the module of a test session.
+	TestModuleID = "test_module_id"
+
+	// TestWorker indicates the worker reporting the test to the aggregation server of ddtest, or
+	// the comma-separated workers of a module.
+	TestWorker = "test.worker"
+
+	// TestCorrelationID indicates the stable ID of the test derived from its repository, suite and name.
+	TestCorrelationID = "test.correlation_id"
+
+	// TestCodeowners indicates the owners of the test file in the CODEOWNERS file, as a JSON array.
+	TestCodeowners = "test.codeowners"
+
+	// Team indicates the Datadog team owning the test.
+	Team = "team"
+
 	// TestSuite indicates the test suite name.
 	TestSuite = "test.suite"
 
+	// TestSuiteID indicates the ID of the suite of a test module.
+	TestSuiteID = "test_suite_id"
+
 	// TestFramework indicates the test framework name.
 	TestFramework = "test.framework"
 
+	// TestFrameworkVersion indicates the test framework version.
+	TestFrameworkVersion = "test.framework_version"
+
 	// TestStatus indicates the test execution status.
 	TestStatus = "test.status"
 
@@ -32,6 +60,148 @@ const (
 
 	// TestSourceEndLine indicates the line of the source file where the test ends.
 	TestSourceEndLine = "test.source.end"
+
+	// TestIsModified indicates whether the lines of the test changed since the base branch.
+	TestIsModified = "test.is_modified"
+
+	// TestAssertions indicates the number of assertions of the test.
+	TestAssertions = "test.assertions"
+
+	// TestNoAssertions indicates the test passed without assertions, a smell of vacuous tests.
+	TestNoAssertions = "test.assertions.none"
+
+	// TestFailureMatcher indicates the description of the matcher or assertion that failed.
+	TestFailureMatcher = "test.failure.matcher"
+
+	// TestFailureActual indicates the actual value of the failed assertion.
+	TestFailureActual = "test.failure.actual"
+
+	// TestFailureExpected indicates the expected value of the failed assertion.
+	TestFailureExpected = "test.failure.expected"
+
+	// TestFailureDiff indicates the diff between the expected and actual values of the failed assertion.
+	TestFailureDiff = "test.failure.diff"
+
+	// TestFailureLocation indicates the source location of the failed assertion.
+	TestFailureLocation = "test.failure.location"
+
+	// TestGoldenFile indicates the golden file that didn't match the test output.
+	TestGoldenFile = "test.golden.file"
+
+	// TestGoldenDiff indicates the unified diff between the golden file and the test output.
+	TestGoldenDiff = "test.golden.diff"
+
+	// TestPropertySeed indicates the seed used by the generator of a property-based test.
+	TestPropertySeed = "test.property.seed"
+
+	// TestPropertyCounterexample indicates the minimized counterexample of a failed property-based test.
+	TestPropertyCounterexample = "test.property.counterexample"
+
+	// TestBenchmarkMem indicates whether the allocations of the benchmark are reported, with
+	// -benchmem or b.ReportAllocs.
+	TestBenchmarkMem = "test.benchmark.benchmem"
+
+	// TestBenchmarkAllocsPerOp indicates the heap allocations per operation of the benchmark.
+	TestBenchmarkAllocsPerOp = "test.benchmark.allocs_per_op"
+
+	// TestBenchmarkAllocatedBytesPerOp indicates the bytes allocated per operation of the benchmark.
+	TestBenchmarkAllocatedBytesPerOp = "test.benchmark.allocated_bytes_per_op"
+
+	// TestBenchmarkIterations indicates the number of iterations of the benchmark whose duration was recorded.
+	TestBenchmarkIterations = "test.benchmark.iterations"
+
+	// TestBenchmarkIterationMin indicates the duration in nanoseconds of the fastest iteration of the benchmark.
+	TestBenchmarkIterationMin = "test.benchmark.iteration.min"
+
+	// TestBenchmarkIterationMax indicates the duration in nanoseconds of the slowest iteration of the benchmark.
+	TestBenchmarkIterationMax = "test.benchmark.iteration.max"
+
+	// TestBenchmarkIterationMean indicates the mean duration in nanoseconds of the iterations of the benchmark.
+	TestBenchmarkIterationMean = "test.benchmark.iteration.mean"
+
+	// TestBenchmarkIterationP50 indicates the median duration in nanoseconds of the iterations of the benchmark.
+	TestBenchmarkIterationP50 = "test.benchmark.iteration.p50"
+
+	// TestBenchmarkIterationP90 indicates the 90th percentile of the durations of the iterations of the benchmark.
+	TestBenchmarkIterationP90 = "test.benchmark.iteration.p90"
+
+	// TestBenchmarkIterationP99 indicates the 99th percentile of the durations of the iterations of the benchmark.
+	TestBenchmarkIterationP99 = "test.benchmark.iteration.p99"
+
+	// TestBenchmarkIterationHistogram indicates the number of iterations of the benchmark by power of two of
+	// their duration, as a JSON object keyed by the upper bound in nanoseconds of each range.
+	TestBenchmarkIterationHistogram = "test.benchmark.iteration.histogram"
+
+	// TestQuarantined indicates the test is quarantined: its failures are known and shouldn't
+	// block the pipeline.
+	TestQuarantined = "test.quarantined"
+
+	// TestDurationBaseline indicates the duration in nanoseconds of the test in the duration baseline.
+	TestDurationBaseline = "test.duration.baseline"
+
+	// TestDurationRatio indicates the ratio between the duration of the test and its baseline.
+	TestDurationRatio = "test.duration.baseline_ratio"
+
+	// TestDurationRegressed indicates whether the test is slower than its baseline beyond the threshold.
+	TestDurationRegressed = "test.duration.regressed"
+
+	// TestDurationBudget indicates the maximum duration in nanoseconds of the test.
+	TestDurationBudget = "test.duration.budget"
+
+	// TestDurationOverBudget indicates whether the test took longer than its duration budget.
+	TestDurationOverBudget = "test.duration.over_budget"
+
+	// TestDurationOverage indicates how long, in nanoseconds, the test ran past its duration budget.
+	TestDurationOverage = "test.duration.overage"
+
+	// TestIsRetry indicates the test already ran in the test binary, like when it's retried.
+	TestIsRetry = "test.is_retry"
+
+	// TestRetryAttempt indicates the number of previous executions of a retried test.
+	TestRetryAttempt = "test.retry.attempt"
+
+	// TestRetryFirstSpanID indicates the span ID of the first execution of a retried test.
+	TestRetryFirstSpanID = "test.retry.first_span_id"
+
+	// TestRetryPreviousSpanID indicates the span ID of the previous execution of a retried test.
+	TestRetryPreviousSpanID = "test.retry.previous_span_id"
+
+	// TestRepeatCount indicates the number of executions of the test requested with the -count flag.
+	TestRepeatCount = "test.repeat.count"
+
+	// TestRepeatIndex indicates the index of the execution of the test run with the -count flag.
+	TestRepeatIndex = "test.repeat.index"
+
+	// TestExecutionGroupID indicates the ID shared by the executions of the test run with the -count flag.
+	TestExecutionGroupID = "test.execution_group_id"
+
+	// TestShuffleSeed indicates the seed of the -shuffle flag ordering the tests.
+	TestShuffleSeed = "test.shuffle.seed"
+
+	// TestShuffleIndex indicates the position of the test in the execution order of the test binary.
+	TestShuffleIndex = "test.shuffle.index"
+
+	// TestTimeoutOverrun indicates how long, in nanoseconds, a test failed because of its
+	// deadline ran past it.
+	TestTimeoutOverrun = "test.timeout.overrun"
+
+	// TestCrashSignal indicates the signal that crashed the test process while the test was running.
+	TestCrashSignal = "test.crash.signal"
+
+	// TestStepName indicates the name of a test step.
+	TestStepName = "test.step.name"
+
+	// TestStepStatus indicates the execution status of a test step.
+	TestStepStatus = "test.step.status"
+
+	// TestFixtureName indicates the name of a setup or teardown fixture of a test session.
+	TestFixtureName = "test.fixture.name"
+
+	// TestFixtureStatus indicates the execution status of a fixture.
+	TestFixtureStatus = "test.fixture.status"
+
+	// TestOutput indicates the output captured from a failed test.
+	TestOutput = "test.output"
 )
 
 // Define valid test status types.
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"strconv"
+)
+
+const (
+	// testCyclePath is the path of the CI Visibility test cycle endpoint of the intake.
+	testCyclePath = "/api/v2/citestcycle"
+
+	// evpProxyPath is the path prefix of the EVP proxy of the agent, and evpSubdomainHeader the
+	// header with the subdomain of the intake the requests are forwarded to.
+	evpProxyPath       = "/evp_proxy/v2"
+	evpSubdomainHeader = "X-Datadog-EVP-Subdomain"
+	testCycleSubdomain = "citestcycle-intake"
+
+	// The tags moved from the meta of the spans to the content of the events.
+	testSessionIDTag = "test_session_id"
+	testModuleIDTag  = "test_module_id"
+	testSuiteIDTag   = "test_suite_id"
+)
+
+// testCycleEventTypes are the event types of the span types, the other spans are sent as
+// span events.
+var testCycleEventTypes = map[string]string{
+	"test":             "test",
+	"test_suite_end":   "test_suite_end",
+	"test_module_end":  "test_module_end",
+	"test_session_end": "test_session_end",
+}
+
+// testCyclePayload returns the CI Visibility test cycle payload of the spans.
+func (t *Tracer) testCyclePayload(spans []*span) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"language":   "go",
+		"runtime-id": t.runtimeID,
+	}
+	if t.cfg.Env != "" {
+		metadata["env"] = t.cfg.Env
+	}
+	events := make([]interface{}, 0, len(spans))
+	for _, s := range spans {
+		events = append(events, testCycleEvent(s))
+	}
+	return map[string]interface{}{
+		"version":  1,
+		"metadata": map[string]interface{}{"*": metadata},
+		"events":   events,
+	}
+}
+
+// testCycleEvent returns the event of a finished span: the test sessions, modules and suites
+// are reported as their own event types with their IDs, the other spans keep their trace.
+func testCycleEvent(s *span) map[string]interface{} {
+	eventType, ok := testCycleEventTypes[s.Type]
+	if !ok {
+		eventType = "span"
+	}
+	meta := make(map[string]string, len(s.Meta))
+	for k, v := range s.Meta {
+		meta[k] = v
+	}
+	content := map[string]interface{}{
+		"name":     s.Name,
+		"service":  s.Service,
+		"resource": s.Resource,
+		"type":     s.Type,
+		"start":    s.Start,
+		"duration": s.Duration,
+		"error":    s.Error,
+		"meta":     meta,
+		"metrics":  s.Metrics,
+	}
+	version := 1
+	switch eventType {
+	case "test", "span":
+		content["trace_id"] = s.TraceID
+		content["span_id"] = s.SpanID
+		content["parent_id"] = s.ParentID
+	}
+	if eventType != "span" {
+		for _, tag := range []string{testSessionIDTag, testModuleIDTag, testSuiteIDTag} {
+			if id, err := strconv.ParseUint(meta[tag], 10, 64); err == nil {
+				content[tag] = id
+				delete(meta, tag)
+			}
+		}
+		if _, ok := content[testSessionIDTag]; !ok && eventType == "test_session_end" {
+			content[testSessionIDTag] = s.TraceID
+		}
+		if eventType == "test" {
+			// The version 2 of the test events has the IDs of the session, module and suite.
+			version = 2
+		}
+	}
+	return map[string]interface{}{
+		"type":    eventType,
+		"version": version,
+		"content": content,
+	}
+}
@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestTestCycleEvent(t *testing.T) {
+	tr := New(Config{Env: "ci"})
+	session := tr.StartSpan("test_session_end", tracer.SpanType("test_session_end")).(*span)
+	sessionID := strconv.FormatUint(session.TraceID, 10)
+	suite := tr.StartSpan("test_suite_end",
+		tracer.SpanType("test_suite_end"),
+		tracer.ChildOf(session.Context()),
+		tracer.Tag(testSessionIDTag, sessionID),
+		tracer.Tag(testModuleIDTag, "7"),
+		tracer.Tag(testSuiteIDTag, "8")).(*span)
+	test := tr.StartSpan("test",
+		tracer.SpanType("test"),
+		tracer.Tag(testSessionIDTag, sessionID),
+		tracer.Tag(testModuleIDTag, "7"),
+		tracer.Tag(testSuiteIDTag, "8"),
+		tracer.Tag("test.status", "pass")).(*span)
+	step := tr.StartSpan("test.step", tracer.ChildOf(test.Context()), tracer.Tag(testSuiteIDTag, "8")).(*span)
+
+	event := testCycleEvent(session)
+	content := event["content"].(map[string]interface{})
+	if event["type"] != "test_session_end" || content[testSessionIDTag] != session.TraceID || content["span_id"] != nil {
+		t.Errorf("unexpected session event %v", event)
+	}
+	event = testCycleEvent(suite)
+	content = event["content"].(map[string]interface{})
+	if event["type"] != "test_suite_end" || content[testModuleIDTag] != uint64(7) || content[testSuiteIDTag] != uint64(8) ||
+		content["meta"].(map[string]string)[testSuiteIDTag] != "" {
+		t.Errorf("unexpected suite event %v", event)
+	}
+	event = testCycleEvent(test)
+	content = event["content"].(map[string]interface{})
+	if event["type"] != "test" || event["version"] != 2 || content["span_id"] != test.SpanID ||
+		content[testSuiteIDTag] != uint64(8) || content["meta"].(map[string]string)["test.status"] != "pass" {
+		t.Errorf("unexpected test event %v", event)
+	}
+	event = testCycleEvent(step)
+	content = event["content"].(map[string]interface{})
+	if event["type"] != "span" || content["parent_id"] != test.SpanID || content["meta"].(map[string]string)[testSuiteIDTag] != "8" {
+		t.Errorf("unexpected span event %v", event)
+	}
+	// The spans aren't modified.
+	if test.Meta[testSuiteIDTag] != "8" {
+		t.Errorf("unexpected meta of the test %v", test.Meta)
+	}
+}
+
+func TestTracerAgentless(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != testCyclePath || r.Header.Get("DD-API-KEY") != "key" || r.Header.Get("Content-Type") != "application/msgpack" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	tr := New(Config{URL: srv.URL, Transport: TransportAgentless, APIKey: "key"})
+	tr.StartSpan("test", tracer.SpanType("test")).Finish()
+	if err := tr.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// The payload is a map of 3 entries: events, metadata and version.
+	if len(body) == 0 || body[0] != 0x83 {
+		t.Errorf("unexpected payload %x", body)
+	}
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	tr.StartSpan("test").Finish()
+	if err := tr.Flush(); err == nil {
+		t.Error("expected an error with an invalid API key")
+	}
+}
+
+func TestTracerEVPProxy(t *testing.T) {
+	var path, subdomain, apiKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, subdomain, apiKey = r.URL.Path, r.Header.Get(evpSubdomainHeader), r.Header.Get("DD-API-KEY")
+	}))
+	defer srv.Close()
+
+	tr := New(Config{URL: srv.URL, Transport: TransportEVPProxy})
+	tr.StartSpan("test", tracer.SpanType("test")).Finish()
+	if err := tr.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/evp_proxy/v2/api/v2/citestcycle" || subdomain != "citestcycle-intake" || apiKey != "" {
+		t.Errorf("unexpected request %s %s %s", path, subdomain, apiKey)
+	}
+}
@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// writeTestCycle writes a test cycle payload in JSON on a single line of the output.
+func (t *Tracer) writeTestCycle(payload map[string]interface{}) error {
+	line, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = t.cfg.Output.Write(append(line, '\n'))
+	return err
+}
+
+// Upload sends the test cycle payloads written with TransportFile, read from r, with the
+// transport of the tracer, TransportAgentless or TransportEVPProxy. It returns the number of
+// events sent, and stops at the first payload which can't be read or sent.
+func (t *Tracer) Upload(r io.Reader) (int, error) {
+	if t.cfg.Transport != TransportAgentless && t.cfg.Transport != TransportEVPProxy {
+		return 0, fmt.Errorf("the test cycle payloads can only be uploaded to the intake or the EVP proxy")
+	}
+	dec := json.NewDecoder(r)
+	// The IDs are 64-bit integers, which don't fit in a float64.
+	dec.UseNumber()
+	sent := 0
+	for n := 1; ; n++ {
+		var payload map[string]interface{}
+		if err := dec.Decode(&payload); err == io.EOF {
+			return sent, nil
+		} else if err != nil {
+			return sent, fmt.Errorf("reading the payload %d: %v", n, err)
+		}
+		events, ok := payload["events"].([]interface{})
+		if !ok {
+			return sent, fmt.Errorf("reading the payload %d: no events", n)
+		}
+		if err := t.postTestCycle(fromJSON(payload, false).(map[string]interface{})); err != nil {
+			return sent, err
+		}
+		sent += len(events)
+	}
+}
+
+// fromJSON converts the numbers of a decoded JSON value to the types of the encoded payloads:
+// the integers to int64 or uint64 and the others, or all of them when float is true, to
+// float64. The values of the metrics are always floats.
+func fromJSON(v interface{}, float bool) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if !float {
+			if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+				return i
+			}
+			if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+				return u
+			}
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = fromJSON(v[i], float)
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = fromJSON(v[k], float || k == "metrics")
+		}
+	}
+	return v
+}
@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestTracerFile(t *testing.T) {
+	var out bytes.Buffer
+	tr := New(Config{Transport: TransportFile, Output: &out, Env: "ci"})
+	for i := 0; i < 2; i++ {
+		tr.StartSpan("test",
+			tracer.SpanType("test"),
+			tracer.Tag(testSuiteIDTag, strconv.FormatUint(math.MaxUint64, 10)),
+			tracer.Tag("test.source.start", 12)).Finish()
+		if err := tr.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One JSON payload per flush.
+	if lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], "{") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	var payloads []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != evpProxyPath+testCyclePath {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		payload, err := decodeMsgpack(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payloads = append(payloads, payload.(map[string]interface{}))
+	}))
+	defer srv.Close()
+
+	n, err := New(Config{URL: srv.URL, Transport: TransportEVPProxy}).Upload(bytes.NewReader(out.Bytes()))
+	if err != nil || n != 2 || len(payloads) != 2 {
+		t.Fatalf("unexpected upload of %d events, %d payloads: %v", n, len(payloads), err)
+	}
+	metadata := payloads[0]["metadata"].(map[string]interface{})["*"].(map[string]interface{})
+	event := payloads[0]["events"].([]interface{})[0].(map[string]interface{})
+	content := event["content"].(map[string]interface{})
+	// The integers are kept, the metrics are floats.
+	if metadata["env"] != "ci" || event["version"] != int64(2) || content[testSuiteIDTag] != uint64(math.MaxUint64) ||
+		content["metrics"].(map[string]interface{})["test.source.start"] != 12.0 {
+		t.Errorf("unexpected payload %v", payloads[0])
+	}
+
+	if _, err := New(Config{URL: srv.URL, Transport: TransportEVPProxy}).Upload(strings.NewReader("{")); err == nil {
+		t.Error("expected an error with a truncated payload")
+	}
+	if _, err := New(Config{URL: srv.URL}).Upload(bytes.NewReader(out.Bytes())); err == nil {
+		t.Error("expected an error with the agent transport")
+	}
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"context"
+	"sync"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var (
+	// current records the spans of the SDK and of the contrib packages when the test session
+	// uses an isolated tracer, otherwise the global tracer of dd-trace-go is used.
+	current      *Tracer
+	currentMutex sync.Mutex
+)
+
+// Current returns the isolated tracer of the test session, or nil.
+func Current() *Tracer {
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+	return current
+}
+
+// SetCurrent sets the isolated tracer of the test session, nil to use the global tracer.
+func SetCurrent(t *Tracer) {
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+	current = t
+}
+
+// StartSpanFromContext starts a span like tracer.StartSpanFromContext, with the isolated tracer
+// of the test session when it uses one.
+func StartSpanFromContext(ctx context.Context, operationName string, opts ...ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	t := Current()
+	if t == nil {
+		return tracer.StartSpanFromContext(ctx, operationName, opts...)
+	}
+	if parent, ok := tracer.SpanFromContext(ctx); ok {
+		// The options given by the caller come last, so their parent takes precedence.
+		opts = append([]ddtrace.StartSpanOption{tracer.ChildOf(parent.Context())}, opts...)
+	}
+	span := t.StartSpan(operationName, opts...)
+	return span, tracer.ContextWithSpan(ctx, span)
+}
+
+// Extract extracts a span context like tracer.Extract, with the isolated tracer of the test
+// session when it uses one.
+func Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	if t := Current(); t != nil {
+		return t.Extract(carrier)
+	}
+	return tracer.Extract(carrier)
+}
+
+// Inject injects a span context like tracer.Inject, with the isolated tracer of the test session
+// when it uses one.
+func Inject(ctx ddtrace.SpanContext, carrier interface{}) error {
+	if t := Current(); t != nil {
+		return t.Inject(ctx, carrier)
+	}
+	return tracer.Inject(ctx, carrier)
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestCurrent(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// Without isolated tracer, the spans are started with the global tracer.
+	s, _ := StartSpanFromContext(context.Background(), "http.request")
+	s.Finish()
+	if len(mt.FinishedSpans()) != 1 {
+		t.Fatal("expected a span of the global tracer")
+	}
+
+	tr := New(Config{URL: "http://localhost:0"})
+	SetCurrent(tr)
+	defer SetCurrent(nil)
+	parent := tr.StartSpan("test")
+	child, ctx := StartSpanFromContext(tracer.ContextWithSpan(context.Background(), parent), "http.request")
+	if _, ok := child.(*span); !ok || child.Context().TraceID() != parent.Context().TraceID() {
+		t.Errorf("unexpected span %v", child)
+	}
+	if s, _ := tracer.SpanFromContext(ctx); s != child {
+		t.Error("expected the span in the context")
+	}
+
+	carrier := tracer.TextMapCarrier{}
+	if err := Inject(child.Context(), carrier); err != nil {
+		t.Fatal(err)
+	}
+	sctx, err := Extract(carrier)
+	if err != nil || sctx.SpanID() != child.Context().SpanID() {
+		t.Errorf("unexpected extracted context %v: %v", sctx, err)
+	}
+	if len(mt.FinishedSpans()) != 1 {
+		t.Error("unexpected span of the global tracer")
+	}
+}
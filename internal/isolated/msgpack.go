@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// encodeMsgpack encodes a value of the payloads in MessagePack, and returns an error for the
+// unsupported types. The keys of the maps are sorted so the encoding is stable.
+func encodeMsgpack(v interface{}) ([]byte, error) {
+	return appendMsgpack(nil, v)
+}
+
+// appendMsgpack appends the MessagePack encoding of a value to b.
+func appendMsgpack(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return msgp.AppendNil(b), nil
+	case bool:
+		return msgp.AppendBool(b, v), nil
+	case int:
+		return msgp.AppendInt64(b, int64(v)), nil
+	case int32:
+		return msgp.AppendInt64(b, int64(v)), nil
+	case int64:
+		return msgp.AppendInt64(b, v), nil
+	case uint64:
+		return msgp.AppendUint64(b, v), nil
+	case float64:
+		return msgp.AppendFloat64(b, v), nil
+	case string:
+		return msgp.AppendString(b, v), nil
+	case []interface{}:
+		b = msgp.AppendArrayHeader(b, uint32(len(v)))
+		for _, item := range v {
+			var err error
+			if b, err = appendMsgpack(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]interface{}:
+		b = msgp.AppendMapHeader(b, uint32(len(v)))
+		for _, k := range sortedKeys(len(v), func(add func(string)) {
+			for k := range v {
+				add(k)
+			}
+		}) {
+			var err error
+			if b, err = appendMsgpack(msgp.AppendString(b, k), v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]string:
+		b = msgp.AppendMapHeader(b, uint32(len(v)))
+		for _, k := range sortedKeys(len(v), func(add func(string)) {
+			for k := range v {
+				add(k)
+			}
+		}) {
+			b = msgp.AppendString(msgp.AppendString(b, k), v[k])
+		}
+		return b, nil
+	case map[string]float64:
+		b = msgp.AppendMapHeader(b, uint32(len(v)))
+		for _, k := range sortedKeys(len(v), func(add func(string)) {
+			for k := range v {
+				add(k)
+			}
+		}) {
+			b = msgp.AppendFloat64(msgp.AppendString(b, k), v[k])
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type %T", v)
+}
+
+// sortedKeys returns the keys listed by each, sorted.
+func sortedKeys(n int, each func(add func(string))) []string {
+	keys := make([]string, 0, n)
+	each(func(k string) { keys = append(keys, k) })
+	sort.Strings(keys)
+	return keys
+}
+
+// decodeMsgpack decodes the MessagePack value of a payload of dd-trace-go: the maps are decoded
+// as map[string]interface{}, the arrays as []interface{}, the integers as int64 or uint64 and
+// the floats as float64 or float32.
+func decodeMsgpack(b []byte) (interface{}, error) {
+	v, _, err := msgp.ReadIntfBytes(b)
+	return v, err
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMsgpackEncoder(t *testing.T) {
+	for _, tc := range []struct {
+		value interface{}
+		want  []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{1, []byte{0x01}},
+		{-1, []byte{0xff}},
+		{-100, []byte{0xd0, 0x9c}},
+		{int64(300), []byte{0xd1, 0x01, 0x2c}},
+		{uint64(300), []byte{0xcd, 0x01, 0x2c}},
+		{uint64(1) << 40, []byte{0xcf, 0, 0, 0x01, 0, 0, 0, 0, 0}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"go", []byte{0xa2, 'g', 'o'}},
+		{strings.Repeat("a", 32), append([]byte{0xd9, 32}, strings.Repeat("a", 32)...)},
+		{[]interface{}{1, "a"}, []byte{0x92, 0x01, 0xa1, 'a'}},
+		{map[string]interface{}{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+		{map[string]string{"k": "v"}, []byte{0x81, 0xa1, 'k', 0xa1, 'v'}},
+	} {
+		got, err := encodeMsgpack(tc.value)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.value, err)
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("%v: expected %x, got %x", tc.value, tc.want, got)
+		}
+	}
+
+	if _, err := encodeMsgpack(struct{}{}); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+}
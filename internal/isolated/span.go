@@ -0,0 +1,207 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+// span is a span in the format of the traces endpoint of the agent.
+type span struct {
+	Name     string             `json:"name"`
+	Service  string             `json:"service"`
+	Resource string             `json:"resource"`
+	Type     string             `json:"type"`
+	Start    int64              `json:"start"`
+	Duration int64              `json:"duration"`
+	Meta     map[string]string  `json:"meta"`
+	Metrics  map[string]float64 `json:"metrics"`
+	SpanID   uint64             `json:"span_id"`
+	TraceID  uint64             `json:"trace_id"`
+	ParentID uint64             `json:"parent_id"`
+	Error    int32              `json:"error"`
+
+	tracer   *Tracer
+	mu       sync.Mutex
+	finished bool
+	baggage  map[string]string
+}
+
+var _ ddtrace.Span = (*span)(nil)
+
+// SetTag sets a tag: the special tags of dd-trace-go set the fields of the span, the numbers
+// are set as metrics and the other values as strings.
+func (s *span) SetTag(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	switch key {
+	case ext.ResourceName:
+		s.Resource = fmt.Sprint(value)
+		return
+	case ext.ServiceName:
+		s.Service = fmt.Sprint(value)
+		return
+	case ext.SpanType:
+		s.Type = fmt.Sprint(value)
+		return
+	case ext.ManualKeep:
+		if v, ok := value.(bool); !ok || v {
+			s.Metrics[samplingPriorityKey] = 2
+		}
+		return
+	case ext.Error:
+		s.setError(value)
+		return
+	}
+	switch v := value.(type) {
+	case bool:
+		s.Meta[key] = strconv.FormatBool(v)
+	case int:
+		s.Metrics[key] = float64(v)
+	case int32:
+		s.Metrics[key] = float64(v)
+	case int64:
+		s.Metrics[key] = float64(v)
+	case uint32:
+		s.Metrics[key] = float64(v)
+	case uint64:
+		s.Metrics[key] = float64(v)
+	case float32:
+		s.Metrics[key] = float64(v)
+	case float64:
+		s.Metrics[key] = v
+	case fmt.Stringer:
+		s.Meta[key] = v.String()
+	default:
+		s.Meta[key] = fmt.Sprint(v)
+	}
+}
+
+// setError sets the error of the span from a boolean or an error, s.mu must be held.
+func (s *span) setError(value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		s.Error = 0
+	case bool:
+		if v {
+			s.Error = 1
+		} else {
+			s.Error = 0
+		}
+	case error:
+		s.Error = 1
+		s.Meta[ext.ErrorMsg] = v.Error()
+		s.Meta[ext.ErrorType] = fmt.Sprintf("%T", v)
+	default:
+		s.Error = 1
+	}
+}
+
+// SetOperationName sets the name of the span.
+func (s *span) SetOperationName(operationName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Name = operationName
+}
+
+// BaggageItem returns the baggage item with the given key.
+func (s *span) BaggageItem(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.baggage[key]
+}
+
+// SetBaggageItem sets a baggage item, propagated to the children of the span.
+func (s *span) SetBaggageItem(key, val string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.baggage == nil {
+		s.baggage = map[string]string{}
+	}
+	s.baggage[key] = val
+}
+
+// Finish finishes the span, it's sent when the tracer is flushed.
+func (s *span) Finish(opts ...ddtrace.FinishOption) {
+	var cfg ddtrace.FinishConfig
+	for _, fn := range opts {
+		if fn != nil {
+			fn(&cfg)
+		}
+	}
+	if cfg.FinishTime.IsZero() {
+		cfg.FinishTime = time.Now()
+	}
+
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	if cfg.Error != nil {
+		s.setError(cfg.Error)
+	}
+	s.Duration = cfg.FinishTime.UnixNano() - s.Start
+	s.finished = true
+	s.mu.Unlock()
+
+	s.tracer.finish(s)
+}
+
+// Context returns the span context of the span.
+func (s *span) Context() ddtrace.SpanContext {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ctx := &spanContext{traceID: s.TraceID, spanID: s.SpanID}
+	for k, v := range s.baggage {
+		if ctx.baggage == nil {
+			ctx.baggage = map[string]string{}
+		}
+		ctx.baggage[k] = v
+	}
+	return ctx
+}
+
+// spanContext is the span context of the spans of the tracer.
+type spanContext struct {
+	traceID uint64
+	spanID  uint64
+	baggage map[string]string
+}
+
+var _ ddtrace.SpanContext = (*spanContext)(nil)
+
+func (c *spanContext) SpanID() uint64  { return c.spanID }
+func (c *spanContext) TraceID() uint64 { return c.traceID }
+
+func (c *spanContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for k, v := range c.baggage {
+		if !handler(k, v) {
+			return
+		}
+	}
+}
+
+// baggageCopy returns a copy of the baggage of the context.
+func (c *spanContext) baggageCopy() map[string]string {
+	if len(c.baggage) == 0 {
+		return nil
+	}
+	baggage := make(map[string]string, len(c.baggage))
+	for k, v := range c.baggage {
+		baggage[k] = v
+	}
+	return baggage
+}
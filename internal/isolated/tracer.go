@@ -0,0 +1,319 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package isolated implements a minimal tracer sending the test spans to the Datadog Agent, or
+// directly to the CI Visibility intake, without using the global tracer of dd-trace-go, so the
+// tests can start, stop or mock the global tracer without affecting the test spans.
+package isolated
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+const (
+	// tracesPath is the path of the traces endpoint of the agent.
+	tracesPath = "/v0.4/traces"
+
+	// Propagation headers, compatible with the ones of dd-trace-go.
+	traceIDHeader  = "x-datadog-trace-id"
+	parentIDHeader = "x-datadog-parent-id"
+
+	// samplingPriorityKey is the metric keeping the traces, set by ext.ManualKeep.
+	samplingPriorityKey = "_sampling_priority_v1"
+)
+
+// Transport is the way the spans are sent.
+type Transport int
+
+const (
+	// TransportAgent sends the spans to the traces endpoint of the agent.
+	TransportAgent Transport = iota
+
+	// TransportAgentless sends the spans as CI Visibility test cycle events to the intake,
+	// authenticated with the API key.
+	TransportAgentless
+
+	// TransportEVPProxy sends the spans as CI Visibility test cycle events to the EVP proxy of
+	// the agent, which forwards them to the intake with its own API key.
+	TransportEVPProxy
+
+	// TransportFile writes the spans as CI Visibility test cycle payloads to Config.Output,
+	// without network, so they can be uploaded later with Upload.
+	TransportFile
+)
+
+// Config configures a Tracer.
+type Config struct {
+	// URL is the base URL of the agent, like http://localhost:8126, or of the intake with
+	// TransportAgentless, like https://citestcycle-intake.datadoghq.com.
+	URL string
+
+	Transport Transport
+	APIKey    string
+
+	// Output receives the payloads of TransportFile, one JSON payload per line written in a
+	// single call. It must be safe for concurrent use when it's shared.
+	Output io.Writer
+
+	Service    string
+	Env        string
+	GlobalTags map[string]interface{}
+
+	Client *http.Client
+}
+
+// Tracer records the finished spans and sends them to the agent when it's flushed.
+type Tracer struct {
+	cfg Config
+
+	runtimeID string
+
+	mu       sync.Mutex
+	finished []*span
+	rand     *rand.Rand
+}
+
+var _ ddtrace.Tracer = (*Tracer)(nil)
+
+// New returns a tracer with the given configuration.
+func New(cfg Config) *Tracer {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+	t := &Tracer{cfg: cfg, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	t.runtimeID = fmt.Sprintf("%016x%016x", t.rand.Uint64(), t.rand.Uint64())
+	return t
+}
+
+// StartSpan starts a span. The span is a child of the span context given with tracer.ChildOf,
+// which can come from any tracer.
+func (t *Tracer) StartSpan(operationName string, opts ...ddtrace.StartSpanOption) ddtrace.Span {
+	cfg := ddtrace.StartSpanConfig{Tags: map[string]interface{}{}}
+	for _, fn := range opts {
+		if fn != nil {
+			fn(&cfg)
+		}
+	}
+	if cfg.StartTime.IsZero() {
+		cfg.StartTime = time.Now()
+	}
+	s := &span{
+		tracer:  t,
+		Name:    operationName,
+		Service: t.cfg.Service,
+		Start:   cfg.StartTime.UnixNano(),
+		Meta:    map[string]string{},
+		Metrics: map[string]float64{},
+	}
+	s.SpanID = cfg.SpanID
+	if s.SpanID == 0 {
+		s.SpanID = t.newID()
+	}
+	if cfg.Parent != nil {
+		s.TraceID = cfg.Parent.TraceID()
+		s.ParentID = cfg.Parent.SpanID()
+		if parent, ok := cfg.Parent.(*spanContext); ok {
+			s.baggage = parent.baggageCopy()
+		}
+	}
+	if s.TraceID == 0 {
+		s.TraceID = s.SpanID
+	}
+	if t.cfg.Env != "" {
+		s.Meta[ext.Environment] = t.cfg.Env
+	}
+	for k, v := range t.cfg.GlobalTags {
+		s.SetTag(k, v)
+	}
+	for k, v := range cfg.Tags {
+		s.SetTag(k, v)
+	}
+	return s
+}
+
+// SetServiceInfo is a no-op, like in dd-trace-go.
+func (t *Tracer) SetServiceInfo(name, app, appType string) {}
+
+// textMapReader is implemented by the carriers of dd-trace-go, like tracer.TextMapCarrier.
+type textMapReader interface {
+	ForeachKey(handler func(key, val string) error) error
+}
+
+// textMapWriter is implemented by the carriers of dd-trace-go, like tracer.TextMapCarrier.
+type textMapWriter interface {
+	Set(key, val string)
+}
+
+// Extract returns the span context propagated in the carrier with the Datadog headers.
+func (t *Tracer) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	reader, ok := carrier.(textMapReader)
+	if !ok {
+		return nil, fmt.Errorf("unsupported carrier %T", carrier)
+	}
+	ctx := &spanContext{}
+	err := reader.ForeachKey(func(key, val string) error {
+		var err error
+		switch strings.ToLower(key) {
+		case traceIDHeader:
+			ctx.traceID, err = strconv.ParseUint(val, 10, 64)
+		case parentIDHeader:
+			ctx.spanID, err = strconv.ParseUint(val, 10, 64)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.traceID == 0 || ctx.spanID == 0 {
+		return nil, fmt.Errorf("no span context in the carrier")
+	}
+	return ctx, nil
+}
+
+// Inject propagates the span context in the carrier with the Datadog headers.
+func (t *Tracer) Inject(context ddtrace.SpanContext, carrier interface{}) error {
+	writer, ok := carrier.(textMapWriter)
+	if !ok {
+		return fmt.Errorf("unsupported carrier %T", carrier)
+	}
+	writer.Set(traceIDHeader, strconv.FormatUint(context.TraceID(), 10))
+	writer.Set(parentIDHeader, strconv.FormatUint(context.SpanID(), 10))
+	return nil
+}
+
+// Stop flushes the finished spans, reporting the error to stderr.
+func (t *Tracer) Stop() {
+	if err := t.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: sending the test spans: %v\n", err)
+	}
+}
+
+// Flush sends the finished spans to the agent grouped by trace, or as test cycle events with
+// the other transports. The spans are sent again once when it fails, like when the agent is
+// restarting, and are dropped if it fails again.
+func (t *Tracer) Flush() error {
+	t.mu.Lock()
+	spans := t.finished
+	t.finished = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+	if err := t.sendSpans(spans); err == nil {
+		return nil
+	}
+	return t.sendSpans(spans)
+}
+
+// sendSpans sends the spans with the transport of the tracer.
+func (t *Tracer) sendSpans(spans []*span) error {
+	if t.cfg.Transport != TransportAgent {
+		return t.sendTestCycle(spans)
+	}
+	return t.sendTraces(spans)
+}
+
+// sendTraces sends the spans to the traces endpoint of the agent.
+func (t *Tracer) sendTraces(spans []*span) error {
+	var traces [][]*span
+	index := map[uint64]int{}
+	for _, s := range spans {
+		i, ok := index[s.TraceID]
+		if !ok {
+			i = len(traces)
+			index[s.TraceID] = i
+			traces = append(traces, nil)
+		}
+		traces[i] = append(traces[i], s)
+	}
+	body, err := json.Marshal(traces)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", t.cfg.URL+tracesPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Datadog-Meta-Lang", "go")
+	req.Header.Set("X-Datadog-Trace-Count", strconv.Itoa(len(traces)))
+	return t.send(req)
+}
+
+// sendTestCycle sends the spans as test cycle events to the intake, or to the EVP proxy of the
+// agent, or writes them to the output with TransportFile.
+func (t *Tracer) sendTestCycle(spans []*span) error {
+	if t.cfg.Transport == TransportFile {
+		return t.writeTestCycle(t.testCyclePayload(spans))
+	}
+	return t.postTestCycle(t.testCyclePayload(spans))
+}
+
+// postTestCycle sends a test cycle payload to the intake, or to the EVP proxy of the agent.
+func (t *Tracer) postTestCycle(payload map[string]interface{}) error {
+	body, err := encodeMsgpack(payload)
+	if err != nil {
+		return err
+	}
+	url := t.cfg.URL + testCyclePath
+	if t.cfg.Transport == TransportEVPProxy {
+		url = t.cfg.URL + evpProxyPath + testCyclePath
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/msgpack")
+	if t.cfg.Transport == TransportEVPProxy {
+		req.Header.Set(evpSubdomainHeader, testCycleSubdomain)
+	} else {
+		req.Header.Set("DD-API-KEY", t.cfg.APIKey)
+	}
+	return t.send(req)
+}
+
+// send sends a request, and returns an error for the unsuccessful responses.
+func (t *Tracer) send(req *http.Request) error {
+	resp, err := t.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("unexpected status %s, check the API key", resp.Status)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// finish records a finished span.
+func (t *Tracer) finish(s *span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finished = append(t.finished, s)
+}
+
+// newID returns a random span ID.
+func (t *Tracer) newID() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return uint64(t.rand.Int63())
+}
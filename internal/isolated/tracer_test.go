@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestTracer(t *testing.T) {
+	var traces [][]map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != tracesPath || r.Header.Get("X-Datadog-Trace-Count") != "2" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&traces); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	tr := New(Config{URL: srv.URL, Service: "svc", Env: "ci", GlobalTags: map[string]interface{}{"team": "a"}})
+	root := tr.StartSpan("test",
+		tracer.ResourceName("pkg.TestA"),
+		tracer.SpanType("test"),
+		tracer.Tag(ext.ManualKeep, true),
+		tracer.Tag("test.status", "fail"),
+		tracer.Tag("test.source.start", 12))
+	child := tr.StartSpan("test.step", tracer.ChildOf(root.Context()))
+	child.Finish()
+	root.Finish(tracer.WithError(errors.New("boom")))
+	other := tr.StartSpan("test")
+	other.Finish()
+
+	if err := tr.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 2 || len(traces[0]) != 2 || len(traces[1]) != 1 {
+		t.Fatalf("unexpected traces %v", traces)
+	}
+	step, test := traces[0][0], traces[0][1]
+	if step["parent_id"] != test["span_id"] || step["trace_id"] != test["trace_id"] {
+		t.Errorf("unexpected hierarchy %v %v", step, test)
+	}
+	meta := test["meta"].(map[string]interface{})
+	metrics := test["metrics"].(map[string]interface{})
+	if test["resource"] != "pkg.TestA" || test["type"] != "test" || test["service"] != "svc" || test["error"] != 1.0 ||
+		meta["test.status"] != "fail" || meta["env"] != "ci" || meta["team"] != "a" || meta[ext.ErrorMsg] != "boom" ||
+		metrics["test.source.start"] != 12.0 || metrics[samplingPriorityKey] != 2.0 {
+		t.Errorf("unexpected span %v", test)
+	}
+
+	// The flushed spans aren't sent again.
+	if err := tr.Flush(); err != nil || len(traces) != 2 {
+		t.Errorf("unexpected flush %v", err)
+	}
+}
+
+func TestTracerFlushRetry(t *testing.T) {
+	var requests, failures int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	tr := New(Config{URL: srv.URL})
+	failures = 1
+	tr.StartSpan("test").Finish()
+	if err := tr.Flush(); err != nil || requests != 2 {
+		t.Errorf("the spans aren't sent again: %v, %d requests", err, requests)
+	}
+
+	failures = 2
+	tr.StartSpan("test").Finish()
+	if err := tr.Flush(); err == nil || requests != 4 {
+		t.Errorf("unexpected flush: %v, %d requests", err, requests)
+	}
+}
+
+func TestTracerPropagation(t *testing.T) {
+	tr := New(Config{})
+	span := tr.StartSpan("test")
+	carrier := tracer.TextMapCarrier{}
+	if err := tr.Inject(span.Context(), carrier); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := tr.Extract(carrier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.TraceID() != span.Context().TraceID() || ctx.SpanID() != span.Context().SpanID() {
+		t.Errorf("unexpected span context %d %d", ctx.TraceID(), ctx.SpanID())
+	}
+	if _, err := tr.Extract(tracer.TextMapCarrier{}); err == nil {
+		t.Error("expected an error without span context")
+	}
+}
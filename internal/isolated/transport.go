@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// tracesResponse is the response of the traces endpoint expected by dd-trace-go.
+const tracesResponse = `{"rate_by_service":{}}`
+
+// testCycleTransport converts the traces sent by dd-trace-go to the traces endpoint of the
+// agent into test cycle events, sent by the tracer.
+type testCycleTransport struct {
+	tracer *Tracer
+	base   http.RoundTripper
+}
+
+// NewTestCycleTransport returns a http.RoundTripper for the HTTP client of dd-trace-go, given
+// with tracer.WithHTTPClient, sending the traces as test cycle events with the transport of the
+// tracer, TransportAgentless, TransportEVPProxy or TransportFile. The other requests are sent
+// with base, or http.DefaultTransport when it's nil.
+func NewTestCycleTransport(t *Tracer, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &testCycleTransport{tracer: t, base: base}
+}
+
+func (tr *testCycleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.HasSuffix(req.URL.Path, "/traces") || req.Method != http.MethodPost {
+		return tr.base.RoundTrip(req)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	spans, err := decodeTraces(body)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the traces: %v", err)
+	}
+	if len(spans) > 0 {
+		if err := tr.tracer.sendTestCycle(spans); err != nil {
+			return nil, err
+		}
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(strings.NewReader(tracesResponse)),
+		ContentLength: int64(len(tracesResponse)),
+		Request:       req,
+	}, nil
+}
+
+// decodeTraces decodes the msgpack payload of the traces endpoint, an array of traces which are
+// arrays of spans.
+func decodeTraces(body []byte) ([]*span, error) {
+	v, err := decodeMsgpack(body)
+	if err != nil {
+		return nil, err
+	}
+	traces, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected payload %T", v)
+	}
+	var spans []*span
+	for _, trace := range traces {
+		trace, ok := trace.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected trace %T", trace)
+		}
+		for _, fields := range trace {
+			fields, ok := fields.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unexpected span %T", fields)
+			}
+			spans = append(spans, decodeSpan(fields))
+		}
+	}
+	return spans, nil
+}
+
+// decodeSpan returns the span of the decoded fields.
+func decodeSpan(fields map[string]interface{}) *span {
+	s := &span{Meta: map[string]string{}, Metrics: map[string]float64{}}
+	s.Name, _ = fields["name"].(string)
+	s.Service, _ = fields["service"].(string)
+	s.Resource, _ = fields["resource"].(string)
+	s.Type, _ = fields["type"].(string)
+	s.Start = int64(toUint64(fields["start"]))
+	s.Duration = int64(toUint64(fields["duration"]))
+	s.SpanID = toUint64(fields["span_id"])
+	s.TraceID = toUint64(fields["trace_id"])
+	s.ParentID = toUint64(fields["parent_id"])
+	s.Error = int32(toUint64(fields["error"]))
+	if meta, ok := fields["meta"].(map[string]interface{}); ok {
+		for k, v := range meta {
+			if v, ok := v.(string); ok {
+				s.Meta[k] = v
+			}
+		}
+	}
+	if metrics, ok := fields["metrics"].(map[string]interface{}); ok {
+		for k, v := range metrics {
+			switch v := v.(type) {
+			case float64:
+				s.Metrics[k] = v
+			case float32:
+				s.Metrics[k] = float64(v)
+			case int64:
+				s.Metrics[k] = float64(v)
+			case uint64:
+				s.Metrics[k] = float64(v)
+			}
+		}
+	}
+	return s
+}
+
+// toUint64 returns the decoded integer as an uint64.
+func toUint64(v interface{}) uint64 {
+	switch v := v.(type) {
+	case int64:
+		return uint64(v)
+	case uint64:
+		return v
+	case float64:
+		return uint64(v)
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package isolated
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTestCycleTransport(t *testing.T) {
+	var events []interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != evpProxyPath+testCyclePath {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		payload, err := decodeMsgpack(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = payload.(map[string]interface{})["events"].([]interface{})
+	}))
+	defer srv.Close()
+
+	// The payload of dd-trace-go: a trace with a test and a span of the code under test.
+	traces, err := encodeMsgpack([]interface{}{[]interface{}{
+		map[string]interface{}{
+			"name": "test", "service": "svc", "resource": "pkg.TestA", "type": "test",
+			"start": int64(1e18), "duration": int64(1e9), "span_id": uint64(1) << 62, "trace_id": uint64(1) << 62,
+			"parent_id": 0, "error": int32(1),
+			"meta":    map[string]string{"test.status": "fail", testSuiteIDTag: "42"},
+			"metrics": map[string]float64{"test.source.start": 12},
+		},
+		map[string]interface{}{
+			"name": "http.request", "service": "svc", "resource": "GET /", "type": "http",
+			"span_id": 2, "trace_id": uint64(1) << 62, "parent_id": uint64(1) << 62,
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := New(Config{URL: srv.URL, Transport: TransportEVPProxy})
+	client := &http.Client{Transport: NewTestCycleTransport(tr, nil)}
+	resp, err := client.Post("http://localhost:8126/v0.4/traces", "application/msgpack", bytes.NewReader(traces))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != tracesResponse {
+		t.Errorf("unexpected response %d %s", resp.StatusCode, body)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("unexpected events %v", events)
+	}
+	test := events[0].(map[string]interface{})
+	content := test["content"].(map[string]interface{})
+	meta := content["meta"].(map[string]interface{})
+	if test["type"] != "test" || content["span_id"] != uint64(1)<<62 || content["error"] != int64(1) ||
+		content[testSuiteIDTag] != int64(42) || meta["test.status"] != "fail" ||
+		content["metrics"].(map[string]interface{})["test.source.start"] != 12.0 {
+		t.Errorf("unexpected test event %v", test)
+	}
+	span := events[1].(map[string]interface{})
+	if span["type"] != "span" || span["content"].(map[string]interface{})["parent_id"] != uint64(1)<<62 {
+		t.Errorf("unexpected span event %v", span)
+	}
+
+	if _, err := client.Post("http://localhost:8126/v0.4/traces", "application/msgpack", bytes.NewReader([]byte{0x91})); err == nil {
+		t.Error("expected an error with a truncated payload")
+	}
+}
+
+func TestMsgpackDecoder(t *testing.T) {
+	for _, value := range []interface{}{
+		nil, true, int64(-100), int64(-70000), uint64(300), uint64(1) << 40, 1.5, "go",
+		[]interface{}{int64(1), "a"}, map[string]interface{}{"k": "v"},
+	} {
+		encoded, err := encodeMsgpack(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeMsgpack(encoded)
+		if err != nil {
+			t.Fatalf("%v: %v", value, err)
+		}
+		if again, _ := encodeMsgpack(got); !bytes.Equal(again, encoded) {
+			t.Errorf("%v: decoded as %v", value, got)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package logs forwards the logs emitted during the tests to the Datadog logs intake,
+// correlated with the test spans.
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxEntrySize is the default maximum size of the message of an entry.
+	DefaultMaxEntrySize = 16 * 1024
+
+	// DefaultMaxTotalSize is the default maximum size of the messages sent by a test binary.
+	DefaultMaxTotalSize = 8 * 1024 * 1024
+
+	// batchSize is the number of entries sent in a single request.
+	batchSize = 100
+)
+
+// Entry is a log entry emitted during a test.
+type Entry struct {
+	Time    time.Time
+	Status  string
+	Message string
+	TraceID uint64
+	SpanID  uint64
+	Logger  string
+}
+
+// Config configures a Forwarder.
+type Config struct {
+	// URL is the logs intake URL, by default the intake of DD_SITE.
+	URL string
+
+	APIKey   string
+	Service  string
+	Hostname string
+	Tags     string
+
+	// MaxEntrySize is the maximum size of a message, longer messages are truncated.
+	MaxEntrySize int
+
+	// MaxTotalSize is the maximum size of all the messages, the entries exceeding it are dropped.
+	MaxTotalSize int
+
+	Client *http.Client
+}
+
+// Forwarder sends log entries to the Datadog logs intake in batches.
+type Forwarder struct {
+	cfg Config
+
+	mu        sync.Mutex
+	batch     []payload
+	totalSize int
+	dropped   int
+	sending   sync.WaitGroup
+}
+
+// payload is a log entry in the format of the logs intake.
+type payload struct {
+	Timestamp int64  `json:"timestamp"`
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	Source    string `json:"ddsource"`
+	Service   string `json:"service,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+	Tags      string `json:"ddtags,omitempty"`
+	Logger    string `json:"logger.name,omitempty"`
+	TraceID   string `json:"dd.trace_id,omitempty"`
+	SpanID    string `json:"dd.span_id,omitempty"`
+}
+
+// NewForwarder returns a forwarder with the given configuration, using the defaults for the
+// empty fields.
+func NewForwarder(cfg Config) *Forwarder {
+	if cfg.URL == "" {
+		site := os.Getenv("DD_SITE")
+		if site == "" {
+			site = "datadoghq.com"
+		}
+		cfg.URL = fmt.Sprintf("https://http-intake.logs.%s/api/v2/logs", site)
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.MaxEntrySize <= 0 {
+		cfg.MaxEntrySize = DefaultMaxEntrySize
+	}
+	if cfg.MaxTotalSize <= 0 {
+		cfg.MaxTotalSize = DefaultMaxTotalSize
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Forwarder{cfg: cfg}
+}
+
+// Send queues an entry, sending the batch in background when it's full.
+func (f *Forwarder) Send(e Entry) {
+	message := e.Message
+	if len(message) > f.cfg.MaxEntrySize {
+		message = message[:f.cfg.MaxEntrySize] + "...(truncated)"
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Status == "" {
+		e.Status = "info"
+	}
+	p := payload{
+		Timestamp: e.Time.UnixNano() / int64(time.Millisecond),
+		Status:    e.Status,
+		Message:   message,
+		Source:    "go",
+		Service:   f.cfg.Service,
+		Hostname:  f.cfg.Hostname,
+		Tags:      f.cfg.Tags,
+		Logger:    e.Logger,
+	}
+	if e.TraceID != 0 {
+		p.TraceID = strconv.FormatUint(e.TraceID, 10)
+		p.SpanID = strconv.FormatUint(e.SpanID, 10)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.totalSize+len(message) > f.cfg.MaxTotalSize {
+		f.dropped++
+		return
+	}
+	f.totalSize += len(message)
+	f.batch = append(f.batch, p)
+	if len(f.batch) >= batchSize {
+		f.sendBatch()
+	}
+}
+
+// Flush sends the queued entries and waits for all the requests to finish.
+func (f *Forwarder) Flush() {
+	f.mu.Lock()
+	f.sendBatch()
+	f.mu.Unlock()
+	f.sending.Wait()
+}
+
+// Dropped returns the number of entries dropped because of the size limit.
+func (f *Forwarder) Dropped() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dropped
+}
+
+// sendBatch sends the queued entries in background, f.mu must be held.
+func (f *Forwarder) sendBatch() {
+	if len(f.batch) == 0 {
+		return
+	}
+	batch := f.batch
+	f.batch = nil
+	f.sending.Add(1)
+	go func() {
+		defer f.sending.Done()
+		if err := f.post(batch); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: sending %d log entries: %v\n", len(batch), err)
+		}
+	}()
+}
+
+func (f *Forwarder) post(batch []payload) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", f.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("DD-API-KEY", f.cfg.APIKey)
+	resp, err := f.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Status returns the status of the logs intake of a logger level, e.g. "warning" or "dpanic".
+func Status(level string) string {
+	switch strings.ToLower(level) {
+	case "trace", "debug":
+		return "debug"
+	case "warn", "warning":
+		return "warn"
+	case "error":
+		return "error"
+	case "dpanic", "panic", "fatal", "critical":
+		return "critical"
+	}
+	return "info"
+}
+
+var (
+	// forwarder is the forwarder of the test binary, nil when the logs aren't forwarded.
+	forwarder      *Forwarder
+	forwarderMutex sync.Mutex
+)
+
+// SetForwarder sets the forwarder used by Send.
+func SetForwarder(f *Forwarder) {
+	forwarderMutex.Lock()
+	defer forwarderMutex.Unlock()
+	forwarder = f
+}
+
+// Enabled returns whether the logs are forwarded.
+func Enabled() bool {
+	forwarderMutex.Lock()
+	defer forwarderMutex.Unlock()
+	return forwarder != nil
+}
+
+// Send sends an entry with the forwarder of the test binary, if any.
+func Send(e Entry) {
+	forwarderMutex.Lock()
+	f := forwarder
+	forwarderMutex.Unlock()
+	if f != nil {
+		f.Send(e)
+	}
+}
+
+// Flush flushes the forwarder of the test binary, if any.
+func Flush() {
+	forwarderMutex.Lock()
+	f := forwarder
+	forwarderMutex.Unlock()
+	if f != nil {
+		f.Flush()
+	}
+}
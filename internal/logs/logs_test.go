@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package logs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestForwarder(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "key" {
+			t.Errorf("unexpected API key: %s", r.Header.Get("DD-API-KEY"))
+		}
+		var batch []map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		received = append(received, batch...)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	f := NewForwarder(Config{URL: srv.URL, APIKey: "key", Service: "svc", MaxEntrySize: 8, MaxTotalSize: 30})
+	f.Send(Entry{Message: "short", TraceID: 1, SpanID: 2})
+	f.Send(Entry{Message: "a very long message", Status: "error"})
+	f.Send(Entry{Message: "dropped"})
+	f.Flush()
+
+	if len(received) != 2 {
+		t.Fatalf("unexpected number of entries: %d", len(received))
+	}
+	if received[0]["message"] != "short" || received[0]["dd.trace_id"] != "1" || received[0]["dd.span_id"] != "2" ||
+		received[0]["service"] != "svc" || received[0]["status"] != "info" {
+		t.Errorf("unexpected entry: %v", received[0])
+	}
+	if msg, _ := received[1]["message"].(string); !strings.HasPrefix(msg, "a very l") || !strings.HasSuffix(msg, "(truncated)") {
+		t.Errorf("unexpected truncated message: %s", msg)
+	}
+	if _, ok := received[1]["dd.trace_id"]; ok {
+		t.Errorf("unexpected trace ID: %v", received[1])
+	}
+	if f.Dropped() != 1 {
+		t.Errorf("unexpected dropped entries: %d", f.Dropped())
+	}
+}
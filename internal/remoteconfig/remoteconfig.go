@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package remoteconfig fetches the Remote Configuration files of a product through the
+// Datadog Agent. The agent verifies the signatures of the configurations it receives from
+// Datadog, so the files returned by its /v0.7/config endpoint are trusted as is.
+package remoteconfig
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// configPath is the path of the Remote Configuration endpoint of the agent.
+const configPath = "/v0.7/config"
+
+// ClientInfo describes the client requesting the configurations, the agent uses the service
+// and the environment to select them.
+type ClientInfo struct {
+	Service string
+	Env     string
+}
+
+// Client fetches Remote Configuration files from the agent.
+type Client struct {
+	// URL is the base URL of the agent, like http://localhost:8126.
+	URL string
+
+	HTTPClient *http.Client
+
+	id string
+}
+
+// NewClient returns a client sending the requests to the agent at url.
+func NewClient(url string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 2 * time.Second}
+	}
+	return &Client{URL: strings.TrimSuffix(url, "/"), HTTPClient: httpClient, id: randomID()}
+}
+
+// request is the body of a request to the Remote Configuration endpoint.
+type request struct {
+	Client struct {
+		ID           string   `json:"id"`
+		Products     []string `json:"products"`
+		IsTracer     bool     `json:"is_tracer"`
+		ClientTracer struct {
+			RuntimeID string `json:"runtime_id"`
+			Language  string `json:"language"`
+			Service   string `json:"service"`
+			Env       string `json:"env"`
+		} `json:"client_tracer"`
+		State struct {
+			RootVersion    int `json:"root_version"`
+			TargetsVersion int `json:"targets_version"`
+		} `json:"state"`
+	} `json:"client"`
+	CachedTargetFiles []interface{} `json:"cached_target_files"`
+}
+
+// response is the body of a response of the Remote Configuration endpoint.
+type response struct {
+	TargetFiles []struct {
+		Path string `json:"path"`
+		Raw  []byte `json:"raw"`
+	} `json:"target_files"`
+	ClientConfigs []string `json:"client_configs"`
+}
+
+// Fetch returns the content of the configuration files of the product assigned to the client,
+// by path. An agent without Remote Configuration support returns no files.
+func (c *Client) Fetch(product string, info ClientInfo) (map[string][]byte, error) {
+	var req request
+	req.Client.ID = c.id
+	req.Client.Products = []string{product}
+	req.Client.IsTracer = true
+	req.Client.ClientTracer.RuntimeID = c.id
+	req.Client.ClientTracer.Language = "go"
+	req.Client.ClientTracer.Service = info.Service
+	req.Client.ClientTracer.Env = info.Env
+	req.Client.State.RootVersion = 1
+	req.CachedTargetFiles = []interface{}{}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTPClient.Post(c.URL+configPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	case len(bytes.TrimSpace(data)) == 0:
+		return nil, nil
+	}
+
+	var r response
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("decoding the response: %v", err)
+	}
+	assigned := make(map[string]bool, len(r.ClientConfigs))
+	for _, path := range r.ClientConfigs {
+		assigned[path] = true
+	}
+	files := map[string][]byte{}
+	for _, f := range r.TargetFiles {
+		if assigned[f.Path] && productOf(f.Path) == product {
+			files[f.Path] = f.Raw
+		}
+	}
+	return files, nil
+}
+
+// productOf returns the product of a configuration path, like
+// datadog/<org_id>/<product>/<config_id>/<name> or employee/<product>/<config_id>/<name>.
+func productOf(path string) string {
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 5 && parts[0] == "datadog":
+		return parts[2]
+	case len(parts) == 4 && parts[0] == "employee":
+		return parts[1]
+	}
+	return ""
+}
+
+// randomID returns a random identifier of the client.
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package remoteconfig
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFetch(t *testing.T) {
+	var received request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != configPath {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"target_files": []map[string]interface{}{
+				{"path": "datadog/2/CI_VISIBILITY/a/config", "raw": []byte(`{"a":1}`)},
+				{"path": "datadog/2/CI_VISIBILITY/b/config", "raw": []byte(`{"b":1}`)},
+				{"path": "datadog/2/ASM_DD/c/config", "raw": []byte(`{"c":1}`)},
+			},
+			"client_configs": []string{"datadog/2/CI_VISIBILITY/a/config", "datadog/2/ASM_DD/c/config"},
+		})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL+"/", nil)
+	files, err := c.Fetch("CI_VISIBILITY", ClientInfo{Service: "svc", Env: "ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]byte{"datadog/2/CI_VISIBILITY/a/config": []byte(`{"a":1}`)}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %q, got %q", expected, files)
+	}
+	if received.Client.Products[0] != "CI_VISIBILITY" || received.Client.ClientTracer.Service != "svc" ||
+		received.Client.ClientTracer.Env != "ci" || !received.Client.IsTracer {
+		t.Errorf("unexpected request %+v", received)
+	}
+}
+
+func TestFetchUnsupported(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	files, err := NewClient(srv.URL, nil).Fetch("CI_VISIBILITY", ClientInfo{})
+	if err != nil || len(files) != 0 {
+		t.Errorf("expected no files and no error, got %v, %v", files, err)
+	}
+}
+
+func TestFetchError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	if _, err := NewClient(srv.URL, nil).Fetch("CI_VISIBILITY", ClientInfo{}); err == nil {
+		t.Error("expected an error")
+	}
+}
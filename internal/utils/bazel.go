@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+// bazelStatusKeys maps the keys of the stamped workspace status to tags. The STABLE_ keys are
+// the ones usually written by a workspace status command, the BUILD_SCM_ keys are the ones
+// written by rules_go and other common scripts.
+var bazelStatusKeys = map[string][]string{
+	constants.GitRepositoryURL:     {"STABLE_GIT_REPOSITORY_URL", "BUILD_SCM_REMOTE"},
+	constants.GitBranch:            {"STABLE_GIT_BRANCH", "BUILD_SCM_BRANCH"},
+	constants.GitTag:               {"STABLE_GIT_TAG", "BUILD_SCM_TAG"},
+	constants.GitCommitSHA:         {"STABLE_GIT_COMMIT", "BUILD_SCM_REVISION"},
+	constants.GitCommitMessage:     {"STABLE_GIT_COMMIT_MESSAGE"},
+	constants.GitCommitAuthorName:  {"STABLE_GIT_COMMIT_AUTHOR_NAME"},
+	constants.GitCommitAuthorEmail: {"STABLE_GIT_COMMIT_AUTHOR_EMAIL"},
+	constants.GitCommitAuthorDate:  {"STABLE_GIT_COMMIT_AUTHOR_DATE"},
+	constants.CIWorkspacePath:      {"STABLE_WORKSPACE_PATH", "BUILD_WORKSPACE_DIRECTORY"},
+}
+
+// IsBazel returns whether the tests are being run by `bazel test`.
+func IsBazel() bool {
+	return isBazel(os.LookupEnv)
+}
+
+func isBazel(env EnvLookup) bool {
+	_, ok := env("TEST_TARGET")
+	return ok
+}
+
+// GetBazelTags extracts the target and shard of a Bazel test run, and the Git metadata of the
+// stamped workspace status files listed in DD_BAZEL_STATUS_FILES, as the sandbox hides the
+// Git repository from the tests.
+func GetBazelTags() map[string]string {
+	return GetBazelTagsFrom(os.LookupEnv)
+}
+
+// GetBazelTagsFrom extracts the Bazel tags from the environment variables looked up by env.
+func GetBazelTagsFrom(env EnvLookup) map[string]string {
+	tags := map[string]string{}
+	if !isBazel(env) {
+		return tags
+	}
+
+	tags[constants.BazelTarget] = getenv(env, "TEST_TARGET")
+	if total := getenv(env, "TEST_TOTAL_SHARDS"); total != "" {
+		tags[constants.BazelShardIndex] = getenv(env, "TEST_SHARD_INDEX")
+		tags[constants.BazelShardTotal] = total
+	}
+
+	status := map[string]string{}
+	for _, path := range filepath.SplitList(getenv(env, "DD_BAZEL_STATUS_FILES")) {
+		readBazelStatus(path, status)
+	}
+	for tag, keys := range bazelStatusKeys {
+		for _, key := range keys {
+			if value := status[key]; value != "" {
+				tags[tag] = value
+				break
+			}
+		}
+	}
+	normalizeTags(tags)
+
+	// remove empty values
+	for tag, value := range tags {
+		if value == "" {
+			delete(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// readBazelStatus reads the "KEY value" lines of a workspace status file.
+func readBazelStatus(path string, status map[string]string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.IndexByte(line, ' '); idx > 0 {
+			status[line[:idx]] = strings.TrimSpace(line[idx+1:])
+		}
+	}
+}
+
+// GetArtifactsPath returns the path where a local artifact should be written. Relative paths
+// are resolved in the undeclared outputs directory when running under Bazel, so the artifacts
+// are kept after the sandbox is removed.
+func GetArtifactsPath(path string) string {
+	if dir := os.Getenv("TEST_UNDECLARED_OUTPUTS_DIR"); dir != "" && !filepath.IsAbs(path) {
+		return filepath.Join(dir, path)
+	}
+	return path
+}
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+func TestGetBazelTags(t *testing.T) {
+	restore := setEnvs(map[string]string{
+		"TEST_TARGET":       "//pkg/server:server_test",
+		"TEST_SHARD_INDEX":  "1",
+		"TEST_TOTAL_SHARDS": "4",
+		"DD_BAZEL_STATUS_FILES": filepath.Join("testdata", "bazel", "stable-status.txt") +
+			string(os.PathListSeparator) + filepath.Join("testdata", "bazel", "volatile-status.txt"),
+	})
+	defer restore()
+
+	expected := map[string]string{
+		constants.BazelTarget:      "//pkg/server:server_test",
+		constants.BazelShardIndex:  "1",
+		constants.BazelShardTotal:  "4",
+		constants.GitRepositoryURL: "https://github.com/DataDog/dd-sdk-go-testing.git",
+		constants.GitBranch:        "main",
+		constants.GitCommitSHA:     "0123456789abcdef0123456789abcdef01234567",
+		constants.GitCommitMessage: "Fix the build",
+	}
+	tags := GetBazelTags()
+	if len(tags) != len(expected) {
+		t.Errorf("expected %d tags, got %d: %v", len(expected), len(tags), tags)
+	}
+	for key, value := range expected {
+		if tags[key] != value {
+			t.Errorf("%s: expected %q, got %q", key, value, tags[key])
+		}
+	}
+}
+
+func TestGetArtifactsPath(t *testing.T) {
+	restore := setEnvs(map[string]string{"TEST_UNDECLARED_OUTPUTS_DIR": "/outputs"})
+	defer restore()
+
+	if path := GetArtifactsPath("allure-results"); path != filepath.Join("/outputs", "allure-results") {
+		t.Errorf("unexpected relative path: %s", path)
+	}
+	if path := GetArtifactsPath("/tmp/allure-results"); path != "/tmp/allure-results" {
+		t.Errorf("unexpected absolute path: %s", path)
+	}
+
+	os.Unsetenv("TEST_UNDECLARED_OUTPUTS_DIR")
+	if path := GetArtifactsPath("allure-results"); path != "allure-results" {
+		t.Errorf("unexpected path outside of Bazel: %s", path)
+	}
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// envMatrix is the environment variable with the variant of the matrix job, as a JSON
+	// object like the toJSON(matrix) expression of GitHub Actions, or as key=value pairs.
+	envMatrix = "DD_CIVISIBILITY_MATRIX"
+
+	// envMatrixVars is the environment variable listing the variables of the matrix job, like
+	// the variables of the parallel:matrix keyword of GitLab.
+	envMatrixVars = "DD_CIVISIBILITY_MATRIX_VARS"
+)
+
+// gitlabMatrixJobRegexp matches the names of the jobs of GitLab matrices, like "test: [1.22, windows]".
+var gitlabMatrixJobRegexp = regexp.MustCompile(`^.+: \[(.+)\]$`)
+
+// GetMatrix returns the variant of the matrix job running the tests, from DD_CIVISIBILITY_MATRIX,
+// from the variables listed by DD_CIVISIBILITY_MATRIX_VARS, or from the values in the name of the
+// job of a GitLab matrix, keyed by their position. It returns nil outside of matrix jobs.
+func GetMatrix() map[string]string {
+	return GetMatrixFrom(os.LookupEnv)
+}
+
+// GetMatrixFrom returns the variant of the matrix job from the environment variables looked up
+// by env.
+func GetMatrixFrom(env EnvLookup) map[string]string {
+	if v := getenv(env, envMatrix); v != "" {
+		return ParseMatrix(v)
+	}
+	if v := getenv(env, envMatrixVars); v != "" {
+		matrix := map[string]string{}
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				matrix[name] = getenv(env, name)
+			}
+		}
+		return matrix
+	}
+	if _, ok := env("GITLAB_CI"); ok {
+		if m := gitlabMatrixJobRegexp.FindStringSubmatch(getenv(env, "CI_JOB_NAME")); m != nil {
+			matrix := map[string]string{}
+			for i, value := range strings.Split(m[1], ",") {
+				matrix[strconv.Itoa(i)] = strings.TrimSpace(value)
+			}
+			return matrix
+		}
+	}
+	return nil
+}
+
+// ParseMatrix parses the variant of a matrix job, given as a JSON object or as comma-separated
+// key=value pairs. The values that aren't strings are kept in JSON.
+func ParseMatrix(value string) map[string]string {
+	matrix := map[string]string{}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &object); err == nil {
+		for k, raw := range object {
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				matrix[k] = s
+			} else {
+				var compacted bytes.Buffer
+				json.Compact(&compacted, raw)
+				matrix[k] = compacted.String()
+			}
+		}
+		return matrix
+	}
+	for _, pair := range strings.Split(value, ",") {
+		if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 && strings.TrimSpace(kv[0]) != "" {
+			matrix[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return matrix
+}
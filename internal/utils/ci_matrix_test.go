@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseMatrix(t *testing.T) {
+	for value, expected := range map[string]map[string]string{
+		`{"go": "1.22", "os": "windows"}`:                     {"go": "1.22", "os": "windows"},
+		"{\n  \"go\": 1.22,\n  \"db\": {\"name\": \"pg\"}\n}": {"go": "1.22", "db": `{"name":"pg"}`},
+		"go=1.22, os = windows":                               {"go": "1.22", "os": "windows"},
+		"invalid":                                             {},
+	} {
+		if matrix := ParseMatrix(value); !reflect.DeepEqual(matrix, expected) {
+			t.Errorf("%q: expected %v, got %v", value, expected, matrix)
+		}
+	}
+}
+
+func TestGetMatrix(t *testing.T) {
+	for _, name := range []string{envMatrix, envMatrixVars, "GITLAB_CI", "CI_JOB_NAME", "GO_VERSION"} {
+		if value, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, value)
+		} else {
+			defer os.Unsetenv(name)
+		}
+		os.Unsetenv(name)
+	}
+
+	if matrix := GetMatrix(); matrix != nil {
+		t.Errorf("unexpected matrix outside of matrix jobs: %v", matrix)
+	}
+
+	os.Setenv("GITLAB_CI", "true")
+	os.Setenv("CI_JOB_NAME", "test: [1.22, windows]")
+	if matrix := GetMatrix(); !reflect.DeepEqual(matrix, map[string]string{"0": "1.22", "1": "windows"}) {
+		t.Errorf("unexpected matrix of the GitLab job: %v", matrix)
+	}
+
+	os.Setenv("GO_VERSION", "1.21")
+	os.Setenv(envMatrixVars, "GO_VERSION, OS")
+	if matrix := GetMatrix(); !reflect.DeepEqual(matrix, map[string]string{"GO_VERSION": "1.21", "OS": ""}) {
+		t.Errorf("unexpected matrix of the variables: %v", matrix)
+	}
+
+	os.Setenv(envMatrix, `{"go":"1.22"}`)
+	if matrix := GetMatrix(); !reflect.DeepEqual(matrix, map[string]string{"go": "1.22"}) {
+		t.Errorf("unexpected matrix of %s: %v", envMatrix, matrix)
+	}
+}
@@ -6,6 +6,7 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"regexp"
@@ -15,7 +16,11 @@ import (
 	"github.com/mitchellh/go-homedir"
 )
 
-type providerType = func() map[string]string
+// EnvLookup looks up an environment variable like os.LookupEnv, so the tags of fabricated
+// environments can be computed without replacing the environment of the process.
+type EnvLookup = func(key string) (string, bool)
+
+type providerType = func(env EnvLookup) map[string]string
 
 var providers = map[string]providerType{
 	"APPVEYOR":           extractAppveyor,
@@ -33,16 +38,21 @@ var providers = map[string]providerType{
 
 // GetProviderTags extracts CI information from environment variables.
 func GetProviderTags() map[string]string {
+	return GetProviderTagsFrom(os.LookupEnv)
+}
+
+// GetProviderTagsFrom extracts CI information from the environment variables looked up by env.
+func GetProviderTagsFrom(env EnvLookup) map[string]string {
 	tags := map[string]string{}
 	for key, provider := range providers {
-		if _, ok := os.LookupEnv(key); !ok {
+		if _, ok := env(key); !ok {
 			continue
 		}
-		tags = provider()
+		tags = provider(env)
 	}
 
 	// replace with user specific tags
-	replaceWithUserSpecificTags(tags)
+	replaceWithUserSpecificTags(tags, env)
 
 	// Normalize tags
 	normalizeTags(tags)
@@ -65,6 +75,30 @@ func GetProviderTags() map[string]string {
 	return tags
 }
 
+// GetEnvironmentTags returns the CI and Git tags defined by the environment: the tags of the CI
+// provider and the user specific ones, completed under Bazel by the stamped workspace status, and
+// the variant of the matrix job.
+func GetEnvironmentTags() map[string]string {
+	return GetEnvironmentTagsFrom(os.LookupEnv)
+}
+
+// GetEnvironmentTagsFrom returns the CI and Git tags defined by the environment variables looked
+// up by env.
+func GetEnvironmentTagsFrom(env EnvLookup) map[string]string {
+	tags := GetProviderTagsFrom(env)
+	for k, v := range GetBazelTagsFrom(env) {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+	if matrix := GetMatrixFrom(env); len(matrix) > 0 {
+		if encoded, err := json.Marshal(matrix); err == nil {
+			tags[constants.CIMatrix] = string(encoded)
+		}
+	}
+	return tags
+}
+
 func normalizeTags(tags map[string]string) {
 	if tag, ok := tags[constants.GitBranch]; ok && tag != "" {
 		if strings.Contains(tag, "refs/tags") || strings.Contains(tag, "origin/tags") || strings.Contains(tag, "refs/heads/tags") {
@@ -81,10 +115,10 @@ func normalizeTags(tags map[string]string) {
 	}
 }
 
-func replaceWithUserSpecificTags(tags map[string]string) {
+func replaceWithUserSpecificTags(tags map[string]string, env EnvLookup) {
 
 	replace := func(tagName, envName string) {
-		tags[tagName] = getEnvironmentVariableIfIsNotEmpty(envName, tags[tagName])
+		tags[tagName] = getEnvironmentVariableIfIsNotEmpty(env, envName, tags[tagName])
 	}
 
 	replace(constants.GitBranch, "DD_GIT_BRANCH")
@@ -100,8 +134,8 @@ func replaceWithUserSpecificTags(tags map[string]string) {
 	replace(constants.GitCommitCommitterDate, "DD_GIT_COMMIT_COMMITTER_DATE")
 }
 
-func getEnvironmentVariableIfIsNotEmpty(key string, defaultValue string) string {
-	if value, ok := os.LookupEnv(key); ok && value != "" {
+func getEnvironmentVariableIfIsNotEmpty(env EnvLookup, key string, defaultValue string) string {
+	if value, ok := env(key); ok && value != "" {
 		return value
 	} else {
 		return defaultValue
@@ -120,6 +154,12 @@ func filterSensitiveInfo(url string) string {
 	return string(regexp.MustCompile("(https?://)[^/]*@").ReplaceAll([]byte(url), []byte("$1"))[:])
 }
 
+// getenv returns the value of the environment variable looked up by env, empty when it isn't set.
+func getenv(env EnvLookup, key string) string {
+	value, _ := env(key)
+	return value
+}
+
 func lookupEnvs(keys ...string) ([]string, bool) {
 	values := make([]string, len(keys))
 	for _, key := range keys {
@@ -132,9 +172,9 @@ func lookupEnvs(keys ...string) ([]string, bool) {
 	return values, true
 }
 
-func firstEnv(keys ...string) string {
+func firstEnv(env EnvLookup, keys ...string) string {
 	for _, key := range keys {
-		if value, ok := os.LookupEnv(key); ok {
+		if value, ok := env(key); ok {
 			if value != "" {
 				return value
 			}
@@ -143,38 +183,38 @@ func firstEnv(keys ...string) string {
 	return ""
 }
 
-func extractAppveyor() map[string]string {
+func extractAppveyor(env EnvLookup) map[string]string {
 	tags := map[string]string{}
-	url := fmt.Sprintf("https://ci.appveyor.com/project/%s/builds/%s", os.Getenv("APPVEYOR_REPO_NAME"), os.Getenv("APPVEYOR_BUILD_ID"))
+	url := fmt.Sprintf("https://ci.appveyor.com/project/%s/builds/%s", getenv(env, "APPVEYOR_REPO_NAME"), getenv(env, "APPVEYOR_BUILD_ID"))
 	tags[constants.CIProviderName] = "appveyor"
-	if os.Getenv("APPVEYOR_REPO_PROVIDER") == "github" {
-		tags[constants.GitRepositoryURL] = fmt.Sprintf("https://github.com/%s.git", os.Getenv("APPVEYOR_REPO_NAME"))
+	if getenv(env, "APPVEYOR_REPO_PROVIDER") == "github" {
+		tags[constants.GitRepositoryURL] = fmt.Sprintf("https://github.com/%s.git", getenv(env, "APPVEYOR_REPO_NAME"))
 	} else {
-		tags[constants.GitRepositoryURL] = os.Getenv("APPVEYOR_REPO_NAME")
+		tags[constants.GitRepositoryURL] = getenv(env, "APPVEYOR_REPO_NAME")
 	}
 
-	tags[constants.GitCommitSHA] = os.Getenv("APPVEYOR_REPO_COMMIT")
-	tags[constants.GitBranch] = firstEnv("APPVEYOR_PULL_REQUEST_HEAD_REPO_BRANCH", "APPVEYOR_REPO_BRANCH")
-	tags[constants.GitTag] = os.Getenv("APPVEYOR_REPO_TAG_NAME")
+	tags[constants.GitCommitSHA] = getenv(env, "APPVEYOR_REPO_COMMIT")
+	tags[constants.GitBranch] = firstEnv(env, "APPVEYOR_PULL_REQUEST_HEAD_REPO_BRANCH", "APPVEYOR_REPO_BRANCH")
+	tags[constants.GitTag] = getenv(env, "APPVEYOR_REPO_TAG_NAME")
 
-	tags[constants.CIWorkspacePath] = os.Getenv("APPVEYOR_BUILD_FOLDER")
-	tags[constants.CIPipelineID] = os.Getenv("APPVEYOR_BUILD_ID")
-	tags[constants.CIPipelineName] = os.Getenv("APPVEYOR_REPO_NAME")
-	tags[constants.CIPipelineNumber] = os.Getenv("APPVEYOR_BUILD_NUMBER")
+	tags[constants.CIWorkspacePath] = getenv(env, "APPVEYOR_BUILD_FOLDER")
+	tags[constants.CIPipelineID] = getenv(env, "APPVEYOR_BUILD_ID")
+	tags[constants.CIPipelineName] = getenv(env, "APPVEYOR_REPO_NAME")
+	tags[constants.CIPipelineNumber] = getenv(env, "APPVEYOR_BUILD_NUMBER")
 	tags[constants.CIPipelineURL] = url
 	tags[constants.CIJobURL] = url
-	tags[constants.GitCommitMessage] = os.Getenv("APPVEYOR_REPO_COMMIT_MESSAGE_EXTENDED")
-	tags[constants.GitCommitAuthorName] = os.Getenv("APPVEYOR_REPO_COMMIT_AUTHOR")
-	tags[constants.GitCommitAuthorEmail] = os.Getenv("APPVEYOR_REPO_COMMIT_AUTHOR_EMAIL")
+	tags[constants.GitCommitMessage] = getenv(env, "APPVEYOR_REPO_COMMIT_MESSAGE_EXTENDED")
+	tags[constants.GitCommitAuthorName] = getenv(env, "APPVEYOR_REPO_COMMIT_AUTHOR")
+	tags[constants.GitCommitAuthorEmail] = getenv(env, "APPVEYOR_REPO_COMMIT_AUTHOR_EMAIL")
 	return tags
 }
 
-func extractAzurePipelines() map[string]string {
+func extractAzurePipelines(env EnvLookup) map[string]string {
 	tags := map[string]string{}
-	baseURL := fmt.Sprintf("%s%s/_build/results?buildId=%s", os.Getenv("SYSTEM_TEAMFOUNDATIONSERVERURI"), os.Getenv("SYSTEM_TEAMPROJECTID"), os.Getenv("BUILD_BUILDID"))
+	baseURL := fmt.Sprintf("%s%s/_build/results?buildId=%s", getenv(env, "SYSTEM_TEAMFOUNDATIONSERVERURI"), getenv(env, "SYSTEM_TEAMPROJECTID"), getenv(env, "BUILD_BUILDID"))
 	pipelineURL := baseURL
-	jobURL := fmt.Sprintf("%s&view=logs&j=%s&t=%s", baseURL, os.Getenv("SYSTEM_JOBID"), os.Getenv("SYSTEM_TASKINSTANCEID"))
-	branchOrTag := firstEnv("SYSTEM_PULLREQUEST_SOURCEBRANCH", "BUILD_SOURCEBRANCH", "BUILD_SOURCEBRANCHNAME")
+	jobURL := fmt.Sprintf("%s&view=logs&j=%s&t=%s", baseURL, getenv(env, "SYSTEM_JOBID"), getenv(env, "SYSTEM_TASKINSTANCEID"))
+	branchOrTag := firstEnv(env, "SYSTEM_PULLREQUEST_SOURCEBRANCH", "BUILD_SOURCEBRANCH", "BUILD_SOURCEBRANCHNAME")
 	branch := ""
 	tag := ""
 	if strings.Contains(branchOrTag, "tags/") {
@@ -183,100 +223,100 @@ func extractAzurePipelines() map[string]string {
 		branch = branchOrTag
 	}
 	tags[constants.CIProviderName] = "azurepipelines"
-	tags[constants.CIWorkspacePath] = os.Getenv("BUILD_SOURCESDIRECTORY")
+	tags[constants.CIWorkspacePath] = getenv(env, "BUILD_SOURCESDIRECTORY")
 
-	tags[constants.CIPipelineID] = os.Getenv("BUILD_BUILDID")
-	tags[constants.CIPipelineName] = os.Getenv("BUILD_DEFINITIONNAME")
-	tags[constants.CIPipelineNumber] = os.Getenv("BUILD_BUILDID")
+	tags[constants.CIPipelineID] = getenv(env, "BUILD_BUILDID")
+	tags[constants.CIPipelineName] = getenv(env, "BUILD_DEFINITIONNAME")
+	tags[constants.CIPipelineNumber] = getenv(env, "BUILD_BUILDID")
 	tags[constants.CIPipelineURL] = pipelineURL
 
-	tags[constants.CIStageName] = os.Getenv("SYSTEM_STAGEDISPLAYNAME")
+	tags[constants.CIStageName] = getenv(env, "SYSTEM_STAGEDISPLAYNAME")
 
-	tags[constants.CIJobName] = os.Getenv("SYSTEM_JOBDISPLAYNAME")
+	tags[constants.CIJobName] = getenv(env, "SYSTEM_JOBDISPLAYNAME")
 	tags[constants.CIJobURL] = jobURL
 
-	tags[constants.GitRepositoryURL] = firstEnv("SYSTEM_PULLREQUEST_SOURCEREPOSITORYURI", "BUILD_REPOSITORY_URI")
-	tags[constants.GitCommitSHA] = firstEnv("SYSTEM_PULLREQUEST_SOURCECOMMITID", "BUILD_SOURCEVERSION")
+	tags[constants.GitRepositoryURL] = firstEnv(env, "SYSTEM_PULLREQUEST_SOURCEREPOSITORYURI", "BUILD_REPOSITORY_URI")
+	tags[constants.GitCommitSHA] = firstEnv(env, "SYSTEM_PULLREQUEST_SOURCECOMMITID", "BUILD_SOURCEVERSION")
 	tags[constants.GitBranch] = branch
 	tags[constants.GitTag] = tag
-	tags[constants.GitCommitMessage] = os.Getenv("BUILD_SOURCEVERSIONMESSAGE")
-	tags[constants.GitCommitAuthorName] = os.Getenv("BUILD_REQUESTEDFORID")
-	tags[constants.GitCommitAuthorEmail] = os.Getenv("BUILD_REQUESTEDFOREMAIL")
+	tags[constants.GitCommitMessage] = getenv(env, "BUILD_SOURCEVERSIONMESSAGE")
+	tags[constants.GitCommitAuthorName] = getenv(env, "BUILD_REQUESTEDFORID")
+	tags[constants.GitCommitAuthorEmail] = getenv(env, "BUILD_REQUESTEDFOREMAIL")
 	return tags
 }
 
-func extractBitrise() map[string]string {
+func extractBitrise(env EnvLookup) map[string]string {
 	tags := map[string]string{}
 	tags[constants.CIProviderName] = "bitrise"
-	tags[constants.GitRepositoryURL] = os.Getenv("GIT_REPOSITORY_URL")
-	tags[constants.GitCommitSHA] = firstEnv("BITRISE_GIT_COMMIT", "GIT_CLONE_COMMIT_HASH")
-	tags[constants.GitBranch] = firstEnv("BITRISEIO_GIT_BRANCH_DEST", "BITRISE_GIT_BRANCH")
-	tags[constants.GitTag] = os.Getenv("BITRISE_GIT_TAG")
-	tags[constants.CIWorkspacePath] = os.Getenv("BITRISE_SOURCE_DIR")
-	tags[constants.CIPipelineID] = os.Getenv("BITRISE_BUILD_SLUG")
-	tags[constants.CIPipelineName] = os.Getenv("BITRISE_TRIGGERED_WORKFLOW_ID")
-	tags[constants.CIPipelineNumber] = os.Getenv("BITRISE_BUILD_NUMBER")
-	tags[constants.CIPipelineURL] = os.Getenv("BITRISE_BUILD_URL")
-	tags[constants.GitCommitMessage] = os.Getenv("BITRISE_GIT_MESSAGE")
+	tags[constants.GitRepositoryURL] = getenv(env, "GIT_REPOSITORY_URL")
+	tags[constants.GitCommitSHA] = firstEnv(env, "BITRISE_GIT_COMMIT", "GIT_CLONE_COMMIT_HASH")
+	tags[constants.GitBranch] = firstEnv(env, "BITRISEIO_GIT_BRANCH_DEST", "BITRISE_GIT_BRANCH")
+	tags[constants.GitTag] = getenv(env, "BITRISE_GIT_TAG")
+	tags[constants.CIWorkspacePath] = getenv(env, "BITRISE_SOURCE_DIR")
+	tags[constants.CIPipelineID] = getenv(env, "BITRISE_BUILD_SLUG")
+	tags[constants.CIPipelineName] = getenv(env, "BITRISE_TRIGGERED_WORKFLOW_ID")
+	tags[constants.CIPipelineNumber] = getenv(env, "BITRISE_BUILD_NUMBER")
+	tags[constants.CIPipelineURL] = getenv(env, "BITRISE_BUILD_URL")
+	tags[constants.GitCommitMessage] = getenv(env, "BITRISE_GIT_MESSAGE")
 	return tags
 }
 
-func extractBitbucket() map[string]string {
+func extractBitbucket(env EnvLookup) map[string]string {
 	tags := map[string]string{}
-	url := fmt.Sprintf("https://bitbucket.org/%s/addon/pipelines/home#!/results/%s", os.Getenv("BITBUCKET_REPO_FULL_NAME"), os.Getenv("BITBUCKET_BUILD_NUMBER"))
+	url := fmt.Sprintf("https://bitbucket.org/%s/addon/pipelines/home#!/results/%s", getenv(env, "BITBUCKET_REPO_FULL_NAME"), getenv(env, "BITBUCKET_BUILD_NUMBER"))
 	tags[constants.CIProviderName] = "bitbucket"
-	tags[constants.GitRepositoryURL] = os.Getenv("BITBUCKET_GIT_SSH_ORIGIN")
-	tags[constants.GitCommitSHA] = os.Getenv("BITBUCKET_COMMIT")
-	tags[constants.GitBranch] = os.Getenv("BITBUCKET_BRANCH")
-	tags[constants.GitTag] = os.Getenv("BITBUCKET_TAG")
-	tags[constants.CIWorkspacePath] = os.Getenv("BITBUCKET_CLONE_DIR")
-	tags[constants.CIPipelineID] = strings.Trim(os.Getenv("BITBUCKET_PIPELINE_UUID"), "{}")
-	tags[constants.CIPipelineNumber] = os.Getenv("BITBUCKET_BUILD_NUMBER")
-	tags[constants.CIPipelineName] = os.Getenv("BITBUCKET_REPO_FULL_NAME")
+	tags[constants.GitRepositoryURL] = getenv(env, "BITBUCKET_GIT_SSH_ORIGIN")
+	tags[constants.GitCommitSHA] = getenv(env, "BITBUCKET_COMMIT")
+	tags[constants.GitBranch] = getenv(env, "BITBUCKET_BRANCH")
+	tags[constants.GitTag] = getenv(env, "BITBUCKET_TAG")
+	tags[constants.CIWorkspacePath] = getenv(env, "BITBUCKET_CLONE_DIR")
+	tags[constants.CIPipelineID] = strings.Trim(getenv(env, "BITBUCKET_PIPELINE_UUID"), "{}")
+	tags[constants.CIPipelineNumber] = getenv(env, "BITBUCKET_BUILD_NUMBER")
+	tags[constants.CIPipelineName] = getenv(env, "BITBUCKET_REPO_FULL_NAME")
 	tags[constants.CIPipelineURL] = url
 	tags[constants.CIJobURL] = url
 	return tags
 }
 
-func extractBuildkite() map[string]string {
+func extractBuildkite(env EnvLookup) map[string]string {
 	tags := map[string]string{}
-	tags[constants.GitBranch] = os.Getenv("BUILDKITE_BRANCH")
-	tags[constants.GitCommitSHA] = os.Getenv("BUILDKITE_COMMIT")
-	tags[constants.GitRepositoryURL] = os.Getenv("BUILDKITE_REPO")
-	tags[constants.GitTag] = os.Getenv("BUILDKITE_TAG")
-	tags[constants.CIPipelineID] = os.Getenv("BUILDKITE_BUILD_ID")
-	tags[constants.CIPipelineName] = os.Getenv("BUILDKITE_PIPELINE_SLUG")
-	tags[constants.CIPipelineNumber] = os.Getenv("BUILDKITE_BUILD_NUMBER")
-	tags[constants.CIPipelineURL] = os.Getenv("BUILDKITE_BUILD_URL")
-	tags[constants.CIJobURL] = fmt.Sprintf("%s#%s", os.Getenv("BUILDKITE_BUILD_URL"), os.Getenv("BUILDKITE_JOB_ID"))
+	tags[constants.GitBranch] = getenv(env, "BUILDKITE_BRANCH")
+	tags[constants.GitCommitSHA] = getenv(env, "BUILDKITE_COMMIT")
+	tags[constants.GitRepositoryURL] = getenv(env, "BUILDKITE_REPO")
+	tags[constants.GitTag] = getenv(env, "BUILDKITE_TAG")
+	tags[constants.CIPipelineID] = getenv(env, "BUILDKITE_BUILD_ID")
+	tags[constants.CIPipelineName] = getenv(env, "BUILDKITE_PIPELINE_SLUG")
+	tags[constants.CIPipelineNumber] = getenv(env, "BUILDKITE_BUILD_NUMBER")
+	tags[constants.CIPipelineURL] = getenv(env, "BUILDKITE_BUILD_URL")
+	tags[constants.CIJobURL] = fmt.Sprintf("%s#%s", getenv(env, "BUILDKITE_BUILD_URL"), getenv(env, "BUILDKITE_JOB_ID"))
 	tags[constants.CIProviderName] = "buildkite"
-	tags[constants.CIWorkspacePath] = os.Getenv("BUILDKITE_BUILD_CHECKOUT_PATH")
-	tags[constants.GitCommitMessage] = os.Getenv("BUILDKITE_MESSAGE")
-	tags[constants.GitCommitAuthorName] = os.Getenv("BUILDKITE_BUILD_AUTHOR")
-	tags[constants.GitCommitAuthorEmail] = os.Getenv("BUILDKITE_BUILD_AUTHOR_EMAIL")
+	tags[constants.CIWorkspacePath] = getenv(env, "BUILDKITE_BUILD_CHECKOUT_PATH")
+	tags[constants.GitCommitMessage] = getenv(env, "BUILDKITE_MESSAGE")
+	tags[constants.GitCommitAuthorName] = getenv(env, "BUILDKITE_BUILD_AUTHOR")
+	tags[constants.GitCommitAuthorEmail] = getenv(env, "BUILDKITE_BUILD_AUTHOR_EMAIL")
 	return tags
 }
 
-func extractCircleCI() map[string]string {
+func extractCircleCI(env EnvLookup) map[string]string {
 	tags := map[string]string{}
 	tags[constants.CIProviderName] = "circleci"
-	tags[constants.GitRepositoryURL] = os.Getenv("CIRCLE_REPOSITORY_URL")
-	tags[constants.GitCommitSHA] = os.Getenv("CIRCLE_SHA1")
-	tags[constants.GitTag] = os.Getenv("CIRCLE_TAG")
-	tags[constants.GitBranch] = os.Getenv("CIRCLE_BRANCH")
-	tags[constants.CIWorkspacePath] = os.Getenv("CIRCLE_WORKING_DIRECTORY")
-	tags[constants.CIPipelineID] = os.Getenv("CIRCLE_WORKFLOW_ID")
-	tags[constants.CIPipelineName] = os.Getenv("CIRCLE_PROJECT_REPONAME")
-	tags[constants.CIPipelineNumber] = os.Getenv("CIRCLE_BUILD_NUM")
-	tags[constants.CIPipelineURL] = fmt.Sprintf("https://app.circleci.com/pipelines/workflows/%s", os.Getenv("CIRCLE_WORKFLOW_ID"))
-	tags[constants.CIJobName] = os.Getenv("CIRCLE_JOB")
-	tags[constants.CIJobURL] = os.Getenv("CIRCLE_BUILD_URL")
+	tags[constants.GitRepositoryURL] = getenv(env, "CIRCLE_REPOSITORY_URL")
+	tags[constants.GitCommitSHA] = getenv(env, "CIRCLE_SHA1")
+	tags[constants.GitTag] = getenv(env, "CIRCLE_TAG")
+	tags[constants.GitBranch] = getenv(env, "CIRCLE_BRANCH")
+	tags[constants.CIWorkspacePath] = getenv(env, "CIRCLE_WORKING_DIRECTORY")
+	tags[constants.CIPipelineID] = getenv(env, "CIRCLE_WORKFLOW_ID")
+	tags[constants.CIPipelineName] = getenv(env, "CIRCLE_PROJECT_REPONAME")
+	tags[constants.CIPipelineNumber] = getenv(env, "CIRCLE_BUILD_NUM")
+	tags[constants.CIPipelineURL] = fmt.Sprintf("https://app.circleci.com/pipelines/workflows/%s", getenv(env, "CIRCLE_WORKFLOW_ID"))
+	tags[constants.CIJobName] = getenv(env, "CIRCLE_JOB")
+	tags[constants.CIJobURL] = getenv(env, "CIRCLE_BUILD_URL")
 	return tags
 }
 
-func extractGithubActions() map[string]string {
+func extractGithubActions(env EnvLookup) map[string]string {
 	tags := map[string]string{}
-	branchOrTag := firstEnv("GITHUB_HEAD_REF", "GITHUB_REF")
+	branchOrTag := firstEnv(env, "GITHUB_HEAD_REF", "GITHUB_REF")
 	tag := ""
 	branch := ""
 	if strings.Contains(branchOrTag, "tags/") {
@@ -285,28 +325,28 @@ func extractGithubActions() map[string]string {
 		branch = branchOrTag
 	}
 
-	serverUrl := os.Getenv("GITHUB_SERVER_URL")
+	serverUrl := getenv(env, "GITHUB_SERVER_URL")
 	if serverUrl == "" {
 		serverUrl = "https://github.com"
 	}
 	serverUrl = strings.TrimSuffix(serverUrl, "/")
 
-	rawRepository := fmt.Sprintf("%s/%s", serverUrl, os.Getenv("GITHUB_REPOSITORY"))
-	pipelineId := os.Getenv("GITHUB_RUN_ID")
-	commitSha := os.Getenv("GITHUB_SHA")
+	rawRepository := fmt.Sprintf("%s/%s", serverUrl, getenv(env, "GITHUB_REPOSITORY"))
+	pipelineId := getenv(env, "GITHUB_RUN_ID")
+	commitSha := getenv(env, "GITHUB_SHA")
 
 	tags[constants.CIProviderName] = "github"
 	tags[constants.GitRepositoryURL] = rawRepository + ".git"
 	tags[constants.GitCommitSHA] = commitSha
 	tags[constants.GitBranch] = branch
 	tags[constants.GitTag] = tag
-	tags[constants.CIWorkspacePath] = os.Getenv("GITHUB_WORKSPACE")
+	tags[constants.CIWorkspacePath] = getenv(env, "GITHUB_WORKSPACE")
 	tags[constants.CIPipelineID] = pipelineId
-	tags[constants.CIPipelineNumber] = os.Getenv("GITHUB_RUN_NUMBER")
-	tags[constants.CIPipelineName] = os.Getenv("GITHUB_WORKFLOW")
+	tags[constants.CIPipelineNumber] = getenv(env, "GITHUB_RUN_NUMBER")
+	tags[constants.CIPipelineName] = getenv(env, "GITHUB_WORKFLOW")
 	tags[constants.CIJobURL] = fmt.Sprintf("%s/commit/%s/checks", rawRepository, commitSha)
 
-	attempts := os.Getenv("GITHUB_RUN_ATTEMPT")
+	attempts := getenv(env, "GITHUB_RUN_ATTEMPT")
 	if attempts == "" {
 		tags[constants.CIPipelineURL] = fmt.Sprintf("%s/actions/runs/%s", rawRepository, pipelineId)
 	} else {
@@ -316,46 +356,48 @@ func extractGithubActions() map[string]string {
 	return tags
 }
 
-func extractGitlab() map[string]string {
+func extractGitlab(env EnvLookup) map[string]string {
 	tags := map[string]string{}
-	url := os.Getenv("CI_PIPELINE_URL")
+	url := getenv(env, "CI_PIPELINE_URL")
 	url = string(regexp.MustCompile("/-/pipelines/").ReplaceAll([]byte(url), []byte("/pipelines/"))[:])
 	url = strings.ReplaceAll(url, "/-/pipelines/", "/pipelines/")
 
 	tags[constants.CIProviderName] = "gitlab"
-	tags[constants.GitRepositoryURL] = os.Getenv("CI_REPOSITORY_URL")
-	tags[constants.GitCommitSHA] = os.Getenv("CI_COMMIT_SHA")
-	tags[constants.GitBranch] = firstEnv("CI_COMMIT_BRANCH", "CI_COMMIT_REF_NAME")
-	tags[constants.GitTag] = os.Getenv("CI_COMMIT_TAG")
-	tags[constants.CIWorkspacePath] = os.Getenv("CI_PROJECT_DIR")
-	tags[constants.CIPipelineID] = os.Getenv("CI_PIPELINE_ID")
-	tags[constants.CIPipelineName] = os.Getenv("CI_PROJECT_PATH")
-	tags[constants.CIPipelineNumber] = os.Getenv("CI_PIPELINE_IID")
+	tags[constants.GitRepositoryURL] = getenv(env, "CI_REPOSITORY_URL")
+	tags[constants.GitCommitSHA] = getenv(env, "CI_COMMIT_SHA")
+	tags[constants.GitBranch] = firstEnv(env, "CI_COMMIT_BRANCH", "CI_COMMIT_REF_NAME")
+	tags[constants.GitTag] = getenv(env, "CI_COMMIT_TAG")
+	tags[constants.CIWorkspacePath] = getenv(env, "CI_PROJECT_DIR")
+	tags[constants.CIPipelineID] = getenv(env, "CI_PIPELINE_ID")
+	tags[constants.CIPipelineName] = getenv(env, "CI_PROJECT_PATH")
+	tags[constants.CIPipelineNumber] = getenv(env, "CI_PIPELINE_IID")
 	tags[constants.CIPipelineURL] = url
-	tags[constants.CIJobURL] = os.Getenv("CI_JOB_URL")
-	tags[constants.CIJobName] = os.Getenv("CI_JOB_NAME")
-	tags[constants.CIStageName] = os.Getenv("CI_JOB_STAGE")
-	tags[constants.GitCommitMessage] = os.Getenv("CI_COMMIT_MESSAGE")
+	tags[constants.CIJobURL] = getenv(env, "CI_JOB_URL")
+	tags[constants.CIJobName] = getenv(env, "CI_JOB_NAME")
+	tags[constants.CIStageName] = getenv(env, "CI_JOB_STAGE")
+	tags[constants.GitCommitMessage] = getenv(env, "CI_COMMIT_MESSAGE")
 
-	author := os.Getenv("CI_COMMIT_AUTHOR")
+	author := getenv(env, "CI_COMMIT_AUTHOR")
 	authorArray := strings.FieldsFunc(author, func(s rune) bool {
 		return s == '<' || s == '>'
 	})
-	tags[constants.GitCommitAuthorName] = strings.TrimSpace(authorArray[0])
-	tags[constants.GitCommitAuthorEmail] = strings.TrimSpace(authorArray[1])
-	tags[constants.GitCommitAuthorDate] = os.Getenv("CI_COMMIT_TIMESTAMP")
+	if len(authorArray) == 2 {
+		tags[constants.GitCommitAuthorName] = strings.TrimSpace(authorArray[0])
+		tags[constants.GitCommitAuthorEmail] = strings.TrimSpace(authorArray[1])
+	}
+	tags[constants.GitCommitAuthorDate] = getenv(env, "CI_COMMIT_TIMESTAMP")
 	return tags
 }
 
-func extractJenkins() map[string]string {
+func extractJenkins(env EnvLookup) map[string]string {
 	tags := map[string]string{}
 	tags[constants.CIProviderName] = "jenkins"
-	tags[constants.GitRepositoryURL] = firstEnv("GIT_URL", "GIT_URL_1")
-	tags[constants.GitCommitSHA] = os.Getenv("GIT_COMMIT")
+	tags[constants.GitRepositoryURL] = firstEnv(env, "GIT_URL", "GIT_URL_1")
+	tags[constants.GitCommitSHA] = getenv(env, "GIT_COMMIT")
 
-	branchOrTag := os.Getenv("GIT_BRANCH")
+	branchOrTag := getenv(env, "GIT_BRANCH")
 	empty := []byte("")
-	name, hasName := os.LookupEnv("JOB_NAME")
+	name, hasName := env("JOB_NAME")
 
 	if strings.Contains(branchOrTag, "tags/") {
 		tags[constants.GitTag] = branchOrTag
@@ -371,44 +413,44 @@ func extractJenkins() map[string]string {
 		name = string(removeVars.ReplaceAll([]byte(name), empty))
 	}
 
-	tags[constants.CIWorkspacePath] = os.Getenv("WORKSPACE")
-	tags[constants.CIPipelineID] = os.Getenv("BUILD_TAG")
-	tags[constants.CIPipelineNumber] = os.Getenv("BUILD_NUMBER")
+	tags[constants.CIWorkspacePath] = getenv(env, "WORKSPACE")
+	tags[constants.CIPipelineID] = getenv(env, "BUILD_TAG")
+	tags[constants.CIPipelineNumber] = getenv(env, "BUILD_NUMBER")
 	tags[constants.CIPipelineName] = name
-	tags[constants.CIPipelineURL] = os.Getenv("BUILD_URL")
+	tags[constants.CIPipelineURL] = getenv(env, "BUILD_URL")
 	return tags
 }
 
-func extractTeamcity() map[string]string {
+func extractTeamcity(env EnvLookup) map[string]string {
 	tags := map[string]string{}
 	tags[constants.CIProviderName] = "teamcity"
-	tags[constants.GitRepositoryURL] = os.Getenv("BUILD_VCS_URL")
-	tags[constants.GitCommitSHA] = os.Getenv("BUILD_VCS_NUMBER")
-	tags[constants.CIWorkspacePath] = os.Getenv("BUILD_CHECKOUTDIR")
-	tags[constants.CIPipelineID] = os.Getenv("BUILD_ID")
-	tags[constants.CIPipelineNumber] = os.Getenv("BUILD_NUMBER")
-	tags[constants.CIPipelineURL] = fmt.Sprintf("%s/viewLog.html?buildId=%s", os.Getenv("SERVER_URL"), os.Getenv("BUILD_ID"))
+	tags[constants.GitRepositoryURL] = getenv(env, "BUILD_VCS_URL")
+	tags[constants.GitCommitSHA] = getenv(env, "BUILD_VCS_NUMBER")
+	tags[constants.CIWorkspacePath] = getenv(env, "BUILD_CHECKOUTDIR")
+	tags[constants.CIPipelineID] = getenv(env, "BUILD_ID")
+	tags[constants.CIPipelineNumber] = getenv(env, "BUILD_NUMBER")
+	tags[constants.CIPipelineURL] = fmt.Sprintf("%s/viewLog.html?buildId=%s", getenv(env, "SERVER_URL"), getenv(env, "BUILD_ID"))
 	return tags
 }
 
-func extractTravis() map[string]string {
+func extractTravis(env EnvLookup) map[string]string {
 	tags := map[string]string{}
-	prSlug := os.Getenv("TRAVIS_PULL_REQUEST_SLUG")
+	prSlug := getenv(env, "TRAVIS_PULL_REQUEST_SLUG")
 	repoSlug := prSlug
 	if strings.TrimSpace(repoSlug) == "" {
-		repoSlug = os.Getenv("TRAVIS_REPO_SLUG")
+		repoSlug = getenv(env, "TRAVIS_REPO_SLUG")
 	}
 	tags[constants.CIProviderName] = "travisci"
 	tags[constants.GitRepositoryURL] = fmt.Sprintf("https://github.com/%s.git", repoSlug)
-	tags[constants.GitCommitSHA] = os.Getenv("TRAVIS_COMMIT")
-	tags[constants.GitTag] = os.Getenv("TRAVIS_TAG")
-	tags[constants.GitBranch] = firstEnv("TRAVIS_PULL_REQUEST_BRANCH", "TRAVIS_BRANCH")
-	tags[constants.CIWorkspacePath] = os.Getenv("TRAVIS_BUILD_DIR")
-	tags[constants.CIPipelineID] = os.Getenv("TRAVIS_BUILD_ID")
-	tags[constants.CIPipelineNumber] = os.Getenv("TRAVIS_BUILD_NUMBER")
+	tags[constants.GitCommitSHA] = getenv(env, "TRAVIS_COMMIT")
+	tags[constants.GitTag] = getenv(env, "TRAVIS_TAG")
+	tags[constants.GitBranch] = firstEnv(env, "TRAVIS_PULL_REQUEST_BRANCH", "TRAVIS_BRANCH")
+	tags[constants.CIWorkspacePath] = getenv(env, "TRAVIS_BUILD_DIR")
+	tags[constants.CIPipelineID] = getenv(env, "TRAVIS_BUILD_ID")
+	tags[constants.CIPipelineNumber] = getenv(env, "TRAVIS_BUILD_NUMBER")
 	tags[constants.CIPipelineName] = repoSlug
-	tags[constants.CIPipelineURL] = os.Getenv("TRAVIS_BUILD_WEB_URL")
-	tags[constants.CIJobURL] = os.Getenv("TRAVIS_JOB_WEB_URL")
-	tags[constants.GitCommitMessage] = os.Getenv("TRAVIS_COMMIT_MESSAGE")
+	tags[constants.CIPipelineURL] = getenv(env, "TRAVIS_BUILD_WEB_URL")
+	tags[constants.CIJobURL] = getenv(env, "TRAVIS_JOB_WEB_URL")
+	tags[constants.GitCommitMessage] = getenv(env, "TRAVIS_COMMIT_MESSAGE")
 	return tags
 }
@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeownersLocations are the paths of the CODEOWNERS file relative to the repository root,
+// in the order GitHub and GitLab look for it.
+var codeownersLocations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+	".gitlab/CODEOWNERS",
+}
+
+// Codeowners contains the rules of a CODEOWNERS file.
+type Codeowners struct {
+	rules []codeownersRule
+}
+
+// codeownersRule assigns the owners to the paths matching a pattern.
+type codeownersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// FindCodeowners reads the CODEOWNERS file of the repository checked out in root. It returns
+// nil when the repository has none.
+func FindCodeowners(root string) (*Codeowners, error) {
+	for _, location := range codeownersLocations {
+		f, err := os.Open(filepath.Join(root, filepath.FromSlash(location)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ParseCodeowners(f)
+	}
+	return nil, nil
+}
+
+// ParseCodeowners parses a CODEOWNERS file. The patterns follow the gitignore syntax, and the
+// GitLab sections and optional rules are ignored.
+func ParseCodeowners(r io.Reader) (*Codeowners, error) {
+	c := &Codeowners{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, " #"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[") ||
+			strings.HasPrefix(fields[0], "^[") {
+			continue
+		}
+		pattern, err := regexp.Compile(codeownersRegexp(fields[0]))
+		if err != nil {
+			continue
+		}
+		c.rules = append(c.rules, codeownersRule{pattern: pattern, owners: fields[1:]})
+	}
+	return c, scanner.Err()
+}
+
+// Owners returns the owners of a file, given by its slash-separated path relative to the
+// repository root. The last matching rule wins, like on GitHub, and a rule without owners
+// leaves the file without owners.
+func (c *Codeowners) Owners(file string) []string {
+	if c == nil {
+		return nil
+	}
+	file = strings.TrimPrefix(file, "/")
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(file) {
+			if len(c.rules[i].owners) == 0 {
+				return nil
+			}
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// codeownersRegexp translates a gitignore pattern to a regular expression matching the paths
+// of the files it applies to: the matching files and the files of the matching directories.
+func codeownersRegexp(pattern string) string {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	// The patterns with a slash at the beginning or in the middle are relative to the root,
+	// the others match at any depth.
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if dirOnly {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(?:/.*)?$")
+	}
+	return b.String()
+}
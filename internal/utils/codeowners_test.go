@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const codeowners = `# Default owners
+*                       @org/platform
+
+*.md                    @org/docs
+/payments/              @org/payments @alice
+internal/**/cache.go    @org/perf
+[Section]
+docs/*.go               @org/docs-tools
+/vendor/
+`
+
+func TestCodeownersOwners(t *testing.T) {
+	c, err := ParseCodeowners(strings.NewReader(codeowners))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for file, expected := range map[string][]string{
+		"main.go":                         {"@org/platform"},
+		"README.md":                       {"@org/docs"},
+		"payments/README.md":              {"@org/payments", "@alice"},
+		"payments/checkout/card_test.go":  {"@org/payments", "@alice"},
+		"api/payments/handler.go":         {"@org/platform"},
+		"internal/cache.go":               {"@org/perf"},
+		"internal/store/lru/cache.go":     {"@org/perf"},
+		"docs/gen.go":                     {"@org/docs-tools"},
+		"docs/tools/gen.go":               {"@org/platform"},
+		"vendor/github.com/pkg/errors.go": nil,
+	} {
+		if owners := c.Owners(file); !reflect.DeepEqual(owners, expected) {
+			t.Errorf("%s: expected %v, got %v", file, expected, owners)
+		}
+	}
+
+	var none *Codeowners
+	if owners := none.Owners("main.go"); owners != nil {
+		t.Errorf("unexpected owners without CODEOWNERS file: %v", owners)
+	}
+}
+
+func TestFindCodeowners(t *testing.T) {
+	root, err := ioutil.TempDir("", "codeowners")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if c, err := FindCodeowners(root); c != nil || err != nil {
+		t.Fatalf("unexpected CODEOWNERS file: %v, %v", c, err)
+	}
+
+	os.MkdirAll(filepath.Join(root, ".github"), 0755)
+	if err := ioutil.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte("* @org/platform\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := FindCodeowners(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owners := c.Owners("main.go"); !reflect.DeepEqual(owners, []string{"@org/platform"}) {
+		t.Errorf("unexpected owners: %v", owners)
+	}
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+// cgroupPath is the cgroup file of the process.
+const cgroupPath = "/proc/self/cgroup"
+
+// containerIDRegex matches the container IDs of Docker, containerd and CRI-O (64 hexadecimal
+// characters), and of ECS Fargate (32 hexadecimal characters and a numeric suffix), at the
+// end of a cgroup path.
+var containerIDRegex = regexp.MustCompile(`([0-9a-f]{64}|[0-9a-f]{32}-\d+)(?:\.scope)?$`)
+
+// containerEnvs contains the environment variables usually set with the Kubernetes downward
+// API or by the container runtime, by tag.
+var containerEnvs = map[string][]string{
+	constants.ContainerImage:      {"DD_CONTAINER_IMAGE", "CONTAINER_IMAGE"},
+	constants.KubernetesPodName:   {"DD_KUBERNETES_POD_NAME", "KUBERNETES_POD_NAME", "POD_NAME", "MY_POD_NAME"},
+	constants.KubernetesNamespace: {"DD_KUBERNETES_NAMESPACE", "KUBERNETES_NAMESPACE", "POD_NAMESPACE", "MY_POD_NAMESPACE"},
+	constants.KubernetesNodeName:  {"DD_KUBERNETES_NODE_NAME", "KUBERNETES_NODE_NAME", "NODE_NAME", "MY_NODE_NAME"},
+}
+
+// GetContainerTags returns the ID of the container running the process, and the image, pod
+// and node names provided by the environment.
+func GetContainerTags() map[string]string {
+	tags := map[string]string{}
+	if id := readContainerID(cgroupPath); id != "" {
+		tags[constants.ContainerID] = id
+	}
+	for tag, keys := range containerEnvs {
+		if value := firstEnv(os.LookupEnv, keys...); value != "" {
+			tags[tag] = value
+		}
+	}
+	// The hostname of a pod is its name.
+	if _, ok := tags[constants.KubernetesPodName]; !ok && os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		if hostname, err := os.Hostname(); err == nil {
+			tags[constants.KubernetesPodName] = hostname
+		}
+	}
+	return tags
+}
+
+// readContainerID returns the container ID found in a cgroup file.
+func readContainerID(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if matches := containerIDRegex.FindStringSubmatch(scanner.Text()); len(matches) > 1 {
+			return matches[1]
+		}
+	}
+	return ""
+}
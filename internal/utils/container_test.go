@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+func TestReadContainerID(t *testing.T) {
+	for file, expected := range map[string]string{
+		"docker":     "3726184226f5d3147c25fdeab5b60097e378e8a720503a5e19ecfdf29f869860",
+		"kubernetes": "7b8952daecf4c0e44bbcefe1b5c5ebc7b4839d4eefeccefe694709d3809b6199",
+		"host":       "",
+		"missing":    "",
+	} {
+		if id := readContainerID(filepath.Join("testdata", "cgroup", file)); id != expected {
+			t.Errorf("%s: expected %q, got %q", file, expected, id)
+		}
+	}
+}
+
+func TestGetContainerTags(t *testing.T) {
+	restore := setEnvs(map[string]string{
+		"POD_NAME":        "tests-7f9c",
+		"POD_NAMESPACE":   "ci",
+		"NODE_NAME":       "node-1",
+		"CONTAINER_IMAGE": "golang:1.16",
+	})
+	defer restore()
+
+	tags := GetContainerTags()
+	if tags[constants.KubernetesPodName] != "tests-7f9c" || tags[constants.KubernetesNamespace] != "ci" ||
+		tags[constants.KubernetesNodeName] != "node-1" || tags[constants.ContainerImage] != "golang:1.16" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+}
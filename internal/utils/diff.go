@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells bounds the size of the LCS table used to compute a diff.
+const maxDiffCells = 4 * 1024 * 1024
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns the unified diff between two texts, with the given number of context
+// lines around each change. It returns an empty string when both texts are equal.
+func UnifiedDiff(fromName, toName, from, to string, context int) string {
+	if from == to {
+		return ""
+	}
+	a := splitLines(from)
+	b := splitLines(to)
+
+	// Trim the common prefix and suffix to reduce the size of the LCS table.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffLines(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	buffer := new(bytes.Buffer)
+	fmt.Fprintf(buffer, "--- %s\n+++ %s\n", fromName, toName)
+	writeHunks(buffer, ops, context)
+	return buffer.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines returns the operations transforming a into b, using the longest common subsequence.
+// When the texts are too big, all the lines of a are removed and all the lines of b are added.
+func diffLines(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// writeHunks writes the hunks of the unified diff.
+func writeHunks(buffer *bytes.Buffer, ops []diffOp, context int) {
+	// Line numbers of each operation in both texts.
+	fromLines := make([]int, len(ops)+1)
+	toLines := make([]int, len(ops)+1)
+	for i, op := range ops {
+		fromLines[i+1], toLines[i+1] = fromLines[i], toLines[i]
+		if op.kind != '+' {
+			fromLines[i+1]++
+		}
+		if op.kind != '-' {
+			toLines[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the changes are separated by less than 2*context lines.
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+			end = next
+		}
+		stop := end + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		fmt.Fprintf(buffer, "@@ -%d,%d +%d,%d @@\n",
+			fromLines[start]+1, fromLines[stop]-fromLines[start],
+			toLines[start]+1, toLines[stop]-toLines[start])
+		for _, op := range ops[start:stop] {
+			buffer.WriteByte(op.kind)
+			buffer.WriteString(op.line)
+			buffer.WriteByte('\n')
+		}
+		i = stop
+	}
+}
@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := UnifiedDiff("a", "b", "same\n", "same\n", 3); diff != "" {
+		t.Errorf("unexpected diff of equal texts: %s", diff)
+	}
+
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+	to := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n"
+	expected := "--- golden\n+++ actual\n@@ -3,5 +3,5 @@\n 3\n 4\n-5\n+five\n 6\n 7\n"
+	if diff := UnifiedDiff("golden", "actual", from, to, 2); diff != expected {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
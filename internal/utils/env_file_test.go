@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	const file = `# CI variables
+GITHUB_ACTIONS=true
+export GITHUB_SHA=b9f0fb3
+declare -x GITHUB_REF="refs/heads/main"
+GITHUB_WORKFLOW='CI "tests"'
+COMMIT_MESSAGE="Fix the \"upload\"\nbody"
+EMPTY=
+INHERITED
+INVALID KEY=value
+`
+	expected := map[string]string{
+		"GITHUB_ACTIONS":  "true",
+		"GITHUB_SHA":      "b9f0fb3",
+		"GITHUB_REF":      "refs/heads/main",
+		"GITHUB_WORKFLOW": `CI "tests"`,
+		"COMMIT_MESSAGE":  "Fix the \"upload\"\nbody",
+		"EMPTY":           "",
+	}
+	actual, err := ParseEnvFile(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// maxWalkedFiles is the maximum number of files visited to compute the size of a directory.
+const maxWalkedFiles = 10000
+
+// OpenFileDescriptors returns the number of file descriptors opened by the process. It
+// returns false on the platforms where they can't be listed.
+func OpenFileDescriptors() (int, bool) {
+	var dir string
+	switch runtime.GOOS {
+	case "linux":
+		dir = "/proc/self/fd"
+	case "darwin", "freebsd":
+		dir = "/dev/fd"
+	default:
+		return 0, false
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return 0, false
+	}
+	// The descriptor used to list the directory is not counted.
+	return len(names) - 1, true
+}
+
+// DirEntries returns the names of the entries of a directory.
+func DirEntries(dir string) map[string]struct{} {
+	entries := map[string]struct{}{}
+	f, err := os.Open(dir)
+	if err != nil {
+		return entries
+	}
+	defer f.Close()
+	names, _ := f.Readdirnames(-1)
+	for _, name := range names {
+		entries[name] = struct{}{}
+	}
+	return entries
+}
+
+// PathSize returns the size of a file, or the total size of the files of a directory. Only
+// the first files of very large directories are counted.
+func PathSize(path string) int64 {
+	var size int64
+	visited := 0
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if visited++; visited > maxWalkedFiles {
+			return filepath.SkipDir
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenFileDescriptors(t *testing.T) {
+	before, ok := OpenFileDescriptors()
+	if !ok {
+		t.Skip("file descriptors can't be listed on this platform")
+	}
+	f, err := os.Open(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := OpenFileDescriptors()
+	f.Close()
+	if after != before+1 {
+		t.Errorf("expected %d file descriptors, got %d", before+1, after)
+	}
+}
+
+func TestPathSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 50), 0644)
+
+	if size := PathSize(dir); size != 150 {
+		t.Errorf("unexpected size: %d", size)
+	}
+	if entries := DirEntries(dir); len(entries) != 2 {
+		t.Errorf("unexpected entries: %v", entries)
+	}
+}
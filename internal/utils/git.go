@@ -6,6 +6,7 @@
 package utils
 
 import (
+	"context"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -28,31 +29,37 @@ type LocalGitData struct {
 
 // LocalGetGitData get the git data from the HEAD in Git repository
 func LocalGetGitData() (LocalGitData, error) {
+	return LocalGetGitDataContext(context.Background())
+}
+
+// LocalGetGitDataContext gets the git data like LocalGetGitData, killing the git commands
+// still running when the context is done.
+func LocalGetGitDataContext(ctx context.Context) (LocalGitData, error) {
 	gitData := LocalGitData{}
 
 	// Extract git working folder
-	out, err := exec.Command("git", "rev-parse", "--absolute-git-dir").Output()
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--absolute-git-dir").Output()
 	if err != nil {
 		return gitData, err
 	}
 	gitData.SourceRoot = strings.ReplaceAll(strings.Trim(string(out), "\n"), ".git", "")
 
 	// Extract repository data
-	out, err = exec.Command("git", "ls-remote", "--get-url").Output()
+	out, err = exec.CommandContext(ctx, "git", "ls-remote", "--get-url").Output()
 	if err != nil {
 		return gitData, err
 	}
 	gitData.RepositoryUrl = strings.Trim(string(out), "\n")
 
 	// Extract the branch name
-	out, err = exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	out, err = exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD").Output()
 	if err != nil {
 		return gitData, err
 	}
 	gitData.Branch = strings.Trim(string(out), "\n")
 
 	// Get remaining data from the git log command: git log -1 --pretty='%H","%aI","%an","%ae","%cI","%cn","%ce","%B'
-	out, err = exec.Command("git", "log", "-1", "--pretty=%H\",\"%at\",\"%an\",\"%ae\",\"%ct\",\"%cn\",\"%ce\",\"%B").Output()
+	out, err = exec.CommandContext(ctx, "git", "log", "-1", "--pretty=%H\",\"%at\",\"%an\",\"%ae\",\"%ct\",\"%cn\",\"%ce\",\"%B").Output()
 	if err != nil {
 		return gitData, err
 	}
@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// baseBranchEnv are the environment variables of the CI providers with the target branch of the
+// pull request being tested.
+var baseBranchEnv = []string{
+	"GITHUB_BASE_REF",
+	"CI_MERGE_REQUEST_TARGET_BRANCH_NAME",
+	"BITBUCKET_PR_DESTINATION_BRANCH",
+	"SYSTEM_PULLREQUEST_TARGETBRANCH",
+	"BUILDKITE_PULL_REQUEST_BASE_BRANCH",
+	"CHANGE_TARGET",
+}
+
+// LineRange is a range of lines of a file, inclusive.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// ProviderBaseBranch returns the target branch of the pull request tested by the CI provider,
+// or an empty string outside of a pull request.
+func ProviderBaseBranch() string {
+	for _, name := range baseBranchEnv {
+		if v := os.Getenv(name); v != "" {
+			return strings.TrimPrefix(v, "refs/heads/")
+		}
+	}
+	return ""
+}
+
+// ChangedLines returns the lines changed in the working tree since the merge base of HEAD and
+// the base branch, by absolute path of the files. The remote branch is used when the base branch
+// isn't checked out, like in the shallow clones of the CI providers.
+func ChangedLines(ctx context.Context, base string) (map[string][]LineRange, error) {
+	root, mergeBase, err := diffBase(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	out, err := exec.CommandContext(ctx, "git", "-C", root, "diff", "--unified=0", "--no-color", "--no-ext-diff",
+		"--no-renames", mergeBase).Output()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := ParseChangedLines(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	absolute := make(map[string][]LineRange, len(changes))
+	for file, ranges := range changes {
+		absolute[filepath.Join(root, filepath.FromSlash(file))] = ranges
+	}
+	return absolute, nil
+}
+
+// DiffStat contains the size of the changes since the base branch.
+type DiffStat struct {
+	FilesChanged int
+	Insertions   int
+	Deletions    int
+
+	// Directories are the sorted top-level directories of the changed files, "." for the
+	// files at the root of the repository.
+	Directories []string
+}
+
+// DiffStats returns the size of the changes in the working tree since the merge base of HEAD
+// and the base branch, like ChangedLines.
+func DiffStats(ctx context.Context, base string) (DiffStat, error) {
+	root, mergeBase, err := diffBase(ctx, base)
+	if err != nil {
+		return DiffStat{}, err
+	}
+	out, err := exec.CommandContext(ctx, "git", "-C", root, "diff", "--numstat", "--no-color", "--no-ext-diff",
+		"--no-renames", mergeBase).Output()
+	if err != nil {
+		return DiffStat{}, err
+	}
+	return ParseNumstat(bytes.NewReader(out))
+}
+
+// ParseNumstat returns the size of the changes of a diff in the --numstat format. The binary
+// files are counted as changed files without lines.
+func ParseNumstat(r io.Reader) (DiffStat, error) {
+	var stat DiffStat
+	directories := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// 12	3	path/to/file.go
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		insertions, _ := strconv.Atoi(fields[0])
+		deletions, _ := strconv.Atoi(fields[1])
+		stat.FilesChanged++
+		stat.Insertions += insertions
+		stat.Deletions += deletions
+
+		dir := "."
+		if idx := strings.IndexByte(fields[2], '/'); idx > 0 {
+			dir = fields[2][:idx]
+		}
+		if !directories[dir] {
+			directories[dir] = true
+			stat.Directories = append(stat.Directories, dir)
+		}
+	}
+	sort.Strings(stat.Directories)
+	return stat, scanner.Err()
+}
+
+// diffBase returns the root of the repository and the merge base of HEAD and the base branch,
+// or of the remote branch when the base branch isn't checked out.
+func diffBase(ctx context.Context, base string) (root, mergeBase string, err error) {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", "", err
+	}
+	root = strings.TrimSpace(string(out))
+
+	for _, ref := range []string{base, "origin/" + base} {
+		if out, err = exec.CommandContext(ctx, "git", "merge-base", "HEAD", ref).Output(); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return root, strings.TrimSpace(string(out)), nil
+}
+
+// ParseChangedLines returns the lines changed by a diff in the unified format with no context
+// lines, by path of the new files. The deleted lines are reported as a change of the line after
+// them, so removing a line of a function changes it.
+func ParseChangedLines(r io.Reader) (map[string][]LineRange, error) {
+	changes := map[string][]LineRange{}
+	var file string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(line, "+++ ")
+			if file == "/dev/null" {
+				file = ""
+			}
+			file = strings.TrimPrefix(file, "b/")
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			// @@ -12,3 +12,4 @@ func TestUpload(t *testing.T) {
+			fields := strings.Fields(line)
+			if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+				continue
+			}
+			start, count := parseHunkRange(strings.TrimPrefix(fields[2], "+"))
+			if count == 0 {
+				// The hunk only deletes lines, after the start line.
+				changes[file] = append(changes[file], LineRange{Start: start, End: start + 1})
+				continue
+			}
+			changes[file] = append(changes[file], LineRange{Start: start, End: start + count - 1})
+		}
+	}
+	return changes, scanner.Err()
+}
+
+// parseHunkRange parses the start,count range of a hunk header, where the count defaults to 1.
+func parseHunkRange(s string) (start, count int) {
+	count = 1
+	if idx := strings.IndexByte(s, ','); idx >= 0 {
+		count, _ = strconv.Atoi(s[idx+1:])
+		s = s[:idx]
+	}
+	start, _ = strconv.Atoi(s)
+	return start, count
+}
+
+// Overlaps returns whether one of the ranges overlaps the lines from start to end.
+func Overlaps(ranges []LineRange, start, end int) bool {
+	for _, r := range ranges {
+		if r.Start <= end && start <= r.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const changedLinesDiff = `diff --git a/payments/card_test.go b/payments/card_test.go
+index 83db48f..bf269f4 100644
+--- a/payments/card_test.go
++++ b/payments/card_test.go
+@@ -12 +12 @@ func TestCharge(t *testing.T) {
+-	amount := 10
++	amount := 20
+@@ -30,2 +30,0 @@ func TestRefund(t *testing.T) {
+-	t.Log("refund")
+-	t.Log("done")
+@@ -40,0 +39,3 @@ func TestRefund(t *testing.T) {
++func TestVoid(t *testing.T) {
++	void(t)
++}
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package old
+`
+
+func TestParseChangedLines(t *testing.T) {
+	changes, err := ParseChangedLines(strings.NewReader(changedLinesDiff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]LineRange{
+		"payments/card_test.go": {{Start: 12, End: 12}, {Start: 30, End: 31}, {Start: 39, End: 41}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+
+	ranges := changes["payments/card_test.go"]
+	for _, c := range []struct {
+		start, end int
+		expected   bool
+	}{
+		{10, 14, true},
+		{13, 29, false},
+		{25, 30, true},
+		{32, 38, false},
+		{41, 50, true},
+	} {
+		if actual := Overlaps(ranges, c.start, c.end); actual != c.expected {
+			t.Errorf("lines %d-%d: expected %v, got %v", c.start, c.end, c.expected, actual)
+		}
+	}
+}
+
+func TestProviderBaseBranch(t *testing.T) {
+	for _, name := range baseBranchEnv {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+	if branch := ProviderBaseBranch(); branch != "" {
+		t.Errorf("unexpected base branch outside of a pull request: %s", branch)
+	}
+	os.Setenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME", "main")
+	if branch := ProviderBaseBranch(); branch != "main" {
+		t.Errorf("unexpected base branch: %s", branch)
+	}
+}
+
+func TestParseNumstat(t *testing.T) {
+	const numstat = "12\t3\tpayments/charge.go\n" +
+		"0\t7\tpayments/refund_test.go\n" +
+		"-\t-\tassets/logo.png\n" +
+		"1\t1\tgo.mod\n"
+	stat, err := ParseNumstat(strings.NewReader(numstat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := DiffStat{FilesChanged: 4, Insertions: 13, Deletions: 11, Directories: []string{".", "assets", "payments"}}
+	if !reflect.DeepEqual(stat, expected) {
+		t.Errorf("unexpected statistics: %+v", stat)
+	}
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ignoredGoroutineFunctions contains the prefixes of the functions of the goroutines
+// that are never reported as leaks: the standard library, the tracer and this SDK.
+var ignoredGoroutineFunctions = []string{
+	"testing.",
+	"os/signal.",
+	"runtime.ensureSigM",
+	"runtime.ReadTrace",
+	"gopkg.in/DataDog/dd-trace-go.v1/",
+	"github.com/DataDog/datadog-go/",
+	"github.com/DataDog/dd-sdk-go-testing.",
+}
+
+// FindLeakedGoroutines returns the stacks of the goroutines still running, other than the
+// current one and the ignored ones. A goroutine is ignored when any of its functions starts
+// with an ignored prefix, or when its top function is one of ignoreTopFunctions. Goroutines
+// may take some time to exit, so it retries until maxWait has elapsed.
+func FindLeakedGoroutines(maxWait time.Duration, ignoreTopFunctions ...string) []string {
+	deadline := time.Now().Add(maxWait)
+	delay := time.Microsecond
+	for {
+		leaks := findLeakedGoroutines(ignoreTopFunctions)
+		if len(leaks) == 0 || time.Now().After(deadline) {
+			return leaks
+		}
+		time.Sleep(delay)
+		if delay < 100*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
+
+func findLeakedGoroutines(ignoreTopFunctions []string) []string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var leaks []string
+	// The first stack is the current goroutine.
+	for _, stack := range strings.Split(string(buf), "\n\n")[1:] {
+		if stack = strings.TrimSpace(stack); stack != "" && !isIgnoredGoroutine(stack, ignoreTopFunctions) {
+			leaks = append(leaks, stack)
+		}
+	}
+	return leaks
+}
+
+// isIgnoredGoroutine checks the functions of a goroutine stack, with the format:
+//
+//	goroutine 7 [chan receive]:
+//	pkg.function(...)
+//		/path/file.go:10 +0x1f
+//	created by pkg.caller
+//		/path/file.go:5 +0x2a
+func isIgnoredGoroutine(stack string, ignoreTopFunctions []string) bool {
+	lines := strings.Split(stack, "\n")
+	for i, line := range lines[1:] {
+		if strings.HasPrefix(line, "\t") {
+			continue
+		}
+		function := strings.TrimPrefix(line, "created by ")
+		if paren := strings.LastIndexByte(function, '('); paren > 0 && !strings.HasPrefix(line, "created by ") {
+			function = function[:paren]
+		}
+		if i == 0 {
+			for _, ignored := range ignoreTopFunctions {
+				if function == ignored {
+					return true
+				}
+			}
+		}
+		for _, prefix := range ignoredGoroutineFunctions {
+			if strings.HasPrefix(function, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func blockingGoroutine(ch chan struct{}) {
+	<-ch
+}
+
+func TestFindLeakedGoroutines(t *testing.T) {
+	ch := make(chan struct{})
+	go blockingGoroutine(ch)
+
+	leaks := FindLeakedGoroutines(10 * time.Millisecond)
+	if len(leaks) != 1 || !strings.Contains(leaks[0], "utils.blockingGoroutine") {
+		t.Errorf("unexpected leaks: %v", leaks)
+	}
+
+	if leaks := FindLeakedGoroutines(0, "github.com/DataDog/dd-sdk-go-testing/internal/utils.blockingGoroutine"); len(leaks) != 0 {
+		t.Errorf("ignored goroutine was reported: %v", leaks)
+	}
+
+	close(ch)
+	if leaks := FindLeakedGoroutines(time.Second); len(leaks) != 0 {
+		t.Errorf("unexpected leaks after the goroutine exited: %v", leaks)
+	}
+}
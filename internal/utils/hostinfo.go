@@ -0,0 +1,176 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+// HostInfo describes the hardware of the host running the tests. The zero values are unknown.
+type HostInfo struct {
+	// CPUModel is the model of the CPU, like "Intel(R) Xeon(R) Platinum 8272CL CPU @ 2.60GHz".
+	CPUModel string
+
+	// CPUCores and CPULogicalCores are the numbers of physical cores and logical CPUs.
+	CPUCores        int
+	CPULogicalCores int
+
+	// MemoryTotal is the total memory, in bytes.
+	MemoryTotal uint64
+
+	// Virtualized is whether the host is a virtual machine, detected when VirtualizationKnown.
+	Virtualized         bool
+	VirtualizationKnown bool
+
+	// Hypervisor is the hypervisor of a virtual machine, like "KVM", when it's identified.
+	Hypervisor string
+}
+
+var (
+	hostInfo     HostInfo
+	hostInfoOnce sync.Once
+)
+
+// GetHostInfo returns the hardware of the host, detected once.
+func GetHostInfo() HostInfo {
+	hostInfoOnce.Do(func() {
+		hostInfo = detectHostInfo()
+	})
+	return hostInfo
+}
+
+// Tags returns the tags of the hardware that was detected.
+func (i HostInfo) Tags() map[string]interface{} {
+	tags := map[string]interface{}{}
+	if i.CPUModel != "" {
+		tags[constants.HostCPUModel] = i.CPUModel
+	}
+	if i.CPUCores > 0 {
+		tags[constants.HostCPUCores] = float64(i.CPUCores)
+	}
+	if i.CPULogicalCores > 0 {
+		tags[constants.HostCPULogicalCores] = float64(i.CPULogicalCores)
+	}
+	if i.MemoryTotal > 0 {
+		tags[constants.HostMemoryTotal] = float64(i.MemoryTotal)
+	}
+	if i.VirtualizationKnown {
+		tags[constants.HostVirtualized] = strconv.FormatBool(i.Virtualized)
+	}
+	if i.Hypervisor != "" {
+		tags[constants.HostHypervisor] = i.Hypervisor
+	}
+	return tags
+}
+
+// hypervisorVendors are the hypervisors identified by the system vendor or product name of the
+// firmware, by substring.
+var hypervisorVendors = []struct {
+	substring string
+	name      string
+}{
+	{"KVM", "KVM"},
+	{"QEMU", "QEMU"},
+	{"VMware", "VMware"},
+	{"VirtualBox", "VirtualBox"},
+	{"Xen", "Xen"},
+	{"Google Compute Engine", "Google Compute Engine"},
+	{"Virtual Machine", "Hyper-V"},
+	{"Parallels", "Parallels"},
+	{"Apple Virtualization", "Apple Virtualization"},
+}
+
+// hypervisorName returns the hypervisor identified by one of the firmware strings.
+func hypervisorName(firmware ...string) string {
+	for _, s := range firmware {
+		for _, v := range hypervisorVendors {
+			if strings.Contains(s, v.substring) {
+				return v.name
+			}
+		}
+	}
+	return ""
+}
+
+// parseCPUInfo parses the /proc/cpuinfo file of Linux: the CPU model, the numbers of cores and
+// logical CPUs, and whether the hypervisor flag is set. The model of the ARM CPUs, which have no
+// model name, is read from the Model and Hardware lines when present.
+func parseCPUInfo(r io.Reader) HostInfo {
+	var info HostInfo
+	var model, fallbackModel, physicalID string
+	cores := map[string]bool{}
+	flags := false
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		parts := strings.SplitN(s.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch key {
+		case "processor":
+			info.CPULogicalCores++
+		case "model name":
+			if model == "" {
+				model = value
+			}
+		case "Model", "Hardware":
+			if fallbackModel == "" {
+				fallbackModel = value
+			}
+		case "physical id":
+			physicalID = value
+		case "core id":
+			cores[physicalID+"/"+value] = true
+		case "flags":
+			flags = true
+			for _, flag := range strings.Fields(value) {
+				if flag == "hypervisor" {
+					info.Virtualized = true
+				}
+			}
+		}
+	}
+	info.CPUModel = model
+	if info.CPUModel == "" {
+		info.CPUModel = fallbackModel
+	}
+	info.CPUCores = len(cores)
+	if info.CPUCores == 0 {
+		info.CPUCores = info.CPULogicalCores
+	}
+	// The hypervisor flag is only reported by the x86 CPUs.
+	info.VirtualizationKnown = flags
+	return info
+}
+
+// parseMemInfo returns the total memory in bytes of the /proc/meminfo file of Linux.
+func parseMemInfo(r io.Reader) uint64 {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		// MemTotal:       16303348 kB
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		total, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		if len(fields) > 2 && strings.EqualFold(fields[2], "kB") {
+			total *= 1024
+		}
+		return total
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func detectHostInfo() HostInfo {
+	var info HostInfo
+	if v, err := syscall.Sysctl("machdep.cpu.brand_string"); err == nil {
+		info.CPUModel = v
+	}
+	if v, err := syscall.SysctlUint32("hw.physicalcpu"); err == nil {
+		info.CPUCores = int(v)
+	}
+	if v, err := syscall.SysctlUint32("hw.logicalcpu"); err == nil {
+		info.CPULogicalCores = int(v)
+	}
+	// hw.memsize is a 64-bit value, which syscall.Sysctl truncates at its first zero byte.
+	if out, err := exec.Command("sysctl", "-n", "hw.memsize").Output(); err == nil {
+		info.MemoryTotal, _ = strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	}
+	if v, err := syscall.SysctlUint32("kern.hv_vmm_present"); err == nil {
+		info.Virtualized = v == 1
+		info.VirtualizationKnown = true
+	}
+	if v, err := syscall.Sysctl("hw.model"); err == nil && info.Virtualized {
+		info.Hypervisor = hypervisorName(v)
+	}
+	return info
+}
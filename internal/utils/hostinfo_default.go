@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+//go:build !windows && !linux && !darwin
+// +build !windows,!linux,!darwin
+
+package utils
+
+import (
+	"runtime"
+)
+
+func detectHostInfo() HostInfo {
+	return HostInfo{CPULogicalCores: runtime.NumCPU()}
+}
@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func detectHostInfo() HostInfo {
+	var info HostInfo
+	if f, err := os.Open("/proc/cpuinfo"); err == nil {
+		info = parseCPUInfo(f)
+		f.Close()
+	}
+	if f, err := os.Open("/proc/meminfo"); err == nil {
+		info.MemoryTotal = parseMemInfo(f)
+		f.Close()
+	}
+
+	var firmware []string
+	for _, path := range []string{"/sys/class/dmi/id/sys_vendor", "/sys/class/dmi/id/product_name", "/sys/hypervisor/type"} {
+		if out, err := ioutil.ReadFile(path); err == nil {
+			firmware = append(firmware, strings.TrimSpace(string(out)))
+		}
+	}
+	if name := hypervisorName(firmware...); name != "" {
+		info.Hypervisor = name
+		info.Virtualized = true
+		info.VirtualizationKnown = true
+	}
+	return info
+}
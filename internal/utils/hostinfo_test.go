@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+func TestParseCPUInfo(t *testing.T) {
+	const x86 = `processor	: 0
+model name	: Intel(R) Xeon(R) Platinum 8272CL CPU @ 2.60GHz
+physical id	: 0
+core id		: 0
+flags		: fpu vme de pse hypervisor lahf_lm
+
+processor	: 1
+model name	: Intel(R) Xeon(R) Platinum 8272CL CPU @ 2.60GHz
+physical id	: 0
+core id		: 0
+flags		: fpu vme de pse hypervisor lahf_lm
+
+processor	: 2
+model name	: Intel(R) Xeon(R) Platinum 8272CL CPU @ 2.60GHz
+physical id	: 0
+core id		: 1
+flags		: fpu vme de pse hypervisor lahf_lm
+`
+	expected := HostInfo{
+		CPUModel:            "Intel(R) Xeon(R) Platinum 8272CL CPU @ 2.60GHz",
+		CPUCores:            2,
+		CPULogicalCores:     3,
+		Virtualized:         true,
+		VirtualizationKnown: true,
+	}
+	if actual := parseCPUInfo(strings.NewReader(x86)); actual != expected {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+
+	const arm = `processor	: 0
+BogoMIPS	: 108.00
+Features	: fp asimd evtstrm
+
+processor	: 1
+BogoMIPS	: 108.00
+
+Hardware	: BCM2835
+Model		: Raspberry Pi 4 Model B Rev 1.4
+`
+	expected = HostInfo{CPUModel: "BCM2835", CPUCores: 2, CPULogicalCores: 2}
+	if actual := parseCPUInfo(strings.NewReader(arm)); actual != expected {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestParseMemInfo(t *testing.T) {
+	const meminfo = `MemTotal:       16303348 kB
+MemFree:         1203348 kB
+`
+	if total := parseMemInfo(strings.NewReader(meminfo)); total != 16303348*1024 {
+		t.Errorf("unexpected total memory: %d", total)
+	}
+	if total := parseMemInfo(strings.NewReader("MemFree: 12 kB\n")); total != 0 {
+		t.Errorf("unexpected total memory: %d", total)
+	}
+}
+
+func TestHypervisorName(t *testing.T) {
+	for _, c := range []struct {
+		firmware []string
+		expected string
+	}{
+		{[]string{"QEMU", "Standard PC (Q35 + ICH9, 2009)"}, "QEMU"},
+		{[]string{"Microsoft Corporation", "Virtual Machine"}, "Hyper-V"},
+		{[]string{"Google", "Google Compute Engine"}, "Google Compute Engine"},
+		{[]string{"Dell Inc.", "PowerEdge R740"}, ""},
+	} {
+		if actual := hypervisorName(c.firmware...); actual != c.expected {
+			t.Errorf("%v: expected %q, got %q", c.firmware, c.expected, actual)
+		}
+	}
+}
+
+func TestHostInfoTags(t *testing.T) {
+	info := HostInfo{CPUModel: "Apple M1", CPUCores: 8, MemoryTotal: 1 << 34, VirtualizationKnown: true}
+	expected := map[string]interface{}{
+		constants.HostCPUModel:    "Apple M1",
+		constants.HostCPUCores:    float64(8),
+		constants.HostMemoryTotal: float64(1 << 34),
+		constants.HostVirtualized: "false",
+	}
+	if actual := info.Tags(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if GetHostInfo().CPULogicalCores == 0 {
+		t.Error("expected the logical CPUs to be detected")
+	}
+}
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"runtime"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+func detectHostInfo() HostInfo {
+	info := HostInfo{CPULogicalCores: runtime.NumCPU()}
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `HARDWARE\DESCRIPTION\System\CentralProcessor\0`, registry.QUERY_VALUE)
+	if err == nil {
+		if model, _, err := k.GetStringValue("ProcessorNameString"); err == nil {
+			info.CPUModel = model
+		}
+		k.Close()
+	}
+	k, err = registry.OpenKey(registry.LOCAL_MACHINE, `HARDWARE\DESCRIPTION\System\BIOS`, registry.QUERY_VALUE)
+	if err == nil {
+		var firmware []string
+		for _, name := range []string{"SystemManufacturer", "SystemProductName"} {
+			if v, _, err := k.GetStringValue(name); err == nil {
+				firmware = append(firmware, v)
+			}
+		}
+		k.Close()
+		if info.Hypervisor = hypervisorName(firmware...); info.Hypervisor != "" {
+			info.Virtualized = true
+			info.VirtualizationKnown = true
+		}
+	}
+	return info
+}
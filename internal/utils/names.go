@@ -8,6 +8,21 @@ package utils
 import (
 	"runtime"
 	"strings"
+	"sync"
+)
+
+// funcInfo contains the metadata resolved from a program counter.
+type funcInfo struct {
+	suite string
+	name  string
+	file  string
+	line  int
+}
+
+var (
+	// funcInfoCache contains the metadata already resolved for each program counter.
+	funcInfoCache      = map[uintptr]*funcInfo{}
+	funcInfoCacheMutex sync.RWMutex
 )
 
 // GetPackageAndName gets the suite name and test name given a program counter.
@@ -25,13 +40,41 @@ import (
 //       suite: github.com/DataDog/dd-sdk-go-testing
 //       name: TestRun.func1
 func GetPackageAndName(pc uintptr) (suite string, name string) {
-	funcFullName := runtime.FuncForPC(pc).Name()
-	lastSlash := strings.LastIndexByte(funcFullName, '/')
-	if lastSlash < 0 {
-		lastSlash = 0
+	info := getFuncInfo(pc)
+	return info.suite, info.name
+}
+
+// GetSourceLocation gets the source file and the line where the function of a program counter starts.
+func GetSourceLocation(pc uintptr) (file string, line int) {
+	info := getFuncInfo(pc)
+	return info.file, info.line
+}
+
+// getFuncInfo returns the cached metadata of a program counter, resolving it on the first call.
+// Table-driven tests start tests from the same caller many times, so the lookup is done only once.
+func getFuncInfo(pc uintptr) *funcInfo {
+	funcInfoCacheMutex.RLock()
+	info, ok := funcInfoCache[pc]
+	funcInfoCacheMutex.RUnlock()
+	if ok {
+		return info
 	}
-	firstDot := strings.IndexByte(funcFullName[lastSlash:], '.') + lastSlash
-	packName := funcFullName[:firstDot]
-	funcName := funcFullName[firstDot+1:]
-	return packName, funcName
+
+	info = &funcInfo{}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		funcFullName := fn.Name()
+		lastSlash := strings.LastIndexByte(funcFullName, '/')
+		if lastSlash < 0 {
+			lastSlash = 0
+		}
+		firstDot := strings.IndexByte(funcFullName[lastSlash:], '.') + lastSlash
+		info.suite = funcFullName[:firstDot]
+		info.name = funcFullName[firstDot+1:]
+		info.file, info.line = fn.FileLine(fn.Entry())
+	}
+
+	funcInfoCacheMutex.Lock()
+	defer funcInfoCacheMutex.Unlock()
+	funcInfoCache[pc] = info
+	return info
 }
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGetPackageAndName(t *testing.T) {
+	pc, _, callerLine, _ := runtime.Caller(0)
+
+	for i := 0; i < 2; i++ {
+		suite, name := GetPackageAndName(pc)
+		if suite != "github.com/DataDog/dd-sdk-go-testing/internal/utils" {
+			t.Errorf("unexpected suite: %s", suite)
+		}
+		if name != "TestGetPackageAndName" {
+			t.Errorf("unexpected name: %s", name)
+		}
+
+		file, line := GetSourceLocation(pc)
+		if !strings.HasSuffix(file, "names_test.go") {
+			t.Errorf("unexpected file: %s", file)
+		}
+		// The function starts on the line above the one calling runtime.Caller.
+		if line != callerLine-1 {
+			t.Errorf("unexpected line: %d, expected: %d", line, callerLine-1)
+		}
+	}
+
+	funcInfoCacheMutex.RLock()
+	defer funcInfoCacheMutex.RUnlock()
+	if _, ok := funcInfoCache[pc]; !ok {
+		t.Error("program counter metadata was not cached")
+	}
+}
@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+// OSInfo describes the operating system running the tests.
+type OSInfo struct {
+	// Name and Version are reported as os.platform and os.version.
+	Name    string
+	Version string
+
+	// Distribution and DistributionVersion are the name and version of the Linux
+	// distribution, or of the macOS and Windows products, like "ubuntu" and "22.04".
+	Distribution        string
+	DistributionVersion string
+
+	// KernelVersion is the version of the kernel, like "5.15.0-1051-azure".
+	KernelVersion string
+
+	// Build is the build number of macOS and Windows, like "23A344" or "19045.3803".
+	Build string
+}
+
+var (
+	osInfo     OSInfo
+	osInfoOnce sync.Once
+)
+
+// GetOSInfo returns the information of the operating system, detected once.
+func GetOSInfo() OSInfo {
+	osInfoOnce.Do(func() {
+		osInfo = detectOSInfo()
+		if osInfo.Version == "" {
+			osInfo.Version = constants.Unknown
+		}
+	})
+	return osInfo
+}
+
+// OSName returns the name of the operating system.
+func OSName() string {
+	return GetOSInfo().Name
+}
+
+// OSVersion returns the version of the operating system.
+func OSVersion() string {
+	return GetOSInfo().Version
+}
+
+// Tags returns the detailed tags of the operating system that were detected.
+func (i OSInfo) Tags() map[string]string {
+	tags := map[string]string{}
+	for k, v := range map[string]string{
+		constants.OSDistributionName:    i.Distribution,
+		constants.OSDistributionVersion: i.DistributionVersion,
+		constants.OSKernelVersion:       i.KernelVersion,
+		constants.OSBuild:               i.Build,
+	} {
+		if v != "" {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+// parseOSRelease parses the KEY=value lines of an os-release file, unquoting the values.
+func parseOSRelease(r io.Reader) map[string]string {
+	values := map[string]string{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := parts[1]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `"'`)
+		}
+		values[parts[0]] = value
+	}
+	return values
+}
@@ -9,18 +9,24 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
-
-	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"syscall"
 )
 
-func OSName() string {
-	return runtime.GOOS
-}
+func detectOSInfo() OSInfo {
+	info := OSInfo{Name: runtime.GOOS, Distribution: "macOS"}
 
-func OSVersion() string {
-	out, err := exec.Command("sw_vers", "-productVersion").Output()
-	if err != nil {
-		return constants.Unknown
+	// kern.osproductversion requires macOS 10.13.4, sw_vers is used by the older versions.
+	if v, err := syscall.Sysctl("kern.osproductversion"); err == nil && v != "" {
+		info.Version = v
+	} else if out, err := exec.Command("sw_vers", "-productVersion").Output(); err == nil {
+		info.Version = strings.TrimSpace(string(out))
+	}
+	info.DistributionVersion = info.Version
+	if v, err := syscall.Sysctl("kern.osversion"); err == nil {
+		info.Build = v
+	}
+	if v, err := syscall.Sysctl("kern.osrelease"); err == nil {
+		info.KernelVersion = v
 	}
-	return strings.Trim(string(out), "\n")
+	return info
 }
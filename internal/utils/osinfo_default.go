@@ -9,14 +9,8 @@ package utils
 
 import (
 	"runtime"
-
-	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
 )
 
-func OSName() string {
-	return runtime.GOOS
-}
-
-func OSVersion() string {
-	return constants.Unknown
+func detectOSInfo() OSInfo {
+	return OSInfo{Name: runtime.GOOS}
 }
@@ -6,21 +6,17 @@
 package utils
 
 import (
-	"os/exec"
 	"runtime"
 	"strings"
-
-	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"syscall"
 )
 
-func OSName() string {
-	return runtime.GOOS
-}
-
-func OSVersion() string {
-	out, err := exec.Command("uname", "-r").Output()
-	if err != nil {
-		return constants.Unknown
+func detectOSInfo() OSInfo {
+	info := OSInfo{Name: runtime.GOOS, Distribution: "FreeBSD"}
+	if release, err := syscall.Sysctl("kern.osrelease"); err == nil {
+		info.KernelVersion = release
+		info.Version = strings.Split(release, "-")[0]
+		info.DistributionVersion = info.Version
 	}
-	return strings.Split(string(out), "-")[0]
+	return info
 }
@@ -6,49 +6,36 @@
 package utils
 
 import (
-	"bufio"
+	"io/ioutil"
 	"os"
 	"strings"
-
-	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
 )
 
-func OSName() string {
-	f, err := os.Open("/etc/os-release")
-	if err != nil {
-		return "Linux (Unknown Distribution)"
-	}
-	defer f.Close()
-	s := bufio.NewScanner(f)
-	name := "Linux (Unknown Distribution)"
-	for s.Scan() {
-		parts := strings.SplitN(s.Text(), "=", 2)
-		switch parts[0] {
-		case "Name":
-			name = strings.Trim(parts[1], "\"")
-		}
-	}
-	return name
-}
+// osReleasePaths are the paths of the os-release file, the second one is the fallback.
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
 
-func OSVersion() string {
-	f, err := os.Open("/etc/os-release")
-	if err != nil {
-		return constants.Unknown
-	}
-	defer f.Close()
-	s := bufio.NewScanner(f)
-	version := constants.Unknown
-	for s.Scan() {
-		parts := strings.SplitN(s.Text(), "=", 2)
-		switch parts[0] {
-		case "VERSION":
-			version = strings.Trim(parts[1], "\"")
-		case "VERSION_ID":
-			if version == "" {
-				version = strings.Trim(parts[1], "\"")
-			}
+func detectOSInfo() OSInfo {
+	info := OSInfo{Name: "Linux (Unknown Distribution)"}
+	for _, path := range osReleasePaths {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		release := parseOSRelease(f)
+		f.Close()
+		if name := release["NAME"]; name != "" {
+			info.Name = name
 		}
+		info.Version = release["VERSION"]
+		if info.Version == "" {
+			info.Version = release["VERSION_ID"]
+		}
+		info.Distribution = release["ID"]
+		info.DistributionVersion = release["VERSION_ID"]
+		break
+	}
+	if out, err := ioutil.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		info.KernelVersion = strings.TrimSpace(string(out))
 	}
-	return version
+	return info
 }
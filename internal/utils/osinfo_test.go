@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	const release = `# os-release
+NAME="Ubuntu"
+VERSION="22.04.3 LTS (Jammy Jellyfish)"
+ID=ubuntu
+VERSION_ID='22.04'
+PRETTY_NAME="Ubuntu \"Jammy\""
+
+invalid
+`
+	expected := map[string]string{
+		"NAME":        "Ubuntu",
+		"VERSION":     "22.04.3 LTS (Jammy Jellyfish)",
+		"ID":          "ubuntu",
+		"VERSION_ID":  "22.04",
+		"PRETTY_NAME": `Ubuntu "Jammy"`,
+	}
+	if actual := parseOSRelease(strings.NewReader(release)); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestOSInfoTags(t *testing.T) {
+	info := OSInfo{Name: "linux", Distribution: "debian", DistributionVersion: "12", KernelVersion: "6.1.0"}
+	expected := map[string]string{
+		constants.OSDistributionName:    "debian",
+		constants.OSDistributionVersion: "12",
+		constants.OSKernelVersion:       "6.1.0",
+	}
+	if actual := info.Tags(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if OSName() == "" || OSVersion() == "" {
+		t.Error("expected the OS name and version to be detected")
+	}
+}
@@ -11,17 +11,22 @@ import (
 	"strings"
 
 	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/registry"
 )
 
-func OSName() string {
-	return runtime.GOOS
-}
+func detectOSInfo() OSInfo {
+	info := OSInfo{Name: runtime.GOOS}
+
+	// RtlGetVersion isn't affected by the compatibility shims of GetVersionEx, which report
+	// Windows 8 to the applications without a manifest.
+	v := windows.RtlGetVersion()
+	info.KernelVersion = fmt.Sprintf("%d.%d.%d", v.MajorVersion, v.MinorVersion, v.BuildNumber)
 
-func OSVersion() string {
 	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows NT\CurrentVersion`, registry.QUERY_VALUE)
 	if err != nil {
-		return constants.Unknown
+		info.Version = constants.Unknown
+		return info
 	}
 	defer k.Close()
 
@@ -51,5 +56,22 @@ func OSVersion() string {
 	} else {
 		version.WriteString(" Unknown Build")
 	}
-	return version.String()
+	info.Version = version.String()
+
+	if name, _, err := k.GetStringValue("ProductName"); err == nil {
+		info.Distribution = name
+	}
+	// DisplayVersion replaced ReleaseId in Windows 10 20H2.
+	if display, _, err := k.GetStringValue("DisplayVersion"); err == nil {
+		info.DistributionVersion = display
+	} else if release, _, err := k.GetStringValue("ReleaseId"); err == nil {
+		info.DistributionVersion = release
+	}
+	if build != "" {
+		info.Build = build
+		if ubr, _, err := k.GetIntegerValue("UBR"); err == nil {
+			info.Build = fmt.Sprintf("%s.%d", build, ubr)
+		}
+	}
+	return info
 }
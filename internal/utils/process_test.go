@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestProcessRunning(t *testing.T) {
+	if !ProcessRunning(os.Getpid()) {
+		t.Error("the current process isn't running")
+	}
+
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd", "windows":
+	default:
+		t.Skip("the exited processes are considered running on " + runtime.GOOS)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if ProcessRunning(cmd.ProcessState.Pid()) {
+		t.Error("the exited process is running")
+	}
+}
@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package utils
+
+import "syscall"
+
+// ProcessRunning returns whether the process with the given ID is running.
+func ProcessRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
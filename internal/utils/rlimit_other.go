@@ -0,0 +1,14 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package utils
+
+// FileDescriptorLimit returns false, the limit isn't available on this platform.
+func FileDescriptorLimit() (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import "strings"
+
+// RuntimeName is the name of the runtime reported by the SDK, like "python" or "node" for the
+// SDKs of the other languages. The compiler is reported separately.
+const RuntimeName = "go"
+
+// architectures maps the GOARCH values to the architecture names used by the other Datadog
+// test SDKs, so aarch64/arm64 and x86_64/amd64 runners are aggregated together.
+var architectures = map[string]string{
+	"amd64":   "x86_64",
+	"386":     "x86",
+	"arm64":   "arm64",
+	"aarch64": "arm64",
+	"arm":     "arm",
+}
+
+// NormalizeArchitecture returns the architecture name of a GOARCH value, or the value itself
+// for the architectures without a conventional name.
+func NormalizeArchitecture(goarch string) string {
+	if arch, ok := architectures[goarch]; ok {
+		return arch
+	}
+	return goarch
+}
+
+// NormalizeRuntimeVersion returns the version of runtime.Version without the "go" prefix, like
+// "1.21.3". Development versions are returned unchanged.
+func NormalizeRuntimeVersion(version string) string {
+	if strings.HasPrefix(version, "go") {
+		return strings.TrimPrefix(version, "go")
+	}
+	return version
+}
@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import "testing"
+
+func TestNormalizeArchitecture(t *testing.T) {
+	for goarch, expected := range map[string]string{
+		"amd64":   "x86_64",
+		"386":     "x86",
+		"arm64":   "arm64",
+		"aarch64": "arm64",
+		"riscv64": "riscv64",
+	} {
+		if actual := NormalizeArchitecture(goarch); actual != expected {
+			t.Errorf("%s: expected %s, got %s", goarch, expected, actual)
+		}
+	}
+}
+
+func TestNormalizeRuntimeVersion(t *testing.T) {
+	for version, expected := range map[string]string{
+		"go1.21.3":                    "1.21.3",
+		"go1.22rc1":                   "1.22rc1",
+		"devel go1.23-a1b2c3d4 +0000": "devel go1.23-a1b2c3d4 +0000",
+	} {
+		if actual := NormalizeRuntimeVersion(version); actual != expected {
+			t.Errorf("%s: expected %s, got %s", version, expected, actual)
+		}
+	}
+}
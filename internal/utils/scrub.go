@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import "regexp"
+
+// ScrubbedValue replaces the sensitive values.
+const ScrubbedValue = "?"
+
+var (
+	// sensitiveName matches the names of flags and variables with sensitive values.
+	sensitiveName = regexp.MustCompile(`(?i)(pass(word|wd)?|secret|token|api[-_]?key|auth|credential)`)
+
+	// urlUserInfo matches the credentials of a URL.
+	urlUserInfo = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s]*@`)
+)
+
+// IsSensitiveName returns whether the name of a flag or variable denotes a sensitive value.
+func IsSensitiveName(name string) bool {
+	return sensitiveName.MatchString(name)
+}
+
+// ScrubURLCredentials removes the credentials of the URLs contained in s.
+func ScrubURLCredentials(s string) string {
+	return urlUserInfo.ReplaceAllString(s, "$1")
+}
+
+// ScrubValue returns the value of a variable with its credentials removed, or ScrubbedValue
+// when the name of the variable denotes a sensitive value.
+func ScrubValue(name, value string) string {
+	if IsSensitiveName(name) {
+		return ScrubbedValue
+	}
+	return ScrubURLCredentials(value)
+}
+
+// ScrubRule replaces the matches of a pattern in the scrubbed values.
+type ScrubRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// BuiltinScrubRules are the rules removing the most common secrets: URL credentials, bearer
+// tokens, key-value secrets, and GitHub, Slack and AWS access keys.
+var BuiltinScrubRules = []ScrubRule{
+	{Pattern: urlUserInfo, Replacement: "$1"},
+	{Pattern: regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`), Replacement: "${1}" + ScrubbedValue},
+	{Pattern: regexp.MustCompile(`(?i)((?:api[_-]?key|access[_-]?key|token|secret|passw(?:or)?d|pwd)["']?\s*[:=]\s*["']?)[^\s"'&,;]+`), Replacement: "${1}" + ScrubbedValue},
+	{Pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), Replacement: ScrubbedValue},
+	{Pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`), Replacement: ScrubbedValue},
+	{Pattern: regexp.MustCompile(`\b(?:A3T[A-Z0-9]|AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16}\b`), Replacement: ScrubbedValue},
+}
+
+// Scrub applies the rules to the value.
+func Scrub(value string, rules []ScrubRule) string {
+	for _, rule := range rules {
+		value = rule.Pattern.ReplaceAllString(value, rule.Replacement)
+	}
+	return value
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sync"
+)
+
+// funcRange is the range of lines of a function.
+type funcRange struct {
+	start int
+	end   int
+}
+
+var (
+	// funcRangesCache contains the ranges of the functions of the parsed source files.
+	funcRangesCache      = map[string][]funcRange{}
+	funcRangesCacheMutex sync.Mutex
+)
+
+// GetSourceEndLine returns the last line of the innermost function containing the given line of
+// a source file, like the function literal of a subtest. The entry of a function literal can be
+// reported at its first statement, so the line isn't expected to be the first one. It returns
+// false when the file can't be parsed.
+func GetSourceEndLine(file string, line int) (int, bool) {
+	end, start := 0, 0
+	for _, f := range getFuncRanges(file) {
+		if f.start <= line && line <= f.end && f.start >= start {
+			end, start = f.end, f.start
+		}
+	}
+	return end, end > 0
+}
+
+// getFuncRanges returns the ranges of the functions and function literals of a source file,
+// parsing it on the first call.
+func getFuncRanges(file string) []funcRange {
+	funcRangesCacheMutex.Lock()
+	defer funcRangesCacheMutex.Unlock()
+	if ranges, ok := funcRangesCache[file]; ok {
+		return ranges
+	}
+
+	var ranges []funcRange
+	fset := token.NewFileSet()
+	if f, err := parser.ParseFile(fset, file, nil, 0); err == nil {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n.(type) {
+			case *ast.FuncDecl, *ast.FuncLit:
+				ranges = append(ranges, funcRange{start: fset.Position(n.Pos()).Line, end: fset.Position(n.End()).Line})
+			}
+			return true
+		})
+	}
+	funcRangesCache[file] = ranges
+	return ranges
+}
@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGetSourceEndLine(t *testing.T) {
+	pc, _, _, _ := runtime.Caller(0)
+	file, line := GetSourceLocation(pc)
+	end, ok := GetSourceEndLine(file, line)
+	if !ok || end != line+21 {
+		t.Errorf("unexpected end line of the test starting at line %d: %d, %v", line, end, ok)
+	}
+
+	subtest := func() (string, int, int) {
+		pc, file, last, _ := runtime.Caller(0)
+		_, line := GetSourceLocation(pc)
+		return file, line, last + 3
+	}
+	file, line, expected := subtest()
+	if end, ok := GetSourceEndLine(file, line); !ok || end != expected {
+		t.Errorf("unexpected end line of the function literal starting at line %d: %d, %v", line, end, ok)
+	}
+
+	if _, ok := GetSourceEndLine("missing.go", 1); ok {
+		t.Error("unexpected end line of a missing file")
+	}
+}
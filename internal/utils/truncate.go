@@ -0,0 +1,27 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import "unicode/utf8"
+
+// TruncatedSuffix ends the values truncated by Truncate.
+const TruncatedSuffix = "...(truncated)"
+
+// Truncate truncates the value to limit bytes, TruncatedSuffix included, without splitting a
+// multi-byte character. It returns whether the value was truncated.
+func Truncate(value string, limit int) (string, bool) {
+	if len(value) <= limit {
+		return value, false
+	}
+	end := limit - len(TruncatedSuffix)
+	if end < 0 {
+		end = 0
+	}
+	for end > 0 && !utf8.RuneStart(value[end]) {
+		end--
+	}
+	return value[:end] + TruncatedSuffix, true
+}
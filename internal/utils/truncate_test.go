@@ -0,0 +1,22 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	if v, truncated := Truncate("short", 20); v != "short" || truncated {
+		t.Errorf("unexpected truncation of a short value: %q", v)
+	}
+	v, truncated := Truncate(strings.Repeat("é", 20), 20)
+	if !truncated || len(v) > 20 || !strings.HasSuffix(v, TruncatedSuffix) || !utf8.ValidString(v) {
+		t.Errorf("unexpected truncated value: %q", v)
+	}
+}
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a significant line of a YAML document.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// ParseYAML parses the subset of YAML used by configuration files: nested mappings of scalars,
+// block sequences of scalars (`- item`) and flow sequences (`[a, b]`). Scalars are returned as
+// strings, mappings as map[string]interface{} and sequences as []string. The double-quoted
+// scalars use the escape sequences of YAML. Anchors, multi-line scalars and multiple documents
+// aren't supported.
+func ParseYAML(data []byte) (map[string]interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(raw, " \r")
+		text := strings.TrimLeft(raw, " ")
+		if text == "" || text == "---" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs can't be used for indentation", i+1)
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: len(raw) - len(text), text: text})
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	m, rest, err := parseYAMLMapping(lines, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("line %d: unexpected indentation", rest[0].num)
+	}
+	return m, nil
+}
+
+// parseYAMLMapping parses the mapping with the given indentation at the start of the lines,
+// and returns the remaining lines.
+func parseYAMLMapping(lines []yamlLine, indent int) (map[string]interface{}, []yamlLine, error) {
+	m := map[string]interface{}{}
+	for len(lines) > 0 && lines[0].indent == indent {
+		l := lines[0]
+		lines = lines[1:]
+		if isYAMLSequenceItem(l.text) {
+			return nil, nil, fmt.Errorf("line %d: unexpected sequence item", l.num)
+		}
+		i := strings.Index(l.text, ":")
+		if i < 0 {
+			return nil, nil, fmt.Errorf("line %d: expected a key", l.num)
+		}
+		key, err := parseYAMLScalar(l.text[:i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", l.num, err)
+		}
+		value := strings.TrimSpace(stripYAMLComment(l.text[i+1:]))
+
+		switch {
+		case value != "":
+			if m[key], err = parseYAMLValue(value); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %v", l.num, err)
+			}
+		case len(lines) > 0 && isYAMLSequenceItem(lines[0].text) && lines[0].indent >= indent:
+			m[key], lines, err = parseYAMLSequence(lines, lines[0].indent)
+		case len(lines) > 0 && lines[0].indent > indent:
+			m[key], lines, err = parseYAMLMapping(lines, lines[0].indent)
+		default:
+			m[key] = ""
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(lines) > 0 && lines[0].indent > indent {
+		return nil, nil, fmt.Errorf("line %d: unexpected indentation", lines[0].num)
+	}
+	return m, lines, nil
+}
+
+// parseYAMLSequence parses the block sequence with the given indentation at the start of the
+// lines, and returns the remaining lines.
+func parseYAMLSequence(lines []yamlLine, indent int) ([]string, []yamlLine, error) {
+	items := []string{}
+	for len(lines) > 0 && lines[0].indent == indent && isYAMLSequenceItem(lines[0].text) {
+		l := lines[0]
+		lines = lines[1:]
+		item, err := parseYAMLScalar(stripYAMLComment(strings.TrimPrefix(l.text, "-")))
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", l.num, err)
+		}
+		items = append(items, item)
+	}
+	return items, lines, nil
+}
+
+// parseYAMLValue parses an inline value: a flow sequence or a scalar.
+func parseYAMLValue(value string) (interface{}, error) {
+	if !strings.HasPrefix(value, "[") {
+		return parseYAMLScalar(value)
+	}
+	if !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("unterminated sequence %s", value)
+	}
+	items := []string{}
+	for _, item := range splitYAMLFlow(value[1 : len(value)-1]) {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		s, err := parseYAMLScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+	return items, nil
+}
+
+// parseYAMLScalar returns the value of a plain or quoted scalar.
+func parseYAMLScalar(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return unquoteYAML(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'"):
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+	return s, nil
+}
+
+// yamlEscapes are the single character escapes of the double-quoted scalars.
+var yamlEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f",
+	'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\", 'N': "\u0085",
+	'_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+// yamlHexEscapes are the number of hexadecimal digits of the code point escapes.
+var yamlHexEscapes = map[byte]int{'x': 2, 'u': 4, 'U': 8}
+
+// unquoteYAML returns the value of a double-quoted scalar, with the escape sequences of YAML.
+func unquoteYAML(s string) (string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			return "", fmt.Errorf("unexpected quote in %s", s)
+		case c != '\\':
+			b.WriteByte(c)
+			continue
+		}
+		if i++; i == len(s)-1 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		if escaped, ok := yamlEscapes[s[i]]; ok {
+			b.WriteString(escaped)
+			continue
+		}
+		size := yamlHexEscapes[s[i]]
+		if size == 0 || i+size >= len(s)-1 {
+			return "", fmt.Errorf("invalid escape sequence in %s", s)
+		}
+		r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape sequence in %s", s)
+		}
+		b.WriteRune(rune(r))
+		i += size
+	}
+	return b.String(), nil
+}
+
+// splitYAMLFlow splits the items of a flow sequence on the commas outside of quotes.
+func splitYAMLFlow(s string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
+
+// stripYAMLComment removes the comment at the end of a value, outside of quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	const doc = `# SDK configuration
+service: my-service
+env: "ci" # comment
+enabled: true
+tags:
+  team: 'platform''s'
+  owner: "#go"
+features:
+  - runtime_metrics
+  - file_leak_check
+ignore: [a, "b"]
+quoted: ["a, b", 'c, d', "e\", f", g]
+escaped: "\/path\ttab \x41\u00e9 \"q\" # no comment" # comment
+empty:
+`
+	expected := map[string]interface{}{
+		"service": "my-service",
+		"env":     "ci",
+		"enabled": "true",
+		"tags": map[string]interface{}{
+			"team":  "platform's",
+			"owner": "#go",
+		},
+		"features": []string{"runtime_metrics", "file_leak_check"},
+		"ignore":   []string{"a", "b"},
+		"quoted":   []string{"a, b", "c, d", "e\", f", "g"},
+		"escaped":  "/path\ttab A\u00e9 \"q\" # no comment",
+		"empty":    "",
+	}
+
+	actual, err := ParseYAML([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, doc := range []string{
+		"service",
+		"tags:\n  team: a\n    owner: b",
+		"- item",
+		"env: \"ci",
+		"ignore: [a, b",
+		"tags:\n\tteam: a",
+		`env: "\q"`,
+		`env: "ci\"`,
+		`env: "\u00"`,
+	} {
+		if _, err := ParseYAML([]byte(doc)); err == nil {
+			t.Errorf("%q: expected an error", doc)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/isolated"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// envIsolatedTracer is the environment variable enabling the isolated tracer.
+const envIsolatedTracer = "DD_CIVISIBILITY_ISOLATED_TRACER"
+
+// isolatedTracerByEnv returns whether the DD_CIVISIBILITY_ISOLATED_TRACER environment variable
+// enables the isolated tracer.
+func isolatedTracerByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envIsolatedTracer))
+	return v
+}
+
+// currentIsolatedTracer returns the isolated tracer of the session, or nil.
+func currentIsolatedTracer() *isolated.Tracer {
+	return isolated.Current()
+}
+
+// setIsolatedTracer sets the isolated tracer of the session, nil to use the global tracer. The
+// spans of the contrib packages are started with it too.
+func setIsolatedTracer(t *isolated.Tracer) {
+	isolated.SetCurrent(t)
+}
+
+// newIsolatedTracer returns the isolated tracer of the session, writing the spans to the output
+// file in offline export mode, sending them to the intake in agentless mode, to the EVP proxy of
+// the agent when it was detected, and to the traces endpoint of the agent otherwise.
+func newIsolatedTracer(cfg *runConfig, service, env string) *isolated.Tracer {
+	globalTags := make(map[string]interface{}, len(cfg.globalTags))
+	for _, tag := range cfg.globalTags {
+		globalTags[tag[0]] = tag[1]
+	}
+	isolatedCfg := isolated.Config{
+		Service:    service,
+		Env:        env,
+		GlobalTags: globalTags,
+	}
+	if cfg.outputFile != nil {
+		isolatedCfg.Transport = isolated.TransportFile
+		isolatedCfg.Output = cfg.outputFile
+	} else if intake, apiKey, err := agentlessIntake(cfg); cfg.agentless && err == nil {
+		isolatedCfg.URL = intake
+		isolatedCfg.Transport = isolated.TransportAgentless
+		isolatedCfg.APIKey = apiKey
+		isolatedCfg.Client = intakeClient(cfg, cfg.uploadTimeout)
+	} else {
+		isolatedCfg.URL, isolatedCfg.Client = agentURL(cfg, cfg.uploadTimeout)
+		if cfg.evpProxy {
+			isolatedCfg.Transport = isolated.TransportEVPProxy
+		}
+	}
+	return isolated.New(isolatedCfg)
+}
+
+// testCycleClient returns the HTTP client of the global tracer, converting its traces into test
+// cycle events sent like the ones of the isolated tracer, in agentless mode or through the EVP
+// proxy of the agent, or written to the output file.
+func testCycleClient(cfg *runConfig, service, env string) *http.Client {
+	_, agentClient := agentURL(cfg, cfg.uploadTimeout)
+	return &http.Client{
+		Timeout:   cfg.uploadTimeout,
+		Transport: isolated.NewTestCycleTransport(newIsolatedTracer(cfg, service, env), agentClient.Transport),
+	}
+}
+
+// startSpanFromContext starts a span of the SDK like tracer.StartSpanFromContext, with the
+// isolated tracer when the session uses one.
+func startSpanFromContext(ctx context.Context, operationName string, opts ...ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	return isolated.StartSpanFromContext(ctx, operationName, opts...)
+}
+
+// extractSpanContext extracts a span context like tracer.Extract, with the isolated tracer
+// when the session uses one.
+func extractSpanContext(carrier interface{}) (ddtrace.SpanContext, error) {
+	return isolated.Extract(carrier)
+}
+
+// flushTracer flushes the isolated tracer when the session uses one, or the global tracer.
+func flushTracer() {
+	if t := currentIsolatedTracer(); t != nil {
+		if err := t.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: sending the test spans: %v\n", err)
+		}
+		return
+	}
+	tracer.Flush()
+}
+
+// stopTracer flushes the isolated tracer when the session uses one, or stops the global tracer.
+// The global tracer is left untouched when the session uses an isolated tracer.
+func stopTracer() {
+	if currentIsolatedTracer() != nil {
+		flushTracer()
+		return
+	}
+	tracer.Stop()
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestIsolatedTracer(t *testing.T) {
+	var traces [][]map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&traces); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	// The global tracer is mocked by the test, the SDK spans don't go through it.
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	cfg := newRunConfig(WithAgentAddr(strings.TrimPrefix(srv.URL, "http://")), WithIsolatedTracer())
+	setIsolatedTracer(newIsolatedTracer(cfg, "svc", "ci"))
+	defer setIsolatedTracer(nil)
+
+	ctx := StartStep(context.Background(), "parent")
+	FinishStep(StartStep(ctx, "child"), nil)
+	FinishStep(ctx, nil)
+	flushTracer()
+
+	if spans := mt.FinishedSpans(); len(spans) != 0 {
+		t.Errorf("unexpected spans in the global tracer: %d", len(spans))
+	}
+	if len(traces) != 1 || len(traces[0]) != 2 {
+		t.Fatalf("unexpected traces %v", traces)
+	}
+	child, parent := traces[0][0], traces[0][1]
+	if child["parent_id"] != parent["span_id"] || parent["type"] != constants.SpanTypeTestStep {
+		t.Errorf("unexpected spans %v %v", child, parent)
+	}
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// leakTestName is the name of the synthetic test reporting the leaked goroutines.
+const leakTestName = "goroutine-leak"
+
+// findLeakedGoroutines returns the error of the find function of WithGoroutineLeakCheck, or
+// lists the leaked goroutines found by the built-in check, in the format of goleak.
+func findLeakedGoroutines(cfg *runConfig) error {
+	if cfg.leakCheckFind != nil {
+		return cfg.leakCheckFind()
+	}
+	if leaks := utils.FindLeakedGoroutines(cfg.leakCheckMaxWait); len(leaks) > 0 {
+		return fmt.Errorf("found unexpected goroutines:\n%s", strings.Join(leaks, "\n\n"))
+	}
+	return nil
+}
+
+// reportLeakedGoroutines reports the leaked goroutines as a failed synthetic test of the suite of
+// the package, in the module of the session like the tests of the package.
+func reportLeakedGoroutines(s *testSession, suite string, err error) {
+	fmt.Fprintf(os.Stderr, "goleak: Errors on successful test run: %v\n", err)
+
+	testSuite := s.defaultModule().StartTestSuite(suite)
+	result := &testResult{
+		name:      leakTestName,
+		suite:     suite,
+		framework: testFramework,
+		start:     now(),
+		status:    constants.TestStatusFail,
+		errorMsg:  err.Error(),
+		errorType: "goroutine_leak",
+		testSuite: testSuite,
+	}
+	opts := append([]tracer.StartSpanOption{
+		tracer.StartTime(now()),
+		childOfSuite(testSuite),
+		tracer.ResourceName(fmt.Sprintf("%s.%s", suite, leakTestName)),
+		tracer.Tag(constants.TestName, leakTestName),
+		tracer.Tag(constants.TestSuite, suite),
+		tracer.Tag(constants.TestSessionID, s.id),
+		tracer.Tag(constants.TestFramework, testFramework),
+		tracer.Tag(constants.TestType, constants.TestTypeTest),
+		tracer.Tag(constants.Origin, constants.CIAppTestOrigin),
+		tracer.Tag(constants.TestStatus, result.status),
+		tracer.Tag(ext.Error, true),
+		tracer.Tag(ext.ErrorMsg, result.errorMsg),
+		tracer.Tag(ext.ErrorType, result.errorType),
+	}, testHierarchyTags(testSuite)...)
+	opts = append(opts, defaultSpanOpts...)
+	optTags := spanOptionTags(opts)
+	span, _ := startScrubbedSpanWithTags(context.Background(), constants.SpanTypeTest, optTags, opts...)
+	setTestCITags(span, optTags)
+	releaseSpanOptionTags(optTags)
+	span.SetTag(constants.TestCorrelationID, correlationID(suite, leakTestName))
+	span.Finish()
+	result.finish = now()
+	writeTestResult(result)
+}
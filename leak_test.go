@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestReportLeakedGoroutines(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// The error of the find function is reported as is.
+	leak := errors.New("found unexpected goroutines:\n[Goroutine 7 in state chan receive]")
+	cfg := newRunConfig(WithGoroutineLeakCheck(func() error { return leak }))
+	if err := findLeakedGoroutines(cfg); err != leak {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := &testSession{
+		id:     "1",
+		cfg:    newRunConfig(),
+		span:   tracer.StartSpan(constants.SpanTypeTestSession),
+		rollup: new(statusRollup),
+	}
+	var results []*testResult
+	defer func(writers []func(*testResult)) { resultWriters = writers }(resultWriters)
+	addResultWriter(func(r *testResult) { results = append(results, r) })
+	reportLeakedGoroutines(s, "github.com/DataDog/example", leak)
+	module := s.defaultModule()
+	module.Finish()
+	s.span.Finish()
+
+	var test, suite mocktracer.Span
+	for _, span := range mt.FinishedSpans() {
+		switch span.OperationName() {
+		case constants.SpanTypeTest:
+			test = span
+		case constants.SpanTypeTestSuite:
+			suite = span
+		}
+	}
+	if test == nil || suite == nil {
+		t.Fatalf("unexpected spans: %v", mt.FinishedSpans())
+	}
+	if test.ParentID() != suite.SpanID() || test.Tag(constants.TestSessionID) != "1" ||
+		test.Tag(constants.TestModuleID) != module.ID() || test.Tag(constants.TestSuiteID) != suite.Tag(constants.TestSuiteID) {
+		t.Errorf("the leak test isn't a child of the suite: %v", test.Tags())
+	}
+	if test.Tag(constants.TestName) != leakTestName || test.Tag(constants.TestStatus) != constants.TestStatusFail ||
+		test.Tag(ext.ErrorMsg) != leak.Error() {
+		t.Errorf("unexpected leak test: %v", test.Tags())
+	}
+	if len(results) != 1 || results[0].name != leakTestName || results[0].status != constants.TestStatusFail {
+		t.Errorf("the result of the leak test wasn't written: %v", results)
+	}
+	if suite.Tag(constants.TestStatus) != constants.TestStatusFail || s.rollup.status() != constants.TestStatusFail {
+		t.Error("the suite and the session didn't fail")
+	}
+}
@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/logs"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var (
+	// activeTests contains the running tests, the last one is the active one.
+	activeTests      []activeTest
+	activeTestsMutex sync.Mutex
+)
+
+// activeTest is a running test.
+type activeTest struct {
+	span     ddtrace.Span
+	tb       testing.TB
+	result   *testResult
+	teardown func()
+	// optTags are the tags of the span options, the CI tags don't override them.
+	optTags map[string]interface{}
+}
+
+// ActiveTestSpan returns the span of the last started test still running. It's used to
+// correlate the logs emitted without the test context, when tests run in parallel the
+// correlation is done with the last started test.
+func ActiveTestSpan() (ddtrace.Span, bool) {
+	activeTestsMutex.Lock()
+	defer activeTestsMutex.Unlock()
+	if len(activeTests) == 0 {
+		return nil, false
+	}
+	return activeTests[len(activeTests)-1].span, true
+}
+
+// TestSpan returns the span of the running test of tb. Unlike ActiveTestSpan, it correlates the
+// logs with the right test when tests run in parallel.
+func TestSpan(tb testing.TB) (ddtrace.Span, bool) {
+	activeTestsMutex.Lock()
+	defer activeTestsMutex.Unlock()
+	for i := len(activeTests) - 1; i >= 0; i-- {
+		if activeTests[i].tb == tb {
+			return activeTests[i].span, true
+		}
+	}
+	return nil, false
+}
+
+// activeTestResult returns the result of the last started test still running.
+func activeTestResult() (*testResult, bool) {
+	activeTestsMutex.Lock()
+	defer activeTestsMutex.Unlock()
+	if len(activeTests) == 0 {
+		return nil, false
+	}
+	return activeTests[len(activeTests)-1].result, true
+}
+
+// CorrelationIDs returns the trace and span IDs used to correlate a log entry with a test: the
+// IDs of the span of ctx, or of the active test span when ctx has no span. ctx can be nil.
+func CorrelationIDs(ctx context.Context) (traceID, spanID uint64, ok bool) {
+	var span ddtrace.Span
+	if ctx != nil {
+		span, ok = tracer.SpanFromContext(ctx)
+	}
+	if !ok {
+		span, ok = ActiveTestSpan()
+	}
+	if !ok {
+		return 0, 0, false
+	}
+	return span.Context().TraceID(), span.Context().SpanID(), true
+}
+
+// pushActiveTest sets the test as the active test. The teardown function stops what was started
+// with the test when it's finished by the watchdog, optTags are the tags of its span options.
+func pushActiveTest(span ddtrace.Span, tb testing.TB, result *testResult, teardown func(), optTags map[string]interface{}) {
+	activeTestsMutex.Lock()
+	defer activeTestsMutex.Unlock()
+	activeTests = append(activeTests, activeTest{span: span, tb: tb, result: result, teardown: teardown, optTags: optTags})
+}
+
+// removeActiveTest removes the test from the running tests.
+func removeActiveTest(span ddtrace.Span) {
+	activeTestsMutex.Lock()
+	defer activeTestsMutex.Unlock()
+	for i := len(activeTests) - 1; i >= 0; i-- {
+		if activeTests[i].span == span {
+			activeTests = append(activeTests[:i], activeTests[i+1:]...)
+			return
+		}
+	}
+}
+
+// startLogsForwarding sets the forwarder of the logs of the test binary.
+func startLogsForwarding(cfg *runConfig, service, env string) {
+	apiKey := os.Getenv("DD_API_KEY")
+	if apiKey == "" {
+		fmt.Fprintln(os.Stderr, "dd-sdk-go-testing: the logs aren't forwarded, DD_API_KEY is not set")
+		return
+	}
+	logsCfg := logs.Config{
+		APIKey:       apiKey,
+		Service:      service,
+		MaxEntrySize: cfg.logsMaxEntrySize,
+		MaxTotalSize: cfg.logsMaxTotalSize,
+		Client:       &http.Client{Timeout: cfg.uploadTimeout},
+	}
+	if env != "" {
+		logsCfg.Tags = "env:" + env
+	}
+	logs.SetForwarder(logs.NewForwarder(logsCfg))
+}
+
+// logWriter prefixes the log lines with the IDs of the active test span.
+type logWriter struct {
+	w io.Writer
+}
+
+// NewLogWriter returns a writer prefixing the lines written during a test with the trace and
+// span IDs of the test, and forwarding them to Datadog when the logs forwarding is enabled. It's
+// meant to be the output of the standard library logger:
+//
+//	func TestMain(m *testing.M) {
+//		log.SetOutput(ddtesting.NewLogWriter(os.Stderr))
+//		os.Exit(ddtesting.Run(m))
+//	}
+//
+// The lines written outside of a test are written unchanged.
+func NewLogWriter(w io.Writer) io.Writer {
+	return &logWriter{w: w}
+}
+
+func (lw *logWriter) Write(p []byte) (int, error) {
+	traceID, spanID, ok := CorrelationIDs(nil)
+	if !ok {
+		return lw.w.Write(p)
+	}
+	logs.Send(logs.Entry{
+		Message: strings.TrimSuffix(string(p), "\n"),
+		TraceID: traceID,
+		SpanID:  spanID,
+		Logger:  "log",
+	})
+	if _, err := fmt.Fprintf(lw.w, "[dd.trace_id=%d dd.span_id=%d] %s", traceID, spanID, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestLogWriter(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	buf := new(bytes.Buffer)
+	logger := log.New(NewLogWriter(buf), "", 0)
+	logger.Print("outside")
+
+	span := tracer.StartSpan("test")
+	pushActiveTest(span, t, &testResult{}, nil, nil)
+	logger.Print("inside")
+	if s, ok := TestSpan(t); !ok || s != span {
+		t.Error("expected the span of the test")
+	}
+	removeActiveTest(span)
+	span.Finish()
+
+	expected := fmt.Sprintf("outside\n[dd.trace_id=%d dd.span_id=%d] inside\n", span.Context().TraceID(), span.Context().SpanID())
+	if buf.String() != expected {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+	if _, ok := ActiveTestSpan(); ok {
+		t.Error("unexpected active test span")
+	}
+	if _, ok := TestSpan(t); ok {
+		t.Error("unexpected test span")
+	}
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// envBaseBranch is the environment variable with the branch the changes are compared with to
+// find the modified tests.
+const envBaseBranch = "DD_CIVISIBILITY_BASE_BRANCH"
+
+// changedLines contains the lines changed since the base branch, read with git the first time.
+type changedLines struct {
+	base string
+
+	once    sync.Once
+	changes map[string][]utils.LineRange
+}
+
+// newChangedLines returns the changes since the base branch of the configuration, or the target
+// branch of the pull request tested by the CI provider. It returns nil without base branch, or
+// when the Git metadata collection is disabled.
+func newChangedLines(cfg *runConfig) *changedLines {
+	if cfg.gitCollectionDisabled || !gitCollectionEnabled() {
+		return nil
+	}
+	base := cfg.baseBranch
+	if base == "" {
+		base = utils.ProviderBaseBranch()
+	}
+	if base == "" {
+		return nil
+	}
+	return &changedLines{base: base}
+}
+
+// setTags tags the span of a test with the last line of its function, and as modified when the
+// changes since the base branch overlap its lines. The test isn't tagged when the changes can't
+// be read, like when the base branch wasn't fetched.
+func (c *changedLines) setTags(span ddtrace.Span, file string, line int) {
+	if line <= 0 {
+		return
+	}
+	end, ok := utils.GetSourceEndLine(file, line)
+	if !ok {
+		return
+	}
+	span.SetTag(constants.TestSourceEndLine, end)
+	if c == nil {
+		return
+	}
+	c.once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), currentGitTimeout())
+		defer cancel()
+		changes, err := utils.ChangedLines(ctx, c.base)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: reading the changes since %s: %v\n", c.base, err)
+			return
+		}
+		c.changes = changes
+	})
+	if c.changes != nil {
+		span.SetTag(constants.TestIsModified, utils.Overlaps(c.changes[file], line, end))
+	}
+}
+
+// setSessionTags tags the session span with the size of the changes since the base branch, so the
+// failure rates can be correlated with the size of the changes. The span isn't tagged when the
+// changes can't be read.
+func (c *changedLines) setSessionTags(span ddtrace.Span, timeout time.Duration) {
+	if c == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	stat, err := utils.DiffStats(ctx, c.base)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: reading the diff statistics since %s: %v\n", c.base, err)
+		return
+	}
+	setDiffStatTags(span, c.base, stat)
+}
+
+// setDiffStatTags tags the session span with the size of the changes since the base branch.
+func setDiffStatTags(span ddtrace.Span, base string, stat utils.DiffStat) {
+	span.SetTag(constants.TestSessionDiffBase, base)
+	span.SetTag(constants.TestSessionDiffFilesChanged, float64(stat.FilesChanged))
+	span.SetTag(constants.TestSessionDiffInsertions, float64(stat.Insertions))
+	span.SetTag(constants.TestSessionDiffDeletions, float64(stat.Deletions))
+	directories := stat.Directories
+	if directories == nil {
+		directories = []string{}
+	}
+	if encoded, err := json.Marshal(directories); err == nil {
+		span.SetTag(constants.TestSessionDiffDirectories, string(encoded))
+	}
+}
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestModifiedTags(t *testing.T) {
+	pc, _, _, _ := runtime.Caller(0)
+	file, line := utils.GetSourceLocation(pc)
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	for _, c := range []struct {
+		changes  []utils.LineRange
+		expected bool
+	}{
+		{[]utils.LineRange{{Start: line + 2, End: line + 3}}, true},
+		{[]utils.LineRange{{Start: 1, End: line - 1}}, false},
+	} {
+		// Use the given changes instead of reading them with git.
+		changes := &changedLines{base: "main"}
+		changes.once.Do(func() {})
+		changes.changes = map[string][]utils.LineRange{file: c.changes}
+
+		span := tracer.StartSpan("test")
+		changes.setTags(span, file, line)
+		tags := span.(mocktracer.Span).Tags()
+		if tags[constants.TestIsModified] != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.changes, c.expected, tags[constants.TestIsModified])
+		}
+		if end, ok := tags[constants.TestSourceEndLine].(int); !ok || end <= line {
+			t.Errorf("unexpected end line: %v", tags[constants.TestSourceEndLine])
+		}
+	}
+
+	// Without base branch, only the end line is set.
+	var none *changedLines
+	span := tracer.StartSpan("test")
+	none.setTags(span, file, line)
+	if tag := span.(mocktracer.Span).Tag(constants.TestIsModified); tag != nil {
+		t.Errorf("unexpected tag without base branch: %v", tag)
+	}
+}
+
+func TestNewChangedLines(t *testing.T) {
+	defer os.Setenv(envGitCollectionDisabled, os.Getenv(envGitCollectionDisabled))
+	os.Unsetenv(envGitCollectionDisabled)
+
+	if c := newChangedLines(newRunConfig(WithBaseBranch("main"))); c == nil || c.base != "main" {
+		t.Errorf("unexpected changes: %+v", c)
+	}
+	if c := newChangedLines(newRunConfig(WithBaseBranch("main"), WithGitCollectionDisabled())); c != nil {
+		t.Errorf("unexpected changes with the Git collection disabled: %+v", c)
+	}
+}
+
+func TestDiffStatTags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("session")
+	setDiffStatTags(span, "main", utils.DiffStat{FilesChanged: 3, Insertions: 20, Deletions: 4, Directories: []string{".", "payments"}})
+	span.Finish()
+
+	tags := mt.FinishedSpans()[0].Tags()
+	for key, expected := range map[string]interface{}{
+		constants.TestSessionDiffBase:         "main",
+		constants.TestSessionDiffFilesChanged: float64(3),
+		constants.TestSessionDiffInsertions:   float64(20),
+		constants.TestSessionDiffDeletions:    float64(4),
+		constants.TestSessionDiffDirectories:  `[".","payments"]`,
+	} {
+		if tags[key] != expected {
+			t.Errorf("%s: expected %v, got %v", key, expected, tags[key])
+		}
+	}
+}
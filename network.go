@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// maxNetworkHosts is the maximum number of hosts listed in the host list tag.
+const maxNetworkHosts = 20
+
+// networkStatsEnabled is set when a dialer counting the network activity has been created,
+// so the tests without network activity report zero metrics.
+var networkStatsEnabled int32
+
+// networkStats counts the network activity of a test.
+type networkStats struct {
+	connections   int64
+	bytesSent     int64
+	bytesReceived int64
+
+	mu    sync.Mutex
+	hosts map[string]struct{}
+}
+
+// DialContextFunc is the signature of net.Dialer.DialContext and http.Transport.DialContext.
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// NetworkStatsDialer wraps dial to count the connections, the bytes sent and received and the
+// hosts contacted by each test, set as metrics of the test span. A nil dial uses a net.Dialer.
+// The connections are attributed to the test of ctx, or to the last started test otherwise. The
+// default HTTP transport can be wrapped in TestMain to find the tests using the network:
+//
+//	transport := http.DefaultTransport.(*http.Transport)
+//	transport.DialContext = ddtesting.NetworkStatsDialer(transport.DialContext)
+func NetworkStatsDialer(dial DialContextFunc) DialContextFunc {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	atomic.StoreInt32(&networkStatsEnabled, 1)
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return conn, err
+		}
+		result, ok := testResultFromContext(ctx)
+		if !ok {
+			result, ok = activeTestResult()
+		}
+		if !ok {
+			return conn, err
+		}
+
+		stats := &result.network
+		atomic.AddInt64(&stats.connections, 1)
+		host, _, splitErr := net.SplitHostPort(address)
+		if splitErr != nil {
+			host = address
+		}
+		stats.mu.Lock()
+		if stats.hosts == nil {
+			stats.hosts = map[string]struct{}{}
+		}
+		stats.hosts[host] = struct{}{}
+		stats.mu.Unlock()
+		return &countingConn{Conn: conn, stats: stats}, nil
+	}
+}
+
+// countingConn counts the bytes sent and received through a connection.
+type countingConn struct {
+	net.Conn
+	stats *networkStats
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(&c.stats.bytesReceived, int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(&c.stats.bytesSent, int64(n))
+	return n, err
+}
+
+// setNetworkMetrics sets the network activity of a test as metrics of its span. The bytes
+// transferred after the test finishes, e.g. by pooled connections, aren't reported.
+func setNetworkMetrics(span ddtrace.Span, result *testResult) {
+	if atomic.LoadInt32(&networkStatsEnabled) == 0 {
+		return
+	}
+	stats := &result.network
+	stats.mu.Lock()
+	hosts := make([]string, 0, len(stats.hosts))
+	for host := range stats.hosts {
+		hosts = append(hosts, host)
+	}
+	stats.mu.Unlock()
+
+	span.SetTag(constants.TestNetworkConnections, float64(atomic.LoadInt64(&stats.connections)))
+	span.SetTag(constants.TestNetworkBytesSent, float64(atomic.LoadInt64(&stats.bytesSent)))
+	span.SetTag(constants.TestNetworkBytesReceived, float64(atomic.LoadInt64(&stats.bytesReceived)))
+	span.SetTag(constants.TestNetworkHosts, float64(len(hosts)))
+	if len(hosts) > 0 {
+		sort.Strings(hosts)
+		if len(hosts) > maxNetworkHosts {
+			hosts = hosts[:maxNetworkHosts]
+		}
+		span.SetTag(constants.TestNetworkHostList, strings.Join(hosts, ","))
+	}
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestNetworkStatsDialer(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: &http.Transport{DialContext: NetworkStatsDialer(nil)}}
+
+	_, finish := StartTest(t)
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	s := spans[0]
+	if s.Tag(constants.TestNetworkConnections) != float64(1) || s.Tag(constants.TestNetworkHosts) != float64(1) ||
+		s.Tag(constants.TestNetworkHostList) != "127.0.0.1" {
+		t.Errorf("unexpected network tags: %v", s.Tags())
+	}
+	if sent, _ := s.Tag(constants.TestNetworkBytesSent).(float64); sent == 0 {
+		t.Error("missing bytes sent")
+	}
+	if received, _ := s.Tag(constants.TestNetworkBytesReceived).(float64); received == 0 {
+		t.Error("missing bytes received")
+	}
+}
@@ -6,8 +6,15 @@
 package dd_sdk_go_testing
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
 	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
@@ -20,12 +27,92 @@ var (
 	// tags contains information detected from CI/CD environment variables.
 	tags      map[string]string
 	tagsMutex sync.Mutex
+
+	// tagsOnce and tagsReady coordinate the background detection of the tags.
+	tagsOnce  sync.Once
+	tagsReady = make(chan struct{})
+
+	// gitCollectionDisabled is set when the session disables the Git metadata collection.
+	gitCollectionDisabled int32
+
+	// sessionLevelCITags is set when the session reports the CI tags only in its span.
+	sessionLevelCITags int32
+)
+
+// envGitCollectionDisabled is the environment variable disabling the Git metadata collection.
+const envGitCollectionDisabled = "DD_CIVISIBILITY_GIT_COLLECTION_DISABLED"
+
+// envCITagsLevel is the environment variable defining the spans with the CI and Git tags:
+// "test", the default, sets them in every span, and "session" only in the session span.
+const envCITagsLevel = "DD_CIVISIBILITY_CI_TAGS_LEVEL"
+
+// Define the valid levels of the CI tags.
+const (
+	ciTagsLevelTest    = "test"
+	ciTagsLevelSession = "session"
+)
+
+// testLevelCITags are the CI tags kept in the test spans when the session reports the
+// others only in its span.
+var testLevelCITags = []string{
+	constants.GitRepositoryURL,
+	constants.GitCommitSHA,
+	constants.GitBranch,
+	constants.CIMatrix,
+}
+
+var (
+	// configPool reuses the config structs and their option slices between tests.
+	configPool = sync.Pool{
+		New: func() interface{} {
+			return &config{
+				spanOpts:   make([]ddtrace.StartSpanOption, 0, 16),
+				startOpts:  make([]ddtrace.StartSpanOption, 0, 32),
+				finishOpts: make([]ddtrace.FinishOption, 0, 4),
+			}
+		},
+	}
+
+	// spanConfigPool and spanTagsPool reuse the span configurations and the tag maps computing
+	// the tags of the span options.
+	spanConfigPool = sync.Pool{
+		New: func() interface{} { return new(ddtrace.StartSpanConfig) },
+	}
+	spanTagsPool = sync.Pool{
+		New: func() interface{} { return map[string]interface{}{} },
+	}
+
+	// defaultSpanOpts contains the span options shared by all the tests.
+	defaultSpanOpts = []ddtrace.StartSpanOption{
+		tracer.SpanType(constants.SpanTypeTest),
+		tracer.Tag(constants.SpanKind, spanKind),
+		tracer.Tag(ext.ManualKeep, true),
+	}
 )
 
 type config struct {
 	skip       int
+	suite      string
+	testSuite  *TestSuite
+	framework  string
+	sourceFile string
+	sourceLine int
+	ambient    bool
+	flaky      bool
+	iterations bool
+	timeout    time.Duration
 	spanOpts   []ddtrace.StartSpanOption
 	finishOpts []ddtrace.FinishOption
+
+	// maxDuration is the duration budget of the test, failing it when maxDurationEnforced is set.
+	maxDuration         time.Duration
+	maxDurationEnforced bool
+
+	// frameworkVersion is the version of the framework, it's only tagged when it's set.
+	frameworkVersion string
+
+	// startOpts is the buffer used to build the final list of span options.
+	startOpts []ddtrace.StartSpanOption
 }
 
 // Option represents an option that can be passed to NewServeMux or WrapHandler.
@@ -34,34 +121,165 @@ type Option func(*config)
 func defaults(cfg *config) {
 	// When StartSpanWithFinish is called directly from test function.
 	cfg.skip = 1
-	cfg.spanOpts = []ddtrace.StartSpanOption{
-		tracer.SpanType(constants.SpanTypeTest),
-		tracer.Tag(constants.SpanKind, spanKind),
-		tracer.Tag(ext.ManualKeep, true),
+	cfg.suite = ""
+	cfg.testSuite = nil
+	cfg.framework = testFramework
+	cfg.frameworkVersion = ""
+	cfg.sourceFile = ""
+	cfg.sourceLine = 0
+	cfg.ambient = false
+	cfg.flaky = false
+	cfg.iterations = false
+	cfg.timeout = 0
+	cfg.maxDuration = 0
+	cfg.maxDurationEnforced = false
+	cfg.spanOpts = append(cfg.spanOpts[:0], defaultSpanOpts...)
+
+	// Start the CI tags detection, the tags are set when the span finishes.
+	startCITagsDetection()
+
+	cfg.finishOpts = cfg.finishOpts[:0]
+}
+
+// acquireConfig gets a config from the pool initialized with the defaults.
+func acquireConfig() *config {
+	cfg := configPool.Get().(*config)
+	defaults(cfg)
+	return cfg
+}
+
+// releaseConfig returns a config to the pool, the config must not be used afterwards.
+func releaseConfig(cfg *config) {
+	// Clear the slices so the pool doesn't retain the options of the previous test.
+	for i := range cfg.spanOpts {
+		cfg.spanOpts[i] = nil
+	}
+	for i := range cfg.startOpts {
+		cfg.startOpts[i] = nil
 	}
+	for i := range cfg.finishOpts {
+		cfg.finishOpts[i] = nil
+	}
+	cfg.testSuite = nil
+	cfg.spanOpts = cfg.spanOpts[:0]
+	cfg.startOpts = cfg.startOpts[:0]
+	cfg.finishOpts = cfg.finishOpts[:0]
+	configPool.Put(cfg)
+}
+
+// startCITagsDetection starts the detection of the CI, Git and OS tags in background.
+// The detection runs only once, so it can be called many times.
+func startCITagsDetection() {
+	loadCIEnvFile()
+	tagsOnce.Do(func() {
+		go func() {
+			defer close(tagsReady)
+			localTags := detectCITags()
+
+			// Replace global tags with local copy
+			tagsMutex.Lock()
+			defer tagsMutex.Unlock()
+
+			tags = localTags
+		}()
+	})
+}
+
+// ensureCITags blocks until the CI tags detection has finished.
+func ensureCITags() {
+	startCITagsDetection()
+	<-tagsReady
+}
 
-	// Ensure CI tags
+// setCITags waits for the CI tags and sets them in the span, skipping the tags already set by
+// the span options, returned by spanOptionTags, or with SetTag while the span was running.
+func setCITags(span ddtrace.Span, optTags map[string]interface{}) {
 	ensureCITags()
 	forEachCITags(func(k, v string) {
-		cfg.spanOpts = append(cfg.spanOpts, tracer.Tag(k, v))
+		if !hasTag(span, optTags, k) {
+			span.SetTag(k, v)
+		}
 	})
+}
 
-	cfg.finishOpts = []ddtrace.FinishOption{}
+// hasTag returns whether the tag was set by the span options or with SetTag on a scrubbed span.
+func hasTag(span ddtrace.Span, optTags map[string]interface{}, key string) bool {
+	if _, ok := optTags[key]; ok {
+		return true
+	}
+	s, ok := span.(*scrubbingSpan)
+	return ok && s.hasCITag(key)
 }
 
-func ensureCITags() {
-	if tags != nil {
+// setTestCITags sets the CI tags in the span of a test. When the session reports them only
+// in its span, the test span only gets the tags joining it with its repository and commit.
+func setTestCITags(span ddtrace.Span, optTags map[string]interface{}) {
+	if atomic.LoadInt32(&sessionLevelCITags) == 0 {
+		setCITags(span, optTags)
 		return
 	}
 
-	localTags := utils.GetProviderTags()
+	ensureCITags()
+	for _, k := range testLevelCITags {
+		if hasTag(span, optTags, k) {
+			continue
+		}
+		if v, ok := getFromCITags(k); ok {
+			span.SetTag(k, v)
+		}
+	}
+}
+
+// spanOptionTags returns the tags set by the span options. They're computed once when the span
+// starts, and the map is given back with releaseSpanOptionTags when the span is finished.
+func spanOptionTags(spanOpts []ddtrace.StartSpanOption) map[string]interface{} {
+	spanCfg := spanConfigPool.Get().(*ddtrace.StartSpanConfig)
+	spanCfg.Tags = spanTagsPool.Get().(map[string]interface{})
+	for _, fn := range spanOpts {
+		fn(spanCfg)
+	}
+	tags := spanCfg.Tags
+	*spanCfg = ddtrace.StartSpanConfig{}
+	spanConfigPool.Put(spanCfg)
+	return tags
+}
+
+// releaseSpanOptionTags gives back the tags returned by spanOptionTags, they can't be used
+// anymore.
+func releaseSpanOptionTags(tags map[string]interface{}) {
+	if tags == nil {
+		return
+	}
+	for k := range tags {
+		delete(tags, k)
+	}
+	spanTagsPool.Put(tags)
+}
+
+func detectCITags() map[string]string {
+	localTags := utils.GetEnvironmentTags()
 	localTags[constants.OSPlatform] = utils.OSName()
 	localTags[constants.OSVersion] = utils.OSVersion()
-	localTags[constants.OSArchitecture] = runtime.GOARCH
-	localTags[constants.RuntimeName] = runtime.Compiler
-	localTags[constants.RuntimeVersion] = runtime.Version()
+	localTags[constants.OSArchitecture] = utils.NormalizeArchitecture(runtime.GOARCH)
+	for k, v := range utils.GetOSInfo().Tags() {
+		localTags[k] = v
+	}
+	localTags[constants.RuntimeName] = utils.RuntimeName
+	localTags[constants.RuntimeVersion] = utils.NormalizeRuntimeVersion(runtime.Version())
+	localTags[constants.RuntimeCompiler] = runtime.Compiler
 
-	gitData, _ := utils.LocalGetGitData()
+	if !gitCollectionEnabled() {
+		return localTags
+	}
+	gitStart := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), currentGitTimeout())
+	gitData, _ := utils.LocalGetGitDataContext(ctx)
+	if ctx.Err() == context.DeadlineExceeded {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: the git commands timed out after %v, the Git metadata may be missing\n",
+			currentGitTimeout())
+	}
+	cancel()
+	addOverhead(&overhead.git, gitStart)
 
 	// Guess Git metadata from a local Git repository otherwise.
 	if _, ok := localTags[constants.CIWorkspacePath]; !ok {
@@ -101,11 +319,34 @@ func ensureCITags() {
 		}
 	}
 
-	// Replace global tags with local copy
-	tagsMutex.Lock()
-	defer tagsMutex.Unlock()
+	return localTags
+}
+
+// gitCollectionEnabled returns whether the Git metadata is read from the local repository
+// when it's not provided by the CI environment variables.
+func gitCollectionEnabled() bool {
+	if atomic.LoadInt32(&gitCollectionDisabled) == 1 {
+		return false
+	}
+	disabled, _ := strconv.ParseBool(os.Getenv(envGitCollectionDisabled))
+	return !disabled
+}
 
-	tags = localTags
+// ciTagsLevelByEnv returns the level of the CI tags defined by DD_CIVISIBILITY_CI_TAGS_LEVEL.
+// It's empty when the variable isn't set, so the remote toggles can change it: the tags are
+// then set in the test spans.
+func ciTagsLevelByEnv() string {
+	switch level := strings.ToLower(os.Getenv(envCITagsLevel)); level {
+	case "":
+		return ""
+	case ciTagsLevelTest:
+		return ciTagsLevelTest
+	case ciTagsLevelSession:
+		return ciTagsLevelSession
+	default:
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: invalid %s value %q, using %q\n", envCITagsLevel, level, ciTagsLevelTest)
+		return ciTagsLevelTest
+	}
 }
 
 func getFromCITags(key string) (string, bool) {
@@ -119,6 +360,17 @@ func getFromCITags(key string) (string, bool) {
 	return "", false
 }
 
+// CITags returns a copy of the CI and Git tags reported on the spans of the tests, waiting for
+// their detection when it's still running. The tag names are the constants of the ext/ci package.
+func CITags() map[string]string {
+	ensureCITags()
+	copied := map[string]string{}
+	forEachCITags(func(k, v string) {
+		copied[k] = v
+	})
+	return copied
+}
+
 func forEachCITags(itemFunc func(string, string)) {
 	tagsMutex.Lock()
 	defer tagsMutex.Unlock()
@@ -136,6 +388,14 @@ func WithSpanOptions(opts ...ddtrace.StartSpanOption) Option {
 	}
 }
 
+// WithFinishOptions defines a set of additional ddtrace.FinishOption to be used when
+// the test span finishes.
+func WithFinishOptions(opts ...ddtrace.FinishOption) Option {
+	return func(cfg *config) {
+		cfg.finishOpts = append(cfg.finishOpts, opts...)
+	}
+}
+
 // WithSkipFrames defines a how many frames should be skipped for caller autodetection.
 // The value should be changed if StartSpanWithFinish is called from a custom wrapper.
 func WithSkipFrames(skip int) Option {
@@ -150,3 +410,95 @@ func WithIncrementSkipFrame() Option {
 		cfg.skip = cfg.skip + 1
 	}
 }
+
+// WithTestSuite defines the suite name of the test instead of the package of the caller.
+func WithTestSuite(suite string) Option {
+	return func(cfg *config) {
+		cfg.suite = suite
+	}
+}
+
+// WithSuite adds the test to a suite started with StartTestSuite or TestModule.StartTestSuite,
+// instead of the suite of the package of the caller.
+func WithSuite(suite *TestSuite) Option {
+	return func(cfg *config) {
+		if suite != nil {
+			cfg.suite = suite.name
+			cfg.testSuite = suite
+		}
+	}
+}
+
+// WithTestFramework defines the name of the framework used to run the test.
+func WithTestFramework(framework string) Option {
+	return func(cfg *config) {
+		cfg.framework = framework
+	}
+}
+
+// WithTestFrameworkVersion defines the version of the framework used to run the test.
+func WithTestFrameworkVersion(version string) Option {
+	return func(cfg *config) {
+		cfg.frameworkVersion = version
+	}
+}
+
+// WithSourceLocation defines the source file and start line of the test instead of the
+// location of the caller. The line is omitted when it's not greater than zero.
+func WithSourceLocation(file string, line int) Option {
+	return func(cfg *config) {
+		cfg.sourceFile = file
+		cfg.sourceLine = line
+	}
+}
+
+// WithAmbientParent sets the test span as the ambient parent returned by AmbientContext
+// while the test runs.
+func WithAmbientParent() Option {
+	return func(cfg *config) {
+		cfg.ambient = true
+	}
+}
+
+// WithFlaky marks the test as flaky, capturing a runtime execution trace of the test when
+// it runs in a session started by Run or Start.
+func WithFlaky() Option {
+	return func(cfg *config) {
+		cfg.flaky = true
+	}
+}
+
+// WithBenchmarkIterationStats records the durations of the iterations of a benchmark run with
+// BenchmarkLoop, tagging their percentiles and histogram in its span. It's ignored by the tests.
+func WithBenchmarkIterationStats() Option {
+	return func(cfg *config) {
+		cfg.iterations = true
+	}
+}
+
+// WithMaxDuration fails the test when it takes longer than the given duration. The budget and
+// how long the test exceeded it are tagged in its span.
+func WithMaxDuration(max time.Duration) Option {
+	return func(cfg *config) {
+		cfg.maxDuration = max
+		cfg.maxDurationEnforced = true
+	}
+}
+
+// WithDurationBudget tags the test when it takes longer than the given duration, like
+// WithMaxDuration without failing it.
+func WithDurationBudget(budget time.Duration) Option {
+	return func(cfg *config) {
+		cfg.maxDuration = budget
+		cfg.maxDurationEnforced = false
+	}
+}
+
+// WithTimeout sets the time budget of the test. The context returned by StartTest is canceled
+// once it's spent, and the test failing after its context expired is reported with the
+// timeout error type and how long it ran past the budget.
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *config) {
+		cfg.timeout = timeout
+	}
+}
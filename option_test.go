@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestDetectCITagsWithoutGitCollection(t *testing.T) {
+	defer os.Setenv(envGitCollectionDisabled, os.Getenv(envGitCollectionDisabled))
+	os.Setenv(envGitCollectionDisabled, "true")
+
+	providerTags := utils.GetProviderTags()
+	localTags := detectCITags()
+	for _, key := range []string{
+		constants.CIWorkspacePath,
+		constants.GitRepositoryURL,
+		constants.GitCommitSHA,
+		constants.GitBranch,
+		constants.GitCommitAuthorDate,
+		constants.GitCommitMessage,
+	} {
+		if localTags[key] != providerTags[key] {
+			t.Errorf("%s: expected the CI provider value %q, got %q", key, providerTags[key], localTags[key])
+		}
+	}
+	if localTags[constants.OSArchitecture] == "" {
+		t.Error("the OS tags are missing")
+	}
+}
+
+func TestSessionLevelCITags(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	atomic.StoreInt32(&sessionLevelCITags, 1)
+	defer atomic.StoreInt32(&sessionLevelCITags, 0)
+
+	opts := []ddtrace.StartSpanOption{tracer.Tag(constants.GitBranch, "custom")}
+	span := tracer.StartSpan("test", opts...)
+	setTestCITags(span, spanOptionTags(opts))
+	span.Finish()
+
+	tags := mt.FinishedSpans()[0].Tags()
+	if url, ok := getFromCITags(constants.GitRepositoryURL); ok && tags[constants.GitRepositoryURL] != url {
+		t.Errorf("unexpected repository URL: %v", tags[constants.GitRepositoryURL])
+	}
+	if v := tags[constants.GitBranch]; v != "custom" {
+		t.Errorf("the span option doesn't take precedence: %v", v)
+	}
+	if v, ok := tags[constants.OSPlatform]; ok {
+		t.Errorf("the test span has the session level tags: %v", v)
+	}
+}
+
+func TestSpanOptionTagsAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector drops the items of the pools")
+	}
+	opts := append([]ddtrace.StartSpanOption{
+		tracer.Tag(constants.TestName, "TestName"),
+		tracer.Tag(constants.TestSuite, "suite"),
+	}, defaultSpanOpts...)
+	allocs := testing.AllocsPerRun(100, func() {
+		releaseSpanOptionTags(spanOptionTags(opts))
+	})
+	if allocs != 0 {
+		t.Errorf("computing the tags of the span options allocates %v times", allocs)
+	}
+}
+
+func TestCITagsSetByTest(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx, finish := StartTest(t)
+	span, _ := tracer.SpanFromContext(ctx)
+	span.SetTag(constants.GitBranch, "custom")
+	finish()
+
+	if v := mt.FinishedSpans()[0].Tag(constants.GitBranch); v != "custom" {
+		t.Errorf("the CI tags override the tag set by the test: %v", v)
+	}
+}
+
+func TestCITagsLevelByEnv(t *testing.T) {
+	defer os.Setenv(envCITagsLevel, os.Getenv(envCITagsLevel))
+	for value, expected := range map[string]string{
+		"":        "",
+		"test":    ciTagsLevelTest,
+		"Session": ciTagsLevelSession,
+		"suite":   ciTagsLevelTest,
+	} {
+		os.Setenv(envCITagsLevel, value)
+		if level := ciTagsLevelByEnv(); level != expected {
+			t.Errorf("%q: expected %q, got %q", value, expected, level)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+)
+
+const (
+	// envOutput is the environment variable writing the test events to a local file instead of
+	// sending them, given as file:<path>.
+	envOutput = "DD_CIVISIBILITY_OUTPUT"
+
+	// outputFileScheme is the prefix of the output files.
+	outputFileScheme = "file:"
+)
+
+// outputFilePath returns the path of the file of an output given as file:<path>.
+func outputFilePath(output string) (string, error) {
+	if !strings.HasPrefix(output, outputFileScheme) || len(output) == len(outputFileScheme) {
+		return "", fmt.Errorf("invalid output %q, expected file:<path>", output)
+	}
+	return utils.GetArtifactsPath(strings.TrimPrefix(output, outputFileScheme)), nil
+}
+
+// outputFile is the file the test events are written to in offline export mode, shared by the
+// isolated tracer and the HTTP client of the global tracer. Every write is a whole line.
+type outputFile struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// openOutput opens the output file of the configuration, if any, in append mode so the test
+// binaries of a `go test` invocation can share it. The agentless mode is disabled, nothing is
+// sent while the test events are written to the file. The events are sent as usual when the
+// file can't be opened.
+func openOutput(cfg *runConfig) {
+	if cfg.output == "" {
+		return
+	}
+	path, err := outputFilePath(cfg.output)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			var f *os.File
+			if f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644); err == nil {
+				cfg.outputFile = &outputFile{path: path, f: f}
+				cfg.agentless = false
+				return
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: the test events are sent instead of written to a file: %v\n", err)
+}
+
+func (o *outputFile) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.f.Write(p)
+}
+
+// Close closes the file, once the tracers are stopped.
+func (o *outputFile) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.f.Close()
+}
+
+// UploadOutputFile sends the test events written to a file in offline export mode, with
+// DD_CIVISIBILITY_OUTPUT or WithOutputFile, from a machine with network access. The events are
+// sent to the intake in agentless mode, enabled with DD_CIVISIBILITY_AGENTLESS_ENABLED or
+// WithAgentless, or to the EVP proxy of the agent otherwise. It returns the number of events
+// sent, and an error when the file can't be read or the events can't be sent.
+func UploadOutputFile(path string, runOpts ...RunOption) (int, error) {
+	cfg := newRunConfig(runOpts...)
+	cfg.output = ""
+	if cfg.agentless {
+		if _, _, err := agentlessIntake(cfg); err != nil {
+			return 0, fmt.Errorf("the agentless mode can't be used: %v", err)
+		}
+	} else {
+		supported, err := agentEVPProxy(cfg, cfg.settingsTimeout)
+		if err != nil {
+			return 0, fmt.Errorf("detecting the EVP proxy of the agent: %v", err)
+		}
+		if !supported {
+			return 0, fmt.Errorf("the %s has no EVP proxy, enable the agentless mode", intakeDescription(cfg))
+		}
+		cfg.evpProxy = true
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return newIsolatedTracer(cfg, "", "").Upload(f)
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dd-output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "results", "dd-results.ndjson")
+
+	// The test spans are written to the file, even in agentless mode.
+	os.Setenv("DD_API_KEY", "key")
+	defer os.Unsetenv("DD_API_KEY")
+	cfg := newRunConfig(WithOutputFile(path), WithIsolatedTracer(), WithAgentless())
+	openOutput(cfg)
+	if cfg.outputFile == nil || cfg.agentless {
+		t.Fatal("expected the output file to be opened")
+	}
+	if got := intakeDescription(cfg); got != "file at "+path {
+		t.Errorf("unexpected intake description: %s", got)
+	}
+	setIsolatedTracer(newIsolatedTracer(cfg, "svc", "ci"))
+	FinishStep(StartStep(context.Background(), "step"), nil)
+	flushTracer()
+	setIsolatedTracer(nil)
+	if err := cfg.outputFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"test.step.name":"step"`) {
+		t.Fatalf("unexpected output file %q: %v", data, err)
+	}
+
+	// The file is uploaded through the EVP proxy of the agent.
+	var paths []string
+	endpoints := `["/v0.4/traces", "/evp_proxy/v2/", "/info"]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" {
+			w.Write([]byte(`{"endpoints": ` + endpoints + `}`))
+			return
+		}
+		paths = append(paths, r.URL.Path)
+	}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	n, err := UploadOutputFile(path, WithAgentAddr(addr))
+	if err != nil || n != 1 || len(paths) != 1 || paths[0] != "/evp_proxy/v2/api/v2/citestcycle" {
+		t.Errorf("unexpected upload of %d events, requests %v: %v", n, paths, err)
+	}
+	endpoints = `["/v0.4/traces", "/info"]`
+	if _, err := UploadOutputFile(path, WithAgentAddr(addr)); err == nil {
+		t.Error("expected an error without EVP proxy")
+	}
+
+	// Invalid outputs are ignored.
+	cfg = newRunConfig(WithAgentless())
+	cfg.output = "s3://bucket"
+	openOutput(cfg)
+	if cfg.outputFile != nil || !cfg.agentless {
+		t.Error("unexpected output file")
+	}
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// overhead accumulates the time in nanoseconds spent by the SDK in each operation.
+var overhead struct {
+	startTest  int64
+	finishTest int64
+	git        int64
+	flush      int64
+}
+
+// addOverhead adds the time elapsed since start to the given counter.
+func addOverhead(counter *int64, start time.Time) {
+	atomic.AddInt64(counter, int64(time.Since(start)))
+}
+
+// setOverheadMetrics sets the accumulated overhead of the SDK as metrics of the span.
+func setOverheadMetrics(span ddtrace.Span) {
+	metrics := []struct {
+		name    string
+		counter *int64
+	}{
+		{constants.SDKOverheadStartTest, &overhead.startTest},
+		{constants.SDKOverheadFinishTest, &overhead.finishTest},
+		{constants.SDKOverheadGit, &overhead.git},
+		{constants.SDKOverheadFlush, &overhead.flush},
+	}
+
+	var total int64
+	for _, metric := range metrics {
+		value := atomic.LoadInt64(metric.counter)
+		total += value
+		span.SetTag(metric.name, toMilliseconds(value))
+	}
+	span.SetTag(constants.SDKOverhead, toMilliseconds(total))
+}
+
+func toMilliseconds(ns int64) float64 {
+	return float64(ns) / float64(time.Millisecond)
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestOverheadMetrics(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	func() {
+		_, finish := StartTest(t)
+		defer finish()
+	}()
+
+	span := tracer.StartSpan(constants.SpanTypeTestSession)
+	setOverheadMetrics(span)
+	span.Finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 {
+		t.FailNow()
+	}
+
+	s := spans[1]
+	for _, metric := range []string{constants.SDKOverheadStartTest, constants.SDKOverheadFinishTest, constants.SDKOverhead} {
+		if value, ok := s.Tag(metric).(float64); !ok || value <= 0 {
+			t.Errorf("unexpected value for %s: %v", metric, s.Tag(metric))
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// unsafeFileChars matches the characters replaced in the names of the profile files.
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+var (
+	// executionTraceOwner is the test being traced, the runtime supports a single execution trace.
+	executionTraceOwner *executionTrace
+	executionTraceMutex sync.Mutex
+)
+
+var (
+	// cpuProfileOwner is the test being profiled, the runtime supports a single CPU profile.
+	cpuProfileOwner *cpuProfile
+	cpuProfileMutex sync.Mutex
+)
+
+// cpuProfile is the CPU profile of a test. It starts when the test exceeds the threshold,
+// or immediately for the tests of the allow list.
+type cpuProfile struct {
+	path  string
+	timer *time.Timer
+
+	mu      sync.Mutex
+	file    *os.File
+	stopped bool
+}
+
+// profilesDir returns the directory of the profiles of the tests.
+func profilesDir(cfg *runConfig) string {
+	if cfg.profilesDir != "" {
+		return utils.GetArtifactsPath(cfg.profilesDir)
+	}
+	if dir := os.Getenv("TEST_UNDECLARED_OUTPUTS_DIR"); dir != "" {
+		return filepath.Join(dir, "dd-profiles")
+	}
+	return filepath.Join(os.TempDir(), "dd-profiles")
+}
+
+// profilePath returns the path of a profile of a test.
+func profilePath(cfg *runConfig, fqn, ext string) string {
+	name := fmt.Sprintf("%s-%d.%s", unsafeFileChars.ReplaceAllString(fqn, "_"), time.Now().UnixNano(), ext)
+	return filepath.Join(profilesDir(cfg), name)
+}
+
+// startCPUProfile schedules the CPU profile of a test, it returns nil when the test
+// isn't profiled.
+func startCPUProfile(cfg *runConfig, suite, name string) *cpuProfile {
+	fqn := fmt.Sprintf("%s.%s", suite, name)
+	p := &cpuProfile{path: profilePath(cfg, fqn, "cpu.pprof")}
+	for _, test := range cfg.cpuProfileTests {
+		if test == name || test == fqn {
+			p.start()
+			return p
+		}
+	}
+	if cfg.cpuProfileThreshold > 0 {
+		p.timer = time.AfterFunc(cfg.cpuProfileThreshold, p.start)
+		return p
+	}
+	return nil
+}
+
+// start starts the CPU profile unless another test is being profiled.
+func (p *cpuProfile) start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+
+	cpuProfileMutex.Lock()
+	defer cpuProfileMutex.Unlock()
+	if cpuProfileOwner != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return
+	}
+	file, err := os.Create(p.path)
+	if err != nil {
+		return
+	}
+	// It fails when the CPU profile is already enabled, e.g. with the -cpuprofile flag.
+	if err := pprof.StartCPUProfile(file); err != nil {
+		file.Close()
+		os.Remove(p.path)
+		return
+	}
+	p.file = file
+	cpuProfileOwner = p
+}
+
+// stop stops the CPU profile, setting its path in the span when it was captured.
+func (p *cpuProfile) stop(span ddtrace.Span) {
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	if p.file == nil {
+		return
+	}
+
+	cpuProfileMutex.Lock()
+	pprof.StopCPUProfile()
+	cpuProfileOwner = nil
+	cpuProfileMutex.Unlock()
+
+	if err := p.file.Close(); err == nil {
+		span.SetTag(constants.TestProfileCPU, p.path)
+	}
+	p.file = nil
+}
+
+// captureHeapProfile writes a heap profile of a failed test, or of a test finishing with more
+// heap in use than the threshold, and sets its path in the span.
+func captureHeapProfile(cfg *runConfig, span ddtrace.Span, fqn, status string) {
+	if !cfg.heapProfile {
+		return
+	}
+	if status != constants.TestStatusFail {
+		if cfg.heapProfileThreshold == 0 {
+			return
+		}
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		if mem.HeapInuse < cfg.heapProfileThreshold {
+			return
+		}
+	}
+
+	path := profilePath(cfg, fqn, "heap.pprof")
+	if err := writeHeapProfile(path); err == nil {
+		span.SetTag(constants.TestProfileHeap, path)
+	}
+}
+
+// writeHeapProfile writes a heap profile with the up-to-date statistics in the given path.
+func writeHeapProfile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.Lookup("heap").WriteTo(file, 0); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// executionTrace is the runtime execution trace of a test.
+type executionTrace struct {
+	path string
+	file *os.File
+}
+
+// startExecutionTrace starts the execution trace of a test marked as flaky, by the test
+// options, the run options or the DD_CIVISIBILITY_FLAKY_TESTS environment variable. It returns
+// nil when the test isn't traced or another test is being traced.
+func startExecutionTrace(cfg *runConfig, suite, name string, flaky bool) *executionTrace {
+	fqn := fmt.Sprintf("%s.%s", suite, name)
+	if !flaky {
+		tests := cfg.executionTraceTests
+		if env := os.Getenv("DD_CIVISIBILITY_FLAKY_TESTS"); env != "" {
+			tests = append(strings.Split(env, ","), tests...)
+		}
+		for _, test := range tests {
+			if test = strings.TrimSpace(test); test == name || test == fqn {
+				flaky = true
+				break
+			}
+		}
+	}
+	if !flaky {
+		return nil
+	}
+
+	executionTraceMutex.Lock()
+	defer executionTraceMutex.Unlock()
+	if executionTraceOwner != nil {
+		return nil
+	}
+	t := &executionTrace{path: profilePath(cfg, fqn, "trace.out")}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return nil
+	}
+	file, err := os.Create(t.path)
+	if err != nil {
+		return nil
+	}
+	// It fails when the execution trace is already enabled, e.g. with the -trace flag.
+	if err := trace.Start(file); err != nil {
+		file.Close()
+		os.Remove(t.path)
+		return nil
+	}
+	t.file = file
+	executionTraceOwner = t
+	return t
+}
+
+// stop stops the execution trace and sets its path in the span.
+func (t *executionTrace) stop(span ddtrace.Span) {
+	executionTraceMutex.Lock()
+	trace.Stop()
+	executionTraceOwner = nil
+	executionTraceMutex.Unlock()
+
+	if err := t.file.Close(); err == nil {
+		span.SetTag(constants.TestExecutionTrace, t.path)
+	}
+}
+
+// setCodeHotspotsLabels sets the profiler labels linking the samples of the goroutine running
+// the test, and of the goroutines it starts, to the test span. It returns the function
+// restoring the labels of ctx.
+func setCodeHotspotsLabels(ctx context.Context, span ddtrace.Span, resource string) func() {
+	pprof.SetGoroutineLabels(codeHotspotsContext(ctx, span, resource))
+	return func() {
+		pprof.SetGoroutineLabels(ctx)
+	}
+}
+
+// codeHotspotsContext returns ctx with the profiler labels of the test span.
+func codeHotspotsContext(ctx context.Context, span ddtrace.Span, resource string) context.Context {
+	spanID := strconv.FormatUint(span.Context().SpanID(), 10)
+	return pprof.WithLabels(ctx, pprof.Labels(
+		"span id", spanID,
+		"local root span id", spanID,
+		"trace endpoint", resource,
+	))
+}
@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestCPUProfile(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := newRunConfig(WithProfilesDir(dir), WithCPUProfile(20*time.Millisecond, "TestAllowed"))
+
+	if p := startCPUProfile(newRunConfig(), "example.com/pkg", "TestFast"); p != nil {
+		t.Error("unexpected profile without threshold")
+	}
+
+	// A fast test isn't profiled.
+	span := tracer.StartSpan("test")
+	startCPUProfile(cfg, "example.com/pkg", "TestFast").stop(span)
+	span.Finish()
+
+	// A slow test is profiled after the threshold.
+	span = tracer.StartSpan("test")
+	p := startCPUProfile(cfg, "example.com/pkg", "TestSlow")
+	time.Sleep(100 * time.Millisecond)
+	p.stop(span)
+	span.Finish()
+
+	// An allowed test is profiled immediately.
+	span = tracer.StartSpan("test")
+	startCPUProfile(cfg, "example.com/pkg", "TestAllowed").stop(span)
+	span.Finish()
+
+	spans := mt.FinishedSpans()
+	if path := spans[0].Tag(constants.TestProfileCPU); path != nil {
+		t.Errorf("unexpected profile of a fast test: %v", path)
+	}
+	for _, s := range spans[1:] {
+		path, _ := s.Tag(constants.TestProfileCPU).(string)
+		if filepath.Dir(path) != dir || !strings.HasSuffix(path, ".cpu.pprof") {
+			t.Errorf("unexpected profile path: %s", path)
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("unexpected profile file: %v", err)
+		}
+	}
+}
+
+func TestHeapProfile(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, test := range []struct {
+		cfg      *runConfig
+		status   string
+		captured bool
+	}{
+		{newRunConfig(WithProfilesDir(dir)), constants.TestStatusFail, false},
+		{newRunConfig(WithProfilesDir(dir), WithHeapProfile(0)), constants.TestStatusPass, false},
+		{newRunConfig(WithProfilesDir(dir), WithHeapProfile(0)), constants.TestStatusFail, true},
+		{newRunConfig(WithProfilesDir(dir), WithHeapProfile(1)), constants.TestStatusPass, true},
+	} {
+		span := tracer.StartSpan("test")
+		captureHeapProfile(test.cfg, span, "example.com/pkg.TestHeap", test.status)
+		span.Finish()
+
+		spans := mt.FinishedSpans()
+		path, _ := spans[len(spans)-1].Tag(constants.TestProfileHeap).(string)
+		if test.captured != (path != "") {
+			t.Errorf("%s: unexpected heap profile: %q", test.status, path)
+		}
+		if test.captured && !strings.HasSuffix(path, ".heap.pprof") {
+			t.Errorf("%s: unexpected heap profile path: %s", test.status, path)
+		}
+	}
+}
+
+func TestExecutionTrace(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("DD_CIVISIBILITY_FLAKY_TESTS", os.Getenv("DD_CIVISIBILITY_FLAKY_TESTS"))
+	os.Setenv("DD_CIVISIBILITY_FLAKY_TESTS", "TestFromEnv, example.com/pkg.TestOther")
+	cfg := newRunConfig(WithProfilesDir(dir), WithExecutionTrace("TestFromOption"))
+
+	if tr := startExecutionTrace(cfg, "example.com/pkg", "TestStable", false); tr != nil {
+		t.Error("unexpected execution trace of a stable test")
+	}
+	for _, name := range []string{"TestFromEnv", "TestFromOption", "TestOther"} {
+		span := tracer.StartSpan("test")
+		tr := startExecutionTrace(cfg, "example.com/pkg", name, false)
+		if tr == nil {
+			t.Errorf("%s: missing execution trace", name)
+			continue
+		}
+		if other := startExecutionTrace(cfg, "example.com/pkg", "TestFlaky", true); other != nil {
+			t.Errorf("%s: unexpected concurrent execution trace", name)
+		}
+		tr.stop(span)
+		span.Finish()
+
+		spans := mt.FinishedSpans()
+		path, _ := spans[len(spans)-1].Tag(constants.TestExecutionTrace).(string)
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 || !strings.HasSuffix(path, ".trace.out") {
+			t.Errorf("%s: unexpected execution trace %q: %v", name, path, err)
+		}
+	}
+}
+
+func TestCodeHotspotsContext(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	ctx := codeHotspotsContext(context.Background(), span, "pkg.TestHotspots")
+
+	spanID := strconv.FormatUint(span.Context().SpanID(), 10)
+	if v, _ := pprof.Label(ctx, "span id"); v != spanID {
+		t.Errorf("unexpected span id label: %s", v)
+	}
+	if v, _ := pprof.Label(ctx, "trace endpoint"); v != "pkg.TestHotspots" {
+		t.Errorf("unexpected trace endpoint label: %s", v)
+	}
+}
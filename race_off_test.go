@@ -0,0 +1,13 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+//go:build !race
+// +build !race
+
+package dd_sdk_go_testing
+
+// raceEnabled is set when the tests run with the race detector, which drops the items of the
+// pools at random.
+const raceEnabled = false
@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/remoteconfig"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+)
+
+const (
+	// envRemoteConfig is the environment variable enabling the Remote Configuration.
+	envRemoteConfig = "DD_REMOTE_CONFIGURATION_ENABLED"
+
+	// envQuarantinedTests is the environment variable with the quarantined tests, separated by
+	// commas. It replaces the list of the Remote Configuration.
+	envQuarantinedTests = "DD_CIVISIBILITY_QUARANTINED_TESTS"
+
+	// remoteConfigProduct is the Remote Configuration product of the feature toggles.
+	remoteConfigProduct = "CI_VISIBILITY"
+)
+
+// remoteToggles are the feature toggles of a Remote Configuration file.
+type remoteToggles struct {
+	// RepositoryURL restricts the toggles to a repository, when it's set.
+	RepositoryURL    string   `json:"repository_url"`
+	SampleRate       *float64 `json:"sample_rate"`
+	Features         []string `json:"features"`
+	FlakyTests       []string `json:"flaky_tests"`
+	QuarantinedTests []string `json:"quarantined_tests"`
+}
+
+// remoteConfigByEnv returns whether the DD_REMOTE_CONFIGURATION_ENABLED environment variable
+// enables the Remote Configuration.
+func remoteConfigByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envRemoteConfig))
+	return v
+}
+
+// loadRemoteToggles fetches the feature toggles from the agent within the timeout and applies
+// them to the configuration. The errors are reported and the local configuration is kept.
+func loadRemoteToggles(cfg *runConfig, service, env string, timeout time.Duration) {
+	url, client := agentURL(cfg, timeout)
+	files, err := remoteconfig.NewClient(url, client).Fetch(remoteConfigProduct, remoteconfig.ClientInfo{
+		Service: service,
+		Env:     env,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: fetching the remote configuration: %v\n", err)
+		return
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	repository := utils.GetProviderTags()[constants.GitRepositoryURL]
+	for _, path := range paths {
+		var toggles remoteToggles
+		if err := json.Unmarshal(files[path], &toggles); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: %s: invalid remote configuration: %v\n", path, err)
+			continue
+		}
+		if toggles.RepositoryURL != "" && repository != "" &&
+			normalizeRepositoryURL(toggles.RepositoryURL) != normalizeRepositoryURL(repository) {
+			continue
+		}
+		toggles.apply(cfg)
+	}
+}
+
+// apply sets the toggles that aren't set by the options or the environment variables.
+func (t *remoteToggles) apply(cfg *runConfig) {
+	if t.SampleRate != nil && cfg.sampleRate == noSampleRate && !isEnvSet("DD_TRACE_SAMPLE_RATE") {
+		cfg.sampleRate = *t.SampleRate
+	}
+	for _, feature := range t.Features {
+		if featureSetLocally(cfg, feature) {
+			continue
+		}
+		if !enableFeature(cfg, feature) {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: unknown remote feature %q\n", feature)
+		}
+	}
+	if !isEnvSet("DD_CIVISIBILITY_FLAKY_TESTS") {
+		cfg.executionTraceTests = append(cfg.executionTraceTests, t.FlakyTests...)
+	}
+	if !isEnvSet(envQuarantinedTests) {
+		cfg.quarantinedTests = append(cfg.quarantinedTests, t.QuarantinedTests...)
+	}
+}
+
+// featureSetLocally returns whether the feature is already set by the environment variables,
+// the options or the configuration file, which take precedence over the remote toggles. The
+// other features can only be enabled, so enabling them again doesn't override anything.
+func featureSetLocally(cfg *runConfig, feature string) bool {
+	switch feature {
+	case "session_level_ci_tags":
+		return cfg.ciTagsLevel != ""
+	}
+	return false
+}
+
+// isQuarantined returns whether the test, identified by name or by suite and name, is
+// quarantined by the DD_CIVISIBILITY_QUARANTINED_TESTS environment variable or, when it isn't
+// set, by the Remote Configuration.
+func isQuarantined(cfg *runConfig, suite, name string) bool {
+	tests := cfg.quarantinedTests
+	if env := os.Getenv(envQuarantinedTests); env != "" {
+		tests = strings.Split(env, ",")
+	}
+	fqn := suite + "." + name
+	for _, test := range tests {
+		if test = strings.TrimSpace(test); test == name || test == fqn {
+			return true
+		}
+	}
+	return false
+}
+
+// agentURL returns the base URL of the agent and the HTTP client used to reach it, with the
+// given timeout.
+func agentURL(cfg *runConfig, timeout time.Duration) (string, *http.Client) {
+	if cfg.udsPath != "" {
+		return "http://localhost", udsClient(cfg.udsPath, timeout)
+	}
+	return "http://" + agentAddr(cfg), &http.Client{Timeout: timeout}
+}
+
+// normalizeRepositoryURL removes the scheme, the credentials and the .git suffix of a
+// repository URL, so the HTTPS and SSH URLs of a repository are equal.
+func normalizeRepositoryURL(url string) string {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	}
+	if i := strings.Index(url, "@"); i >= 0 {
+		url = url[i+1:]
+	}
+	url = strings.Replace(url, ":", "/", 1)
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git"))
+}
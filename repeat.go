@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// repeatCount returns the number of executions of each test requested with the -count flag.
+func repeatCount() int {
+	f := flag.Lookup("test.count")
+	if f == nil {
+		return 1
+	}
+	count, err := strconv.Atoi(f.Value.String())
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+// setRepeatTags tags the execution of a test run with -count greater than 1 with the number
+// of executions, its index, and the ID of the group of the executions: the span ID of the first.
+func setRepeatTags(span ddtrace.Span, attempt int, firstSpanID uint64) {
+	count := repeatCount()
+	if count <= 1 {
+		return
+	}
+	span.SetTag(constants.TestRepeatCount, count)
+	span.SetTag(constants.TestRepeatIndex, attempt)
+	span.SetTag(constants.TestExecutionGroupID, strconv.FormatUint(firstSpanID, 10))
+}
+
+// repeatStats aggregates the results of the executions of the tests by suite, to report their
+// stability when they run with -count greater than 1.
+type repeatStats struct {
+	mu       sync.Mutex
+	suites   map[string]*suiteStability
+	statuses map[string]map[string]bool
+}
+
+// suiteStability counts the passed executions of the tests of a suite.
+type suiteStability struct {
+	Passes int `json:"passes"`
+	Runs   int `json:"runs"`
+}
+
+func newRepeatStats() *repeatStats {
+	return &repeatStats{suites: map[string]*suiteStability{}, statuses: map[string]map[string]bool{}}
+}
+
+// add adds the result of a finished test, it's registered as a result writer.
+func (r *repeatStats) add(result *testResult) {
+	if result.status == constants.TestStatusSkip {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suite, ok := r.suites[result.suite]
+	if !ok {
+		suite = new(suiteStability)
+		r.suites[result.suite] = suite
+	}
+	suite.Runs++
+	if result.status == constants.TestStatusPass {
+		suite.Passes++
+	}
+
+	fqn := fmt.Sprintf("%s.%s", result.suite, result.name)
+	if r.statuses[fqn] == nil {
+		r.statuses[fqn] = map[string]bool{}
+	}
+	r.statuses[fqn][result.status] = true
+}
+
+// setTags sets the stability of the suites and the number of tests both passing and failing as
+// tags of the session span, when the tests run with -count greater than 1.
+func (r *repeatStats) setTags(span ddtrace.Span) {
+	if r == nil || repeatCount() <= 1 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stability, err := json.Marshal(r.suites); err == nil {
+		span.SetTag(constants.TestSessionRepeatStability, string(stability))
+	}
+	unstable := 0
+	for _, statuses := range r.statuses {
+		if statuses[constants.TestStatusPass] && statuses[constants.TestStatusFail] {
+			unstable++
+		}
+	}
+	span.SetTag(constants.TestSessionRepeatCount, float64(repeatCount()))
+	span.SetTag(constants.TestSessionUnstableTests, float64(unstable))
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"flag"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// setRepeatCount sets the -count flag, and returns the function restoring it.
+func setRepeatCount(t *testing.T, count int) func() {
+	previous := flag.Lookup("test.count").Value.String()
+	if err := flag.Set("test.count", strconv.Itoa(count)); err != nil {
+		t.Fatal(err)
+	}
+	return func() { flag.Set("test.count", previous) }
+}
+
+func TestRepeatTags(t *testing.T) {
+	defer setRepeatCount(t, 3)()
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	for i := 0; i < 3; i++ {
+		_, finish := StartTest(t, WithTestSuite("repeat"))
+		finish()
+	}
+
+	spans := mt.FinishedSpans()
+	group := strconv.FormatUint(spans[0].SpanID(), 10)
+	for i, span := range spans {
+		if span.Tag(constants.TestRepeatCount) != 3 || span.Tag(constants.TestRepeatIndex) != i {
+			t.Errorf("unexpected repeat tags: %v", span.Tags())
+		}
+		if span.Tag(constants.TestExecutionGroupID) != group {
+			t.Errorf("unexpected execution group: %v", span.Tag(constants.TestExecutionGroupID))
+		}
+	}
+}
+
+func TestRepeatTagsWithoutCount(t *testing.T) {
+	defer setRepeatCount(t, 1)()
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, finish := StartTest(t)
+	finish()
+
+	if v, ok := mt.FinishedSpans()[0].Tags()[constants.TestRepeatCount]; ok {
+		t.Errorf("the test is tagged without -count: %v", v)
+	}
+}
+
+func TestRepeatStats(t *testing.T) {
+	defer setRepeatCount(t, 2)()
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	stats := newRepeatStats()
+	for _, r := range []*testResult{
+		{suite: "pkg", name: "TestStable", status: constants.TestStatusPass},
+		{suite: "pkg", name: "TestStable", status: constants.TestStatusPass},
+		{suite: "pkg", name: "TestFlaky", status: constants.TestStatusPass},
+		{suite: "pkg", name: "TestFlaky", status: constants.TestStatusFail},
+		{suite: "other", name: "TestSkipped", status: constants.TestStatusSkip},
+	} {
+		stats.add(r)
+	}
+	span := tracer.StartSpan("session")
+	stats.setTags(span)
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	if v := s.Tag(constants.TestSessionRepeatStability); v != `{"pkg":{"passes":3,"runs":4}}` {
+		t.Errorf("unexpected stability: %v", v)
+	}
+	if v := s.Tag(constants.TestSessionUnstableTests); v != float64(1) {
+		t.Errorf("unexpected number of unstable tests: %v", v)
+	}
+}
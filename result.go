@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type testResultContextKey struct{}
+
+// testResult contains the data of a test also reported in its span. It's given to the
+// result writers when the test finishes.
+type testResult struct {
+	name      string
+	suite     string
+	framework string
+	file      string
+	line      int
+	start     time.Time
+	finish    time.Time
+
+	status     string
+	errorMsg   string
+	errorType  string
+	errorStack string
+
+	mu          sync.Mutex
+	attachments []testAttachment
+	claimed     bool
+
+	network networkStats
+
+	assertions        int64
+	assertionsCounted int32
+
+	// testSuite is the suite the status of the test is rolled up to.
+	testSuite *TestSuite
+
+	// iterations records the durations of the iterations of a benchmark run with BenchmarkLoop.
+	iterations *iterationHistogram
+}
+
+// testAttachment is a file attached to a test result.
+type testAttachment struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+// attach adds an attachment to the test result.
+func (r *testResult) attach(name, contentType string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attachments = append(r.attachments, testAttachment{name: name, contentType: contentType, data: data})
+}
+
+// claim returns true the first time it's called, so the test is reported once when both its
+// finish function and the timeout watchdog try to finish it.
+func (r *testResult) claim() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.claimed {
+		return false
+	}
+	r.claimed = true
+	return true
+}
+
+// testResultFromContext returns the result of the test running with ctx.
+func testResultFromContext(ctx context.Context) (*testResult, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	r, ok := ctx.Value(testResultContextKey{}).(*testResult)
+	return r, ok
+}
+
+var (
+	// resultWriters are called with the result of each finished test.
+	resultWriters      []func(*testResult)
+	resultWritersMutex sync.Mutex
+)
+
+// addResultWriter registers a function called with the result of each finished test.
+func addResultWriter(writer func(*testResult)) {
+	resultWritersMutex.Lock()
+	defer resultWritersMutex.Unlock()
+	resultWriters = append(resultWriters, writer)
+}
+
+// writeTestResult gives the result of a finished test to the result writers.
+func writeTestResult(r *testResult) {
+	if r.testSuite != nil {
+		r.testSuite.rollup.record(r.status)
+	}
+
+	resultWritersMutex.Lock()
+	writers := resultWriters
+	resultWritersMutex.Unlock()
+
+	for _, writer := range writers {
+		writer(r)
+	}
+}
@@ -0,0 +1,53 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// testAttempts contains the spans of the executions of a test.
+type testAttempts struct {
+	count       int
+	firstSpanID uint64
+	lastSpanID  uint64
+}
+
+var (
+	// attempts contains the executions of the tests of the test binary by fully qualified name.
+	attempts      = map[string]*testAttempts{}
+	attemptsMutex sync.Mutex
+)
+
+// linkTestAttempt records the span as an execution of the test. When the test already ran, like
+// when it's retried or run with -count, the span is tagged as a retry with the span IDs of the
+// first and previous executions, so the executions can be chained together. It returns the
+// number of previous executions and the span ID of the first one.
+func linkTestAttempt(span ddtrace.Span, fqn string) (int, uint64) {
+	spanID := span.Context().SpanID()
+
+	attemptsMutex.Lock()
+	a, ok := attempts[fqn]
+	if !ok {
+		attempts[fqn] = &testAttempts{count: 1, firstSpanID: spanID, lastSpanID: spanID}
+		attemptsMutex.Unlock()
+		return 0, spanID
+	}
+	attempt, first, previous := a.count, a.firstSpanID, a.lastSpanID
+	a.count++
+	a.lastSpanID = spanID
+	attemptsMutex.Unlock()
+
+	span.SetTag(constants.TestIsRetry, true)
+	span.SetTag(constants.TestRetryAttempt, attempt)
+	span.SetTag(constants.TestRetryFirstSpanID, strconv.FormatUint(first, 10))
+	span.SetTag(constants.TestRetryPreviousSpanID, strconv.FormatUint(previous, 10))
+	return attempt, first
+}
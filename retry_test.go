@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestLinkTestAttempt(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	for i := 0; i < 3; i++ {
+		_, finish := StartTest(t, WithTestSuite("retry"))
+		finish()
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 3 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if spans[0].Tag(constants.TestIsRetry) != nil {
+		t.Errorf("the first execution is tagged as a retry: %v", spans[0].Tags())
+	}
+	first := strconv.FormatUint(spans[0].SpanID(), 10)
+	for i, span := range spans[1:] {
+		if span.Tag(constants.TestIsRetry) != true || span.Tag(constants.TestRetryAttempt) != i+1 {
+			t.Errorf("unexpected retry tags: %v", span.Tags())
+		}
+		if span.Tag(constants.TestRetryFirstSpanID) != first {
+			t.Errorf("unexpected first span ID: %v", span.Tag(constants.TestRetryFirstSpanID))
+		}
+		if previous := strconv.FormatUint(spans[i].SpanID(), 10); span.Tag(constants.TestRetryPreviousSpanID) != previous {
+			t.Errorf("unexpected previous span ID: %v", span.Tag(constants.TestRetryPreviousSpanID))
+		}
+	}
+}
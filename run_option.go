@@ -0,0 +1,844 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"crypto/tls"
+	"os"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type runConfig struct {
+	enabled    bool
+	service    string
+	env        string
+	agentAddr  string
+	udsPath    string
+	sampleRate float64
+	globalTags [][2]string
+	tracerOpts []tracer.StartOption
+
+	tracerRuntimeMetrics bool
+	isolatedTracer       bool
+
+	agentless    bool
+	agentlessURL string
+	tlsConfig    *tls.Config
+
+	evpProxyDisabled bool
+	evpProxy         bool
+
+	output     string
+	outputFile *outputFile
+
+	testOpts        []Option
+	suiteTrimPrefix string
+	serviceMappings []serviceMapping
+
+	gitCollectionDisabled bool
+	ciTagsLevel           string
+
+	flushInterval        time.Duration
+	flushJitter          time.Duration
+	maxConcurrentFlushes int
+	flushOnTestFinish    bool
+	flushPeriod          time.Duration
+	finalFlushTimeout    time.Duration
+
+	gitTimeout      time.Duration
+	settingsTimeout time.Duration
+	uploadTimeout   time.Duration
+	maxBlockingTime time.Duration
+
+	leakCheck        bool
+	leakCheckFind    func() error
+	leakCheckMaxWait time.Duration
+
+	allureResultsDir string
+
+	durationBaseline           string
+	durationBaselineUpdate     bool
+	durationRegressionRatio    float64
+	durationRegressionMinDelta time.Duration
+	durationBudgets            []durationBudget
+	durationBudgetsEnforced    bool
+
+	coverageDir string
+
+	codeownersTeams     map[string]string
+	codeownersTeamsFile string
+
+	baseBranch string
+
+	runtimeMetrics bool
+
+	profilesDir         string
+	cpuProfileThreshold time.Duration
+	cpuProfileTests     []string
+
+	heapProfile          bool
+	heapProfileThreshold uint64
+
+	executionTraceTests []string
+
+	remoteConfig     bool
+	quarantinedTests []string
+
+	logsForwarding   bool
+	logsMaxEntrySize int
+	maxTagSize       int
+	outputSpanIDs    bool
+	logsMaxTotalSize int
+
+	clock              Clock
+	clockSkewThreshold time.Duration
+
+	fileLeakCheck bool
+	minLeakedTemp int64
+
+	envAllowlist []string
+
+	profilerStart func() error
+	profilerStop  func()
+
+	scrubRules                []utils.ScrubRule
+	builtinScrubRulesDisabled bool
+
+	diagnostics         bool
+	sessionLinkDisabled bool
+
+	signalHandlerDisabled bool
+	signals               []os.Signal
+	signalCallback        func(os.Signal)
+
+	module             string
+	sessionRoot        bool
+	sessionID          string
+	jobSessionDisabled bool
+	invocationSession  bool
+}
+
+// noSampleRate is the sample rate of the configuration when WithSampleRate isn't used.
+const noSampleRate = -1
+
+// RunOption represents an option that can be passed to RunWithOptions.
+type RunOption func(*runConfig)
+
+// newRunConfig returns a runConfig with the defaults and the given options applied.
+func newRunConfig(runOpts ...RunOption) *runConfig {
+	// When DD_CIVISIBILITY_ENABLED disables the SDK, the CI environment and configuration files
+	// aren't read, so the disabled SDK costs nothing.
+	enabled := enabledByEnv()
+	cfg := buildRunConfig(enabled, runOpts)
+	if !enabled && cfg.enabled {
+		// An option enables the SDK again, the files apply under the options.
+		cfg = buildRunConfig(true, runOpts)
+	}
+	return cfg
+}
+
+// buildRunConfig returns the configuration of the defaults, the files when readFiles is set, the
+// options of Configure and the given options.
+func buildRunConfig(readFiles bool, runOpts []RunOption) *runConfig {
+	if readFiles {
+		loadCIEnvFile()
+	}
+	cfg := new(runConfig)
+	runDefaults(cfg)
+	if readFiles {
+		loadConfigFile(cfg)
+	}
+	for _, fn := range configuredOptions() {
+		fn(cfg)
+	}
+	for _, fn := range runOpts {
+		fn(cfg)
+	}
+	return cfg
+}
+
+func runDefaults(cfg *runConfig) {
+	cfg.enabled = enabledByEnv()
+	cfg.service = ""
+	cfg.env = ""
+	cfg.agentAddr = ""
+	cfg.udsPath = ""
+	cfg.sampleRate = noSampleRate
+	cfg.globalTags = nil
+	cfg.tracerOpts = []tracer.StartOption{}
+	cfg.tracerRuntimeMetrics = false
+	cfg.isolatedTracer = isolatedTracerByEnv()
+	cfg.agentless = agentlessByEnv()
+	cfg.agentlessURL = os.Getenv(envAgentlessURL)
+	cfg.tlsConfig = nil
+	cfg.evpProxyDisabled = evpProxyDisabledByEnv()
+	cfg.evpProxy = false
+	cfg.output = os.Getenv(envOutput)
+	cfg.outputFile = nil
+	cfg.testOpts = nil
+	cfg.suiteTrimPrefix = ""
+	cfg.serviceMappings = nil
+	cfg.gitCollectionDisabled = false
+	cfg.ciTagsLevel = ciTagsLevelByEnv()
+	cfg.flushInterval = 0
+	cfg.flushJitter = 0
+	cfg.maxConcurrentFlushes = 1
+	cfg.flushOnTestFinish = false
+	cfg.flushPeriod = flushPeriodByEnv()
+	cfg.finalFlushTimeout = defaultFinalFlushTimeout
+	cfg.gitTimeout = defaultGitTimeout
+	cfg.settingsTimeout = defaultSettingsTimeout
+	cfg.uploadTimeout = defaultUploadTimeout
+	cfg.maxBlockingTime = defaultMaxBlockingTime
+	cfg.leakCheck = false
+	cfg.leakCheckFind = nil
+	cfg.leakCheckMaxWait = time.Second
+	cfg.allureResultsDir = ""
+	cfg.durationBaseline = os.Getenv(envDurationBaseline)
+	cfg.durationBaselineUpdate = durationBaselineUpdateByEnv()
+	cfg.durationRegressionRatio = defaultDurationRegressionRatio
+	cfg.durationRegressionMinDelta = defaultDurationRegressionMinDelta
+	cfg.durationBudgets = nil
+	cfg.durationBudgetsEnforced = false
+	cfg.coverageDir = os.Getenv(envCoverageDir)
+	cfg.codeownersTeams = nil
+	cfg.codeownersTeamsFile = os.Getenv(envCodeownersTeams)
+	cfg.baseBranch = os.Getenv(envBaseBranch)
+	cfg.runtimeMetrics = false
+	cfg.profilesDir = ""
+	cfg.cpuProfileThreshold = 0
+	cfg.cpuProfileTests = nil
+	cfg.heapProfile = false
+	cfg.heapProfileThreshold = 0
+	cfg.executionTraceTests = nil
+	cfg.remoteConfig = remoteConfigByEnv()
+	cfg.quarantinedTests = nil
+	cfg.logsForwarding = false
+	cfg.logsMaxEntrySize = 0
+	cfg.maxTagSize = maxTagSizeByEnv()
+	cfg.outputSpanIDs = outputSpanIDsByEnv()
+	cfg.clock = nil
+	cfg.clockSkewThreshold = clockSkewThresholdByEnv()
+	cfg.logsMaxTotalSize = 0
+	cfg.fileLeakCheck = false
+	cfg.minLeakedTemp = 0
+	cfg.envAllowlist = nil
+	cfg.profilerStart = nil
+	cfg.profilerStop = nil
+	cfg.scrubRules = nil
+	cfg.builtinScrubRulesDisabled = false
+	cfg.diagnostics = debugByEnv()
+	cfg.sessionLinkDisabled = sessionLinkDisabledByEnv()
+	cfg.signalHandlerDisabled = false
+	cfg.signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	cfg.signalCallback = nil
+	cfg.module = ""
+	cfg.sessionRoot = false
+	cfg.sessionID = ""
+	cfg.jobSessionDisabled = jobSessionDisabledByEnv()
+	cfg.invocationSession = invocationSessionByEnv()
+}
+
+// WithEnabled enables or disables the SDK, overriding the DD_CIVISIBILITY_ENABLED environment
+// variable. When it's disabled, Run only runs the tests, StartTest returns a no-op FinishFunc, and
+// neither the tracer nor the CI and Git detection are started.
+func WithEnabled(enabled bool) RunOption {
+	return func(cfg *runConfig) {
+		cfg.enabled = enabled
+	}
+}
+
+// WithEnv sets the environment of the tests, overriding the DD_ENV environment variable. By
+// default, the environment is "ci" when the tests run in a CI provider and "local" otherwise.
+func WithEnv(env string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.env = env
+	}
+}
+
+// WithTracerOptions defines a set of additional tracer.StartOption to be used
+// when starting the tracer.
+func WithTracerOptions(opts ...tracer.StartOption) RunOption {
+	return func(cfg *runConfig) {
+		cfg.tracerOpts = append(cfg.tracerOpts, opts...)
+	}
+}
+
+// WithAgentAddr sets the address of the Datadog Agent, as host:port. It's ignored when
+// WithUDS is also used.
+func WithAgentAddr(addr string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.agentAddr = addr
+	}
+}
+
+// WithUDS sends the traces to the Datadog Agent through the Unix domain socket at path.
+func WithUDS(path string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.udsPath = path
+	}
+}
+
+// WithSampleRate sets the rate, between 0 and 1, of the traces kept by the tracer. The test
+// spans are always kept, so the rate only applies to the spans of the code under test that
+// aren't children of a test.
+func WithSampleRate(rate float64) RunOption {
+	return func(cfg *runConfig) {
+		cfg.sampleRate = rate
+	}
+}
+
+// WithTracerRuntimeMetrics enables the runtime metrics of the tracer, sent to DogStatsD
+// while the tests run. See WithTestRuntimeMetrics for the runtime metrics of each test.
+func WithTracerRuntimeMetrics() RunOption {
+	return func(cfg *runConfig) {
+		cfg.tracerRuntimeMetrics = true
+	}
+}
+
+// WithIsolatedTracer sends the test spans through a tracer of the SDK instead of the global
+// tracer of dd-trace-go, like the DD_CIVISIBILITY_ISOLATED_TRACER environment variable, so the
+// tests can start, stop or mock the global tracer themselves. The global tracer isn't started,
+// the options given with WithTracerOptions are ignored, and the spans of the code under test
+// traced with the global tracer aren't children of the test spans.
+func WithIsolatedTracer() RunOption {
+	return func(cfg *runConfig) {
+		cfg.isolatedTracer = true
+	}
+}
+
+// WithAgentless sends the test spans as CI Visibility events directly to the intake of the
+// Datadog site of DD_SITE, authenticated with DD_API_KEY, like the
+// DD_CIVISIBILITY_AGENTLESS_ENABLED environment variable, so the tests can run without agent.
+// Without DD_API_KEY, the mode is disabled and the test spans are sent to the agent.
+func WithAgentless() RunOption {
+	return func(cfg *runConfig) {
+		cfg.agentless = true
+	}
+}
+
+// WithAgentlessURL sets the URL of the intake of the agentless mode, like the one of a proxy,
+// overriding DD_CIVISIBILITY_AGENTLESS_URL and the URL derived from DD_SITE.
+func WithAgentlessURL(url string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.agentlessURL = url
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the requests to the intake in agentless mode,
+// like the certificate authorities of a TLS intercepting proxy.
+func WithTLSConfig(config *tls.Config) RunOption {
+	return func(cfg *runConfig) {
+		cfg.tlsConfig = config
+	}
+}
+
+// WithEVPProxyDisabled doesn't send the test spans through the EVP proxy of the agent, like the
+// DD_CIVISIBILITY_EVP_PROXY_DISABLED environment variable, so they are sent as APM spans even
+// when the agent supports it.
+func WithEVPProxyDisabled() RunOption {
+	return func(cfg *runConfig) {
+		cfg.evpProxyDisabled = true
+	}
+}
+
+// WithOutputFile writes the test events to the file at path instead of sending them, like the
+// DD_CIVISIBILITY_OUTPUT=file:<path> environment variable, for the environments without network
+// access. The file is uploaded later with UploadOutputFile or `ddtest upload`.
+func WithOutputFile(path string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.output = outputFileScheme + path
+	}
+}
+
+// WithTestOptions sets the default options of every test started in the session, applied
+// before the options given to StartTest, so wrappers of the SDK don't need to repeat them:
+//
+//	ddtesting.RunWithOptions(m, ddtesting.WithTestOptions(
+//		ddtesting.WithTestFramework("github.com/org/e2e"),
+//		ddtesting.WithTestFrameworkVersion("1.4.0"),
+//		ddtesting.WithSpanOptions(tracer.Tag("team", "payments")),
+//	))
+func WithTestOptions(opts ...Option) RunOption {
+	return func(cfg *runConfig) {
+		cfg.testOpts = append(cfg.testOpts, opts...)
+	}
+}
+
+// WithSuiteTrimPrefix removes the prefix from the package paths used as suite names, like the
+// module path of a monorepo.
+func WithSuiteTrimPrefix(prefix string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.suiteTrimPrefix = prefix
+	}
+}
+
+// WithServiceMapping reports the tests of the packages matching the pattern with the given
+// service, so the tests of a monorepo are attributed to the services owning them. Patterns
+// ending with "/..." match a package and its subpackages, other patterns are matched with
+// path.Match. The most specific matching pattern is used:
+//
+//	ddtesting.WithServiceMapping("github.com/org/repo/payments/...", "payments-api")
+func WithServiceMapping(pattern, service string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.serviceMappings = append(cfg.serviceMappings, serviceMapping{pattern: pattern, service: service})
+	}
+}
+
+// WithSessionRoot makes the process report the session span shared by the test binaries it
+// runs, like the ddtest command does around `go test ./...`. The session ID is exported to the
+// child processes, which report their tests in a module of the session. StopWithExitCode
+// finishes the session with the status given by the exit code of the child processes.
+func WithSessionRoot() RunOption {
+	return func(cfg *runConfig) {
+		cfg.sessionRoot = true
+	}
+}
+
+// WithJobSessionDisabled stops deriving the session ID of a root process from the CI job, like
+// the DD_CIVISIBILITY_JOB_SESSION_DISABLED environment variable, so the root processes of the
+// shards of a job report their own session.
+func WithJobSessionDisabled() RunOption {
+	return func(cfg *runConfig) {
+		cfg.jobSessionDisabled = true
+	}
+}
+
+// WithInvocationSession makes the test binaries started by a plain `go test` report a module of
+// the session of the invocation, like the DD_CIVISIBILITY_INVOCATION_SESSION_ENABLED environment
+// variable. The first test binary of the invocation starts itself again in background, without
+// running the tests, to report the session span once `go test` exits. It's not supported on
+// Windows. Running the tests with ddtest reports a single session without a background process.
+func WithInvocationSession() RunOption {
+	return func(cfg *runConfig) {
+		cfg.invocationSession = true
+	}
+}
+
+// WithGitCollectionDisabled disables the execution of git to read the Git metadata of the
+// local repository, like the DD_CIVISIBILITY_GIT_COLLECTION_DISABLED environment variable.
+// The Git metadata provided by the CI environment variables is still reported.
+func WithGitCollectionDisabled() RunOption {
+	return func(cfg *runConfig) {
+		cfg.gitCollectionDisabled = true
+	}
+}
+
+// WithSessionLevelCITags sets the CI and Git tags only in the session span, like setting the
+// DD_CIVISIBILITY_CI_TAGS_LEVEL environment variable to "session". The test spans only keep
+// the repository URL, commit SHA, branch and matrix variant joining them with their commit.
+func WithSessionLevelCITags() RunOption {
+	return func(cfg *runConfig) {
+		cfg.ciTagsLevel = ciTagsLevelSession
+	}
+}
+
+// WithTestLevelCITags sets the CI and Git tags in every test span, the default behavior, like
+// setting the DD_CIVISIBILITY_CI_TAGS_LEVEL environment variable to "test".
+func WithTestLevelCITags() RunOption {
+	return func(cfg *runConfig) {
+		cfg.ciTagsLevel = ciTagsLevelTest
+	}
+}
+
+// WithFlushInterval enables the incremental flush of the tracer as tests finish, defining
+// the interval of the background flushes of the tests finished since the last flush, instead
+// of the flush period. The final flush of the session is always done.
+func WithFlushInterval(interval time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.flushInterval = interval
+	}
+}
+
+// WithFlushPeriod sets the period of the background flushes of the tests finished since the last
+// flush, like the DD_CIVISIBILITY_FLUSH_PERIOD environment variable, so the results of long
+// sessions, like nightly soak suites, are reported while they run. Defaults to one minute, and
+// a period that isn't positive disables the background flushes. The session span is reported
+// when the session finishes.
+func WithFlushPeriod(period time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.flushPeriod = period
+	}
+}
+
+// WithFlushJitter defines the maximum random delay added before each background flush, so test
+// binaries running in parallel on the same runner don't flush to the agent at the same time.
+// The final flush of the session and the flushes of WithFlushOnTestFinish aren't delayed.
+func WithFlushJitter(jitter time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.flushJitter = jitter
+	}
+}
+
+// WithMaxConcurrentFlushes defines how many flushes can be done at the same time
+// by the test binary. Values lower than 1 are ignored.
+func WithMaxConcurrentFlushes(max int) RunOption {
+	return func(cfg *runConfig) {
+		if max > 0 {
+			cfg.maxConcurrentFlushes = max
+		}
+	}
+}
+
+// WithFlushOnTestFinish flushes the tracer synchronously every time a test finishes, so no
+// data is lost if the test binary exits without stopping the session.
+func WithFlushOnTestFinish() RunOption {
+	return func(cfg *runConfig) {
+		cfg.flushOnTestFinish = true
+	}
+}
+
+// WithFinalFlushTimeout sets how long the end of the session waits for the remaining data to be
+// flushed, 10 seconds by default. When it times out, some data may have been dropped: the session
+// span is tagged and FinalFlushStatus returns FlushStatusTimedOut. There's no timeout when it's
+// not positive.
+func WithFinalFlushTimeout(timeout time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.finalFlushTimeout = timeout
+	}
+}
+
+// WithGitTimeout sets the timeout of the git commands reading the Git metadata of the local
+// repository, 10 seconds by default. The commands still running are killed and the Git metadata
+// they read is missing.
+func WithGitTimeout(timeout time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.gitTimeout = timeout
+	}
+}
+
+// WithSettingsTimeout sets the timeout of the requests fetching the settings of the session,
+// like the Remote Configuration toggles, 2 seconds by default. The local configuration is used
+// when it times out.
+func WithSettingsTimeout(timeout time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.settingsTimeout = timeout
+	}
+}
+
+// WithUploadTimeout sets the timeout of the requests sending the data, like the traces sent
+// through a Unix socket and the forwarded logs, 10 seconds by default.
+func WithUploadTimeout(timeout time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.uploadTimeout = timeout
+	}
+}
+
+// WithMaxBlockingTime bounds the total time the SDK blocks the test binary when the session
+// starts and stops, in the git commands, the settings requests and the final flush, 1 minute
+// by default. The timeout of each operation is reduced to the remaining time, and the
+// operations are abandoned once it's spent. There's no limit when it's not positive.
+func WithMaxBlockingTime(max time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.maxBlockingTime = max
+	}
+}
+
+// WithGoroutineLeakCheck checks for leaked goroutines with find after a successful run of the
+// tests, like goleak.VerifyTestMain does:
+//
+//	ddtesting.WithGoroutineLeakCheck(func() error {
+//		return goleak.Find(goleak.IgnoreTopFunction("github.com/org/pkg.worker"))
+//	})
+//
+// The error of find is reported as a failed "goroutine-leak" test of the suite of the package
+// and the exit code is set to 1. The tracer is still running when find is called, so its
+// goroutines must be ignored, or the isolated tracer used. A nil find uses the check of the
+// goroutine_leak_check feature, which ignores the goroutines of the tracer and the SDK.
+func WithGoroutineLeakCheck(find func() error) RunOption {
+	return func(cfg *runConfig) {
+		cfg.leakCheck = true
+		cfg.leakCheckFind = find
+	}
+}
+
+// WithAllureResults writes an Allure result file for every test in the given directory,
+// so Allure reports can be generated from the same instrumentation.
+func WithAllureResults(dir string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.allureResultsDir = dir
+	}
+}
+
+// WithDurationBaseline compares the duration of every passed test with the one of the given
+// baseline file, like the DD_CIVISIBILITY_DURATION_BASELINE environment variable. The file is a
+// JSON object with the durations of the tests by fully qualified name, like
+// {"github.com/org/repo/pkg.TestUpload": "1.5s"}. The tests slower than their baseline by more
+// than the threshold set with WithDurationRegressionThreshold are tagged as regressions.
+func WithDurationBaseline(path string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.durationBaseline = path
+	}
+}
+
+// WithDurationBaselineUpdate writes the durations of the passed tests to the baseline file when
+// the session finishes, like the DD_CIVISIBILITY_DURATION_BASELINE_UPDATE environment variable.
+func WithDurationBaselineUpdate() RunOption {
+	return func(cfg *runConfig) {
+		cfg.durationBaselineUpdate = true
+	}
+}
+
+// WithDurationRegressionThreshold sets how much slower than its baseline a test must be to be
+// tagged as a regression: more than ratio times its baseline, and more than minDelta. Defaults
+// to 1.5 times and 100 milliseconds.
+func WithDurationRegressionThreshold(ratio float64, minDelta time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.durationRegressionRatio = ratio
+		cfg.durationRegressionMinDelta = minDelta
+	}
+}
+
+// WithIntegrationCoverage collects the coverage of the binaries built with -cover, like the
+// services started by integration tests, like the DD_CIVISIBILITY_COVERAGE_DIR environment
+// variable. GOCOVERDIR is set to the given directory, so the binaries inheriting the environment
+// write their coverage counters to it when they exit. When the session finishes, the counters are
+// merged into the coverage.out profile of the directory, and the percentage of covered statements
+// is set on the session span. It requires Go 1.20 or later.
+func WithIntegrationCoverage(dir string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.coverageDir = dir
+	}
+}
+
+// WithCodeownersTeams tags the tests with the Datadog team of their code owners, given by the
+// mapping file, like the DD_CIVISIBILITY_CODEOWNERS_TEAMS environment variable. The file is a
+// JSON object with the Datadog teams of the GitHub teams and users of the CODEOWNERS file, like
+// {"@org/payments-backend": "payments"}. The tests are tagged with their code owners either way.
+func WithCodeownersTeams(path string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.codeownersTeamsFile = path
+	}
+}
+
+// WithBaseBranch sets the branch the changes are compared with to tag the modified tests, like
+// the DD_CIVISIBILITY_BASE_BRANCH environment variable. Defaults to the target branch of the pull
+// request tested by the CI provider.
+func WithBaseBranch(branch string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.baseBranch = branch
+	}
+}
+
+// WithTestRuntimeMetrics sets the memory allocated, the garbage collections and the goroutines
+// started during each test as metrics of its span. The metrics of parallel tests include the
+// activity of the tests running at the same time.
+func WithTestRuntimeMetrics() RunOption {
+	return func(cfg *runConfig) {
+		cfg.runtimeMetrics = true
+	}
+}
+
+// WithProfilesDir defines the directory of the profiles captured during the tests. By default,
+// the profiles are written in the Bazel undeclared outputs directory or in the temp directory.
+func WithProfilesDir(dir string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.profilesDir = dir
+	}
+}
+
+// WithCPUProfile captures a CPU profile of the tests running longer than threshold, from the
+// moment the threshold is exceeded, and of the whole run of the given tests, identified by
+// name or by suite and name. The path of the profile is set in the test span. A single test is
+// profiled at a time, and the profiles are disabled when the -cpuprofile flag is used.
+func WithCPUProfile(threshold time.Duration, tests ...string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.cpuProfileThreshold = threshold
+		cfg.cpuProfileTests = append(cfg.cpuProfileTests, tests...)
+	}
+}
+
+// WithHeapProfile captures a heap profile when a test fails, or when it finishes with at
+// least heapInUseThreshold bytes in use in the heap. A zero threshold only captures the profiles
+// of the failed tests. The path of the profile is set in the test span.
+func WithHeapProfile(heapInUseThreshold uint64) RunOption {
+	return func(cfg *runConfig) {
+		cfg.heapProfile = true
+		cfg.heapProfileThreshold = heapInUseThreshold
+	}
+}
+
+// WithExecutionTrace captures a runtime execution trace of the given flaky tests, identified
+// by name or by suite and name. The tests can also be listed in the DD_CIVISIBILITY_FLAKY_TESTS
+// environment variable, separated by commas. The path of the trace is set in the test span.
+func WithExecutionTrace(tests ...string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.executionTraceTests = append(cfg.executionTraceTests, tests...)
+	}
+}
+
+// WithRemoteConfig fetches feature toggles from Datadog Remote Configuration through the agent
+// when the session starts, like the DD_REMOTE_CONFIGURATION_ENABLED environment variable, so
+// features, the sample rate, and the flaky and quarantined tests can be changed for a
+// repository without changing the CI configuration. The options and the environment variables
+// always take precedence over the remote toggles.
+func WithRemoteConfig() RunOption {
+	return func(cfg *runConfig) {
+		cfg.remoteConfig = true
+	}
+}
+
+// WithLogsForwarding sends the logs written with the SDK log integrations during the tests to
+// the Datadog logs intake, correlated with the test spans. It requires the DD_API_KEY
+// environment variable.
+func WithLogsForwarding() RunOption {
+	return func(cfg *runConfig) {
+		cfg.logsForwarding = true
+	}
+}
+
+// WithLogsLimits defines the maximum size of a forwarded log message, longer messages are
+// truncated, and the maximum size of all the messages forwarded by the test binary, the
+// following messages are dropped. The defaults are 16KB and 8MB.
+func WithLogsLimits(maxEntrySize, maxTotalSize int) RunOption {
+	return func(cfg *runConfig) {
+		cfg.logsMaxEntrySize = maxEntrySize
+		cfg.logsMaxTotalSize = maxTotalSize
+	}
+}
+
+// WithSpanIDsInOutput logs a line with the trace and span IDs of every test when it starts, like
+// the DD_CIVISIBILITY_OUTPUT_SPAN_IDS environment variable, such as "dd.trace_id=1 dd.span_id=2".
+// Like the other test logs, the line is printed when the test fails or with the -v flag.
+func WithSpanIDsInOutput() RunOption {
+	return func(cfg *runConfig) {
+		cfg.outputSpanIDs = true
+	}
+}
+
+// WithClock replaces the clock of the timestamps of the session and test spans, and of the
+// durations of the tests, like a fake clock making the spans of the tests of an instrumentation
+// deterministic.
+func WithClock(clock Clock) RunOption {
+	return func(cfg *runConfig) {
+		cfg.clock = clock
+	}
+}
+
+// WithClockSkewDetection measures the skew of the clock against the agent when the session starts,
+// like the DD_CIVISIBILITY_CLOCK_SKEW_THRESHOLD environment variable. The session span is tagged
+// with the skew, and as having unreliable timestamps when it's larger than the threshold.
+func WithClockSkewDetection(threshold time.Duration) RunOption {
+	return func(cfg *runConfig) {
+		cfg.clockSkewThreshold = threshold
+	}
+}
+
+// WithMaxTagSize defines the maximum size in bytes of the tag values, 5000 by default, like the
+// DD_CIVISIBILITY_MAX_TAG_SIZE environment variable. Longer values are truncated and marked with
+// a tag suffixed by "_truncated", the stacks and outputs are allowed 32KB at least.
+func WithMaxTagSize(size int) RunOption {
+	return func(cfg *runConfig) {
+		if size > 0 {
+			cfg.maxTagSize = size
+		}
+	}
+}
+
+// WithFileLeakCheck tags the tests finishing with more open file descriptors than when they
+// started, and the tests leaving at least minTempSize bytes of new files in the temp directory.
+// The directories created by t.TempDir are ignored. When tests run in parallel, the leaks are
+// attributed to the test finishing first.
+func WithFileLeakCheck(minTempSize int64) RunOption {
+	return func(cfg *runConfig) {
+		cfg.fileLeakCheck = true
+		cfg.minLeakedTemp = minTempSize
+	}
+}
+
+// WithEnvironmentVariables adds the given environment variables to the snapshot of the
+// environment set in the session span, in addition to the Go settings. The values of the
+// variables whose name denotes a secret are scrubbed.
+func WithEnvironmentVariables(names ...string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.envAllowlist = append(cfg.envAllowlist, names...)
+	}
+}
+
+// WithProfiler starts the Datadog profiler with the session and stops it at the end, and
+// labels the profiles with the running test spans so they can be filtered by test (code
+// hotspots). The profiler is started and stopped by the given functions:
+//
+//	ddtesting.WithProfiler(func() error {
+//		return profiler.Start(
+//			profiler.WithPeriod(10*time.Second),
+//			profiler.WithProfileTypes(profiler.CPUProfile, profiler.HeapProfile),
+//		)
+//	}, profiler.Stop)
+//
+// A shorter period than the default one is recommended, as tests are short. The profiler CPU
+// profiles can't be captured at the same time as the ones of WithCPUProfile.
+func WithProfiler(start func() error, stop func()) RunOption {
+	return func(cfg *runConfig) {
+		cfg.profilerStart = start
+		cfg.profilerStop = stop
+	}
+}
+
+// WithScrubbingRule replaces the matches of the pattern in the tag values of the test and
+// session spans with the replacement, which can refer to the submatches like
+// regexp.ReplaceAllString. The rules are applied after the built-in rules removing URL
+// credentials, tokens, passwords and access keys.
+func WithScrubbingRule(pattern *regexp.Regexp, replacement string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.scrubRules = append(cfg.scrubRules, utils.ScrubRule{Pattern: pattern, Replacement: replacement})
+	}
+}
+
+// WithBuiltinScrubbingRulesDisabled disables the built-in scrubbing rules, only the rules given
+// with WithScrubbingRule are applied.
+func WithBuiltinScrubbingRulesDisabled() RunOption {
+	return func(cfg *runConfig) {
+		cfg.builtinScrubRulesDisabled = true
+	}
+}
+
+// WithDiagnostics prints the effective configuration of the session to stderr when it starts,
+// like the DD_CIVISIBILITY_DEBUG environment variable: the CI provider, the service and
+// environment, the Git metadata, where the data is sent and the enabled features.
+func WithDiagnostics() RunOption {
+	return func(cfg *runConfig) {
+		cfg.diagnostics = true
+	}
+}
+
+// WithSessionLinkDisabled doesn't print the number of passed, failed and skipped tests and the
+// link to the session in Datadog when the session finishes, like the
+// DD_CIVISIBILITY_SESSION_LINK_DISABLED environment variable.
+func WithSessionLinkDisabled() RunOption {
+	return func(cfg *runConfig) {
+		cfg.sessionLinkDisabled = true
+	}
+}
+
+// WithSignalHandlerDisabled doesn't install the signal handler flushing the data and exiting
+// when the test binary is interrupted, for test binaries handling the signals themselves. Stop
+// or the end of Run then flush the data.
+func WithSignalHandlerDisabled() RunOption {
+	return func(cfg *runConfig) {
+		cfg.signalHandlerDisabled = true
+	}
+}
+
+// WithSignals sets the signals handled by the signal handler, SIGINT and SIGTERM by default.
+func WithSignals(signals ...os.Signal) RunOption {
+	return func(cfg *runConfig) {
+		cfg.signals = signals
+	}
+}
+
+// WithSignalCallback calls fn with the received signal after the data is flushed, instead of
+// exiting with code 1. The session is stopped, so the tests finishing afterwards aren't reported.
+func WithSignalCallback(fn func(os.Signal)) RunOption {
+	return func(cfg *runConfig) {
+		cfg.signalCallback = fn
+	}
+}
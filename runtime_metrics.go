@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"runtime"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// runtimeStats is a snapshot of the runtime statistics.
+type runtimeStats struct {
+	mem        runtime.MemStats
+	goroutines int
+}
+
+// readRuntimeStats returns a snapshot of the runtime statistics.
+func readRuntimeStats() *runtimeStats {
+	stats := &runtimeStats{goroutines: runtime.NumGoroutine()}
+	runtime.ReadMemStats(&stats.mem)
+	return stats
+}
+
+// setRuntimeMetrics sets the difference between the runtime statistics at the start and at
+// the end of a test as metrics of its span.
+func setRuntimeMetrics(span ddtrace.Span, start, end *runtimeStats) {
+	span.SetTag(constants.TestMemoryAllocatedBytes, float64(end.mem.TotalAlloc-start.mem.TotalAlloc))
+	span.SetTag(constants.TestMemoryAllocations, float64(end.mem.Mallocs-start.mem.Mallocs))
+	span.SetTag(constants.TestMemoryHeapInUseBytes, float64(end.mem.HeapInuse))
+	span.SetTag(constants.TestGCCount, float64(end.mem.NumGC-start.mem.NumGC))
+	span.SetTag(constants.TestGCPauseTotal, toMilliseconds(int64(end.mem.PauseTotalNs-start.mem.PauseTotalNs)))
+	span.SetTag(constants.TestGoroutinesDelta, float64(end.goroutines-start.goroutines))
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var allocSink []byte
+
+func TestSetRuntimeMetrics(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("test")
+	start := readRuntimeStats()
+	for i := 0; i < 10; i++ {
+		allocSink = make([]byte, 1024*1024)
+	}
+	runtime.GC()
+	done := make(chan struct{})
+	go func() { <-done }()
+	setRuntimeMetrics(span, start, readRuntimeStats())
+	close(done)
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	if allocated, _ := s.Tag(constants.TestMemoryAllocatedBytes).(float64); allocated < 10*1024*1024 {
+		t.Errorf("unexpected allocated bytes: %v", allocated)
+	}
+	if gc, _ := s.Tag(constants.TestGCCount).(float64); gc < 1 {
+		t.Errorf("unexpected GC count: %v", gc)
+	}
+	if goroutines, _ := s.Tag(constants.TestGoroutinesDelta).(float64); goroutines < 1 {
+		t.Errorf("unexpected goroutines delta: %v", goroutines)
+	}
+}
@@ -0,0 +1,155 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+var (
+	// scrubRules are the rules applied to the tag values of the spans started by the SDK.
+	scrubRules      = utils.BuiltinScrubRules
+	scrubRulesMutex sync.RWMutex
+)
+
+// setScrubRules replaces the rules applied to the tag values.
+func setScrubRules(rules []utils.ScrubRule) {
+	scrubRulesMutex.Lock()
+	defer scrubRulesMutex.Unlock()
+	scrubRules = rules
+}
+
+// currentScrubRules returns the rules applied to the tag values.
+func currentScrubRules() []utils.ScrubRule {
+	scrubRulesMutex.RLock()
+	defer scrubRulesMutex.RUnlock()
+	return scrubRules
+}
+
+// sessionScrubRules returns the scrubbing rules of the session configuration.
+func sessionScrubRules(cfg *runConfig) []utils.ScrubRule {
+	var rules []utils.ScrubRule
+	if !cfg.builtinScrubRulesDisabled {
+		rules = append(rules, utils.BuiltinScrubRules...)
+	}
+	return append(rules, cfg.scrubRules...)
+}
+
+// scrubbingSpan scrubs the string and error values of the tags set in the span, so the
+// secrets contained in test names, error messages or Git metadata aren't sent, and truncates
+// the values larger than their limit, setting a marker tag. It records the CI and Git tags set
+// while the span runs, so the ones detected by the SDK don't override them when it finishes.
+type scrubbingSpan struct {
+	ddtrace.Span
+
+	mu     sync.Mutex
+	ciTags map[string]struct{}
+}
+
+// startScrubbedSpan starts a span like tracer.StartSpanFromContext, scrubbing and truncating
+// the values of the tags of the options and of the tags set afterwards through the returned
+// span or context.
+func startScrubbedSpan(ctx context.Context, operationName string, opts ...ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	// The start time of the options, like the ones of the reported results, overrides the clock.
+	opts = append([]ddtrace.StartSpanOption{tracer.StartTime(now())}, opts...)
+	optTags := spanOptionTags(opts)
+	defer releaseSpanOptionTags(optTags)
+	return startScrubbedSpanWithTags(ctx, operationName, optTags, opts...)
+}
+
+// startScrubbedSpanWithTags is startScrubbedSpan with the tags of the options, returned by
+// spanOptionTags, so the callers reusing them don't compute them again. The options must start
+// with tracer.StartTime(now()), so the callers can append it to a slice they reuse.
+func startScrubbedSpanWithTags(ctx context.Context, operationName string, optTags map[string]interface{}, opts ...ddtrace.StartSpanOption) (ddtrace.Span, context.Context) {
+	span, ctx := startSpanFromContext(ctx, operationName, opts...)
+	s := &scrubbingSpan{Span: span}
+	for k, v := range optTags {
+		if str, ok := v.(string); ok {
+			if sanitized := s.sanitize(k, str); sanitized != str {
+				s.Span.SetTag(k, sanitized)
+			}
+		}
+	}
+	return s, tracer.ContextWithSpan(ctx, s)
+}
+
+// SetTag sets the tag with its value scrubbed and truncated.
+func (s *scrubbingSpan) SetTag(key string, value interface{}) {
+	if isCITag(key) {
+		s.mu.Lock()
+		if s.ciTags == nil {
+			s.ciTags = map[string]struct{}{}
+		}
+		s.ciTags[key] = struct{}{}
+		s.mu.Unlock()
+	}
+	switch v := value.(type) {
+	case string:
+		value = s.sanitize(key, v)
+	case error:
+		if key == ext.Error {
+			s.Span.SetTag(ext.Error, true)
+			s.Span.SetTag(ext.ErrorMsg, s.sanitize(ext.ErrorMsg, v.Error()))
+			s.Span.SetTag(ext.ErrorType, fmt.Sprintf("%T", v))
+			return
+		}
+		value = s.sanitize(key, v.Error())
+	}
+	s.Span.SetTag(key, value)
+}
+
+// hasCITag returns whether the CI or Git tag was set in the span.
+func (s *scrubbingSpan) hasCITag(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.ciTags[key]
+	return ok
+}
+
+// isCITag returns whether the tag is one of the CI, Git, OS or runtime tags detected by the SDK.
+func isCITag(key string) bool {
+	for _, prefix := range []string{"ci.", "git.", "os.", "runtime."} {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitize scrubs and truncates the value of the tag, marking the truncated tags.
+func (s *scrubbingSpan) sanitize(key, value string) string {
+	if rules := currentScrubRules(); len(rules) > 0 {
+		value = utils.Scrub(value, rules)
+	}
+	value, truncated := truncateTag(key, value)
+	if truncated {
+		s.Span.SetTag(key+truncatedTagSuffix, true)
+	}
+	return value
+}
+
+// Finish finishes the span at the time of the clock of the session, scrubbing the message of the
+// error given with tracer.WithError.
+func (s *scrubbingSpan) Finish(opts ...ddtrace.FinishOption) {
+	opts = append([]ddtrace.FinishOption{tracer.FinishTime(now())}, opts...)
+	var cfg ddtrace.FinishConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	if cfg.Error != nil {
+		s.SetTag(ext.Error, cfg.Error)
+		opts = append(opts, tracer.WithError(nil))
+	}
+	s.Span.Finish(opts...)
+}
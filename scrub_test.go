@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestScrubbedSpan(t *testing.T) {
+	defer setScrubRules(currentScrubRules())
+	setScrubRules(sessionScrubRules(newRunConfig(
+		WithScrubbingRule(regexp.MustCompile(`user-\d+`), "user-?"),
+	)))
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx, finish := StartTest(t, WithSpanOptions(tracer.Tag("db.url", "postgres://admin:secret@db:5432/app")))
+	span, _ := tracer.SpanFromContext(ctx)
+	span.SetTag("customer", "user-1234")
+	span.SetTag(ext.Error, errors.New("login failed: password=hunter2"))
+	span.SetTag("retries", 3)
+	finish()
+
+	s := mt.FinishedSpans()[0]
+	for key, expected := range map[string]interface{}{
+		"db.url":     "postgres://db:5432/app",
+		"customer":   "user-?",
+		ext.ErrorMsg: "login failed: password=?",
+		"retries":    3,
+	} {
+		if actual := s.Tag(key); actual != expected {
+			t.Errorf("%s: expected %v, got %v", key, expected, actual)
+		}
+	}
+}
+
+func TestScrubbedSpanWithoutRules(t *testing.T) {
+	defer setScrubRules(currentScrubRules())
+	setScrubRules(sessionScrubRules(newRunConfig(WithBuiltinScrubbingRulesDisabled())))
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx, finish := StartTest(t)
+	span, _ := tracer.SpanFromContext(ctx)
+	span.SetTag("token", "token=abc")
+	finish()
+
+	if v := mt.FinishedSpans()[0].Tag("token"); v != "token=abc" {
+		t.Errorf("unexpected tag value: %v", v)
+	}
+}
+
+func TestTruncatedTags(t *testing.T) {
+	defer setMaxTagSize(defaultMaxTagSize)
+	setMaxTagSize(200)
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	before := truncatedTagsCount()
+	long := strings.Repeat("é", 150)
+	ctx, finish := StartTest(t, WithSpanOptions(tracer.Tag("param", long)))
+	span, _ := tracer.SpanFromContext(ctx)
+	span.SetTag("short", "value")
+	span.SetTag(ext.ErrorMsg, long)
+	span.SetTag(ext.ErrorStack, long)
+	finish()
+
+	s := mt.FinishedSpans()[0]
+	for _, key := range []string{"param", ext.ErrorMsg} {
+		v, _ := s.Tag(key).(string)
+		if len(v) > 200 || !strings.HasSuffix(v, utils.TruncatedSuffix) || !utf8.ValidString(v) {
+			t.Errorf("%s: unexpected truncated value %q", key, v)
+		}
+		if s.Tag(key+truncatedTagSuffix) != true {
+			t.Errorf("%s: the truncation marker is missing", key)
+		}
+	}
+	if v := s.Tag(ext.ErrorStack); v != long {
+		t.Errorf("the stack is truncated below its limit: %v", v)
+	}
+	if _, ok := s.Tags()["short"+truncatedTagSuffix]; ok {
+		t.Error("the short tag is marked as truncated")
+	}
+	if n := truncatedTagsCount() - before; n != 2 {
+		t.Errorf("unexpected number of truncations: %d", n)
+	}
+}
@@ -0,0 +1,204 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+// Package sdktest captures the spans emitted by the SDK, so the wrappers and integrations built
+// on top of it can verify their instrumentation in unit tests. It replaces the global tracer with
+// the mock tracer of dd-trace-go while the recorder is running:
+//
+//	func TestWrapper(t *testing.T) {
+//		rec := sdktest.Start()
+//		defer rec.Stop()
+//
+//		mywrapper.RunScenario(t, "checkout")
+//
+//		rec.AssertTestCount(t, 1)
+//		test, _ := rec.Test("checkout")
+//		rec.AssertTag(t, test, "team", "payments")
+//	}
+//
+// The spans aren't captured when the session of the test binary uses the isolated tracer.
+package sdktest
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+// Recorder captures the spans finished while it's running.
+type Recorder struct {
+	mt mocktracer.Tracer
+}
+
+// Start replaces the global tracer with a mock tracer capturing the spans. Stop must be called
+// to restore it.
+func Start() *Recorder {
+	return &Recorder{mt: mocktracer.Start()}
+}
+
+// Stop stops capturing the spans.
+func (r *Recorder) Stop() {
+	r.mt.Stop()
+}
+
+// Reset discards the captured spans.
+func (r *Recorder) Reset() {
+	r.mt.Reset()
+}
+
+// Span is a span captured by the recorder.
+type Span struct {
+	mocktracer.Span
+}
+
+// Type returns the type of the span, like "test".
+func (s Span) Type() string { return s.stringTag(ext.SpanType) }
+
+// TestName returns the name of the test of a test span.
+func (s Span) TestName() string { return s.stringTag(constants.TestName) }
+
+// TestSuite returns the suite of the test of a test span.
+func (s Span) TestSuite() string { return s.stringTag(constants.TestSuite) }
+
+// TestStatus returns the status of the test of a test span: "pass", "fail" or "skip".
+func (s Span) TestStatus() string { return s.stringTag(constants.TestStatus) }
+
+// stringTag returns the value of a tag as a string, or an empty string when it's not set.
+func (s Span) stringTag(key string) string {
+	if v := s.Tag(key); v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// Spans returns the finished spans, in the order they finished.
+func (r *Recorder) Spans() []Span {
+	finished := r.mt.FinishedSpans()
+	spans := make([]Span, len(finished))
+	for i, s := range finished {
+		spans[i] = Span{s}
+	}
+	return spans
+}
+
+// Tests returns the finished test spans, in the order they finished.
+func (r *Recorder) Tests() []Span {
+	var tests []Span
+	for _, s := range r.Spans() {
+		if s.Type() == constants.SpanTypeTest {
+			tests = append(tests, s)
+		}
+	}
+	return tests
+}
+
+// Test returns the last finished test span with the given name.
+func (r *Recorder) Test(name string) (Span, bool) {
+	tests := r.Tests()
+	for i := len(tests) - 1; i >= 0; i-- {
+		if tests[i].TestName() == name {
+			return tests[i], true
+		}
+	}
+	return Span{}, false
+}
+
+// Children returns the finished spans whose parent is the given span.
+func (r *Recorder) Children(parent Span) []Span {
+	var children []Span
+	for _, s := range r.Spans() {
+		if s.ParentID() == parent.SpanID() && s.TraceID() == parent.TraceID() {
+			children = append(children, s)
+		}
+	}
+	return children
+}
+
+// AssertSpanCount checks the number of finished spans, failing the test otherwise.
+func (r *Recorder) AssertSpanCount(tb testing.TB, expected int) bool {
+	tb.Helper()
+	if n := len(r.Spans()); n != expected {
+		tb.Errorf("expected %d finished spans, got %d", expected, n)
+		return false
+	}
+	return true
+}
+
+// AssertTestCount checks the number of finished test spans, failing the test otherwise.
+func (r *Recorder) AssertTestCount(tb testing.TB, expected int) bool {
+	tb.Helper()
+	if n := len(r.Tests()); n != expected {
+		tb.Errorf("expected %d finished test spans, got %d", expected, n)
+		return false
+	}
+	return true
+}
+
+// AssertTestStatus checks the status of the last finished test span with the given name,
+// failing the test otherwise.
+func (r *Recorder) AssertTestStatus(tb testing.TB, name, expected string) bool {
+	tb.Helper()
+	test, ok := r.Test(name)
+	if !ok {
+		tb.Errorf("no finished test span named %q", name)
+		return false
+	}
+	if status := test.TestStatus(); status != expected {
+		tb.Errorf("expected status %q for test %q, got %q", expected, name, status)
+		return false
+	}
+	return true
+}
+
+// AssertTag checks the value of a tag of the span, failing the test otherwise. The numbers are
+// compared by value, so an int tag can be checked with an int64 or a float64.
+func (r *Recorder) AssertTag(tb testing.TB, span Span, key string, expected interface{}) bool {
+	tb.Helper()
+	actual := span.Tag(key)
+	if !equalTags(actual, expected) {
+		tb.Errorf("expected tag %q of span %q to be %v (%T), got %v (%T)", key, span.OperationName(), expected, expected, actual, actual)
+		return false
+	}
+	return true
+}
+
+// AssertChildOf checks that the child span is a child of the parent span, failing the test otherwise.
+func (r *Recorder) AssertChildOf(tb testing.TB, child, parent Span) bool {
+	tb.Helper()
+	if child.TraceID() != parent.TraceID() || child.ParentID() != parent.SpanID() {
+		tb.Errorf("span %q (parent %d) is not a child of span %q (%d)", child.OperationName(), child.ParentID(),
+			parent.OperationName(), parent.SpanID())
+		return false
+	}
+	return true
+}
+
+// equalTags returns whether two tag values are equal, comparing the numbers by value.
+func equalTags(actual, expected interface{}) bool {
+	if a, ok := toFloat(actual); ok {
+		if e, ok := toFloat(expected); ok {
+			return a == e
+		}
+	}
+	return reflect.DeepEqual(actual, expected)
+}
+
+// toFloat converts a number to a float64.
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package sdktest
+
+import (
+	"context"
+	"testing"
+
+	ddtesting "github.com/DataDog/dd-sdk-go-testing"
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := Start()
+	defer rec.Stop()
+
+	pass := &ddtesting.TBAdapter{TestName: "TestPass"}
+	ctx, finish := ddtesting.StartTestWithContext(context.Background(), pass, ddtesting.WithTestSuite("suite"),
+		ddtesting.WithSpanOptions(tracer.Tag("team", "payments"), tracer.Tag("retries", 2)))
+	child, _ := tracer.StartSpanFromContext(ctx, "http.request")
+	child.Finish()
+	finish()
+
+	fail := &ddtesting.TBAdapter{TestName: "TestFail"}
+	_, finish = ddtesting.StartTestWithContext(context.Background(), fail, ddtesting.WithTestSuite("suite"))
+	fail.Error("unexpected value")
+	finish()
+
+	rec.AssertSpanCount(t, 3)
+	rec.AssertTestCount(t, 2)
+	rec.AssertTestStatus(t, "TestPass", constants.TestStatusPass)
+	rec.AssertTestStatus(t, "TestFail", constants.TestStatusFail)
+
+	test, ok := rec.Test("TestPass")
+	if !ok {
+		t.Fatal("the test span wasn't captured")
+	}
+	if test.TestSuite() != "suite" {
+		t.Errorf("unexpected suite: %s", test.TestSuite())
+	}
+	rec.AssertTag(t, test, "team", "payments")
+	rec.AssertTag(t, test, "retries", int64(2))
+
+	children := rec.Children(test)
+	if len(children) != 1 {
+		t.Fatalf("unexpected number of children: %d", len(children))
+	}
+	rec.AssertChildOf(t, children[0], test)
+
+	rec.Reset()
+	rec.AssertSpanCount(t, 0)
+}
+
+func TestAssertionFailures(t *testing.T) {
+	rec := Start()
+	defer rec.Stop()
+
+	tb := &ddtesting.TBAdapter{TestName: "TestAssertions"}
+	span, _ := tracer.StartSpanFromContext(context.Background(), "op", tracer.Tag("key", "value"))
+	span.Finish()
+	spans := rec.Spans()
+
+	if rec.AssertSpanCount(tb, 2) || rec.AssertTestCount(tb, 1) || rec.AssertTestStatus(tb, "TestMissing", "pass") ||
+		rec.AssertTag(tb, spans[0], "key", "other") || rec.AssertChildOf(tb, spans[0], spans[0]) {
+		t.Error("expected the assertions to fail")
+	}
+	if !tb.Failed() {
+		t.Error("expected the failures to be reported")
+	}
+}
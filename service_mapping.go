@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"path"
+	"strings"
+)
+
+// serviceMapping maps the packages matching a pattern to a service.
+type serviceMapping struct {
+	pattern string
+	service string
+}
+
+// serviceForSuite returns the service of the packages matching the suite, using the most
+// specific pattern, or an empty string when no pattern matches.
+func serviceForSuite(mappings []serviceMapping, suite string) string {
+	var service, pattern string
+	for _, m := range mappings {
+		if len(m.pattern) > len(pattern) && matchPackagePattern(m.pattern, suite) {
+			service, pattern = m.service, m.pattern
+		}
+	}
+	return service
+}
+
+// matchPackagePattern returns whether the package path matches the pattern. Patterns ending
+// with "/..." match the package and its subpackages, like the go command, other patterns are
+// matched with path.Match.
+func matchPackagePattern(pattern, pkg string) bool {
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		return pkg == prefix || strings.HasPrefix(pkg, prefix+"/")
+	}
+	matched, _ := path.Match(pattern, pkg)
+	return matched
+}
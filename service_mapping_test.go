@@ -0,0 +1,28 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import "testing"
+
+func TestServiceForSuite(t *testing.T) {
+	mappings := []serviceMapping{
+		{pattern: "example.com/repo/...", service: "monorepo"},
+		{pattern: "example.com/repo/payments/...", service: "payments"},
+		{pattern: "example.com/repo/*/api", service: "api"},
+	}
+	for suite, expected := range map[string]string{
+		"example.com/repo":                  "monorepo",
+		"example.com/repo/payments":         "payments",
+		"example.com/repo/payments/ledger":  "payments",
+		"example.com/repo/users/api":        "api",
+		"example.com/repository/payments":   "",
+		"example.com/other/payments/ledger": "",
+	} {
+		if actual := serviceForSuite(mappings, suite); actual != expected {
+			t.Errorf("%s: expected %q, got %q", suite, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,473 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/logs"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+const (
+	// envSessionID contains the ID of the test session shared with the child processes.
+	envSessionID = "DD_CIVISIBILITY_SESSION_ID"
+
+	// envSessionSpanID contains the span ID of the session span of the parent process.
+	envSessionSpanID = "DD_CIVISIBILITY_SESSION_SPAN_ID"
+)
+
+var (
+	// session is the running test session of the test binary.
+	session      *testSession
+	sessionMutex sync.Mutex
+)
+
+// testSession contains the state of a running test session.
+type testSession struct {
+	cfg      *runConfig
+	budget   *blockingBudget
+	baseline *durationBaseline
+	owners   *codeownersResolver
+	changes  *changedLines
+	span     ddtrace.Span
+	id       string
+	service  string
+	env      string
+	start    time.Time
+	summary  *sessionSummary
+	repeats  *repeatStats
+	rollup   *statusRollup
+
+	// module is the module of the tests without explicit suite, and modules are the modules
+	// started in the session and finished with it.
+	module       *TestModule
+	modules      []*TestModule
+	modulesMutex sync.Mutex
+	stopOnce     sync.Once
+	signals      chan os.Signal
+
+	// stopFlush stops the background flushes.
+	stopFlush func()
+
+	// watchdog finishes the running tests before the -timeout deadline.
+	watchdog      *time.Timer
+	watchdogOnce  sync.Once
+	watchdogMutex sync.Mutex
+}
+
+// Start starts the tracer and the test session of the test binary, for packages without a
+// TestMain function calling Run. Stop must be called to flush the remaining data. When the
+// session is already running, the options are ignored.
+func Start(runOpts ...RunOption) {
+	cfg := newRunConfig(runOpts...)
+	setEnabled(cfg.enabled)
+	if cfg.enabled {
+		startSession(cfg)
+	}
+}
+
+// Stop finishes the test session started by Start, flushing and stopping the tracer.
+func Stop() {
+	sessionMutex.Lock()
+	s := session
+	sessionMutex.Unlock()
+	if s != nil {
+		s.stop()
+	}
+}
+
+// StopWithExitCode finishes the test session started by Start like Stop, for the processes
+// running the tests in child processes, like ddtest around `go test`, tagging the session span
+// with the status given by the exit code of the tests.
+func StopWithExitCode(code int) {
+	sessionMutex.Lock()
+	s := session
+	sessionMutex.Unlock()
+	if s != nil {
+		s.setExitCode(code)
+		s.stop()
+	}
+}
+
+// setExitCode records the status given by the exit code of the tests run by child processes:
+// the session fails when the code isn't zero, and passes when it's zero unless the tests of the
+// process recorded a status.
+func (s *testSession) setExitCode(code int) {
+	if code != 0 {
+		s.rollup.fail()
+	} else if s.rollup.status() == "" {
+		s.rollup.record(constants.TestStatusPass)
+	}
+}
+
+// startSession starts the tracer and the test session, or returns the running session.
+func startSession(cfg *runConfig) *testSession {
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+	if session != nil {
+		return session
+	}
+
+	budget := newBlockingBudget(cfg.maxBlockingTime)
+	setClock(cfg.clock)
+	env := resolveEnv(cfg)
+	if cfg.remoteConfig {
+		service := os.Getenv("DD_SERVICE")
+		if service == "" {
+			service = cfg.service
+		}
+		settingsStart := time.Now()
+		loadRemoteToggles(cfg, service, env, budget.timeout(cfg.settingsTimeout))
+		budget.spend(settingsStart)
+	}
+	opts, errs := tracerStartOptions(cfg, env)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: %v\n", err)
+	}
+	start := now()
+	summary := newSessionSummary(start)
+	addResultWriter(summary.add)
+	repeats := newRepeatStats()
+	addResultWriter(repeats.add)
+	controller := newFlushController(cfg)
+	setFlusher(controller)
+	if cfg.allureResultsDir != "" {
+		addResultWriter(newAllureWriter(utils.GetArtifactsPath(cfg.allureResultsDir)))
+	}
+
+	setScrubRules(sessionScrubRules(cfg))
+
+	baseline, err := loadDurationBaseline(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: %v\n", err)
+	}
+	owners, err := newCodeownersResolver(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: %v\n", err)
+	}
+	if cfg.coverageDir != "" {
+		if cfg.coverageDir, err = setupIntegrationCoverage(cfg.coverageDir); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: setting up the integration coverage: %v\n", err)
+			cfg.coverageDir = ""
+		}
+	}
+
+	// Preload all CI and Git tags in background.
+	if cfg.gitCollectionDisabled {
+		atomic.StoreInt32(&gitCollectionDisabled, 1)
+	}
+	setMaxTagSize(cfg.maxTagSize)
+	if cfg.ciTagsLevel == ciTagsLevelSession {
+		atomic.StoreInt32(&sessionLevelCITags, 1)
+	}
+	setGitTimeout(budget.timeout(cfg.gitTimeout))
+	startCITagsDetection()
+
+	// Check if DD_SERVICE or the configuration file set the service; otherwise we default to repo name.
+	service := os.Getenv("DD_SERVICE")
+	if service == "" && cfg.service != "" {
+		service = cfg.service
+		opts = append(opts, tracer.WithService(service))
+	}
+	if service == "" {
+		// The repository URL is usually provided by the CI environment variables,
+		// otherwise we need to wait for the Git detection.
+		repoUrl, ok := utils.GetProviderTags()[constants.GitRepositoryURL]
+		if !ok {
+			gitStart := time.Now()
+			ensureCITags()
+			budget.spend(gitStart)
+			repoUrl, ok = getFromCITags(constants.GitRepositoryURL)
+		}
+		if ok {
+			matches := repoRegex.FindStringSubmatch(repoUrl)
+			if len(matches) > 1 {
+				repoUrl = strings.TrimSuffix(matches[1], ".git")
+			}
+			service = repoUrl
+			opts = append(opts, tracer.WithService(repoUrl))
+		}
+	}
+	if cfg.logsForwarding {
+		startLogsForwarding(cfg, service, env)
+	}
+
+	// Initialize tracer
+	openOutput(cfg)
+	checkAgentless(cfg)
+	if !cfg.agentless && !cfg.evpProxyDisabled && cfg.outputFile == nil {
+		evpStart := time.Now()
+		detectEVPProxy(cfg, budget.timeout(cfg.settingsTimeout))
+		budget.spend(evpStart)
+	}
+	if cfg.isolatedTracer {
+		setIsolatedTracer(newIsolatedTracer(cfg, service, env))
+	} else {
+		if cfg.agentless || cfg.evpProxy || cfg.outputFile != nil {
+			// The traces of the global tracer are sent as test cycle events.
+			opts = append(opts, tracer.WithHTTPClient(testCycleClient(cfg, service, env)))
+		}
+		tracer.Start(opts...)
+	}
+	if cfg.profilerStart != nil {
+		if err := cfg.profilerStart(); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: starting the profiler: %v\n", err)
+		}
+	}
+	span, id, moduleID := startSessionSpan(cfg)
+	if cfg.clockSkewThreshold > 0 {
+		skewStart := time.Now()
+		checkClockSkew(span, cfg, budget.timeout(cfg.settingsTimeout))
+		budget.spend(skewStart)
+	}
+	setEnvironmentTags(span, cfg.envAllowlist)
+	for k, v := range utils.GetContainerTags() {
+		span.SetTag(k, v)
+	}
+	for k, v := range utils.GetHostInfo().Tags() {
+		span.SetTag(k, v)
+	}
+	s := &testSession{
+		cfg:      cfg,
+		budget:   budget,
+		baseline: baseline,
+		owners:   owners,
+		changes:  newChangedLines(cfg),
+		span:     span,
+		id:       id,
+		service:  service,
+		env:      env,
+		start:    start,
+		summary:  summary,
+		repeats:  repeats,
+		rollup:   new(statusRollup),
+	}
+	if moduleID != "" {
+		// The session span of the test binary is the module of its tests.
+		s.module = &TestModule{
+			name:    moduleName(cfg),
+			id:      moduleID,
+			span:    span,
+			session: s,
+			rollup:  &statusRollup{parents: []*statusRollup{s.rollup}},
+			suites:  map[string]*TestSuite{},
+		}
+	}
+	s.stopFlush = controller.startPeriodicFlush(cfg.flushPeriod)
+	if cfg.diagnostics {
+		writeDiagnostics(os.Stderr, cfg, service, env, id)
+	}
+	if !cfg.signalHandlerDisabled {
+		s.handleSignals(cfg.signals, s.stop, cfg.signalCallback)
+	}
+
+	session = s
+	return s
+}
+
+// resolveEnv returns the environment of the tests: the one given with WithEnv, DD_ENV, or
+// "ci" when the tests run in a CI provider and "local" otherwise, so the spans of the tests
+// don't inherit the environment of a shared agent.
+func resolveEnv(cfg *runConfig) string {
+	if cfg.env != "" {
+		return cfg.env
+	}
+	if env := os.Getenv("DD_ENV"); env != "" {
+		return env
+	}
+	if _, ok := utils.GetProviderTags()[constants.CIProviderName]; ok {
+		return "ci"
+	}
+	return "local"
+}
+
+// handleSignals calls stop when one of the signals is received, so the data is flushed before
+// the test binary exits. The callback is called afterwards, the test binary exits with
+// code 1 when there's no callback.
+func (s *testSession) handleSignals(signals []os.Signal, stop func(), callback func(os.Signal)) {
+	s.signals = make(chan os.Signal, 1)
+	signal.Notify(s.signals, signals...)
+	go func() {
+		if sig, ok := <-s.signals; ok {
+			stop()
+			if callback != nil {
+				callback(sig)
+				return
+			}
+			osExit(1)
+		}
+	}()
+}
+
+// startSessionSpan starts the span of the test binary and returns the session ID and the
+// module ID. The session ID and the parent span are inherited from the environment variables
+// set by a parent process, like ddtest, or derived from the `go test` invocation when it's
+// enabled, so the tests split across many processes are reported in a single session: the test
+// binary then reports a module of the shared session, and the module ID is empty otherwise. Without a shared session,
+// the session ID of a root process is derived from the CI job, and the trace ID of the session
+// span is used otherwise. The session ID is exported to the child processes.
+func startSessionSpan(cfg *runConfig) (ddtrace.Span, string, string) {
+	opts := []ddtrace.StartSpanOption{
+		tracer.Tag(constants.TestFramework, testFramework),
+		tracer.Tag(constants.Origin, constants.CIAppTestOrigin),
+		tracer.Tag(ext.ManualKeep, true),
+	}
+
+	id := os.Getenv(envSessionID)
+	parentID := os.Getenv(envSessionSpanID)
+	inherited := id != ""
+	module := inherited
+	switch {
+	case inherited:
+	case cfg.sessionID != "":
+		// The reporter of the session of a `go test` invocation uses its ID.
+		id = cfg.sessionID
+	case cfg.invocationSession && !cfg.sessionRoot:
+		// When enabled, the test binaries started by a plain `go test` report a module of the
+		// session of the invocation, whose span is reported by the session reporter using the
+		// session ID as span ID.
+		if invocation := invocationSessionID(os.Args[0]); invocation != "" && joinInvocationSession(invocation) {
+			id, parentID, module = invocation, invocation, true
+		}
+	case cfg.jobSessionDisabled:
+	case cfg.sessionRoot:
+		// The root processes of a CI job, like ddtest on the shards of the job, report the
+		// session of the job using the session ID as span ID.
+		id = jobSessionID(utils.GetProviderTags())
+	}
+
+	operationName := constants.SpanTypeTestSession
+	if module {
+		operationName = constants.SpanTypeTestModule
+		if parentID != "" {
+			parent, err := extractSpanContext(tracer.TextMapCarrier{
+				tracer.DefaultTraceIDHeader:  id,
+				tracer.DefaultParentIDHeader: parentID,
+			})
+			if err == nil {
+				opts = append(opts, tracer.ChildOf(parent))
+			}
+		}
+		opts = append(opts, tracer.Tag(constants.TestModule, moduleName(cfg)))
+	} else if id != "" {
+		// A root span gets its span ID as trace ID.
+		if spanID, err := strconv.ParseUint(id, 10, 64); err == nil {
+			opts = append(opts, tracer.WithSpanID(spanID))
+		}
+	}
+	opts = append(opts, tracer.SpanType(operationName))
+
+	span, _ := startScrubbedSpan(context.Background(), operationName, opts...)
+	if id == "" {
+		id = strconv.FormatUint(span.Context().TraceID(), 10)
+	}
+	if !inherited {
+		// The child processes of a module report modules of its session.
+		sessionSpanID := strconv.FormatUint(span.Context().SpanID(), 10)
+		if module {
+			sessionSpanID = parentID
+		}
+		os.Setenv(envSessionID, id)
+		os.Setenv(envSessionSpanID, sessionSpanID)
+	}
+	span.SetTag(constants.TestSessionID, id)
+
+	var moduleID string
+	if module {
+		moduleID = strconv.FormatUint(span.Context().SpanID(), 10)
+		span.SetTag(constants.TestModuleID, moduleID)
+	}
+	return span, id, moduleID
+}
+
+// currentSession returns the running test session, or nil.
+func currentSession() *testSession {
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
+	return session
+}
+
+// stop finishes the session span, flushes and stops the tracer. It only runs once.
+func (s *testSession) stop() {
+	s.stopOnce.Do(func() {
+		if s.signals != nil {
+			signal.Stop(s.signals)
+			close(s.signals)
+		}
+		s.stopWatchdog()
+		if s.stopFlush != nil {
+			s.stopFlush()
+		}
+		if err := s.baseline.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: writing the duration baseline: %v\n", err)
+		}
+
+		diffStart := time.Now()
+		s.changes.setSessionTags(s.span, s.budget.timeout(s.cfg.gitTimeout))
+		s.budget.spend(diffStart)
+
+		if s.cfg.coverageDir != "" {
+			coverageStart := time.Now()
+			err := reportIntegrationCoverage(s.span, s.cfg.coverageDir, s.budget.timeout(defaultCoverageTimeout))
+			s.budget.spend(coverageStart)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: %v\n", err)
+			}
+		}
+
+		s.finishModules()
+		writeModuleStatus(s)
+		ensureCITags()
+		flushStart := time.Now()
+		flushed := s.budget.run(func() { flush(true) }, s.cfg.finalFlushTimeout)
+		addOverhead(&overhead.flush, flushStart)
+
+		// Report the SDK overhead in the session span.
+		setCITags(s.span, nil)
+		setOverheadMetrics(s.span)
+		s.summary.setTags(s.span)
+		s.repeats.setTags(s.span)
+		s.span.SetTag(constants.TestSessionFlushTimedOut, !flushed)
+		s.span.SetTag(constants.TestSessionTruncatedTags, truncatedTagsCount())
+		s.span.Finish()
+		if s.cfg.profilerStop != nil {
+			s.cfg.profilerStop()
+		}
+
+		// The tracer flushes the session span when it stops.
+		if flushed = s.budget.run(stopTracer, s.cfg.finalFlushTimeout) && flushed; flushed {
+			setFinalFlushStatus(FlushStatusFlushed)
+		} else {
+			setFinalFlushStatus(FlushStatusTimedOut)
+			fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: the final flush timed out after %v, some data may have been dropped\n",
+				s.cfg.finalFlushTimeout)
+		}
+		if !s.budget.run(logs.Flush, s.cfg.uploadTimeout) {
+			fmt.Fprintln(os.Stderr, "dd-sdk-go-testing: the upload of the logs timed out, some logs may have been dropped")
+		}
+		if s.cfg.outputFile != nil {
+			if err := s.cfg.outputFile.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: writing the test events: %v\n", err)
+			}
+		}
+		if !s.cfg.sessionLinkDisabled {
+			s.writeSessionLink(os.Stderr)
+		}
+	})
+}
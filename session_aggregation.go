@@ -0,0 +1,246 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+)
+
+const (
+	// envJobSessionDisabled is the environment variable disabling the session ID derived from
+	// the CI job.
+	envJobSessionDisabled = "DD_CIVISIBILITY_JOB_SESSION_DISABLED"
+
+	// envInvocationSessionEnabled is the environment variable making the test binaries started
+	// by a plain `go test` report a module of the session of the invocation.
+	envInvocationSessionEnabled = "DD_CIVISIBILITY_INVOCATION_SESSION_ENABLED"
+
+	// envInvocationReporter contains the process ID of the `go test` invocation whose session is
+	// reported by the test binary re-executed as session reporter.
+	envInvocationReporter = "DD_CIVISIBILITY_INVOCATION_REPORTER"
+
+	// invocationPollInterval is the interval between the checks of the session reporter waiting
+	// for the end of the `go test` invocation.
+	invocationPollInterval = 100 * time.Millisecond
+)
+
+// jobSessionDisabledByEnv returns whether the DD_CIVISIBILITY_JOB_SESSION_DISABLED environment
+// variable disables the session ID derived from the CI job.
+func jobSessionDisabledByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envJobSessionDisabled))
+	return v
+}
+
+// invocationSessionByEnv returns whether the DD_CIVISIBILITY_INVOCATION_SESSION_ENABLED
+// environment variable enables the session of the `go test` invocation.
+func invocationSessionByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envInvocationSessionEnabled))
+	return v
+}
+
+// jobSessionID returns a session ID derived from the CI job described by the provider tags, or
+// an empty string outside of a CI job. The root processes of the job, like ddtest on its shards,
+// derive the same ID so they report the same session.
+func jobSessionID(tags map[string]string) string {
+	provider := tags[constants.CIProviderName]
+	if provider == "" || tags[constants.CIPipelineID] == "" && tags[constants.CIJobURL] == "" {
+		return ""
+	}
+	var parts []string
+	for _, key := range []string{
+		constants.CIProviderName,
+		constants.CIPipelineID,
+		constants.CIPipelineNumber,
+		constants.CIJobName,
+		constants.CIJobURL,
+	} {
+		parts = append(parts, tags[key])
+	}
+	return hashSessionID(parts...)
+}
+
+func init() {
+	if pid, ok := invocationReporterPID(); ok {
+		// The test binary was re-executed to report the session of its `go test` invocation,
+		// which is done before the TestMain function of the package runs any setup.
+		reportInvocationSession(newRunConfig(), pid)
+		osExit(0)
+	}
+}
+
+// invocationWorkDir returns the temporary work directory of the `go test` invocation running the
+// test binary at path, or an empty string when the binary wasn't built by `go test`. The binaries
+// of an invocation, like the packages of `go test ./...`, are built in the same work directory of
+// the go command, named go-build followed by a random number, removed when the invocation ends.
+func invocationWorkDir(path string) string {
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(path)), "/")
+	for i, dir := range dirs {
+		if strings.HasPrefix(dir, "go-build") && len(dir) > len("go-build") {
+			return filepath.FromSlash(strings.Join(dirs[:i+1], "/"))
+		}
+	}
+	return ""
+}
+
+// invocationSessionID returns a session ID derived from the `go test` invocation running the test
+// binary at path, or an empty string when the binary wasn't built by `go test`, so every binary
+// of the same invocation derives the same ID.
+func invocationSessionID(path string) string {
+	dir := invocationWorkDir(path)
+	if dir == "" {
+		return ""
+	}
+	return hashSessionID("go test", filepath.ToSlash(dir))
+}
+
+// invocationDir returns the directory where the test binaries of the `go test` invocation of the
+// session record the statuses of their modules for the session reporter.
+func invocationDir(id string) string {
+	return filepath.Join(os.TempDir(), "dd-civisibility-session-"+id)
+}
+
+// joinInvocationSession returns whether the test binary reports a module of the session of its
+// `go test` invocation. There's no process around the test binaries of a plain `go test` to
+// report the session span, so the first binary of the invocation, creating the directory of the
+// session, re-executes itself as session reporter, waiting for the end of the invocation. It's
+// not supported on Windows, where the running reporter would keep the work directory of the
+// invocation from being removed.
+func joinInvocationSession(id string) bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	dir := invocationDir(id)
+	err := os.Mkdir(dir, 0700)
+	if os.IsExist(err) {
+		return true
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: creating the directory of the session: %v\n", err)
+		return false
+	}
+	if err := startInvocationReporter(); err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: starting the session reporter: %v\n", err)
+		os.Remove(dir)
+		return false
+	}
+	return true
+}
+
+// startInvocationReporter re-executes the test binary, without running its tests, as reporter of
+// the session of its `go test` invocation, the parent process. The reporter doesn't inherit the
+// output of the test binary, which `go test` waits to be closed.
+func startInvocationReporter() error {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), envInvocationReporter+"="+strconv.Itoa(os.Getppid()))
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// invocationReporterPID returns the process ID of the `go test` invocation when the test binary
+// was re-executed as reporter of its session.
+func invocationReporterPID() (int, bool) {
+	pid, err := strconv.Atoi(os.Getenv(envInvocationReporter))
+	return pid, err == nil
+}
+
+// reportInvocationSession reports the session span of the `go test` invocation with the given
+// process ID, once it ended, tagged with the statuses of the modules of its test binaries.
+func reportInvocationSession(cfg *runConfig, pid int) {
+	os.Unsetenv(envInvocationReporter)
+	id := invocationSessionID(os.Args[0])
+	workDir := invocationWorkDir(os.Args[0])
+	dir := invocationDir(id)
+	defer os.RemoveAll(dir)
+
+	cfg.sessionRoot = true
+	cfg.sessionID = id
+	cfg.signalHandlerDisabled = true
+	cfg.sessionLinkDisabled = true
+	s := startSession(cfg)
+	// The work directory is removed when the invocation ends, which is checked as well on the
+	// platforms where the process can't be checked.
+	for utils.ProcessRunning(pid) {
+		if _, err := os.Stat(workDir); err != nil {
+			break
+		}
+		time.Sleep(invocationPollInterval)
+	}
+	for _, status := range readModuleStatuses(dir) {
+		s.rollup.record(status)
+	}
+	s.stop()
+}
+
+// writeModuleStatus records the status of the module of the test binary for the reporter of the
+// session of its `go test` invocation, when it reports one.
+func writeModuleStatus(s *testSession) {
+	if s.module == nil {
+		return
+	}
+	dir := invocationDir(s.id)
+	if _, err := os.Stat(dir); err != nil {
+		return
+	}
+	status := s.rollup.status()
+	if err := ioutil.WriteFile(filepath.Join(dir, s.module.id), []byte(status), 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "dd-sdk-go-testing: recording the status of the module: %v\n", err)
+	}
+}
+
+// readModuleStatuses returns the statuses of the modules recorded in the directory of the
+// session.
+func readModuleStatuses(dir string) []string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var statuses []string
+	for _, f := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if status := strings.TrimSpace(string(data)); err == nil && status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// hashSessionID returns a session ID derived from the parts.
+func hashSessionID(parts ...string) string {
+	h := fnv.New64a()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	// The ID is used as trace ID, which must be a positive int64 for the agent.
+	id := h.Sum64() & (1<<63 - 1)
+	if id == 0 {
+		id = 1
+	}
+	return strconv.FormatUint(id, 10)
+}
+
+// moduleName returns the name of the module reported by the test binary: the package of its
+// TestMain function, or the name of the binary for the packages without a TestMain function.
+func moduleName(cfg *runConfig) string {
+	if cfg.module != "" {
+		return cfg.module
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe"), ".test")
+}
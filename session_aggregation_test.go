@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestJobSessionID(t *testing.T) {
+	job := map[string]string{
+		constants.CIProviderName: "github",
+		constants.CIPipelineID:   "1234",
+		constants.CIJobName:      "test",
+	}
+	id := jobSessionID(job)
+	if id == "" {
+		t.Fatal("expected a session ID for a CI job")
+	}
+	if other := jobSessionID(job); other != id {
+		t.Errorf("the session ID isn't deterministic: %s != %s", id, other)
+	}
+	if n, err := strconv.ParseInt(id, 10, 64); err != nil || n <= 0 {
+		t.Errorf("the session ID isn't a positive int64: %s", id)
+	}
+
+	other := map[string]string{
+		constants.CIProviderName: "github",
+		constants.CIPipelineID:   "1234",
+		constants.CIJobName:      "lint",
+	}
+	if jobSessionID(other) == id {
+		t.Error("expected different session IDs for different jobs")
+	}
+
+	if id := jobSessionID(map[string]string{constants.CIProviderName: "github"}); id != "" {
+		t.Errorf("unexpected session ID without pipeline: %s", id)
+	}
+	if id := jobSessionID(nil); id != "" {
+		t.Errorf("unexpected session ID outside of a CI job: %s", id)
+	}
+}
+
+func TestInvocationSessionID(t *testing.T) {
+	id := invocationSessionID("/tmp/go-build123/b001/ddtesting.test")
+	if id == "" {
+		t.Fatal("expected a session ID for a binary built by go test")
+	}
+	if other := invocationSessionID("/tmp/go-build123/b042/utils.test"); other != id {
+		t.Errorf("expected the session ID of the invocation for all its binaries: %s != %s", id, other)
+	}
+	if other := invocationSessionID("/tmp/go-build456/b001/ddtesting.test"); other == id {
+		t.Error("expected different session IDs for different invocations")
+	}
+	if n, err := strconv.ParseInt(id, 10, 64); err != nil || n <= 0 {
+		t.Errorf("the session ID isn't a positive int64: %s", id)
+	}
+	for _, path := range []string{"/usr/local/bin/ddtesting.test", "/tmp/go-build/ddtesting.test", "ddtesting.test"} {
+		if id := invocationSessionID(path); id != "" {
+			t.Errorf("%s: unexpected session ID of a binary not built by go test: %s", path, id)
+		}
+	}
+}
+
+func TestModuleName(t *testing.T) {
+	cfg := newRunConfig()
+	cfg.module = "github.com/DataDog/dd-sdk-go-testing/contrib"
+	if name := moduleName(cfg); name != cfg.module {
+		t.Errorf("unexpected module name: %s", name)
+	}
+
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"/tmp/go-build123/b001/ddtesting.test"}
+	if name := moduleName(newRunConfig()); name != "ddtesting" {
+		t.Errorf("unexpected module name of the binary: %s", name)
+	}
+}
+
+func TestStartSessionSpanRoot(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	defer os.Setenv(envSessionID, os.Getenv(envSessionID))
+	defer os.Setenv(envSessionSpanID, os.Getenv(envSessionSpanID))
+	os.Unsetenv(envSessionID)
+	os.Unsetenv(envSessionSpanID)
+
+	// A root process, like ddtest, reports the session span of the test binaries it runs.
+	root, id, moduleID := startSessionSpan(newRunConfig(WithJobSessionDisabled(), WithSessionRoot()))
+	root.Finish()
+	if moduleID != "" {
+		t.Errorf("unexpected module ID of the root process: %s", moduleID)
+	}
+	if os.Getenv(envSessionID) != id || os.Getenv(envSessionSpanID) != strconv.FormatUint(root.Context().SpanID(), 10) {
+		t.Error("the session isn't exported to the child processes")
+	}
+
+	// A nested root process reports a module of the session.
+	nested, nestedID, moduleID := startSessionSpan(newRunConfig(WithJobSessionDisabled(), WithSessionRoot()))
+	nested.Finish()
+	if nestedID != id || moduleID == "" {
+		t.Errorf("expected a module of the session %s, got session %s and module %q", id, nestedID, moduleID)
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if spans[1].ParentID() != spans[0].SpanID() {
+		t.Error("the module span isn't a child of the session span")
+	}
+}
+
+func TestStartSessionSpanStandalone(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	defer os.Setenv(envSessionID, os.Getenv(envSessionID))
+	defer os.Setenv(envSessionSpanID, os.Getenv(envSessionSpanID))
+	os.Unsetenv(envSessionID)
+	os.Unsetenv(envSessionSpanID)
+
+	// Without a parent process, the test binary started by `go test` reports its session unless
+	// the session of the invocation is enabled.
+	span, id, moduleID := startSessionSpan(newRunConfig())
+	span.Finish()
+	if moduleID != "" || id != strconv.FormatUint(span.Context().TraceID(), 10) {
+		t.Errorf("expected a session, got session %s and module %q", id, moduleID)
+	}
+	if spans := mt.FinishedSpans(); len(spans) != 1 || spans[0].OperationName() != constants.SpanTypeTestSession {
+		t.Errorf("unexpected spans %v", spans)
+	}
+}
+
+func TestStartSessionSpanInvocation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the session of the invocation isn't supported on Windows")
+	}
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	defer os.Setenv(envSessionID, os.Getenv(envSessionID))
+	defer os.Setenv(envSessionSpanID, os.Getenv(envSessionSpanID))
+	os.Unsetenv(envSessionID)
+	os.Unsetenv(envSessionSpanID)
+	defer func(arg string) { os.Args[0] = arg }(os.Args[0])
+	os.Args[0] = filepath.Join(os.TempDir(), "go-build42", "b001", "ddtesting.test")
+
+	// The directory of the session exists when another test binary of the invocation started the
+	// session reporter.
+	invocation := invocationSessionID(os.Args[0])
+	dir := invocationDir(invocation)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	span, id, moduleID := startSessionSpan(newRunConfig(WithInvocationSession()))
+	span.Finish()
+	if id != invocation || moduleID == "" {
+		t.Errorf("expected a module of the session %s, got session %s and module %q", invocation, id, moduleID)
+	}
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 || strconv.FormatUint(spans[0].ParentID(), 10) != invocation {
+		t.Fatalf("the module isn't a child of the session span: %v", spans)
+	}
+	if os.Getenv(envSessionID) != id || os.Getenv(envSessionSpanID) != invocation {
+		t.Error("the session isn't exported to the child processes")
+	}
+
+	// The session reporter reads the statuses of the modules.
+	s := &testSession{id: id, module: &TestModule{id: moduleID}, rollup: new(statusRollup)}
+	s.rollup.record(constants.TestStatusFail)
+	writeModuleStatus(s)
+	if statuses := readModuleStatuses(dir); len(statuses) != 1 || statuses[0] != constants.TestStatusFail {
+		t.Errorf("unexpected statuses of the modules: %v", statuses)
+	}
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+// envSessionLinkDisabled is the environment variable disabling the link to the session printed
+// when it finishes.
+const envSessionLinkDisabled = "DD_CIVISIBILITY_SESSION_LINK_DISABLED"
+
+// sessionLinkDisabledByEnv returns whether the DD_CIVISIBILITY_SESSION_LINK_DISABLED environment
+// variable disables the link to the session.
+func sessionLinkDisabledByEnv() bool {
+	v, _ := strconv.ParseBool(os.Getenv(envSessionLinkDisabled))
+	return v
+}
+
+// appHost returns the host of the Datadog application of the site: the sites with their own
+// subdomain, like us3.datadoghq.com, are their application, the others are served on app.
+func appHost(site string) string {
+	if site == "" {
+		site = defaultSite
+	}
+	if strings.Count(site, ".") >= 2 {
+		return site
+	}
+	return "app." + site
+}
+
+// sessionLink returns the URL of the test runs of the session in CI Visibility.
+func sessionLink(site, service, env, sessionID string) string {
+	query := []string{"@test_session_id:" + sessionID}
+	if service != "" {
+		query = append(query, "@test.service:"+strconv.Quote(service))
+	}
+	if env != "" {
+		query = append(query, "env:"+env)
+	}
+	return fmt.Sprintf("https://%s/ci/test-runs?query=%s", appHost(site), url.QueryEscape(strings.Join(query, " ")))
+}
+
+// writeSessionLink writes the number of passed, failed and skipped tests of the session and the
+// link to its test runs, so the developers running the tests locally can open them. Nothing is
+// written when no test ran.
+func (s *testSession) writeSessionLink(w io.Writer) {
+	s.summary.mu.Lock()
+	passed := s.summary.counts[constants.TestStatusPass]
+	failed := s.summary.counts[constants.TestStatusFail]
+	skipped := s.summary.counts[constants.TestStatusSkip]
+	s.summary.mu.Unlock()
+	if passed+failed+skipped == 0 {
+		return
+	}
+	fmt.Fprintf(w, "dd-sdk-go-testing: %d passed, %d failed, %d skipped, see %s\n", passed, failed, skipped,
+		sessionLink(os.Getenv("DD_SITE"), s.service, s.env, s.id))
+}
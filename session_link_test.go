@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+)
+
+func TestAppHost(t *testing.T) {
+	for site, want := range map[string]string{
+		"":                  "app.datadoghq.com",
+		"datadoghq.com":     "app.datadoghq.com",
+		"datadoghq.eu":      "app.datadoghq.eu",
+		"us3.datadoghq.com": "us3.datadoghq.com",
+		"ddog-gov.com":      "app.ddog-gov.com",
+	} {
+		if got := appHost(site); got != want {
+			t.Errorf("%s: expected %s, got %s", site, want, got)
+		}
+	}
+}
+
+func TestWriteSessionLink(t *testing.T) {
+	defer os.Setenv("DD_SITE", os.Getenv("DD_SITE"))
+	os.Setenv("DD_SITE", "datadoghq.eu")
+
+	s := &testSession{id: "123", service: "my-repo", env: "local", summary: newSessionSummary(time.Now())}
+	var buf bytes.Buffer
+	s.writeSessionLink(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output without tests: %s", buf.String())
+	}
+
+	s.summary.add(&testResult{status: constants.TestStatusPass})
+	s.summary.add(&testResult{status: constants.TestStatusPass})
+	s.summary.add(&testResult{status: constants.TestStatusFail})
+	s.writeSessionLink(&buf)
+	want := "dd-sdk-go-testing: 2 passed, 1 failed, 0 skipped, see " +
+		"https://app.datadoghq.eu/ci/test-runs?query=%40test_session_id%3A123+%40test.service%3A%22my-repo%22+env%3Alocal\n"
+	if buf.String() != want {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestStartSessionSpan(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	defer os.Setenv(envSessionID, os.Getenv(envSessionID))
+	defer os.Setenv(envSessionSpanID, os.Getenv(envSessionSpanID))
+	cfg := newRunConfig(WithJobSessionDisabled())
+	cfg.module = "github.com/DataDog/dd-sdk-go-testing/child"
+
+	// The first process exports its session to the child processes.
+	os.Unsetenv(envSessionID)
+	os.Unsetenv(envSessionSpanID)
+	parent, parentID, parentModuleID := startSessionSpan(cfg)
+	parent.Finish()
+	if parentID != strconv.FormatUint(parent.Context().TraceID(), 10) || os.Getenv(envSessionID) != parentID {
+		t.Errorf("unexpected session ID: %s", parentID)
+	}
+	if parentModuleID != "" {
+		t.Errorf("unexpected module ID of the session: %s", parentModuleID)
+	}
+	if os.Getenv(envSessionSpanID) != strconv.FormatUint(parent.Context().SpanID(), 10) {
+		t.Errorf("unexpected session span ID: %s", os.Getenv(envSessionSpanID))
+	}
+
+	// A child process reports a module of the parent session.
+	child, childID, moduleID := startSessionSpan(cfg)
+	child.Finish()
+	if childID != parentID {
+		t.Errorf("expected session ID %s, got %s", parentID, childID)
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if spans[0].Tag(ext.SpanType) != constants.SpanTypeTestSession {
+		t.Errorf("unexpected span type of the session: %v", spans[0].Tag(ext.SpanType))
+	}
+	if spans[1].TraceID() != spans[0].TraceID() || spans[1].ParentID() != spans[0].SpanID() {
+		t.Error("module span is not a child of the session span")
+	}
+	if spans[1].Tag(ext.SpanType) != constants.SpanTypeTestModule {
+		t.Errorf("unexpected span type of the module: %v", spans[1].Tag(ext.SpanType))
+	}
+	if spans[1].Tag(constants.TestSessionID) != parentID {
+		t.Errorf("unexpected session ID tag: %v", spans[1].Tag(constants.TestSessionID))
+	}
+	if spans[1].Tag(constants.TestModuleID) != moduleID || moduleID != strconv.FormatUint(spans[1].SpanID(), 10) {
+		t.Errorf("unexpected module ID tag: %v", spans[1].Tag(constants.TestModuleID))
+	}
+	if spans[1].Tag(constants.TestModule) != cfg.module {
+		t.Errorf("unexpected module tag: %v", spans[1].Tag(constants.TestModule))
+	}
+}
+
+func TestResolveEnv(t *testing.T) {
+	defer os.Setenv("DD_ENV", os.Getenv("DD_ENV"))
+
+	os.Setenv("DD_ENV", "staging")
+	if env := resolveEnv(newRunConfig(WithEnv("test"))); env != "test" {
+		t.Errorf("unexpected env with WithEnv: %s", env)
+	}
+	if env := resolveEnv(newRunConfig()); env != "staging" {
+		t.Errorf("unexpected env with DD_ENV: %s", env)
+	}
+
+	os.Unsetenv("DD_ENV")
+	expected := "local"
+	if _, ok := utils.GetProviderTags()[constants.CIProviderName]; ok {
+		expected = "ci"
+	}
+	if env := resolveEnv(newRunConfig()); env != expected {
+		t.Errorf("unexpected default env: %s", env)
+	}
+}
+
+func TestSetExitCode(t *testing.T) {
+	s := &testSession{rollup: new(statusRollup)}
+	s.setExitCode(0)
+	if status := s.rollup.status(); status != constants.TestStatusPass {
+		t.Errorf("unexpected status of the exit code 0: %s", status)
+	}
+	s.setExitCode(1)
+	if status := s.rollup.status(); status != constants.TestStatusFail {
+		t.Errorf("unexpected status of the exit code 1: %s", status)
+	}
+
+	// The statuses of the tests of the process are kept when the tests succeeded.
+	s = &testSession{rollup: new(statusRollup)}
+	s.rollup.record(constants.TestStatusSkip)
+	s.setExitCode(0)
+	if status := s.rollup.status(); status != constants.TestStatusSkip {
+		t.Errorf("unexpected status of the skipped tests: %s", status)
+	}
+}
+
+func TestHandleSignalsCallback(t *testing.T) {
+	var calls []string
+	s := &testSession{}
+	received := make(chan os.Signal, 1)
+	s.handleSignals([]os.Signal{os.Interrupt}, func() { calls = append(calls, "stop") }, func(sig os.Signal) {
+		calls = append(calls, "callback")
+		received <- sig
+	})
+	defer signal.Stop(s.signals)
+	s.signals <- os.Interrupt
+
+	select {
+	case sig := <-received:
+		if sig != os.Interrupt {
+			t.Errorf("unexpected signal: %v", sig)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the callback wasn't called")
+	}
+	if !reflect.DeepEqual(calls, []string{"stop", "callback"}) {
+		t.Errorf("the session wasn't stopped before the callback: %v", calls)
+	}
+}
+
+func TestHandleSignalsExit(t *testing.T) {
+	exited := make(chan int, 1)
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	osExit = func(code int) { exited <- code }
+
+	stopped := false
+	s := &testSession{}
+	s.handleSignals([]os.Signal{os.Interrupt}, func() { stopped = true }, nil)
+	defer signal.Stop(s.signals)
+	s.signals <- os.Interrupt
+
+	select {
+	case code := <-exited:
+		if code != 1 || !stopped {
+			t.Errorf("unexpected exit: code %d, stopped %v", code, stopped)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the test binary didn't exit")
+	}
+}
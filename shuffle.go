@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"flag"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// executionIndex counts the tests started by the test binary.
+var executionIndex int64
+
+// fixShuffleSeed replaces -shuffle=on with a seed from the clock before the tests run, like the
+// testing package does, so the seed is known to the SDK. The testing package prints it all the same.
+func fixShuffleSeed() {
+	f := flag.Lookup("test.shuffle")
+	if f == nil {
+		return
+	}
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+	if f.Value.String() == "on" {
+		f.Value.Set(strconv.FormatInt(time.Now().UnixNano(), 10))
+	}
+}
+
+// shuffleSeed returns the seed of the -shuffle flag, and whether the tests are shuffled. With
+// -shuffle=on, the seed is only known when it was fixed by Run.
+func shuffleSeed() (int64, bool) {
+	f := flag.Lookup("test.shuffle")
+	if f == nil {
+		return 0, false
+	}
+	seed, err := strconv.ParseInt(f.Value.String(), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seed, true
+}
+
+// setShuffleTags tags the span of a test with the seed of the -shuffle flag and its position in
+// the execution order, so an order-dependent failure can be replayed with -shuffle=<seed>.
+func setShuffleTags(span ddtrace.Span) {
+	index := atomic.AddInt64(&executionIndex, 1)
+	if seed, ok := shuffleSeed(); ok {
+		span.SetTag(constants.TestShuffleSeed, seed)
+		span.SetTag(constants.TestShuffleIndex, index)
+	}
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"flag"
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestShuffleTags(t *testing.T) {
+	f := flag.Lookup("test.shuffle")
+	if f == nil {
+		t.Skip("-shuffle isn't supported by this version of Go")
+	}
+	defer f.Value.Set(f.Value.String())
+
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	f.Value.Set("off")
+	span := tracer.StartSpan("test")
+	setShuffleTags(span)
+	span.Finish()
+	if tag := span.(mocktracer.Span).Tag(constants.TestShuffleSeed); tag != nil {
+		t.Errorf("unexpected seed without -shuffle: %v", tag)
+	}
+
+	f.Value.Set("on")
+	fixShuffleSeed()
+	seed, err := strconv.ParseInt(f.Value.String(), 10, 64)
+	if err != nil {
+		t.Fatalf("the seed of -shuffle=on wasn't fixed: %s", f.Value.String())
+	}
+
+	first := tracer.StartSpan("test")
+	setShuffleTags(first)
+	second := tracer.StartSpan("test")
+	setShuffleTags(second)
+	for _, s := range []mocktracer.Span{first.(mocktracer.Span), second.(mocktracer.Span)} {
+		if tag := s.Tag(constants.TestShuffleSeed); tag != seed {
+			t.Errorf("unexpected seed: %v", tag)
+		}
+	}
+	i1, _ := first.(mocktracer.Span).Tag(constants.TestShuffleIndex).(int64)
+	i2, _ := second.(mocktracer.Span).Tag(constants.TestShuffleIndex).(int64)
+	if i1 <= 0 || i2 != i1+1 {
+		t.Errorf("unexpected execution order: %d, %d", i1, i2)
+	}
+}
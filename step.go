@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type stepContextKey struct{}
+
+// RecordStep runs fn as a named step of the test of ctx, recorded as a child span of the test
+// span with its own status. The step fails when fn returns an error or panics. The context
+// given to fn contains the step span, so steps can be nested:
+//
+//	err := ddtesting.RecordStep(ctx, "provisioning", func(ctx context.Context) error {
+//		return cluster.Create(ctx)
+//	})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+func RecordStep(ctx context.Context, name string, fn func(ctx context.Context) error) (err error) {
+	ctx = StartStep(ctx, name)
+	defer func() {
+		if r := recover(); r != nil {
+			if span, ok := ctx.Value(stepContextKey{}).(ddtrace.Span); ok {
+				span.SetTag(ext.ErrorStack, getStacktrace(2))
+				span.SetTag(ext.ErrorType, "panic")
+			}
+			FinishStep(ctx, fmt.Errorf("%v", r))
+			panic(r)
+		}
+		FinishStep(ctx, err)
+	}()
+	return fn(ctx)
+}
+
+// StartStep starts a named step of the test of ctx, recorded as a child span of the test span.
+// The returned context must be given to FinishStep when the step ends. When the SDK is disabled,
+// ctx is returned and the step isn't recorded.
+func StartStep(ctx context.Context, name string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !isEnabled() {
+		return ctx
+	}
+	span, ctx := startScrubbedSpan(ctx, constants.SpanTypeTestStep,
+		tracer.SpanType(constants.SpanTypeTestStep),
+		tracer.ResourceName(name),
+		tracer.Tag(constants.TestStepName, name),
+		tracer.Tag(constants.Origin, constants.CIAppTestOrigin))
+	return context.WithValue(ctx, stepContextKey{}, span)
+}
+
+// FinishStep finishes the step started by StartStep with ctx. The step fails when err isn't nil.
+func FinishStep(ctx context.Context, err error) {
+	if ctx == nil {
+		return
+	}
+	span, ok := ctx.Value(stepContextKey{}).(ddtrace.Span)
+	if !ok {
+		return
+	}
+	if err != nil {
+		span.SetTag(constants.TestStepStatus, constants.TestStatusFail)
+		span.SetTag(ext.Error, true)
+		span.SetTag(ext.ErrorMsg, err.Error())
+	} else {
+		span.SetTag(constants.TestStepStatus, constants.TestStatusPass)
+	}
+	span.Finish()
+}
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestRecordStep(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	test, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	err := RecordStep(ctx, "provisioning", func(ctx context.Context) error {
+		return RecordStep(ctx, "create cluster", func(context.Context) error {
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = RecordStep(ctx, "verification", func(context.Context) error {
+		return errors.New("unexpected response")
+	})
+	if err == nil || err.Error() != "unexpected response" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	test.Finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 4 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	create, provisioning, verification := spans[0], spans[1], spans[2]
+	if create.ParentID() != provisioning.SpanID() || provisioning.ParentID() != test.Context().SpanID() {
+		t.Error("unexpected step hierarchy")
+	}
+	if provisioning.Tag(constants.TestStepName) != "provisioning" ||
+		provisioning.Tag(constants.TestStepStatus) != constants.TestStatusPass {
+		t.Errorf("unexpected passed step span: %v", provisioning.Tags())
+	}
+	if verification.Tag(constants.TestStepStatus) != constants.TestStatusFail ||
+		verification.Tag(ext.ErrorMsg) != "unexpected response" {
+		t.Errorf("unexpected failed step span: %v", verification.Tags())
+	}
+}
+
+func TestRecordStepPanic(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("unexpected panic: %v", r)
+			}
+		}()
+		RecordStep(context.Background(), "request", func(context.Context) error {
+			panic("boom")
+		})
+	}()
+
+	s := mt.FinishedSpans()[0]
+	if s.Tag(constants.TestStepStatus) != constants.TestStatusFail || s.Tag(ext.ErrorType) != "panic" ||
+		s.Tag(ext.ErrorMsg) != "boom" {
+		t.Errorf("unexpected step span: %v", s.Tags())
+	}
+}
+
+func TestFinishStepWithoutStep(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+	FinishStep(ctx, nil)
+	if len(mt.FinishedSpans()) != 0 {
+		t.Error("FinishStep finished a span that isn't a step")
+	}
+	span.Finish()
+}
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+)
+
+// slowestTestsCount is the number of slowest tests reported in the session summary.
+const slowestTestsCount = 5
+
+// sessionSummary aggregates the results of the tests of a session.
+type sessionSummary struct {
+	start time.Time
+
+	mu           sync.Mutex
+	counts       map[string]int
+	firstStart   time.Time
+	firstFailure time.Time
+	slowest      []testDuration
+}
+
+// testDuration is the duration of a test.
+type testDuration struct {
+	name     string
+	duration time.Duration
+}
+
+func newSessionSummary(start time.Time) *sessionSummary {
+	return &sessionSummary{start: start, counts: map[string]int{}}
+}
+
+// add adds the result of a finished test, it's registered as a result writer.
+func (s *sessionSummary) add(r *testResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[r.status]++
+	if s.firstStart.IsZero() || r.start.Before(s.firstStart) {
+		s.firstStart = r.start
+	}
+	if r.status == constants.TestStatusFail && (s.firstFailure.IsZero() || r.finish.Before(s.firstFailure)) {
+		s.firstFailure = r.finish
+	}
+
+	// Keep the slowest tests sorted by decreasing duration.
+	d := testDuration{name: fmt.Sprintf("%s.%s", r.suite, r.name), duration: r.finish.Sub(r.start)}
+	i := sort.Search(len(s.slowest), func(i int) bool { return s.slowest[i].duration < d.duration })
+	if i < slowestTestsCount {
+		s.slowest = append(s.slowest, testDuration{})
+		copy(s.slowest[i+1:], s.slowest[i:])
+		s.slowest[i] = d
+		if len(s.slowest) > slowestTestsCount {
+			s.slowest = s.slowest[:slowestTestsCount]
+		}
+	}
+}
+
+// setTags sets the summary as tags of the session span.
+func (s *sessionSummary) setTags(span ddtrace.Span) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span.SetTag(constants.TestSessionPassed, float64(s.counts[constants.TestStatusPass]))
+	span.SetTag(constants.TestSessionFailed, float64(s.counts[constants.TestStatusFail]))
+	span.SetTag(constants.TestSessionSkipped, float64(s.counts[constants.TestStatusSkip]))
+	if !s.firstStart.IsZero() {
+		span.SetTag(constants.TestSessionSetupTime, toMilliseconds(int64(s.firstStart.Sub(s.start))))
+	}
+	if !s.firstFailure.IsZero() {
+		span.SetTag(constants.TestSessionTimeToFirstFailure, toMilliseconds(int64(s.firstFailure.Sub(s.start))))
+	}
+	if len(s.slowest) > 0 {
+		slowest := make([]string, len(s.slowest))
+		for i, d := range s.slowest {
+			slowest[i] = fmt.Sprintf("%s (%s)", d.name, d.duration.Round(time.Millisecond))
+		}
+		span.SetTag(constants.TestSessionSlowestTests, strings.Join(slowest, ", "))
+	}
+}
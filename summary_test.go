@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestSessionSummary(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	start := time.Now()
+	summary := newSessionSummary(start)
+	for i := 1; i <= 7; i++ {
+		status := constants.TestStatusPass
+		if i == 3 || i == 5 {
+			status = constants.TestStatusFail
+		} else if i == 7 {
+			status = constants.TestStatusSkip
+		}
+		testStart := start.Add(time.Duration(i) * time.Second)
+		summary.add(&testResult{
+			name:   fmt.Sprintf("Test%d", i),
+			suite:  "pkg",
+			status: status,
+			start:  testStart,
+			finish: testStart.Add(time.Duration(i) * 100 * time.Millisecond),
+		})
+	}
+
+	span := tracer.StartSpan("session")
+	summary.setTags(span)
+	span.Finish()
+
+	s := mt.FinishedSpans()[0]
+	if s.Tag(constants.TestSessionPassed) != float64(4) || s.Tag(constants.TestSessionFailed) != float64(2) ||
+		s.Tag(constants.TestSessionSkipped) != float64(1) {
+		t.Errorf("unexpected counts: %v", s.Tags())
+	}
+	if s.Tag(constants.TestSessionSetupTime) != float64(1000) || s.Tag(constants.TestSessionTimeToFirstFailure) != float64(3300) {
+		t.Errorf("unexpected times: %v", s.Tags())
+	}
+	expected := "pkg.Test7 (700ms), pkg.Test6 (600ms), pkg.Test5 (500ms), pkg.Test4 (400ms), pkg.Test3 (300ms)"
+	if s.Tag(constants.TestSessionSlowestTests) != expected {
+		t.Errorf("unexpected slowest tests: %v", s.Tag(constants.TestSessionSlowestTests))
+	}
+}
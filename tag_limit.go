@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"github.com/DataDog/dd-sdk-go-testing/internal/utils"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+const (
+	// envMaxTagSize is the environment variable defining the maximum size of the tag values.
+	envMaxTagSize = "DD_CIVISIBILITY_MAX_TAG_SIZE"
+
+	// defaultMaxTagSize is the default maximum size of the tag values, in bytes.
+	defaultMaxTagSize = 5000
+
+	// maxLargeTagSize is the minimum limit of the tags with long values by nature, like stacks.
+	maxLargeTagSize = 32 * 1024
+
+	// truncatedTagSuffix ends the name of the marker tags of the truncated tags.
+	truncatedTagSuffix = "_truncated"
+)
+
+var (
+	// maxTagSize is the maximum size of the tag values of the spans started by the SDK.
+	maxTagSize int64 = defaultMaxTagSize
+
+	// truncatedTags counts the tag values truncated during the session.
+	truncatedTags int64
+
+	// largeTags are the tags whose values are long by nature, limited to maxLargeTagSize at least.
+	largeTags = map[string]bool{
+		ext.ErrorStack:                    true,
+		constants.TestOutput:              true,
+		constants.TestFailureDiff:         true,
+		constants.TestGoldenDiff:          true,
+		constants.TestSessionSlowestTests: true,
+	}
+)
+
+// maxTagSizeByEnv returns the maximum size of the tag values defined by DD_CIVISIBILITY_MAX_TAG_SIZE.
+func maxTagSizeByEnv() int {
+	if size, err := strconv.Atoi(os.Getenv(envMaxTagSize)); err == nil && size > 0 {
+		return size
+	}
+	return defaultMaxTagSize
+}
+
+// setMaxTagSize sets the maximum size of the tag values.
+func setMaxTagSize(size int) {
+	atomic.StoreInt64(&maxTagSize, int64(size))
+}
+
+// tagSizeLimit returns the maximum size of the value of the tag.
+func tagSizeLimit(key string) int {
+	limit := int(atomic.LoadInt64(&maxTagSize))
+	if largeTags[key] && limit < maxLargeTagSize {
+		return maxLargeTagSize
+	}
+	return limit
+}
+
+// truncateTag truncates the value of the tag when it's larger than its limit, so a huge commit
+// message or error doesn't get the whole payload rejected by the intake. It returns whether
+// the value was truncated, and counts the truncations of the session.
+func truncateTag(key, value string) (string, bool) {
+	limit := tagSizeLimit(key)
+	if len(value) <= limit {
+		return value, false
+	}
+	atomic.AddInt64(&truncatedTags, 1)
+	return utils.Truncate(value, limit)
+}
+
+// truncatedTagsCount returns the number of tag values truncated during the session.
+func truncatedTagsCount() int64 {
+	return atomic.LoadInt64(&truncatedTags)
+}
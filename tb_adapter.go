@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// skipReasoner is implemented by the testing objects reporting why the test was skipped.
+type skipReasoner interface {
+	SkipReason() string
+}
+
+// failureMessager is implemented by the testing objects reporting why the test failed.
+type failureMessager interface {
+	FailureMessage() string
+}
+
+// TBAdapter reports the tests of a harness that isn't built on the testing package, like an
+// in-house end-to-end runner, through the testing.TB interface expected by StartTest. testing.TB
+// can't be implemented outside of the testing package, so TBAdapter embeds a nil testing.TB and
+// implements the methods used by the SDK:
+//
+//   - Name, Failed and Skipped report the test, using the callbacks when they're set.
+//   - SkipReason and FailureMessage are set as the skip reason and the error message of the span.
+//   - Error, Errorf, Fatal, Fatalf, Fail and FailNow record a failure, and Skip, Skipf and
+//     SkipNow record a skip, when the SDK or a helper fails or skips the test. They don't stop
+//     the test: the harness keeps control of its execution.
+//   - Cleanup registers a function called when the test finishes, after its span. TempDir and
+//     Setenv use it to remove the directory and restore the variable.
+//   - Helper, Log and Logf do nothing.
+//
+// The other methods of testing.TB, like Context in recent Go versions, panic. A harness reports
+// a test with:
+//
+//	tb := &ddtesting.TBAdapter{
+//		TestName:           scenario.Name,
+//		FailedFunc:         func() bool { return scenario.Err != nil },
+//		FailureMessageFunc: func() string { return scenario.Err.Error() },
+//	}
+//	ctx, finish := ddtesting.StartTestWithContext(ctx, tb, ddtesting.WithTestSuite("e2e/checkout"))
+//	runScenario(ctx, scenario)
+//	finish()
+type TBAdapter struct {
+	testing.TB
+
+	// TestName is the name of the test.
+	TestName string
+
+	// FailedFunc reports whether the test failed, in addition to the failures recorded with Error.
+	FailedFunc func() bool
+
+	// SkippedFunc reports whether the test was skipped, in addition to the skips recorded with Skip.
+	SkippedFunc func() bool
+
+	// SkipReasonFunc returns why the test was skipped, in addition to the reasons given to Skip.
+	SkipReasonFunc func() string
+
+	// FailureMessageFunc returns why the test failed, in addition to the messages given to Error.
+	FailureMessageFunc func() string
+
+	mu       sync.Mutex
+	failed   bool
+	skipped  bool
+	messages []string
+	reasons  []string
+	cleanups []func()
+}
+
+var (
+	_ testing.TB      = (*TBAdapter)(nil)
+	_ skipReasoner    = (*TBAdapter)(nil)
+	_ failureMessager = (*TBAdapter)(nil)
+)
+
+// Name returns the name of the test.
+func (tb *TBAdapter) Name() string { return tb.TestName }
+
+// Failed reports whether the test failed.
+func (tb *TBAdapter) Failed() bool {
+	tb.mu.Lock()
+	failed := tb.failed
+	tb.mu.Unlock()
+	return failed || tb.FailedFunc != nil && tb.FailedFunc()
+}
+
+// Skipped reports whether the test was skipped.
+func (tb *TBAdapter) Skipped() bool {
+	tb.mu.Lock()
+	skipped := tb.skipped
+	tb.mu.Unlock()
+	return skipped || tb.SkippedFunc != nil && tb.SkippedFunc()
+}
+
+// FailureMessage returns the messages of the recorded failures and of FailureMessageFunc.
+func (tb *TBAdapter) FailureMessage() string {
+	return tb.join(tb.messages, tb.FailureMessageFunc)
+}
+
+// SkipReason returns the reasons of the recorded skips and of SkipReasonFunc.
+func (tb *TBAdapter) SkipReason() string {
+	return tb.join(tb.reasons, tb.SkipReasonFunc)
+}
+
+// join joins the recorded messages and the message returned by fn.
+func (tb *TBAdapter) join(messages []string, fn func() string) string {
+	tb.mu.Lock()
+	all := append([]string(nil), messages...)
+	tb.mu.Unlock()
+	if fn != nil {
+		if msg := fn(); msg != "" {
+			all = append(all, msg)
+		}
+	}
+	return strings.Join(all, "\n")
+}
+
+// fail records a failure with its message.
+func (tb *TBAdapter) fail(msg string) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.failed = true
+	if msg != "" {
+		tb.messages = append(tb.messages, msg)
+	}
+}
+
+// skip records a skip with its reason.
+func (tb *TBAdapter) skip(reason string) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.skipped = true
+	if reason != "" {
+		tb.reasons = append(tb.reasons, reason)
+	}
+}
+
+// Error records a failure with the message.
+func (tb *TBAdapter) Error(args ...interface{}) { tb.fail(fmt.Sprint(args...)) }
+
+// Errorf records a failure with the formatted message.
+func (tb *TBAdapter) Errorf(format string, args ...interface{}) {
+	tb.fail(fmt.Sprintf(format, args...))
+}
+
+// Fatal records a failure with the message, without stopping the test.
+func (tb *TBAdapter) Fatal(args ...interface{}) { tb.fail(fmt.Sprint(args...)) }
+
+// Fatalf records a failure with the formatted message, without stopping the test.
+func (tb *TBAdapter) Fatalf(format string, args ...interface{}) {
+	tb.fail(fmt.Sprintf(format, args...))
+}
+
+// Fail records a failure.
+func (tb *TBAdapter) Fail() { tb.fail("") }
+
+// FailNow records a failure, without stopping the test.
+func (tb *TBAdapter) FailNow() { tb.fail("") }
+
+// Skip records a skip with the reason, without stopping the test.
+func (tb *TBAdapter) Skip(args ...interface{}) { tb.skip(fmt.Sprint(args...)) }
+
+// Skipf records a skip with the formatted reason, without stopping the test.
+func (tb *TBAdapter) Skipf(format string, args ...interface{}) { tb.skip(fmt.Sprintf(format, args...)) }
+
+// SkipNow records a skip, without stopping the test.
+func (tb *TBAdapter) SkipNow() { tb.skip("") }
+
+// Helper does nothing.
+func (tb *TBAdapter) Helper() {}
+
+// Log does nothing.
+func (tb *TBAdapter) Log(args ...interface{}) {}
+
+// Logf does nothing.
+func (tb *TBAdapter) Logf(format string, args ...interface{}) {}
+
+// Cleanup registers a function called when the test finishes, once its span is finished. The
+// functions are called in the reverse order of their registration, like with testing.T.
+func (tb *TBAdapter) Cleanup(fn func()) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.cleanups = append(tb.cleanups, fn)
+}
+
+// runCleanups calls the functions registered with Cleanup, the last one first.
+func (tb *TBAdapter) runCleanups() {
+	tb.mu.Lock()
+	cleanups := tb.cleanups
+	tb.cleanups = nil
+	tb.mu.Unlock()
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+}
+
+// TempDir returns a new temporary directory, removed when the test finishes. It records a
+// failure and returns an empty string when the directory can't be created.
+func (tb *TBAdapter) TempDir() string {
+	dir, err := ioutil.TempDir("", "ddtesting")
+	if err != nil {
+		tb.Fatalf("TempDir: %v", err)
+		return ""
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// Setenv sets the environment variable, restored when the test finishes.
+func (tb *TBAdapter) Setenv(key, value string) {
+	prev, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		tb.Fatalf("Setenv: %v", err)
+		return
+	}
+	tb.Cleanup(func() {
+		if ok {
+			os.Setenv(key, prev)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestTBAdapter(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	err := errors.New("checkout returned 500")
+	for _, tb := range []*TBAdapter{
+		{TestName: "pass"},
+		{
+			TestName:           "callbacks",
+			FailedFunc:         func() bool { return err != nil },
+			FailureMessageFunc: func() string { return err.Error() },
+		},
+		{
+			TestName:       "skip",
+			SkippedFunc:    func() bool { return true },
+			SkipReasonFunc: func() string { return "staging is down" },
+		},
+	} {
+		_, finish := StartTestWithContext(context.Background(), tb, WithTestSuite("e2e"))
+		finish()
+	}
+
+	// The failures recorded by the SDK or the helpers are reported like the ones of the harness.
+	tb := &TBAdapter{TestName: "recorded"}
+	_, finish := StartTestWithContext(context.Background(), tb, WithTestSuite("e2e"))
+	tb.Errorf("expected %d items", 3)
+	tb.Fatal("unexpected response")
+	finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 4 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	if spans[0].Tag(constants.TestStatus) != constants.TestStatusPass || spans[0].Tag(ext.ErrorMsg) != nil {
+		t.Errorf("unexpected passed test: %v", spans[0].Tags())
+	}
+	if spans[1].Tag(constants.TestStatus) != constants.TestStatusFail || spans[1].Tag(ext.ErrorMsg) != err.Error() {
+		t.Errorf("unexpected failed test: %v", spans[1].Tags())
+	}
+	if spans[2].Tag(constants.TestStatus) != constants.TestStatusSkip || spans[2].Tag(constants.TestSkipReason) != "staging is down" {
+		t.Errorf("unexpected skipped test: %v", spans[2].Tags())
+	}
+	if spans[3].Tag(constants.TestStatus) != constants.TestStatusFail ||
+		spans[3].Tag(ext.ErrorMsg) != "expected 3 items\nunexpected response" {
+		t.Errorf("unexpected test failed by the helpers: %v", spans[3].Tags())
+	}
+}
+
+func TestTBAdapterCleanup(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	defer os.Unsetenv("DD_TEST_TB_ADAPTER")
+
+	tb := &TBAdapter{TestName: "cleanup"}
+	ctx, finish := StartTestWithContext(context.Background(), tb, WithTestSuite("e2e"))
+	var calls []string
+	Cleanup(ctx, tb, "close database", func() { calls = append(calls, "close database") })
+	tb.Cleanup(func() { calls = append(calls, "last registered") })
+	dir := tb.TempDir()
+	tb.Setenv("DD_TEST_TB_ADAPTER", "1")
+	if len(calls) != 0 || os.Getenv("DD_TEST_TB_ADAPTER") != "1" {
+		t.Fatal("the cleanup functions ran before the end of the test")
+	}
+	finish()
+
+	if len(calls) != 2 || calls[0] != "last registered" || calls[1] != "close database" {
+		t.Errorf("unexpected cleanup calls: %v", calls)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("the temporary directory wasn't removed: %v", err)
+	}
+	if _, ok := os.LookupEnv("DD_TEST_TB_ADAPTER"); ok {
+		t.Error("the environment variable wasn't restored")
+	}
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 || spans[1].OperationName() != constants.SpanTypeTestCleanup || spans[1].ParentID() != spans[0].SpanID() {
+		t.Errorf("unexpected spans: %v", spans)
+	}
+}
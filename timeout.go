@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+const (
+	// maxTimeoutMargin is the maximum time before the -timeout deadline the watchdog finishes
+	// the running tests, leaving time to flush them before the process is killed.
+	maxTimeoutMargin = 5 * time.Second
+
+	// maxGoroutineDumpSize is the maximum size of the goroutine dump attached to a timed out test.
+	maxGoroutineDumpSize = 1024 * 1024
+)
+
+// startWatchdog starts the watchdog of the -timeout deadline of the test binary. It's started
+// by the first test, when the flags are parsed. Shortly before the deadline, the running tests
+// are finished as timed out with a goroutine dump and the session is stopped, instead of being
+// lost when the testing package kills the process.
+func (s *testSession) startWatchdog(tb testing.TB) {
+	s.watchdogOnce.Do(func() {
+		timeout := testTimeout()
+		if timeout <= 0 {
+			return
+		}
+		deadline := s.start.Add(timeout)
+		if t, ok := tb.(interface{ Deadline() (time.Time, bool) }); ok {
+			if d, ok := t.Deadline(); ok {
+				deadline = d
+			}
+		}
+
+		s.watchdogMutex.Lock()
+		defer s.watchdogMutex.Unlock()
+		s.watchdog = time.AfterFunc(watchdogDelay(deadline, timeout, time.Now()), func() {
+			finishTimedOutTests(timeout)
+			s.stop()
+		})
+	})
+}
+
+// stopWatchdog stops the watchdog of the -timeout deadline.
+func (s *testSession) stopWatchdog() {
+	s.watchdogMutex.Lock()
+	defer s.watchdogMutex.Unlock()
+	if s.watchdog != nil {
+		s.watchdog.Stop()
+	}
+}
+
+// testTimeout returns the value of the -timeout flag, or 0 when there's no timeout.
+func testTimeout() time.Duration {
+	f := flag.Lookup("test.timeout")
+	if f == nil {
+		return 0
+	}
+	timeout, err := time.ParseDuration(f.Value.String())
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// watchdogDelay returns the delay until the watchdog fires: a tenth of the timeout, up to
+// maxTimeoutMargin, before the deadline.
+func watchdogDelay(deadline time.Time, timeout time.Duration, now time.Time) time.Duration {
+	margin := timeout / 10
+	if margin > maxTimeoutMargin {
+		margin = maxTimeoutMargin
+	}
+	if d := deadline.Add(-margin).Sub(now); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// finishTimedOutTests finishes the running tests as failed by a timeout, attaching the dump
+// of all the goroutines. Their finish functions don't report them again.
+func finishTimedOutTests(timeout time.Duration) {
+	finishActiveTests(fmt.Sprintf("test timed out after %v", timeout), "timeout", goroutineDump())
+}
+
+// finishActiveTests finishes the running tests as failed with the given error, stopping what was
+// started with them like their finish functions, and returns them. Their finish functions don't
+// report them again.
+func finishActiveTests(msg, errorType, stack string) []activeTest {
+	activeTestsMutex.Lock()
+	tests := append([]activeTest(nil), activeTests...)
+	activeTestsMutex.Unlock()
+
+	finished := tests[:0]
+	for _, t := range tests {
+		if !t.result.claim() {
+			continue
+		}
+		if t.teardown != nil {
+			t.teardown()
+		}
+		removeActiveTest(t.span)
+
+		t.result.status = constants.TestStatusFail
+		t.result.errorMsg = msg
+		t.result.errorType = errorType
+		t.result.errorStack = stack
+		t.span.SetTag(constants.TestStatus, t.result.status)
+		t.span.SetTag(ext.Error, true)
+		t.span.SetTag(ext.ErrorMsg, t.result.errorMsg)
+		t.span.SetTag(ext.ErrorStack, t.result.errorStack)
+		t.span.SetTag(ext.ErrorType, t.result.errorType)
+		setTestCITags(t.span, t.optTags)
+		releaseSpanOptionTags(t.optTags)
+		t.span.SetTag(constants.TestCorrelationID, correlationID(t.result.suite, t.result.name))
+		if s := currentSession(); s != nil {
+			s.owners.setTags(t.span, t.result.file)
+		}
+		t.span.Finish()
+		t.result.finish = now()
+		writeTestResult(t.result)
+		finished = append(finished, t)
+	}
+	return finished
+}
+
+// goroutineDump returns the stack traces of all the goroutines.
+func goroutineDump() string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxGoroutineDumpSize {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DataDog/dd-sdk-go-testing/internal/constants"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func TestWatchdogDelay(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		deadline time.Time
+		timeout  time.Duration
+		want     time.Duration
+	}{
+		{deadline: now.Add(10 * time.Minute), timeout: 10 * time.Minute, want: 10*time.Minute - maxTimeoutMargin},
+		{deadline: now.Add(10 * time.Second), timeout: 10 * time.Second, want: 9 * time.Second},
+		{deadline: now.Add(-time.Second), timeout: time.Minute, want: 0},
+	} {
+		if got := watchdogDelay(tt.deadline, tt.timeout, now); got != tt.want {
+			t.Errorf("watchdogDelay(%v) = %v, want %v", tt.timeout, got, tt.want)
+		}
+	}
+}
+
+func TestFinishTimedOutTests(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, finish := StartTest(t)
+	finishTimedOutTests(time.Minute)
+	finish()
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	s := spans[0]
+	if s.Tag(constants.TestStatus) != constants.TestStatusFail || s.Tag(ext.ErrorType) != "timeout" ||
+		s.Tag(ext.ErrorMsg) != "test timed out after 1m0s" {
+		t.Errorf("unexpected timed out test span: %v", s.Tags())
+	}
+	if stack, _ := s.Tag(ext.ErrorStack).(string); !strings.Contains(stack, "TestFinishTimedOutTests") {
+		t.Errorf("unexpected goroutine dump: %s", stack)
+	}
+	if _, ok := ActiveTestSpan(); ok {
+		t.Error("the timed out test is still active")
+	}
+}
+
+func TestFinishTimedOutTestsSpanOptions(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, finish := StartTest(t, WithSpanOptions(tracer.Tag(constants.GitBranch, "custom")))
+	finishTimedOutTests(time.Minute)
+	finish()
+
+	if v := mt.FinishedSpans()[0].Tag(constants.GitBranch); v != "custom" {
+		t.Errorf("the CI tags override the span option: %v", v)
+	}
+}
+
+func TestFinishTimedOutTestsTeardown(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ctx, finish := StartTest(t, WithAmbientParent(), WithTimeout(time.Hour))
+	finishTimedOutTests(time.Minute)
+	defer finish()
+
+	if _, ok := tracer.SpanFromContext(AmbientContext(context.Background())); ok {
+		t.Error("the timed out test is still the ambient parent")
+	}
+	if ctx.Err() != context.Canceled {
+		t.Errorf("the context of the timed out test isn't canceled: %v", ctx.Err())
+	}
+}
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// tracerStartOptions returns the options used to start the tracer. The options of the SDK come
+// first, so the tracer options given with WithTracerOptions take precedence. The conflicting
+// SDK options are reported and ignored.
+func tracerStartOptions(cfg *runConfig, env string) ([]tracer.StartOption, []error) {
+	errs := cfg.validate()
+	opts := []tracer.StartOption{tracer.WithEnv(env)}
+	switch {
+	case cfg.udsPath != "":
+		opts = append(opts, tracer.WithHTTPClient(udsClient(cfg.udsPath, cfg.uploadTimeout)))
+	case cfg.agentAddr != "":
+		if _, _, err := net.SplitHostPort(cfg.agentAddr); err == nil {
+			opts = append(opts, tracer.WithAgentAddr(cfg.agentAddr))
+		}
+	}
+	if cfg.sampleRate >= 0 && cfg.sampleRate <= 1 {
+		opts = append(opts, tracer.WithSampler(tracer.NewRateSampler(cfg.sampleRate)))
+	}
+	if cfg.tracerRuntimeMetrics {
+		opts = append(opts, tracer.WithRuntimeMetrics())
+	}
+	for _, tag := range cfg.globalTags {
+		opts = append(opts, tracer.WithGlobalTag(tag[0], tag[1]))
+	}
+	return append(opts, cfg.tracerOpts...), errs
+}
+
+// validate returns the errors of the conflicting or invalid tracer options.
+func (cfg *runConfig) validate() []error {
+	var errs []error
+	if cfg.udsPath != "" && cfg.agentAddr != "" {
+		errs = append(errs, fmt.Errorf("the agent address %s is ignored, the agent is reached through the Unix socket %s",
+			cfg.agentAddr, cfg.udsPath))
+	} else if cfg.agentAddr != "" {
+		if _, _, err := net.SplitHostPort(cfg.agentAddr); err != nil {
+			errs = append(errs, fmt.Errorf("the agent address is ignored: %v", err))
+		}
+	}
+	if cfg.sampleRate > 1 || (cfg.sampleRate < 0 && cfg.sampleRate != noSampleRate) {
+		errs = append(errs, fmt.Errorf("the sample rate %v is ignored, it must be between 0 and 1", cfg.sampleRate))
+	}
+	return errs
+}
+
+// udsClient returns an HTTP client sending the requests to the agent through a Unix socket.
+func udsClient(path string, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
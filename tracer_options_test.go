@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2021 Datadog, Inc.
+
+package dd_sdk_go_testing
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestTracerStartOptionsValidation(t *testing.T) {
+	for _, test := range []struct {
+		opts    []RunOption
+		options int
+		errors  int
+	}{
+		{opts: nil, options: 1},
+		{opts: []RunOption{WithAgentAddr("agent:8126"), WithSampleRate(0.5), WithTracerRuntimeMetrics()}, options: 4},
+		{opts: []RunOption{WithAgentAddr("agent:8126"), WithUDS("/var/run/datadog/apm.socket")}, options: 2, errors: 1},
+		{opts: []RunOption{WithAgentAddr("agent")}, options: 1, errors: 1},
+		{opts: []RunOption{WithSampleRate(2)}, options: 1, errors: 1},
+	} {
+		cfg := new(runConfig)
+		runDefaults(cfg)
+		for _, fn := range test.opts {
+			fn(cfg)
+		}
+		opts, errs := tracerStartOptions(cfg, "ci")
+		if len(opts) != test.options || len(errs) != test.errors {
+			t.Errorf("%+v: unexpected options %d and errors %v", cfg, len(opts), errs)
+		}
+	}
+}
+
+func TestUDSClient(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix sockets aren't supported")
+	}
+	dir, err := ioutil.TempDir("", "dd-uds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "apm.socket")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	resp, err := udsClient(path, defaultUploadTimeout).Get("http://localhost:8126/v0.4/traces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+}